@@ -0,0 +1,156 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"ads-mrkt/internal/config"
+	"ads-mrkt/internal/event/application/deadletter"
+	eventredis "ads-mrkt/internal/event/repository/redis"
+	"ads-mrkt/internal/redis"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func Cmd(ctx context.Context, conf *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Event stream dead-letter commands",
+		Long: `Inspect and replay the dead letters of the event streams. Streams are given by key, with or without
+the "events:" prefix, e.g. telegram_notification.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	cmd.AddCommand(listCmd(ctx, conf), inspectCmd(ctx, conf), replayCmd(ctx, conf), purgeCmd(ctx, conf))
+
+	return cmd
+}
+
+// withService runs fn with a dead-letter service connected to the configured redis.
+func withService(ctx context.Context, conf *config.Config, fn func(svc *deadletter.Service) error) error {
+	redisClient, err := redis.New(ctx, conf.Redis)
+	if err != nil {
+		return errors.Wrap(err, "redis")
+	}
+	defer redisClient.Close()
+
+	return fn(deadletter.NewService(eventredis.New(redisClient)))
+}
+
+func listCmd(ctx context.Context, conf *config.Config) *cobra.Command {
+	var (
+		after string
+		count int64
+	)
+	cmd := &cobra.Command{
+		Use:   "list [stream]",
+		Short: "list dead letters of a stream, or dead-letter counts of all streams",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withService(ctx, conf, func(svc *deadletter.Service) error {
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+				defer w.Flush()
+
+				if len(args) == 0 {
+					counts, err := svc.Counts(ctx)
+					if err != nil {
+						return err
+					}
+					fmt.Fprintln(w, "STREAM\tDEAD LETTERS")
+					for _, stream := range deadletter.Streams {
+						fmt.Fprintf(w, "%s\t%d\n", stream, counts[stream])
+					}
+					return nil
+				}
+
+				list, err := svc.List(ctx, deadletter.StreamKey(args[0]), after, count)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(w, "ID\tORIGIN ID\tREASON\tDELIVERIES\tFAILED AT\tERROR")
+				for _, dl := range list {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", dl.ID, dl.OriginID, dl.Reason, dl.Deliveries, dl.FailedAt.Format(time.RFC3339), dl.Error)
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&after, "after", "", "list dead letters after this ID")
+	cmd.Flags().Int64Var(&count, "count", 50, "maximum number of dead letters to list")
+	return cmd
+}
+
+func inspectCmd(ctx context.Context, conf *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "inspect <stream> <id>",
+		Short: "print a dead letter with its original fields as JSON",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withService(ctx, conf, func(svc *deadletter.Service) error {
+				dl, err := svc.Get(ctx, deadletter.StreamKey(args[0]), args[1])
+				if err != nil {
+					return err
+				}
+				if dl == nil {
+					return fmt.Errorf("dead letter %s not found", args[1])
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(dl)
+			})
+		},
+	}
+}
+
+func replayCmd(ctx context.Context, conf *config.Config) *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "replay <stream> [id...]",
+		Short: "publish dead letters to their stream again and remove them from the dead-letter stream",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids := args[1:]
+			if len(ids) == 0 && !all {
+				return errors.New("give dead letter IDs or --all")
+			}
+			return withService(ctx, conf, func(svc *deadletter.Service) error {
+				n, err := svc.Replay(ctx, deadletter.StreamKey(args[0]), ids)
+				fmt.Fprintf(cmd.OutOrStdout(), "replayed %d dead letters\n", n)
+				return err
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "replay every dead letter of the stream")
+	return cmd
+}
+
+func purgeCmd(ctx context.Context, conf *config.Config) *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "purge <stream> [id...]",
+		Short: "delete dead letters",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids := args[1:]
+			if len(ids) == 0 && !all {
+				return errors.New("give dead letter IDs or --all")
+			}
+			return withService(ctx, conf, func(svc *deadletter.Service) error {
+				n, err := svc.Purge(ctx, deadletter.StreamKey(args[0]), ids)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "purged %d dead letters\n", n)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "delete the whole dead-letter stream")
+	return cmd
+}
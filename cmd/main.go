@@ -7,6 +7,7 @@ import (
 
 	"ads-mrkt/cmd/blockchain_observer"
 	"ads-mrkt/cmd/bot"
+	"ads-mrkt/cmd/events"
 	"ads-mrkt/cmd/market"
 	"ads-mrkt/cmd/userbot"
 	"ads-mrkt/internal/config"
@@ -56,6 +57,7 @@ func main() {
 		bot.BotCmd(ctx, conf),
 		userbot.UserbotCmd(ctx, conf),
 		blockchain_observer.Cmd(ctx, conf),
+		events.Cmd(ctx, conf),
 	)
 
 	if err := errors.Wrap(rootCmd.ExecuteContext(ctx), "error executing root cmd"); err != nil {
@@ -12,11 +12,17 @@ import (
 	"ads-mrkt/internal/config"
 	channelupdateevent "ads-mrkt/internal/event/application/channel_update_stats/event"
 	escrowdepositevent "ads-mrkt/internal/event/application/escrow_deposit/event"
-	telegramnotifyevent "ads-mrkt/internal/event/application/telegram_notification/event"
+	"ads-mrkt/internal/event/application/outbox"
+	outboxrepo "ads-mrkt/internal/event/repository/outbox"
 	eventredis "ads-mrkt/internal/event/repository/redis"
 	"ads-mrkt/internal/helpers/telegram"
 	"ads-mrkt/internal/liteclient"
+	adminhttp "ads-mrkt/internal/market/application/admin/http"
 	"ads-mrkt/internal/market/application/market/http"
+	"ads-mrkt/internal/market/domain"
+	adminauditrepo "ads-mrkt/internal/market/repository/admin_audit"
+	apikeyrepo "ads-mrkt/internal/market/repository/api_key"
+	authsessionrepo "ads-mrkt/internal/market/repository/auth_session"
 	"ads-mrkt/internal/market/repository/channel"
 	"ads-mrkt/internal/market/repository/channel_admin"
 	"ads-mrkt/internal/market/repository/deal"
@@ -24,14 +30,21 @@ import (
 	"ads-mrkt/internal/market/repository/deal_forum_topic"
 	"ads-mrkt/internal/market/repository/deal_post_message"
 	"ads-mrkt/internal/market/repository/listing"
+	listingreportrepo "ads-mrkt/internal/market/repository/listing_report"
 	"ads-mrkt/internal/market/repository/user"
+	webhookrepo "ads-mrkt/internal/market/repository/webhook"
+	adminservice "ads-mrkt/internal/market/service/admin"
+	apikeyservice "ads-mrkt/internal/market/service/api_key"
+	authsessionservice "ads-mrkt/internal/market/service/auth_session"
 	channelservice "ads-mrkt/internal/market/service/channel"
 	dealservice "ads-mrkt/internal/market/service/deal"
 	dealchatservice "ads-mrkt/internal/market/service/deal_chat"
 	dealpostmessage "ads-mrkt/internal/market/service/deal_post_message"
+	dealstateservice "ads-mrkt/internal/market/service/deal_state"
 	escrowservice "ads-mrkt/internal/market/service/escrow"
 	listingservice "ads-mrkt/internal/market/service/listing"
 	userservice "ads-mrkt/internal/market/service/user"
+	webhookservice "ads-mrkt/internal/market/service/webhook"
 	"ads-mrkt/internal/postgres"
 	"ads-mrkt/internal/redis"
 	"ads-mrkt/internal/server"
@@ -101,22 +114,31 @@ func httpCmd(ctx context.Context, cfg *config.Config) *cobra.Command {
 			analyticsRepo := analyticsrepo.New(pg)
 			analyticsSvc := analyticsservice.New(analyticsRepo, cfg.MarketTransactionGasTON, cfg.MarketCommissionPercent)
 
-			userSvc := userservice.NewUserService(cfg.Telegram.Token, userRepo)
-			listingSvc := listingservice.NewListingService(listingRepo, channelAdminRepo)
+			userSvc := userservice.NewUserService(cfg.Telegram.Token, userRepo, redisClient, lc, cfg.TonProofDomain, cfg.TonProofPayloadTTL)
+			contentPolicy, err := domain.NewContentPolicy(cfg.Moderation.BlockedWords, cfg.Moderation.BlockedPatterns, cfg.Moderation.BlockedDomains)
+			if err != nil {
+				return errors.Wrap(err, "moderation blocklists")
+			}
+			listingReportRepo := listingreportrepo.New(pg)
+			listingSvc := listingservice.NewListingService(listingRepo, channelAdminRepo, channelRepo, listingReportRepo, contentPolicy)
 			dealChatSvc := dealchatservice.NewService(dealRepo, dealForumTopicRepo, telegramClient, cfg.Telegram.BotUsername)
 			vaultClient, err := vault.NewClient(cfg.Vault)
 			if err != nil {
 				return errors.Wrap(err, "create vault client")
 			}
-			escrowSvc := escrowservice.NewService(dealRepo, vaultClient, dealActionLockRepo, lc, redisClient, dealChatSvc, cfg.MarketTransactionGasTON, cfg.MarketCommissionPercent)
 			eventRepo := eventredis.New(redisClient)
 			escrowDepositEventSvc := escrowdepositevent.NewService(eventRepo)
 			channelUpdateStatsEventSvc := channelupdateevent.NewService(eventRepo)
-			telegramNotifyEventSvc := telegramnotifyevent.NewService(eventRepo)
+			// Notifications are written to the outbox in the transaction of the change they announce.
+			outboxSvc := outbox.NewService(outboxrepo.New(pg), eventRepo, pg)
+			dealStateSvc := dealstateservice.NewService(dealRepo, userRepo, outboxSvc, dealChatSvc, pg)
+			escrowSvc := escrowservice.NewService(dealRepo, vaultClient, dealActionLockRepo, lc, redisClient, dealStateSvc, cfg.MarketTransactionGasTON, cfg.MarketCommissionPercent)
 
 			channelSvc := channelservice.NewChannelService(channelRepo, channelAdminRepo, listingRepo, channelUpdateStatsEventSvc)
-			dealSvc := dealservice.NewDealService(dealRepo, userRepo, escrowSvc, telegramNotifyEventSvc)
-			dealPostMessageSvc := dealpostmessage.NewService(dealPostMessageRepo)
+			dealSvc := dealservice.NewDealService(dealRepo, userRepo, escrowSvc, dealStateSvc, outboxSvc, pg, contentPolicy, cfg.TonProofDomain, cfg.TonProofPayloadTTL)
+			dealPostMessageSvc := dealpostmessage.NewService(dealPostMessageRepo, dealRepo, dealStateSvc, pg)
+			// Deal transitions queue webhook deliveries; the worker sends them.
+			webhookSvc := webhookservice.NewService(webhookrepo.New(pg), webhookservice.NewHTTPClient())
 			// Preload: mark deals in waiting_escrow_deposit past deposit deadline (updated_at + 1h) as expired
 			preloadCtx, preloadCancel := context.WithTimeout(ctxRun, 30*time.Second)
 			if errPreload := dealSvc.ExpireTimedOutDeposits(preloadCtx, time.Now().Add(-1*time.Hour)); errPreload != nil {
@@ -124,16 +146,27 @@ func httpCmd(ctx context.Context, cfg *config.Config) *cobra.Command {
 			}
 			preloadCancel()
 
+			go outboxSvc.RunRelay(ctxRun)
 			go escrowSvc.Worker(ctxRun)
 			go escrowSvc.DepositStreamWorker(ctxRun, escrowDepositEventSvc)
 			go escrowSvc.ReleaseRefundWorker(ctxRun)
+			go escrowSvc.ReconciliationWorker(ctxRun)
 			go dealPostMessageSvc.RunPassedWorker(ctxRun)
 			go dealSvc.RunCompletedWorker(ctxRun)
 			go analyticsSvc.Run(ctxRun)
+			go webhookSvc.RunDeliveryWorker(ctxRun)
 
-			jwtManager := auth.NewJWTManager(cfg.Auth.JWTSecret, time.Duration(cfg.Auth.JWTTimeToLive)*time.Hour)
-			authMiddleware := auth.NewAuthMiddleware(jwtManager)
-			handler := http.NewHandler(userSvc, listingSvc, dealSvc, dealChatSvc, channelSvc, jwtManager)
+			previousKeys, err := auth.ParseSigningKeys(cfg.Auth.JWTPreviousKeys)
+			if err != nil {
+				return errors.Wrap(err, "parse previous JWT signing keys")
+			}
+			jwtManager := auth.NewJWTManager(auth.SigningKey{ID: cfg.Auth.JWTKeyID, Secret: cfg.Auth.JWTSecret}, previousKeys, cfg.Auth.AccessTokenTTL)
+			// Access tokens are short-lived; sessions hold the refresh tokens and are checked for revocation on every request.
+			sessionSvc := authsessionservice.NewService(authsessionrepo.New(pg), userRepo, jwtManager, redisClient, cfg.Auth.RefreshTokenTTL, cfg.Auth.RoleCacheTTL)
+			go sessionSvc.RunCleanup(ctxRun)
+			apiKeySvc := apikeyservice.NewService(apikeyrepo.New(pg), redisClient)
+			authMiddleware := auth.NewAuthMiddleware(jwtManager, sessionSvc, apiKeySvc)
+			handler := http.NewHandler(userSvc, listingSvc, dealSvc, dealChatSvc, channelSvc, webhookSvc, apiKeySvc, sessionSvc)
 
 			healthChecker := health.NewChecker(cfg.Health, pg)
 			srv := server.NewServer(cfg.Server, healthChecker)
@@ -144,7 +177,9 @@ func httpCmd(ctx context.Context, cfg *config.Config) *cobra.Command {
 			})
 
 			analyticsHandler := analyticshttp.NewHandler(analyticsSvc)
-			router := marketrouter.NewRouter(cfg.Server, handler, authMiddleware, analyticsHandler)
+			adminSvc := adminservice.NewService(userRepo, listingRepo, dealRepo, listingReportRepo, adminauditrepo.New(pg), escrowSvc, sessionSvc, pg)
+			adminHandler := adminhttp.NewHandler(escrowSvc, adminSvc)
+			router := marketrouter.NewRouter(cfg.Server, handler, authMiddleware, analyticsHandler, adminHandler)
 
 			go srv.Start(ctxRun, router.GetRoutes())
 
@@ -2,6 +2,8 @@ package userbot
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	"ads-mrkt/internal/config"
 	channelupdateevent "ads-mrkt/internal/event/application/channel_update_stats/event"
@@ -14,8 +16,13 @@ import (
 	"ads-mrkt/internal/market/repository/listing"
 	"ads-mrkt/internal/postgres"
 	"ads-mrkt/internal/redis"
+	userbotconfig "ads-mrkt/internal/userbot/config"
+	userbotaccountrepo "ads-mrkt/internal/userbot/repository/account"
+	userbotsessionrepo "ads-mrkt/internal/userbot/repository/session"
 	userbotrepo "ads-mrkt/internal/userbot/repository/state"
 	userbotservice "ads-mrkt/internal/userbot/service/userbot"
+	"ads-mrkt/internal/userbot/session"
+	"ads-mrkt/internal/vault"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -30,7 +37,7 @@ func UserbotCmd(ctx context.Context, conf *config.Config) *cobra.Command {
 		},
 	}
 
-	cmd.AddCommand(runCmd(ctx, conf))
+	cmd.AddCommand(runCmd(ctx, conf), loginCmd(ctx, conf), sessionCmd(ctx, conf))
 
 	return cmd
 }
@@ -44,6 +51,7 @@ func runCmd(ctx context.Context, cfg *config.Config) *cobra.Command {
 			if err != nil {
 				return errors.Wrap(err, "postgres")
 			}
+			defer pg.Close()
 
 			redisClient, err := redis.New(ctx, cfg.Redis)
 			if err != nil {
@@ -51,7 +59,13 @@ func runCmd(ctx context.Context, cfg *config.Config) *cobra.Command {
 			}
 			defer redisClient.Close()
 
+			sessionStore, err := newSessionStore(cfg, userbotsessionrepo.New(pg))
+			if err != nil {
+				return errors.Wrap(err, "session store")
+			}
+
 			stateStorage := userbotrepo.New(pg)
+			accountRepo := userbotaccountrepo.New(pg)
 			channelRepo := channel.New(pg)
 			channelAdminRepo := channel_admin.New(pg)
 			listingRepo := listing.New(pg)
@@ -60,7 +74,10 @@ func runCmd(ctx context.Context, cfg *config.Config) *cobra.Command {
 			dealActionLockRepo := deal_action_lock.New(pg)
 			eventRepo := eventredis.New(redisClient)
 			channelUpdateStatsEventSvc := channelupdateevent.NewService(eventRepo)
-			b := userbotservice.New(cfg.UserBot, stateStorage, channelRepo, channelAdminRepo, listingRepo, dealRepo, dealPostMessageRepo, dealActionLockRepo, channelUpdateStatsEventSvc)
+			b, err := userbotservice.New(cfg.UserBot, stateStorage, sessionStore, accountRepo, channelRepo, channelAdminRepo, listingRepo, dealRepo, dealPostMessageRepo, dealActionLockRepo, channelUpdateStatsEventSvc, pg)
+			if err != nil {
+				return errors.Wrap(err, "userbot")
+			}
 
 			if err := b.Start(ctx); err != nil {
 				return errors.Wrap(err, "userbot start")
@@ -69,3 +86,141 @@ func runCmd(ctx context.Context, cfg *config.Config) *cobra.Command {
 		},
 	}
 }
+
+// newSessionStore returns the configured session store, nil for session files. pgStore keeps sessions in postgres.
+func newSessionStore(cfg *config.Config, pgStore session.Store) (session.Store, error) {
+	switch cfg.UserBot.SessionStorage {
+	case userbotconfig.SessionStorageFile:
+		return nil, nil
+	case userbotconfig.SessionStorageVault:
+		vaultClient, err := vault.NewClient(cfg.Vault)
+		if err != nil {
+			return nil, err
+		}
+		return vaultSessionStore{vaultClient}, nil
+	case userbotconfig.SessionStoragePostgres:
+		key, err := cfg.UserBot.EncryptionKey()
+		if err != nil {
+			return nil, err
+		}
+		return session.NewEncryptedStore(pgStore, key)
+	}
+	return nil, fmt.Errorf("unknown session storage %q", cfg.UserBot.SessionStorage)
+}
+
+type vaultSessionStore struct {
+	client *vault.Client
+}
+
+func (s vaultSessionStore) LoadSession(ctx context.Context, key string) ([]byte, error) {
+	return s.client.GetUserbotSession(ctx, key)
+}
+
+func (s vaultSessionStore) StoreSession(ctx context.Context, key string, data []byte) error {
+	return s.client.PutUserbotSession(ctx, key, data)
+}
+
+// accountStorage returns the session storage of the configured account with the phone. The postgres pool it opens
+// for postgres session storage is released by closeStore.
+func accountStorage(ctx context.Context, cfg *config.Config, phone string) (store session.Store, acc userbotconfig.Account, closeStore func(), err error) {
+	closeStore = func() {}
+	accounts, err := cfg.UserBot.AccountList()
+	if err != nil {
+		return nil, acc, closeStore, err
+	}
+	acc = userbotconfig.Account{Phone: phone}
+	found := false
+	for _, a := range accounts {
+		if session.Key(a.Phone) == session.Key(phone) {
+			acc, found = a, true
+			break
+		}
+	}
+	if !found && cfg.UserBot.SessionStorage == userbotconfig.SessionStorageFile {
+		return nil, acc, closeStore, fmt.Errorf("account %s is not configured", phone)
+	}
+
+	var pgStore session.Store
+	if cfg.UserBot.SessionStorage == userbotconfig.SessionStoragePostgres {
+		pg, err := postgres.New(ctx, cfg.Database)
+		if err != nil {
+			return nil, acc, closeStore, errors.Wrap(err, "postgres")
+		}
+		closeStore = pg.Close
+		pgStore = userbotsessionrepo.New(pg)
+	}
+	store, err = newSessionStore(cfg, pgStore)
+	if err != nil {
+		closeStore()
+		return nil, acc, func() {}, errors.Wrap(err, "session store")
+	}
+	return store, acc, closeStore, nil
+}
+
+func loginCmd(ctx context.Context, cfg *config.Config) *cobra.Command {
+	var opts session.LoginOptions
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "log a userbot account in without a terminal",
+		Long: `Logs the account in and stores its session in the configured session storage.
+The login code is read from stdin once sent; with --send-code the code hash is printed instead and the
+login is finished later with --code and --code-hash. A 2FA password not given with --password is read from stdin.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, acc, closeStore, err := accountStorage(ctx, cfg, opts.Phone)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+			opts.Input = cmd.InOrStdin()
+			opts.Output = cmd.OutOrStdout()
+			return session.Login(ctx, cfg.UserBot.ApiID, cfg.UserBot.ApiHash, session.AccountStorage(store, acc), opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.Phone, "phone", "", "account phone number")
+	cmd.Flags().BoolVar(&opts.SendCodeOnly, "send-code", false, "only send the login code and print its hash")
+	cmd.Flags().StringVar(&opts.Code, "code", "", "login code")
+	cmd.Flags().StringVar(&opts.CodeHash, "code-hash", "", "code hash printed by --send-code")
+	cmd.Flags().StringVar(&opts.Password, "password", "", "2FA password")
+	cmd.Flags().BoolVar(&opts.Reset, "reset", false, "replace the stored session with a new one")
+	_ = cmd.MarkFlagRequired("phone")
+	return cmd
+}
+
+func sessionCmd(ctx context.Context, cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "session",
+		Short: "Userbot session commands",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	var phone, file string
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "copy a session file into the configured session storage",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, acc, closeStore, err := accountStorage(ctx, cfg, phone)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+			if store == nil {
+				return errors.New("session storage is file, nothing to import into")
+			}
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return session.Import(ctx, session.AccountStorage(store, acc), f)
+		},
+	}
+	importCmd.Flags().StringVar(&phone, "phone", "", "account phone number")
+	importCmd.Flags().StringVar(&file, "file", "", "session file path")
+	_ = importCmd.MarkFlagRequired("phone")
+	_ = importCmd.MarkFlagRequired("file")
+
+	cmd.AddCommand(importCmd)
+	return cmd
+}
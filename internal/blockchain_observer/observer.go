@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"ads-mrkt/internal/event/domain/entity"
+	marketentity "ads-mrkt/internal/market/domain/entity"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/xssnick/tonutils-go/address"
@@ -27,7 +28,8 @@ type lt interface {
 }
 
 type dealRepository interface {
-	SetDealStatusExpiredByEscrowAddress(ctx context.Context, escrowAddress string) error
+	GetDealByEscrowAddress(ctx context.Context, escrowAddress string) (*marketentity.Deal, error)
+	TransitionDeal(ctx context.Context, d *marketentity.Deal, to marketentity.DealStatus, t marketentity.DealTransition) error
 }
 
 type escrowDepositEventService interface {
@@ -56,6 +58,8 @@ type Observer struct {
 	log            *slog.Logger
 }
 
+// New creates an observer. rdb may be nil (e.g. in tests): Redis keyspace events are then not
+// handled and escrow addresses must be registered with WatchAddress.
 func New(lt lt, rdb *redis.Client, dealRepository dealRepository, eventService escrowDepositEventService, dbIndex int) *Observer {
 	return &Observer{
 		lt:             lt,
@@ -73,18 +77,20 @@ func New(lt lt, rdb *redis.Client, dealRepository dealRepository, eventService e
 }
 
 func (o *Observer) Start(ctx context.Context) error {
-	o.log.Info("loading escrow wallets from Redis...")
-	if err := o.loadAddresses(ctx); err != nil {
-		return err
-	}
-	o.log.Info("loading done")
-
 	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		o.startRedisEventsHandler(ctx)
-	}()
+	if o.rdb != nil {
+		o.log.Info("loading escrow wallets from Redis...")
+		if err := o.loadAddresses(ctx); err != nil {
+			return err
+		}
+		o.log.Info("loading done")
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.startRedisEventsHandler(ctx)
+		}()
+	}
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -129,6 +135,16 @@ func (o *Observer) loadAddresses(ctx context.Context) error {
 	return nil
 }
 
+// WatchAddress starts tracking deposits to the escrow wallet with the given raw address.
+func (o *Observer) WatchAddress(rawAddress string) error {
+	addr, err := address.ParseRawAddr(rawAddress)
+	if err != nil {
+		return err
+	}
+	o.addAddress(WalletAddress(addr.Data()))
+	return nil
+}
+
 func (o *Observer) isAddressWatched(key WalletAddress) bool {
 	o.addressesMutex.RLock()
 	defer o.addressesMutex.RUnlock()
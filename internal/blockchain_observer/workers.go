@@ -10,6 +10,8 @@ import (
 
 	"ads-mrkt/internal/event/domain/entity"
 	"ads-mrkt/internal/liteclient"
+	marketdomain "ads-mrkt/internal/market/domain"
+	marketentity "ads-mrkt/internal/market/domain/entity"
 
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/tlb"
@@ -55,10 +57,8 @@ func (o *Observer) startRedisEventsHandler(ctx context.Context) {
 				}
 				o.log.Info("expired key", "key", msg.Payload)
 				o.removeAddress(WalletAddress(addr.Data()))
-				if err := o.dealRepository.SetDealStatusExpiredByEscrowAddress(ctx, msg.Payload); err != nil {
+				if err := o.expireDeal(ctx, msg.Payload); err != nil {
 					o.log.Error("set deal expired", "address", msg.Payload, "error", err)
-				} else {
-					o.log.Info("deal marked expired", "address", msg.Payload)
 				}
 			case expireCh:
 				addr, err := address.ParseRawAddr(msg.Payload)
@@ -74,6 +74,20 @@ func (o *Observer) startRedisEventsHandler(ctx context.Context) {
 	}
 }
 
+// expireDeal moves the deal still waiting for a deposit to the expired escrow address to expired.
+func (o *Observer) expireDeal(ctx context.Context, escrowAddress string) error {
+	deal, err := o.dealRepository.GetDealByEscrowAddress(ctx, escrowAddress)
+	if err != nil || deal == nil {
+		return err
+	}
+	t := marketentity.WorkerDealTransition(marketdomain.DealWorkerBlockchainObserver, "escrow deposit address expired")
+	if err := o.dealRepository.TransitionDeal(ctx, deal, marketentity.DealStatusExpired, t); err != nil {
+		return err
+	}
+	o.log.Info("deal marked expired", "address", escrowAddress, "deal_id", deal.ID)
+	return nil
+}
+
 func (o *Observer) startDepositNotifier(ctx context.Context) {
 	for {
 		select {
@@ -0,0 +1,55 @@
+package updates
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+
+	"ads-mrkt/internal/event/application/consumer"
+	evententity "ads-mrkt/internal/event/domain/entity"
+	"ads-mrkt/internal/helpers/telegram"
+	"ads-mrkt/internal/helpers/telegram/telegramtest"
+)
+
+// notificationStream hands its events to the handler once, acking the handled ones like the consumer does.
+type notificationStream struct {
+	events []*evententity.EventTelegramNotification
+	acked  []string
+}
+
+func (n *notificationStream) ConsumeTelegramNotificationEvents(ctx context.Context, cfg consumer.Config, handler consumer.Handler[*evententity.EventTelegramNotification]) error {
+	for _, ev := range n.events {
+		if handler(ctx, ev) == nil {
+			n.acked = append(n.acked, ev.ID)
+		}
+	}
+	return nil
+}
+
+func TestDeliverNotificationsAcksOnlyDelivered(t *testing.T) {
+	srv := telegramtest.NewServer()
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := &notificationStream{events: []*evententity.EventTelegramNotification{
+		{ID: "1-0", ChatID: 10, Message: "first"},
+		{ID: "2-0", ChatID: 20, Message: "second"},
+	}}
+	s := NewService(telegram.NewAPIClient(ctx, srv.Config(), telegramtest.RateLimitStore{}), nil, stream, nil)
+
+	srv.FailNext("sendMessage", http.StatusForbidden, "Forbidden: bot was blocked by the user")
+	s.StartBackgroundProcessingNotifications(ctx)
+
+	calls := srv.Calls("sendMessage")
+	if len(calls) != 2 {
+		t.Fatalf("got %d sendMessage calls, want 2", len(calls))
+	}
+	if calls[1].Int64("chat_id") != 20 || calls[1].String("text") != "second" {
+		t.Fatalf("unexpected payload: %+v", calls[1].Payload)
+	}
+	if !slices.Equal(stream.acked, []string{"2-0"}) {
+		t.Fatalf("acked = %v, want [2-0]", stream.acked)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"ads-mrkt/internal/event/application/consumer"
 	evententity "ads-mrkt/internal/event/domain/entity"
 	"ads-mrkt/internal/helpers/telegram"
 )
@@ -17,28 +18,24 @@ const (
 	UpdateCommandStart UpdateType = "start"
 	UpdateCallback     UpdateType = "callback"
 	UpdateUnknown      UpdateType = "unknown"
-
-	groupName                     = "master"
-	consumerName                  = "updates"
-	readTelegramUpdateEventsLimit = 100
-	telegramUpdateEventsAge       = 48 * time.Hour
-	pendingPeriod                 = 15 * time.Second
-	pendingMinIdle                = 30 * time.Second
 )
 
+var telegramUpdateConsumerConfig = consumer.Config{
+	Group:          "master",
+	Consumer:       "updates",
+	StartID:        "$",
+	BatchSize:      100,
+	ReclaimMinIdle: 30 * time.Second,
+	RetentionAge:   48 * time.Hour,
+}
+
 type eventService interface {
 	AddTelegramUpdateEvent(ctx context.Context, update *telegram.Update, createdAt time.Time) error
-	ReadTelegramUpdateEvents(ctx context.Context, group string, consumer string, limit int64) ([]*evententity.EventTelegramUpdate, error)
-	PendingTelegramUpdateEvents(ctx context.Context, group string, consumer string, limit int64, minIdle time.Duration) ([]*evententity.EventTelegramUpdate, error)
-	AckMessages(ctx context.Context, group string, messageIDs []string) error
-	TrimStreamByAge(ctx context.Context, age time.Duration) error
+	ConsumeTelegramUpdateEvents(ctx context.Context, cfg consumer.Config, handler consumer.Handler[*evententity.EventTelegramUpdate]) error
 }
 
 type telegramNotificationEventService interface {
-	ReadTelegramNotificationEvents(ctx context.Context, group, consumer string, limit int64) ([]*evententity.EventTelegramNotification, error)
-	PendingTelegramNotificationEvents(ctx context.Context, group, consumer string, limit int64, minIdle time.Duration) ([]*evententity.EventTelegramNotification, error)
-	AckTelegramNotificationMessages(ctx context.Context, group string, messageIDs []string) error
-	TrimStreamByAge(ctx context.Context, age time.Duration) error
+	ConsumeTelegramNotificationEvents(ctx context.Context, cfg consumer.Config, handler consumer.Handler[*evententity.EventTelegramNotification]) error
 }
 
 type telegramService interface {
@@ -82,50 +79,11 @@ func (s *service) HandleUpdate(ctx context.Context, raw []byte) error {
 }
 
 func (s *service) StartBackgroundProcessingUpdates(ctx context.Context) {
-	go s.streamCleaner(ctx)
-	go s.processPendingUpdates(ctx)
-
-	for {
-		select {
-		case <-ctx.Done():
-			slog.Info("context done, stopping background processing updates")
-			return
-		default:
-			if err := s.processUpdates(ctx); err != nil {
-				slog.Error("failed to process updates", "error", err)
-			}
-		}
+	if err := s.eventService.ConsumeTelegramUpdateEvents(ctx, telegramUpdateConsumerConfig, s.processUpdate); err != nil {
+		slog.Error("telegram update worker", "error", err)
 	}
 }
 
-func (s *service) processUpdates(ctx context.Context) error {
-	telegramUpdateEvents, err := s.eventService.ReadTelegramUpdateEvents(ctx, groupName, consumerName, readTelegramUpdateEventsLimit)
-	if err != nil {
-		return fmt.Errorf("failed to get pending updates: %w", err)
-	}
-
-	if len(telegramUpdateEvents) == 0 {
-		return nil
-	}
-
-	messageIDs := make([]string, 0, len(telegramUpdateEvents))
-	for _, updateEvent := range telegramUpdateEvents {
-		if err := s.processUpdate(ctx, updateEvent); err != nil {
-			slog.Error("failed to process update", "error", err, "event_id", updateEvent.ID)
-			continue
-		}
-		messageIDs = append(messageIDs, updateEvent.ID)
-	}
-
-	if len(messageIDs) > 0 {
-		if err := s.eventService.AckMessages(ctx, groupName, messageIDs); err != nil {
-			slog.Error("failed to ack telegram update messages", "error", err)
-		}
-	}
-
-	return nil
-}
-
 func (s *service) processUpdate(ctx context.Context, updateEvent *evententity.EventTelegramUpdate) error {
 	update := updateEvent.Update
 	updateType := s.getUpdateType(update)
@@ -157,63 +115,3 @@ func (s *service) getUpdateType(update *telegram.Update) UpdateType {
 	}
 	return UpdateUnknown
 }
-
-func (s *service) processPendingUpdates(ctx context.Context) {
-	tickerPending := time.NewTicker(pendingPeriod)
-	defer tickerPending.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			slog.Info("pending telegram update events processor stopped")
-			return
-		case <-tickerPending.C:
-			events, err := s.eventService.PendingTelegramUpdateEvents(ctx, groupName, consumerName, readTelegramUpdateEventsLimit, pendingMinIdle)
-			if err != nil {
-				slog.Error("failed to read pending telegram update events", "error", err)
-				continue
-			}
-
-			if len(events) == 0 {
-				tickerPending.Reset(pendingPeriod)
-				continue
-			}
-
-			messageIDs := make([]string, 0, len(events))
-			for _, updateEvent := range events {
-				if err := s.processUpdate(ctx, updateEvent); err != nil {
-					slog.Error("failed to process pending update", "error", err, "event_id", updateEvent.ID)
-					continue
-				}
-				messageIDs = append(messageIDs, updateEvent.ID)
-			}
-
-			if len(messageIDs) > 0 {
-				if err := s.eventService.AckMessages(ctx, groupName, messageIDs); err != nil {
-					slog.Error("failed to ack pending telegram update messages", "error", err)
-				}
-			}
-
-			tickerPending.Reset(pendingPeriod)
-		}
-	}
-}
-
-func (s *service) streamCleaner(ctx context.Context) {
-	tickerPeriod := 24 * time.Hour
-	ticker := time.NewTicker(tickerPeriod)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			slog.Info("telegram update events stream cleaner stopped")
-			return
-		case <-ticker.C:
-			if err := s.eventService.TrimStreamByAge(ctx, telegramUpdateEventsAge); err != nil {
-				slog.Error("failed to trim telegram update events stream by age", "err", err)
-			}
-			ticker.Reset(tickerPeriod)
-		}
-	}
-}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"net/url"
+	"time"
+
 	telegramconfig "ads-mrkt/internal/helpers/telegram/config"
 	liteclientconfig "ads-mrkt/internal/liteclient/config"
 	dbconfig "ads-mrkt/internal/postgres/config"
@@ -28,8 +31,19 @@ type Config struct {
 	IsTestnet               bool                    `env:"IS_TESTNET" env-default:"false"`
 	MarketTransactionGasTON float64                 `env:"MARKET_TRANSACTION_GAS_TON" env-default:"0.1"`
 	MarketCommissionPercent float64                 `env:"MARKET_COMMISSION_PERCENT" env-default:"2"`
+	// TonProofDomain is the dApp domain expected in TON Connect ton_proof and signData; defaults to the host of CLIENT_DOMAIN.
+	// TonProofPayloadTTL bounds the age of a ton_proof payload and of a signData timestamp.
+	TonProofDomain     string        `env:"TON_PROOF_DOMAIN"`
+	TonProofPayloadTTL time.Duration `env:"TON_PROOF_PAYLOAD_TTL" env-default:"15m"`
+
+	Moderation ModerationConfig `env-prefix:"MODERATION_"`
 }
 
 func (c *Config) InternalHandling() {
 	c.Server.InternalHandling()
+	if c.TonProofDomain == "" {
+		if u, err := url.Parse(c.Server.ClientDomain); err == nil {
+			c.TonProofDomain = u.Host
+		}
+	}
 }
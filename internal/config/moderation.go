@@ -0,0 +1,11 @@
+package config
+
+// ModerationConfig holds the blocklists applied to listing descriptions and deal ad messages.
+type ModerationConfig struct {
+	// BlockedWords are refused as whole words in any case.
+	BlockedWords []string `env:"BLOCKED_WORDS" env-separator:","`
+	// BlockedPatterns are regular expressions; they are separated by ";;" since patterns often contain commas.
+	BlockedPatterns []string `env:"BLOCKED_PATTERNS" env-separator:";;"`
+	// BlockedDomains are refused in links, with their subdomains.
+	BlockedDomains []string `env:"BLOCKED_DOMAINS" env-separator:","`
+}
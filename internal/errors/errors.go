@@ -10,6 +10,7 @@ const (
 	ErrorCodeBadRequest          ErrorCode = "bad_request"
 	ErrorCodeInternalServerError ErrorCode = "internal_server_error"
 	ErrorCodeForbidden           ErrorCode = "forbidden"
+	ErrorCodeConflict            ErrorCode = "conflict"
 )
 
 type ServiceError struct {
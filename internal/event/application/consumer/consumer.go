@@ -0,0 +1,426 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"ads-mrkt/internal/event/domain/entity"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DeadLetterSuffix is appended to a stream key to get the stream its undeliverable messages are moved to.
+const DeadLetterSuffix = ":dlq"
+
+// ErrorsSuffix is appended to a stream key to get the hash of its pending messages' last handler errors.
+const ErrorsSuffix = ":errors"
+
+// Fields added to a dead-lettered message next to its original ones.
+const (
+	FieldOriginID   = "dlq_origin_id"
+	FieldGroup      = "dlq_group"
+	FieldReason     = "dlq_reason"
+	FieldError      = "dlq_error"
+	FieldDeliveries = "dlq_deliveries"
+	FieldFailedAt   = "dlq_failed_at"
+)
+
+// Why a message was dead-lettered.
+const (
+	ReasonMalformed     = "malformed"
+	ReasonMaxDeliveries = "max_deliveries"
+	ReasonExpired       = "expired"
+)
+
+const (
+	defaultBatchSize       = 10
+	defaultBlock           = 2 * time.Second
+	defaultReclaimInterval = 15 * time.Second
+	defaultReclaimMinIdle  = 30 * time.Second
+	defaultMaxDeliveries   = 10
+	defaultShutdownTimeout = 10 * time.Second
+
+	trimInterval = 24 * time.Hour
+	retryDelay   = time.Second
+)
+
+type Repository interface {
+	PushMessage(ctx context.Context, stream string, values map[string]interface{}) error
+	ReadEvents(ctx context.Context, args *redis.XReadGroupArgs) ([]redis.XMessage, error)
+	CreateGroup(ctx context.Context, stream, group, id string) error
+	AckMessages(ctx context.Context, stream, group string, messageIDs []string) error
+	ClaimMessage(ctx context.Context, stream, group, consumer string, messageIDs []string) ([]redis.XMessage, error)
+	AutoClaimPendingEvents(ctx context.Context, args *redis.XAutoClaimArgs) ([]redis.XMessage, string, error)
+	PendingEvents(ctx context.Context, args *redis.XPendingExtArgs) ([]redis.XPendingExt, error)
+	TrimStreamByAge(ctx context.Context, stream string, maxAge time.Duration) error
+	SetMessageError(ctx context.Context, key, messageID, errText string) error
+	PopMessageError(ctx context.Context, key, messageID string) (string, error)
+	ClearMessageError(ctx context.Context, key, messageID string) error
+}
+
+// Config describes a consumer group member. Zero values fall back to the defaults above.
+type Config struct {
+	Group    string
+	Consumer string
+	// StartID is where a newly created group starts reading: "0" for the whole stream, "$" for new messages.
+	StartID   string
+	BatchSize int64
+	Block     time.Duration
+	// Messages idle for ReclaimMinIdle are reclaimed every ReclaimInterval and handled again.
+	ReclaimInterval time.Duration
+	ReclaimMinIdle  time.Duration
+	// A message that failed MaxDeliveries times is moved to the dead-letter stream on its next delivery.
+	MaxDeliveries int64
+	// RetentionAge trims older messages from the stream once a day, dead-lettering the ones still pending;
+	// zero keeps them.
+	RetentionAge time.Duration
+	// ShutdownTimeout bounds how long the in-flight message may take once Run's context is done.
+	ShutdownTimeout time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.StartID == "" {
+		c.StartID = "0"
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultBatchSize
+	}
+	if c.Block <= 0 {
+		c.Block = defaultBlock
+	}
+	if c.ReclaimInterval <= 0 {
+		c.ReclaimInterval = defaultReclaimInterval
+	}
+	if c.ReclaimMinIdle <= 0 {
+		c.ReclaimMinIdle = defaultReclaimMinIdle
+	}
+	if c.MaxDeliveries <= 0 {
+		c.MaxDeliveries = defaultMaxDeliveries
+	}
+	if c.ShutdownTimeout <= 0 {
+		c.ShutdownTimeout = defaultShutdownTimeout
+	}
+	return c
+}
+
+// Handler handles one event. A nil error acks the message; otherwise it stays pending and is retried.
+type Handler[P any] func(ctx context.Context, event P) error
+
+// Consumer reads typed events of one stream in a consumer group. E is the event struct, P its pointer
+// implementing entity.Event.
+type Consumer[E any, P interface {
+	*E
+	entity.Event
+}] struct {
+	repo   Repository
+	cfg    Config
+	stream string
+	logger *slog.Logger
+}
+
+func New[E any, P interface {
+	*E
+	entity.Event
+}](repo Repository, cfg Config) *Consumer[E, P] {
+	stream := P(new(E)).StreamKey()
+	cfg = cfg.withDefaults()
+	return &Consumer[E, P]{
+		repo:   repo,
+		cfg:    cfg,
+		stream: stream,
+		logger: slog.With("component", "event_consumer", "stream", stream, "group", cfg.Group, "consumer", cfg.Consumer),
+	}
+}
+
+// Run creates the consumer group if needed and handles new and reclaimed messages until ctx is done.
+// The message being handled then gets ShutdownTimeout to finish; the rest of the batch stays pending.
+func (c *Consumer[E, P]) Run(ctx context.Context, handler Handler[P]) error {
+	if err := c.repo.CreateGroup(ctx, c.stream, c.cfg.Group, c.cfg.StartID); err != nil && !strings.Contains(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("create group %s on %s: %w", c.cfg.Group, c.stream, err)
+	}
+
+	handleCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() { time.AfterFunc(c.cfg.ShutdownTimeout, cancel) })
+	defer stop()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		c.reclaimLoop(ctx, handleCtx, handler)
+	}()
+	go func() {
+		defer wg.Done()
+		c.trimLoop(ctx)
+	}()
+	c.readLoop(ctx, handleCtx, handler)
+	wg.Wait()
+	c.logger.Info("event consumer stopped")
+	return nil
+}
+
+func (c *Consumer[E, P]) readLoop(ctx, handleCtx context.Context, handler Handler[P]) {
+	for ctx.Err() == nil {
+		messages, err := c.repo.ReadEvents(ctx, &redis.XReadGroupArgs{
+			Group:    c.cfg.Group,
+			Consumer: c.cfg.Consumer,
+			Streams:  []string{c.stream, ">"},
+			Count:    c.cfg.BatchSize,
+			Block:    c.cfg.Block,
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				c.logger.Error("read events", "error", err)
+				sleep(ctx, retryDelay)
+			}
+			continue
+		}
+		for _, msg := range messages {
+			if ctx.Err() != nil {
+				break
+			}
+			// A fresh message has been delivered once.
+			c.handle(handleCtx, handler, msg, 1)
+		}
+	}
+}
+
+func (c *Consumer[E, P]) reclaimLoop(ctx, handleCtx context.Context, handler Handler[P]) {
+	ticker := time.NewTicker(c.cfg.ReclaimInterval)
+	defer ticker.Stop()
+	start := "0-0"
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			next, err := c.reclaim(ctx, handleCtx, handler, start)
+			if err != nil {
+				c.logger.Error("reclaim pending events", "error", err)
+				continue
+			}
+			start = next
+		}
+	}
+}
+
+// reclaim claims one page of idle pending messages from start and handles them, returning the next start.
+func (c *Consumer[E, P]) reclaim(ctx, handleCtx context.Context, handler Handler[P], start string) (string, error) {
+	messages, next, err := c.repo.AutoClaimPendingEvents(ctx, &redis.XAutoClaimArgs{
+		Stream:   c.stream,
+		Group:    c.cfg.Group,
+		Consumer: c.cfg.Consumer,
+		MinIdle:  c.cfg.ReclaimMinIdle,
+		Start:    start,
+		Count:    c.cfg.BatchSize,
+	})
+	if err != nil {
+		return start, err
+	}
+	if next == "" {
+		next = "0-0"
+	}
+	if len(messages) == 0 {
+		return next, nil
+	}
+	promReclaimedTotal.WithLabelValues(c.stream, c.cfg.Group).Add(float64(len(messages)))
+
+	deliveries, err := c.deliveries(ctx, messages)
+	if err != nil {
+		return start, err
+	}
+	for _, msg := range messages {
+		if ctx.Err() != nil {
+			break
+		}
+		c.handle(handleCtx, handler, msg, deliveries[msg.ID])
+	}
+	return next, nil
+}
+
+// deliveries returns how many times each claimed message has been delivered, the claim included.
+func (c *Consumer[E, P]) deliveries(ctx context.Context, messages []redis.XMessage) (map[string]int64, error) {
+	pending, err := c.repo.PendingEvents(ctx, &redis.XPendingExtArgs{
+		Stream: c.stream,
+		Group:  c.cfg.Group,
+		Start:  messages[0].ID,
+		End:    messages[len(messages)-1].ID,
+		// Messages of this consumer still being handled by the read loop may fall in the range too.
+		Count:    int64(len(messages)) + c.cfg.BatchSize,
+		Consumer: c.cfg.Consumer,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pending events: %w", err)
+	}
+	out := make(map[string]int64, len(pending))
+	for _, p := range pending {
+		out[p.ID] = p.RetryCount
+	}
+	return out, nil
+}
+
+// handle decodes and handles one message. Malformed messages and messages delivered more than MaxDeliveries
+// times are dead-lettered instead.
+func (c *Consumer[E, P]) handle(ctx context.Context, handler Handler[P], msg redis.XMessage, deliveries int64) {
+	logger := c.logger.With("message_id", msg.ID, "deliveries", deliveries)
+	if deliveries > c.cfg.MaxDeliveries {
+		c.deadLetter(ctx, logger, msg, deliveries, ReasonMaxDeliveries, "")
+		return
+	}
+
+	event := P(new(E))
+	event.SetID(msg.ID)
+	if err := event.FromMap(msg.Values); err != nil {
+		c.deadLetter(ctx, logger, msg, deliveries, ReasonMalformed, err.Error())
+		return
+	}
+
+	started := time.Now()
+	err := handler(ctx, event)
+	promHandleDuration.WithLabelValues(c.stream, c.cfg.Group).Observe(time.Since(started).Seconds())
+	if err != nil {
+		promMessagesTotal.WithLabelValues(c.stream, c.cfg.Group, resultFailed).Inc()
+		logger.Error("handle event", "error", err)
+		if err := c.repo.SetMessageError(ctx, c.stream+ErrorsSuffix, msg.ID, err.Error()); err != nil {
+			logger.Error("remember event error", "error", err)
+		}
+		return
+	}
+	if err := c.repo.AckMessages(ctx, c.stream, c.cfg.Group, []string{msg.ID}); err != nil {
+		logger.Error("ack event", "error", err)
+		return
+	}
+	promMessagesTotal.WithLabelValues(c.stream, c.cfg.Group, resultAcked).Inc()
+	if deliveries > 1 {
+		if err := c.repo.ClearMessageError(ctx, c.stream+ErrorsSuffix, msg.ID); err != nil {
+			logger.Error("forget event error", "error", err)
+		}
+	}
+}
+
+// deadLetter copies the message to the dead-letter stream and acks it, so it is no longer redelivered.
+// Without errText the last error the handler failed the message with is attached. It reports whether the
+// message was moved.
+func (c *Consumer[E, P]) deadLetter(ctx context.Context, logger *slog.Logger, msg redis.XMessage, deliveries int64, reason, errText string) bool {
+	lastErr, err := c.repo.PopMessageError(ctx, c.stream+ErrorsSuffix, msg.ID)
+	if err != nil {
+		logger.Error("get event error", "error", err)
+	}
+	if errText == "" {
+		errText = lastErr
+	}
+
+	values := make(map[string]interface{}, len(msg.Values)+6)
+	for k, v := range msg.Values {
+		values[k] = v
+	}
+	values[FieldOriginID] = msg.ID
+	values[FieldGroup] = c.cfg.Group
+	values[FieldReason] = reason
+	values[FieldError] = errText
+	values[FieldDeliveries] = deliveries
+	values[FieldFailedAt] = time.Now().Unix()
+
+	if err := c.repo.PushMessage(ctx, c.stream+DeadLetterSuffix, values); err != nil {
+		logger.Error("dead-letter event", "error", err)
+		return false
+	}
+	if err := c.repo.AckMessages(ctx, c.stream, c.cfg.Group, []string{msg.ID}); err != nil {
+		logger.Error("ack dead-lettered event", "error", err)
+		return false
+	}
+	promMessagesTotal.WithLabelValues(c.stream, c.cfg.Group, resultDeadLettered).Inc()
+	logger.Warn("event moved to dead-letter stream", "reason", reason, "error", errText)
+	return true
+}
+
+func (c *Consumer[E, P]) trimLoop(ctx context.Context) {
+	if c.cfg.RetentionAge <= 0 {
+		return
+	}
+	ticker := time.NewTicker(trimInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.expirePending(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				c.logger.Error("dead-letter expiring pending events", "error", err)
+				continue
+			}
+			if err := c.repo.TrimStreamByAge(ctx, c.stream, c.cfg.RetentionAge); err != nil && !errors.Is(err, context.Canceled) {
+				c.logger.Error("trim stream by age", "error", err)
+			}
+		}
+	}
+}
+
+// expirePending dead-letters pending messages older than RetentionAge, which trimming would drop unhandled.
+func (c *Consumer[E, P]) expirePending(ctx context.Context) error {
+	cutoff := fmt.Sprintf("%d-0", time.Now().Add(-c.cfg.RetentionAge).UnixMilli())
+	for {
+		pending, err := c.repo.PendingEvents(ctx, &redis.XPendingExtArgs{
+			Stream: c.stream,
+			Group:  c.cfg.Group,
+			Start:  "-",
+			End:    cutoff,
+			Count:  c.cfg.BatchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("pending events: %w", err)
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		ids := make([]string, 0, len(pending))
+		deliveries := make(map[string]int64, len(pending))
+		for _, p := range pending {
+			ids = append(ids, p.ID)
+			deliveries[p.ID] = p.RetryCount
+		}
+		messages, err := c.repo.ClaimMessage(ctx, c.stream, c.cfg.Group, c.cfg.Consumer, ids)
+		if err != nil {
+			return err
+		}
+		claimed := make(map[string]bool, len(messages))
+		moved := 0
+		for _, msg := range messages {
+			claimed[msg.ID] = true
+			if c.deadLetter(ctx, c.logger.With("message_id", msg.ID), msg, deliveries[msg.ID], ReasonExpired, "") {
+				moved++
+			}
+		}
+		// Entries already gone from the stream have nothing left to dead-letter.
+		var gone []string
+		for _, id := range ids {
+			if !claimed[id] {
+				gone = append(gone, id)
+			}
+		}
+		if len(gone) > 0 {
+			if err := c.repo.AckMessages(ctx, c.stream, c.cfg.Group, gone); err != nil {
+				return err
+			}
+		}
+		if moved+len(gone) == 0 || int64(len(pending)) < c.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
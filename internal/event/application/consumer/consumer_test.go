@@ -0,0 +1,272 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ads-mrkt/internal/event/domain/entity"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const testStream = "events:channel_update_stats"
+
+// streamRepo is an in-memory Repository serving a fixed set of new and pending messages.
+type streamRepo struct {
+	mu         sync.Mutex
+	fresh      []redis.XMessage
+	pending    []redis.XMessage
+	deliveries map[string]int64
+	acked      []string
+	pushed     map[string][]map[string]interface{}
+	errs       map[string]string
+}
+
+func newStreamRepo() *streamRepo {
+	return &streamRepo{
+		deliveries: make(map[string]int64),
+		pushed:     make(map[string][]map[string]interface{}),
+		errs:       make(map[string]string),
+	}
+}
+
+func (r *streamRepo) PushMessage(ctx context.Context, stream string, values map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pushed[stream] = append(r.pushed[stream], values)
+	return nil
+}
+
+func (r *streamRepo) ReadEvents(ctx context.Context, args *redis.XReadGroupArgs) ([]redis.XMessage, error) {
+	r.mu.Lock()
+	messages := r.fresh
+	r.fresh = nil
+	r.mu.Unlock()
+	if len(messages) > 0 {
+		return messages, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (r *streamRepo) CreateGroup(ctx context.Context, stream, group, id string) error {
+	return errors.New("BUSYGROUP Consumer Group name already exists")
+}
+
+func (r *streamRepo) AckMessages(ctx context.Context, stream, group string, messageIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.acked = append(r.acked, messageIDs...)
+	return nil
+}
+
+func (r *streamRepo) ClaimMessage(ctx context.Context, stream, group, consumer string, messageIDs []string) ([]redis.XMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []redis.XMessage
+	for _, msg := range r.pending {
+		if slices.Contains(messageIDs, msg.ID) {
+			out = append(out, msg)
+		}
+	}
+	return out, nil
+}
+
+func (r *streamRepo) AutoClaimPendingEvents(ctx context.Context, args *redis.XAutoClaimArgs) ([]redis.XMessage, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	messages := r.pending
+	r.pending = nil
+	return messages, "0-0", nil
+}
+
+func (r *streamRepo) PendingEvents(ctx context.Context, args *redis.XPendingExtArgs) ([]redis.XPendingExt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []redis.XPendingExt
+	for id, n := range r.deliveries {
+		if !slices.Contains(r.acked, id) {
+			out = append(out, redis.XPendingExt{ID: id, Consumer: args.Consumer, RetryCount: n})
+		}
+	}
+	slices.SortFunc(out, func(a, b redis.XPendingExt) int { return strings.Compare(a.ID, b.ID) })
+	return out, nil
+}
+
+func (r *streamRepo) TrimStreamByAge(ctx context.Context, stream string, maxAge time.Duration) error {
+	return nil
+}
+
+func (r *streamRepo) SetMessageError(ctx context.Context, key, messageID, errText string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs[key+"/"+messageID] = errText
+	return nil
+}
+
+func (r *streamRepo) PopMessageError(ctx context.Context, key, messageID string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	errText := r.errs[key+"/"+messageID]
+	delete(r.errs, key+"/"+messageID)
+	return errText, nil
+}
+
+func (r *streamRepo) ClearMessageError(ctx context.Context, key, messageID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.errs, key+"/"+messageID)
+	return nil
+}
+
+func statsMessage(id, channelID string) redis.XMessage {
+	return redis.XMessage{ID: id, Values: map[string]interface{}{"channel_id": channelID}}
+}
+
+func TestConsumerRemembersLastErrorUntilHandled(t *testing.T) {
+	repo := newStreamRepo()
+	c := New[entity.EventChannelUpdateStats](repo, Config{Group: "g", Consumer: "c"})
+	fail := true
+	handler := func(ctx context.Context, ev *entity.EventChannelUpdateStats) error {
+		if fail {
+			return errors.New("bot was blocked by the user")
+		}
+		return nil
+	}
+
+	c.handle(context.Background(), handler, statsMessage("1-0", "1"), 1)
+	if got := repo.errs[testStream+ErrorsSuffix+"/1-0"]; got != "bot was blocked by the user" {
+		t.Fatalf("remembered error = %q", got)
+	}
+	fail = false
+	c.handle(context.Background(), handler, statsMessage("1-0", "1"), 2)
+	if len(repo.errs) != 0 {
+		t.Fatalf("errors left = %v", repo.errs)
+	}
+}
+
+func TestConsumerDeadLettersPendingEventsBeforeTrim(t *testing.T) {
+	repo := newStreamRepo()
+	repo.pending = []redis.XMessage{statsMessage("1-0", "1")}
+	repo.deliveries = map[string]int64{"1-0": 2, "2-0": 1}
+	c := New[entity.EventChannelUpdateStats](repo, Config{Group: "g", Consumer: "c", RetentionAge: time.Hour})
+
+	if err := c.expirePending(context.Background()); err != nil {
+		t.Fatalf("expirePending: %v", err)
+	}
+
+	dead := repo.pushed[testStream+DeadLetterSuffix]
+	if len(dead) != 1 || dead[0][FieldOriginID] != "1-0" || dead[0][FieldReason] != ReasonExpired {
+		t.Fatalf("dead-lettered = %v", dead)
+	}
+	// 2-0 is no longer in the stream: it is acked without a dead letter.
+	if !slices.Equal(repo.acked, []string{"1-0", "2-0"}) {
+		t.Fatalf("acked = %v", repo.acked)
+	}
+}
+
+func TestConsumerAcksOnlyHandledEvents(t *testing.T) {
+	repo := newStreamRepo()
+	c := New[entity.EventChannelUpdateStats](repo, Config{Group: "g", Consumer: "c"})
+	var handled []int64
+	handler := func(ctx context.Context, ev *entity.EventChannelUpdateStats) error {
+		handled = append(handled, ev.ChannelID)
+		if ev.ChannelID == 2 {
+			return errors.New("stats unavailable")
+		}
+		return nil
+	}
+
+	c.handle(context.Background(), handler, statsMessage("1-0", "1"), 1)
+	c.handle(context.Background(), handler, statsMessage("2-0", "2"), 1)
+
+	if !slices.Equal(handled, []int64{1, 2}) {
+		t.Fatalf("handled = %v", handled)
+	}
+	if !slices.Equal(repo.acked, []string{"1-0"}) {
+		t.Fatalf("acked = %v, want [1-0]", repo.acked)
+	}
+}
+
+func TestConsumerDeadLettersMalformedEvent(t *testing.T) {
+	repo := newStreamRepo()
+	c := New[entity.EventChannelUpdateStats](repo, Config{Group: "g", Consumer: "c"})
+	handler := func(ctx context.Context, ev *entity.EventChannelUpdateStats) error {
+		t.Fatal("malformed event handled")
+		return nil
+	}
+
+	c.handle(context.Background(), handler, statsMessage("1-0", "not-a-number"), 1)
+
+	dead := repo.pushed[testStream+DeadLetterSuffix]
+	if len(dead) != 1 || dead[0][FieldOriginID] != "1-0" || dead[0]["channel_id"] != "not-a-number" {
+		t.Fatalf("dead-lettered = %v", dead)
+	}
+	if !slices.Equal(repo.acked, []string{"1-0"}) {
+		t.Fatalf("acked = %v, want [1-0]", repo.acked)
+	}
+}
+
+func TestConsumerReclaimDeadLettersAfterMaxDeliveries(t *testing.T) {
+	repo := newStreamRepo()
+	repo.pending = []redis.XMessage{statsMessage("1-0", "1"), statsMessage("2-0", "2")}
+	repo.deliveries = map[string]int64{"1-0": 3, "2-0": 4}
+	repo.errs[testStream+ErrorsSuffix+"/2-0"] = "stats unavailable"
+	c := New[entity.EventChannelUpdateStats](repo, Config{Group: "g", Consumer: "c", MaxDeliveries: 3})
+	var handled []string
+	handler := func(ctx context.Context, ev *entity.EventChannelUpdateStats) error {
+		handled = append(handled, ev.ID)
+		return nil
+	}
+
+	if _, err := c.reclaim(context.Background(), context.Background(), handler, "0-0"); err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+
+	if !slices.Equal(handled, []string{"1-0"}) {
+		t.Fatalf("handled = %v, want [1-0]", handled)
+	}
+	dead := repo.pushed[testStream+DeadLetterSuffix]
+	if len(dead) != 1 || dead[0][FieldOriginID] != "2-0" || dead[0][FieldDeliveries] != int64(4) ||
+		dead[0][FieldReason] != ReasonMaxDeliveries || dead[0][FieldError] != "stats unavailable" {
+		t.Fatalf("dead-lettered = %v", dead)
+	}
+	if len(repo.errs) != 0 {
+		t.Fatalf("errors left = %v", repo.errs)
+	}
+	if !slices.Equal(repo.acked, []string{"1-0", "2-0"}) {
+		t.Fatalf("acked = %v", repo.acked)
+	}
+}
+
+func TestConsumerRunFinishesInFlightEventOnShutdown(t *testing.T) {
+	repo := newStreamRepo()
+	repo.fresh = []redis.XMessage{statsMessage("1-0", "1"), statsMessage("2-0", "2")}
+	c := New[entity.EventChannelUpdateStats](repo, Config{Group: "g", Consumer: "c"})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error)
+	go func() {
+		done <- c.Run(ctx, func(handleCtx context.Context, ev *entity.EventChannelUpdateStats) error {
+			cancel()
+			return handleCtx.Err()
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	if !slices.Equal(repo.acked, []string{"1-0"}) {
+		t.Fatalf("acked = %v, want only the in-flight [1-0]", repo.acked)
+	}
+}
@@ -0,0 +1,39 @@
+package consumer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus data collector definitions
+
+const (
+	resultAcked        = "acked"
+	resultFailed       = "failed"
+	resultDeadLettered = "dead_lettered"
+)
+
+var promMessagesTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "ads_mrkt_event_consumer_messages_total",
+		Help: "Total number of stream messages handled, by result (acked, failed, dead_lettered)",
+	},
+	[]string{"stream", "group", "result"},
+)
+
+var promHandleDuration = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "ads_mrkt_event_consumer_handle_duration_seconds",
+		Help:    "Duration of a single stream message handler call",
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{"stream", "group"},
+)
+
+var promReclaimedTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "ads_mrkt_event_consumer_reclaimed_total",
+		Help: "Total number of pending stream messages reclaimed with XAUTOCLAIM",
+	},
+	[]string{"stream", "group"},
+)
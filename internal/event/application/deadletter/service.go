@@ -0,0 +1,195 @@
+package deadletter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ads-mrkt/internal/event/application/consumer"
+	"ads-mrkt/internal/event/domain/entity"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	streamPrefix = "events:"
+	pageSize     = 100
+)
+
+// Streams lists the keys of the streams consumed with dead-lettering.
+var Streams = []string{
+	(*entity.EventTelegramUpdate)(nil).StreamKey(),
+	(*entity.EventTelegramNotification)(nil).StreamKey(),
+	(*entity.EventChannelUpdateStats)(nil).StreamKey(),
+	(*entity.EventEscrowDeposit)(nil).StreamKey(),
+}
+
+type repository interface {
+	PushMessage(ctx context.Context, stream string, values map[string]interface{}) error
+	RangeMessages(ctx context.Context, stream, start, end string, count int64) ([]redis.XMessage, error)
+	DeleteMessages(ctx context.Context, stream string, ids []string) (int64, error)
+	StreamLength(ctx context.Context, stream string) (int64, error)
+	DeleteStream(ctx context.Context, stream string) error
+}
+
+// Service inspects dead-letter streams and moves their messages back for another delivery.
+type Service struct {
+	repository repository
+}
+
+func NewService(repository repository) *Service {
+	return &Service{
+		repository: repository,
+	}
+}
+
+// StreamKey accepts a stream key with or without the "events:" prefix.
+func StreamKey(name string) string {
+	if strings.HasPrefix(name, streamPrefix) {
+		return name
+	}
+	return streamPrefix + name
+}
+
+// Counts returns the number of dead letters of each known stream.
+func (s *Service) Counts(ctx context.Context) (map[string]int64, error) {
+	out := make(map[string]int64, len(Streams))
+	for _, stream := range Streams {
+		n, err := s.repository.StreamLength(ctx, stream+consumer.DeadLetterSuffix)
+		if err != nil {
+			return nil, err
+		}
+		out[stream] = n
+	}
+	return out, nil
+}
+
+// List returns up to count dead letters of the stream, oldest first, starting after the dead letter with ID
+// after (from the beginning when empty).
+func (s *Service) List(ctx context.Context, stream, after string, count int64) ([]*entity.DeadLetter, error) {
+	start := "-"
+	if after != "" {
+		start = "(" + after
+	}
+	messages, err := s.repository.RangeMessages(ctx, stream+consumer.DeadLetterSuffix, start, "+", count)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*entity.DeadLetter, 0, len(messages))
+	for _, msg := range messages {
+		out = append(out, toDeadLetter(stream, msg))
+	}
+	return out, nil
+}
+
+// Get returns the dead letter with the ID, nil when there is none.
+func (s *Service) Get(ctx context.Context, stream, id string) (*entity.DeadLetter, error) {
+	messages, err := s.repository.RangeMessages(ctx, stream+consumer.DeadLetterSuffix, id, id, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	return toDeadLetter(stream, messages[0]), nil
+}
+
+// Replay publishes the dead letters with the IDs, all of them when ids is empty, to the stream again as new
+// messages and removes them from the dead-letter stream. It returns the number replayed.
+func (s *Service) Replay(ctx context.Context, stream string, ids []string) (int, error) {
+	replayed := 0
+	replay := func(dl *entity.DeadLetter) error {
+		values := make(map[string]interface{}, len(dl.Values))
+		for k, v := range dl.Values {
+			values[k] = v
+		}
+		if err := s.repository.PushMessage(ctx, stream, values); err != nil {
+			return fmt.Errorf("replay %s: %w", dl.ID, err)
+		}
+		if _, err := s.repository.DeleteMessages(ctx, stream+consumer.DeadLetterSuffix, []string{dl.ID}); err != nil {
+			return fmt.Errorf("remove replayed %s: %w", dl.ID, err)
+		}
+		replayed++
+		return nil
+	}
+
+	if len(ids) > 0 {
+		for _, id := range ids {
+			dl, err := s.Get(ctx, stream, id)
+			if err != nil {
+				return replayed, err
+			}
+			if dl == nil {
+				return replayed, fmt.Errorf("dead letter %s not found in %s", id, stream)
+			}
+			if err := replay(dl); err != nil {
+				return replayed, err
+			}
+		}
+		return replayed, nil
+	}
+
+	// Replayed dead letters are removed, so every page starts from the beginning.
+	for {
+		page, err := s.List(ctx, stream, "", pageSize)
+		if err != nil {
+			return replayed, err
+		}
+		if len(page) == 0 {
+			return replayed, nil
+		}
+		for _, dl := range page {
+			if err := replay(dl); err != nil {
+				return replayed, err
+			}
+		}
+	}
+}
+
+// Purge deletes the dead letters with the IDs, the whole dead-letter stream when ids is empty, and returns
+// the number deleted.
+func (s *Service) Purge(ctx context.Context, stream string, ids []string) (int64, error) {
+	if len(ids) > 0 {
+		return s.repository.DeleteMessages(ctx, stream+consumer.DeadLetterSuffix, ids)
+	}
+	n, err := s.repository.StreamLength(ctx, stream+consumer.DeadLetterSuffix)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.repository.DeleteStream(ctx, stream+consumer.DeadLetterSuffix); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func toDeadLetter(stream string, msg redis.XMessage) *entity.DeadLetter {
+	dl := &entity.DeadLetter{
+		ID:     msg.ID,
+		Stream: stream,
+		Values: make(map[string]string, len(msg.Values)),
+	}
+	for k, v := range msg.Values {
+		value := fmt.Sprint(v)
+		switch k {
+		case consumer.FieldOriginID:
+			dl.OriginID = value
+		case consumer.FieldGroup:
+			dl.Group = value
+		case consumer.FieldReason:
+			dl.Reason = value
+		case consumer.FieldError:
+			dl.Error = value
+		case consumer.FieldDeliveries:
+			dl.Deliveries, _ = strconv.ParseInt(value, 10, 64)
+		case consumer.FieldFailedAt:
+			if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+				dl.FailedAt = time.Unix(ts, 0).UTC()
+			}
+		default:
+			dl.Values[k] = value
+		}
+	}
+	return dl
+}
@@ -0,0 +1,86 @@
+package deadletter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"ads-mrkt/internal/event/application/consumer"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// streams is an in-memory repository; IDs are sequence numbers and ranges are only "-" to "+" or a single ID.
+type streams struct {
+	messages map[string][]redis.XMessage
+	nextID   int
+}
+
+func (s *streams) PushMessage(ctx context.Context, stream string, values map[string]interface{}) error {
+	s.nextID++
+	s.messages[stream] = append(s.messages[stream], redis.XMessage{ID: fmt.Sprintf("%d-0", s.nextID), Values: values})
+	return nil
+}
+
+func (s *streams) RangeMessages(ctx context.Context, stream, start, end string, count int64) ([]redis.XMessage, error) {
+	var out []redis.XMessage
+	for _, msg := range s.messages[stream] {
+		if (start == "-" || msg.ID == start) && int64(len(out)) < count {
+			out = append(out, msg)
+		}
+	}
+	return out, nil
+}
+
+func (s *streams) DeleteMessages(ctx context.Context, stream string, ids []string) (int64, error) {
+	before := len(s.messages[stream])
+	s.messages[stream] = slices.DeleteFunc(s.messages[stream], func(msg redis.XMessage) bool { return slices.Contains(ids, msg.ID) })
+	return int64(before - len(s.messages[stream])), nil
+}
+
+func (s *streams) StreamLength(ctx context.Context, stream string) (int64, error) {
+	return int64(len(s.messages[stream])), nil
+}
+
+func (s *streams) DeleteStream(ctx context.Context, stream string) error {
+	delete(s.messages, stream)
+	return nil
+}
+
+func TestReplayPublishesOriginalFields(t *testing.T) {
+	ctx := context.Background()
+	repo := &streams{messages: make(map[string][]redis.XMessage)}
+	stream := StreamKey("telegram_notification")
+	for _, chatID := range []string{"10", "20"} {
+		_ = repo.PushMessage(ctx, stream+consumer.DeadLetterSuffix, map[string]interface{}{
+			"chat_id":                chatID,
+			"message":                "hi",
+			consumer.FieldOriginID:   "1700000000000-0",
+			consumer.FieldReason:     consumer.ReasonMaxDeliveries,
+			consumer.FieldError:      "Forbidden: bot was blocked by the user",
+			consumer.FieldDeliveries: "11",
+		})
+	}
+	svc := NewService(repo)
+
+	dl, err := svc.Get(ctx, stream, "1-0")
+	if err != nil || dl == nil {
+		t.Fatalf("Get = %v, %v", dl, err)
+	}
+	if dl.Deliveries != 11 || dl.Error != "Forbidden: bot was blocked by the user" || len(dl.Values) != 2 {
+		t.Fatalf("dead letter = %+v", dl)
+	}
+
+	n, err := svc.Replay(ctx, stream, nil)
+	if err != nil || n != 2 {
+		t.Fatalf("Replay = %d, %v", n, err)
+	}
+	if len(repo.messages[stream+consumer.DeadLetterSuffix]) != 0 {
+		t.Fatal("replayed dead letters left in the dead-letter stream")
+	}
+	replayed := repo.messages[stream]
+	if len(replayed) != 2 || replayed[0].Values["chat_id"] != "10" || len(replayed[0].Values) != 2 {
+		t.Fatalf("replayed = %+v", replayed)
+	}
+}
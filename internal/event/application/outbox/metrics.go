@@ -0,0 +1,32 @@
+package outbox
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus data collector definitions
+
+var promOutboxPublishedTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "ads_mrkt_outbox_published_total",
+		Help: "Total number of outbox messages published to their stream",
+	},
+	[]string{"stream"},
+)
+
+var promOutboxPublishErrorsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "ads_mrkt_outbox_publish_errors_total",
+		Help: "Total number of failed attempts to publish an outbox message",
+	},
+	[]string{"stream"},
+)
+
+var promOutboxDeadLetteredTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "ads_mrkt_outbox_dead_lettered_total",
+		Help: "Total number of outbox messages given up on after repeated failed publishes",
+	},
+	[]string{"stream"},
+)
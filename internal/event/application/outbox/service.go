@@ -0,0 +1,157 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ads-mrkt/internal/event/domain/entity"
+)
+
+const (
+	relayInterval   = time.Second
+	relayBatchSize  = 100
+	cleanupInterval = time.Hour
+	sentRetention   = 7 * 24 * time.Hour
+
+	// relayMaxAttempts failed publishes, backing off from relayBaseBackoff to relayMaxBackoff (about an hour in
+	// total), make the relay give up on a message so it no longer holds back the ones after it.
+	relayMaxAttempts = 20
+	relayBaseBackoff = time.Second
+	relayMaxBackoff  = 5 * time.Minute
+)
+
+type repository interface {
+	AddOutboxMessage(ctx context.Context, stream string, values map[string]string) error
+	LockUnsentOutboxMessages(ctx context.Context, limit int) ([]*entity.OutboxMessage, error)
+	MarkOutboxMessagesSent(ctx context.Context, ids []int64) error
+	MarkOutboxMessageFailed(ctx context.Context, id int64, errText string, baseBackoff, maxBackoff time.Duration) error
+	MarkOutboxMessageDead(ctx context.Context, id int64, errText string) error
+	DeleteSentOutboxMessages(ctx context.Context, before time.Time) (int64, error)
+}
+
+type publisher interface {
+	PushMessage(ctx context.Context, stream string, values map[string]interface{}) error
+}
+
+type transactor interface {
+	InTx(ctx context.Context, source string, fn func(ctx context.Context) error) error
+}
+
+// Service stores events in the outbox table and relays them to their Redis streams, at least once.
+type Service struct {
+	repository repository
+	publisher  publisher
+	transactor transactor
+}
+
+func NewService(repository repository, publisher publisher, transactor transactor) *Service {
+	return &Service{
+		repository: repository,
+		publisher:  publisher,
+		transactor: transactor,
+	}
+}
+
+// Add stores the event for the relay. Called with the context of a transaction, the event is kept only if
+// the transaction commits.
+func (s *Service) Add(ctx context.Context, event entity.Event) error {
+	values := make(map[string]string)
+	for k, v := range event.ToMap() {
+		values[k] = fmt.Sprint(v)
+	}
+	return s.repository.AddOutboxMessage(ctx, event.StreamKey(), values)
+}
+
+func (s *Service) AddTelegramNotificationEvent(ctx context.Context, chatID int64, message string) error {
+	return s.Add(ctx, &entity.EventTelegramNotification{ChatID: chatID, Message: message})
+}
+
+// RunRelay publishes stored events until ctx is done and removes the ones sent a week ago.
+func (s *Service) RunRelay(ctx context.Context) {
+	logger := slog.With("component", "outbox_relay")
+	ticker := time.NewTicker(relayInterval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(cleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("outbox relay stopped")
+			return
+		case <-ticker.C:
+			for {
+				sent, err := s.relay(ctx, logger)
+				if err != nil {
+					logger.Error("relay outbox messages", "error", err)
+					break
+				}
+				if sent < relayBatchSize {
+					break
+				}
+			}
+		case <-cleanup.C:
+			n, err := s.repository.DeleteSentOutboxMessages(ctx, time.Now().Add(-sentRetention))
+			if err != nil {
+				logger.Error("delete sent outbox messages", "error", err)
+				continue
+			}
+			if n > 0 {
+				logger.Info("sent outbox messages deleted", "count", n)
+			}
+		}
+	}
+}
+
+// relay publishes one batch of unsent messages in order and marks them sent. It stops at the first message that
+// fails or waits for its backoff, so later events of a stream are not published before it, unless the message failed
+// relayMaxAttempts times: that one is given up on and the batch goes on. It returns the number sent.
+func (s *Service) relay(ctx context.Context, logger *slog.Logger) (sent int, err error) {
+	err = s.transactor.InTx(ctx, "OutboxRelay", func(ctx context.Context) error {
+		messages, err := s.repository.LockUnsentOutboxMessages(ctx, relayBatchSize)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		ids := make([]int64, 0, len(messages))
+		for _, msg := range messages {
+			if msg.RetryAt != nil && msg.RetryAt.After(now) {
+				break
+			}
+			values := make(map[string]interface{}, len(msg.Values))
+			for k, v := range msg.Values {
+				values[k] = v
+			}
+			if pushErr := s.publisher.PushMessage(ctx, msg.Stream, values); pushErr != nil {
+				promOutboxPublishErrorsTotal.WithLabelValues(msg.Stream).Inc()
+				attempts := msg.Attempts + 1
+				if attempts >= relayMaxAttempts {
+					if err := s.repository.MarkOutboxMessageDead(ctx, msg.ID, pushErr.Error()); err != nil {
+						return err
+					}
+					promOutboxDeadLetteredTotal.WithLabelValues(msg.Stream).Inc()
+					logger.Error("outbox message given up", "id", msg.ID, "stream", msg.Stream, "attempts", attempts, "error", pushErr)
+					continue
+				}
+				logger.Warn("publish outbox message", "id", msg.ID, "stream", msg.Stream, "attempts", attempts, "error", pushErr)
+				if err := s.repository.MarkOutboxMessageFailed(ctx, msg.ID, pushErr.Error(), relayBaseBackoff, relayMaxBackoff); err != nil {
+					return err
+				}
+				break
+			}
+			promOutboxPublishedTotal.WithLabelValues(msg.Stream).Inc()
+			ids = append(ids, msg.ID)
+		}
+		if len(ids) > 0 {
+			if err := s.repository.MarkOutboxMessagesSent(ctx, ids); err != nil {
+				return err
+			}
+		}
+		sent = len(ids)
+		return nil
+	})
+	return sent, err
+}
@@ -0,0 +1,158 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"ads-mrkt/internal/event/domain/entity"
+)
+
+// table is an in-memory outbox; "locking" returns the rows neither sent nor dead, in order.
+type table struct {
+	rows   []*entity.OutboxMessage
+	sent   []int64
+	dead   []int64
+	errs   map[int64]string
+	nextID int64
+}
+
+func (t *table) AddOutboxMessage(ctx context.Context, stream string, values map[string]string) error {
+	t.nextID++
+	t.rows = append(t.rows, &entity.OutboxMessage{ID: t.nextID, Stream: stream, Values: values})
+	return nil
+}
+
+func (t *table) LockUnsentOutboxMessages(ctx context.Context, limit int) ([]*entity.OutboxMessage, error) {
+	var out []*entity.OutboxMessage
+	for _, row := range t.rows {
+		if !slices.Contains(t.sent, row.ID) && !slices.Contains(t.dead, row.ID) && len(out) < limit {
+			out = append(out, row)
+		}
+	}
+	return out, nil
+}
+
+func (t *table) MarkOutboxMessagesSent(ctx context.Context, ids []int64) error {
+	t.sent = append(t.sent, ids...)
+	return nil
+}
+
+func (t *table) MarkOutboxMessageFailed(ctx context.Context, id int64, errText string, baseBackoff, maxBackoff time.Duration) error {
+	row := t.rows[id-1]
+	retryAt := time.Now().Add(baseBackoff << row.Attempts)
+	row.Attempts++
+	row.RetryAt = &retryAt
+	t.errs[id] = errText
+	return nil
+}
+
+func (t *table) MarkOutboxMessageDead(ctx context.Context, id int64, errText string) error {
+	t.rows[id-1].Attempts++
+	t.dead = append(t.dead, id)
+	t.errs[id] = errText
+	return nil
+}
+
+// skipBackoff makes failed rows due for the next relay.
+func (t *table) skipBackoff() {
+	for _, row := range t.rows {
+		row.RetryAt = nil
+	}
+}
+
+func (t *table) DeleteSentOutboxMessages(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+type streams struct {
+	failChatID string
+	published  []map[string]interface{}
+}
+
+func (p *streams) PushMessage(ctx context.Context, stream string, values map[string]interface{}) error {
+	if values["chat_id"] == p.failChatID {
+		return errors.New("redis unavailable")
+	}
+	p.published = append(p.published, values)
+	return nil
+}
+
+type directTx struct{}
+
+func (directTx) InTx(ctx context.Context, source string, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestRelayStopsAtFirstFailedMessage(t *testing.T) {
+	ctx := context.Background()
+	repo := &table{errs: make(map[int64]string)}
+	pub := &streams{failChatID: "2"}
+	svc := NewService(repo, pub, directTx{})
+	for _, chatID := range []int64{1, 2, 3} {
+		if err := svc.AddTelegramNotificationEvent(ctx, chatID, "hi"); err != nil {
+			t.Fatalf("AddTelegramNotificationEvent: %v", err)
+		}
+	}
+
+	sent, err := svc.relay(ctx, slog.Default())
+	if err != nil || sent != 1 {
+		t.Fatalf("relay = %d, %v", sent, err)
+	}
+	if !slices.Equal(repo.sent, []int64{1}) || repo.errs[2] != "redis unavailable" {
+		t.Fatalf("sent = %v, errors = %v", repo.sent, repo.errs)
+	}
+
+	// The failed message waits for its backoff and holds back the ones after it.
+	pub.failChatID = ""
+	if sent, err := svc.relay(ctx, slog.Default()); err != nil || sent != 0 {
+		t.Fatalf("relay during backoff = %d, %v", sent, err)
+	}
+	repo.skipBackoff()
+	if sent, err := svc.relay(ctx, slog.Default()); err != nil || sent != 2 {
+		t.Fatalf("relay = %d, %v", sent, err)
+	}
+	if len(pub.published) != 3 || pub.published[1]["chat_id"] != "2" || pub.published[2]["message"] != "hi" {
+		t.Fatalf("published = %v", pub.published)
+	}
+}
+
+func TestRelayGivesUpOnMessageThatKeepsFailing(t *testing.T) {
+	ctx := context.Background()
+	repo := &table{errs: make(map[int64]string)}
+	pub := &streams{failChatID: "1"}
+	svc := NewService(repo, pub, directTx{})
+	for _, chatID := range []int64{1, 2} {
+		if err := svc.AddTelegramNotificationEvent(ctx, chatID, "hi"); err != nil {
+			t.Fatalf("AddTelegramNotificationEvent: %v", err)
+		}
+	}
+
+	for attempt := 1; attempt < relayMaxAttempts; attempt++ {
+		if sent, err := svc.relay(ctx, slog.Default()); err != nil || sent != 0 {
+			t.Fatalf("attempt %d: relay = %d, %v", attempt, sent, err)
+		}
+		if len(repo.dead) != 0 || repo.rows[0].Attempts != attempt {
+			t.Fatalf("attempt %d: dead = %v, attempts = %d", attempt, repo.dead, repo.rows[0].Attempts)
+		}
+		repo.skipBackoff()
+	}
+
+	// The last attempt gives up on the message and the one after it is published in the same batch.
+	sent, err := svc.relay(ctx, slog.Default())
+	if err != nil || sent != 1 {
+		t.Fatalf("relay = %d, %v", sent, err)
+	}
+	if !slices.Equal(repo.dead, []int64{1}) || !slices.Equal(repo.sent, []int64{2}) || repo.errs[1] != "redis unavailable" {
+		t.Fatalf("dead = %v, sent = %v, errors = %v", repo.dead, repo.sent, repo.errs)
+	}
+	if len(pub.published) != 1 || pub.published[0]["chat_id"] != "2" {
+		t.Fatalf("published = %v", pub.published)
+	}
+	if sent, err := svc.relay(ctx, slog.Default()); err != nil || sent != 0 || len(pub.published) != 1 {
+		t.Fatalf("relay after give-up = %d, %v, published %d", sent, err, len(pub.published))
+	}
+}
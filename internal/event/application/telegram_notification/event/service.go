@@ -2,44 +2,22 @@ package event
 
 import (
 	"context"
-	"log"
-	"strings"
-	"time"
 
+	"ads-mrkt/internal/event/application/consumer"
 	"ads-mrkt/internal/event/domain/entity"
-
-	"github.com/redis/go-redis/v9"
 )
 
 type repository interface {
+	consumer.Repository
 	PushEvent(ctx context.Context, event entity.Event) error
-	ReadEvents(ctx context.Context, args *redis.XReadGroupArgs) ([]redis.XMessage, error)
-	CreateGroup(ctx context.Context, stream, group, id string) error
-	AckMessages(ctx context.Context, stream, group string, messageIDs []string) error
-	AutoClaimPendingEvents(ctx context.Context, args *redis.XAutoClaimArgs) ([]redis.XMessage, string, error)
-	TrimStreamByAge(ctx context.Context, stream string, maxAge time.Duration) error
 }
 
 type Service struct {
 	repository repository
 }
 
-const (
-	groupName = "bot"
-)
-
 func NewService(repository repository) *Service {
-	s := &Service{
+	return &Service{
 		repository: repository,
 	}
-
-	streamKey := (*entity.EventTelegramNotification)(nil).StreamKey()
-	err := s.repository.CreateGroup(context.Background(), streamKey, groupName, "0")
-	if err != nil {
-		if !strings.Contains(err.Error(), "BUSYGROUP") {
-			log.Fatalf("failed to create telegram_notification event group: %v", err)
-		}
-	}
-
-	return s
 }
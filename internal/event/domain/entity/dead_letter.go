@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// DeadLetter is a message moved to a stream's dead-letter stream after it could not be handled.
+type DeadLetter struct {
+	ID         string            `json:"id"`
+	Stream     string            `json:"stream"`
+	OriginID   string            `json:"origin_id"`
+	Group      string            `json:"group"`
+	Reason     string            `json:"reason"`
+	Error      string            `json:"error"`
+	Deliveries int64             `json:"deliveries"`
+	FailedAt   time.Time         `json:"failed_at"`
+	Values     map[string]string `json:"values"` // fields the message was published with
+}
@@ -1,31 +1,61 @@
 package entity
 
 import (
+	"fmt"
 	"strconv"
-
-	"github.com/google/uuid"
 )
 
 type Event interface {
 	ToMap() map[string]interface{}
-	FromMap(m map[string]interface{})
+	// FromMap fills the event from stream entry fields and fails on missing or malformed ones.
+	FromMap(m map[string]interface{}) error
 	StreamKey() string
+	SetID(id string)
+}
+
+func stringField(m map[string]interface{}, k string) (string, error) {
+	switch v := m[k].(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case nil:
+		return "", fmt.Errorf("missing field %q", k)
+	default:
+		return "", fmt.Errorf("field %q: unexpected type %T", k, v)
+	}
+}
+
+func int64Field(m map[string]interface{}, k string) (int64, error) {
+	switch v := m[k].(type) {
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("field %q: %w", k, err)
+		}
+		return n, nil
+	case int64:
+		return v, nil
+	case float64:
+		return int64(v), nil
+	case nil:
+		return 0, fmt.Errorf("missing field %q", k)
+	default:
+		return 0, fmt.Errorf("field %q: unexpected type %T", k, v)
+	}
 }
 
-func mustParseInt64(v interface{}) int64 {
-	vStr := v.(string)
-	vInt, err := strconv.ParseInt(vStr, 10, 64)
-	if err != nil {
-		return -1
+// optionalString and optionalInt64 read fields older producers may leave out.
+func optionalString(m map[string]interface{}, k string) (string, error) {
+	if _, ok := m[k]; !ok {
+		return "", nil
 	}
-	return vInt
+	return stringField(m, k)
 }
 
-func mustParseUUID(v interface{}) uuid.UUID {
-	vStr := v.(string)
-	vUUID, err := uuid.Parse(vStr)
-	if err != nil {
-		return uuid.Nil
+func optionalInt64(m map[string]interface{}, k string) (int64, error) {
+	if _, ok := m[k]; !ok {
+		return 0, nil
 	}
-	return vUUID
+	return int64Field(m, k)
 }
@@ -17,10 +17,15 @@ func (e *EventChannelUpdateStats) ToMap() map[string]interface{} {
 	}
 }
 
-func (e *EventChannelUpdateStats) FromMap(m map[string]interface{}) {
-	e.ChannelID = int64FromMap(m, "channel_id")
+func (e *EventChannelUpdateStats) FromMap(m map[string]interface{}) (err error) {
+	e.ChannelID, err = int64Field(m, "channel_id")
+	return err
 }
 
 func (e *EventChannelUpdateStats) StreamKey() string {
 	return streamKeyChannelUpdateStats
 }
+
+func (e *EventChannelUpdateStats) SetID(id string) {
+	e.ID = id
+}
@@ -25,14 +25,27 @@ func (e *EventCryptoPayment) ToMap() map[string]interface{} {
 	}
 }
 
-func (e *EventCryptoPayment) FromMap(m map[string]interface{}) {
-	e.Address = m["address"].(string)
-	e.Currency = m["currency"].(string)
-	e.Amount = mustParseInt64(m["amount"])
-	e.TxHash = m["tx_hash"].(string)
-	e.Timestamp = mustParseInt64(m["timestamp"])
+func (e *EventCryptoPayment) FromMap(m map[string]interface{}) (err error) {
+	if e.Address, err = stringField(m, "address"); err != nil {
+		return err
+	}
+	if e.Currency, err = stringField(m, "currency"); err != nil {
+		return err
+	}
+	if e.Amount, err = int64Field(m, "amount"); err != nil {
+		return err
+	}
+	if e.TxHash, err = stringField(m, "tx_hash"); err != nil {
+		return err
+	}
+	e.Timestamp, err = int64Field(m, "timestamp")
+	return err
 }
 
 func (e *EventCryptoPayment) StreamKey() string {
 	return streamCryptoPayment
 }
+
+func (e *EventCryptoPayment) SetID(id string) {
+	e.ID = id
+}
@@ -19,11 +19,18 @@ func (e *EventTelegramNotification) ToMap() map[string]interface{} {
 	}
 }
 
-func (e *EventTelegramNotification) FromMap(m map[string]interface{}) {
-	e.ChatID = int64FromMap(m, "chat_id")
-	e.Message = stringFromMap(m, "message")
+func (e *EventTelegramNotification) FromMap(m map[string]interface{}) (err error) {
+	if e.ChatID, err = int64Field(m, "chat_id"); err != nil {
+		return err
+	}
+	e.Message, err = stringField(m, "message")
+	return err
 }
 
 func (e *EventTelegramNotification) StreamKey() string {
 	return streamKeyTelegramNotification
 }
+
+func (e *EventTelegramNotification) SetID(id string) {
+	e.ID = id
+}
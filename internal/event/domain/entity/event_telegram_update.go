@@ -3,6 +3,7 @@ package entity
 import (
 	"ads-mrkt/internal/helpers/telegram"
 	"encoding/json"
+	"fmt"
 )
 
 const (
@@ -28,19 +29,23 @@ func (e *EventTelegramUpdate) ToMap() map[string]interface{} {
 	}
 }
 
-func (e *EventTelegramUpdate) FromMap(m map[string]interface{}) {
-	raw, ok := m["update"].(string)
-	if !ok {
-		return
+func (e *EventTelegramUpdate) FromMap(m map[string]interface{}) error {
+	raw, err := stringField(m, "update")
+	if err != nil {
+		return err
 	}
 	e.Update = &telegram.Update{}
-	err := json.Unmarshal([]byte(raw), e.Update)
-	if err != nil {
-		return
+	if err := json.Unmarshal([]byte(raw), e.Update); err != nil {
+		return fmt.Errorf("field %q: %w", "update", err)
 	}
-	e.Timestamp = mustParseInt64(m["timestamp"])
+	e.Timestamp, err = int64Field(m, "timestamp")
+	return err
 }
 
 func (e *EventTelegramUpdate) StreamKey() string {
 	return streamKeyTelegramUpdate
 }
+
+func (e *EventTelegramUpdate) SetID(id string) {
+	e.ID = id
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// OutboxMessage is an event stored in Postgres until the relay publishes it to its stream.
+type OutboxMessage struct {
+	ID        int64
+	Stream    string
+	Values    map[string]string
+	Attempts  int
+	RetryAt   *time.Time // not published before; set after a failed attempt
+	CreatedAt time.Time
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"ads-mrkt/internal/event/domain/entity"
+)
+
+type OutboxMessageRow struct {
+	ID        int64             `db:"id"`
+	Stream    string            `db:"stream"`
+	Payload   map[string]string `db:"payload"`
+	Attempts  int               `db:"attempts"`
+	RetryAt   *time.Time        `db:"retry_at"`
+	CreatedAt time.Time         `db:"created_at"`
+}
+
+func OutboxMessageRowToEntity(row OutboxMessageRow) *entity.OutboxMessage {
+	return &entity.OutboxMessage{
+		ID:        row.ID,
+		Stream:    row.Stream,
+		Values:    row.Payload,
+		Attempts:  row.Attempts,
+		RetryAt:   row.RetryAt,
+		CreatedAt: row.CreatedAt,
+	}
+}
@@ -0,0 +1,126 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ads-mrkt/internal/event/domain/entity"
+	"ads-mrkt/internal/event/repository/outbox/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type database interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+type repository struct {
+	db database
+}
+
+func New(db database) *repository {
+	return &repository{db: db}
+}
+
+// AddOutboxMessage stores the message, in the caller's transaction when ctx carries one.
+func (r *repository) AddOutboxMessage(ctx context.Context, stream string, values map[string]string) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO market.outbox (stream, payload)
+		VALUES (@stream, @payload)`,
+		pgx.NamedArgs{
+			"stream":  stream,
+			"payload": values,
+		})
+	if err != nil {
+		return fmt.Errorf("failed to add outbox message: %w", err)
+	}
+	return nil
+}
+
+// LockUnsentOutboxMessages returns the oldest messages neither sent nor given up on, locked until the transaction
+// in ctx ends; messages locked by another relay are skipped.
+func (r *repository) LockUnsentOutboxMessages(ctx context.Context, limit int) ([]*entity.OutboxMessage, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, stream, payload, attempts, retry_at, created_at
+		FROM market.outbox
+		WHERE sent_at IS NULL AND failed_at IS NULL
+		ORDER BY id
+		LIMIT @limit
+		FOR UPDATE SKIP LOCKED`,
+		pgx.NamedArgs{"limit": limit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	list, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.OutboxMessageRow])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect outbox messages: %w", err)
+	}
+	out := make([]*entity.OutboxMessage, 0, len(list))
+	for _, row := range list {
+		out = append(out, model.OutboxMessageRowToEntity(row))
+	}
+	return out, nil
+}
+
+func (r *repository) MarkOutboxMessagesSent(ctx context.Context, ids []int64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE market.outbox
+		SET sent_at = NOW(), attempts = attempts + 1, last_error = NULL, retry_at = NULL
+		WHERE id = ANY(@ids)`,
+		pgx.NamedArgs{"ids": ids})
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox messages sent: %w", err)
+	}
+	return nil
+}
+
+// MarkOutboxMessageFailed records a failed publish and holds the message back for a backoff that doubles with
+// every attempt, from baseBackoff up to maxBackoff.
+func (r *repository) MarkOutboxMessageFailed(ctx context.Context, id int64, errText string, baseBackoff, maxBackoff time.Duration) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE market.outbox
+		SET attempts = attempts + 1, last_error = @last_error,
+			retry_at = NOW() + make_interval(secs => LEAST(@base_seconds * power(2, attempts), @max_seconds))
+		WHERE id = @id`,
+		pgx.NamedArgs{
+			"id":           id,
+			"last_error":   errText,
+			"base_seconds": baseBackoff.Seconds(),
+			"max_seconds":  maxBackoff.Seconds(),
+		})
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message failed: %w", err)
+	}
+	return nil
+}
+
+// MarkOutboxMessageDead records the last failed publish and gives up on the message; it stays in the table with
+// failed_at set and is no longer relayed.
+func (r *repository) MarkOutboxMessageDead(ctx context.Context, id int64, errText string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE market.outbox
+		SET attempts = attempts + 1, last_error = @last_error, retry_at = NULL, failed_at = NOW()
+		WHERE id = @id`,
+		pgx.NamedArgs{"id": id, "last_error": errText})
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message dead: %w", err)
+	}
+	return nil
+}
+
+// DeleteSentOutboxMessages removes messages sent before the given time and returns how many were removed.
+func (r *repository) DeleteSentOutboxMessages(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `
+		DELETE FROM market.outbox
+		WHERE sent_at IS NOT NULL AND sent_at < @before`,
+		pgx.NamedArgs{"before": before})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sent outbox messages: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
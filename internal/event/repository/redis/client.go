@@ -18,6 +18,13 @@ type db interface {
 	XPendingAutoClaim(ctx context.Context, args *redisclient.XAutoClaimArgs) *redisclient.XAutoClaimCmd
 	XGroupDelConsumer(ctx context.Context, stream, group, consumer string) (int64, error)
 	XTrim(ctx context.Context, stream, minId string, limit int64) *redisclient.IntCmd
+	XRangeN(ctx context.Context, stream, start, stop string, count int64) *redisclient.XMessageSliceCmd
+	XDel(ctx context.Context, stream string, ids ...string) *redisclient.IntCmd
+	XLen(ctx context.Context, stream string) *redisclient.IntCmd
+	Del(ctx context.Context, keys ...string) error
+	HSet(ctx context.Context, key, field string, value interface{}) error
+	HGet(ctx context.Context, key, field string) (string, error)
+	HDel(ctx context.Context, key string, fields ...string) error
 }
 
 type repository struct {
@@ -21,6 +21,18 @@ func (r *repository) PushEvent(ctx context.Context, event entity.Event) error {
 	return nil
 }
 
+// PushMessage adds raw field values to the stream, e.g. to move a message to another stream as is.
+func (r *repository) PushMessage(ctx context.Context, stream string, values map[string]interface{}) error {
+	if cmd := r.db.XAdd(ctx, &redisclient.XAddArgs{
+		Stream: stream,
+		Values: values,
+	}); cmd.Err() != nil {
+		return fmt.Errorf("failed to add message to stream: %w", cmd.Err())
+	}
+
+	return nil
+}
+
 // ReadEvents reads events from the stream. Provide the event to be read as an argument (do not initialize it).
 func (r *repository) ReadEvents(ctx context.Context, args *redisclient.XReadGroupArgs) ([]redisclient.XMessage, error) {
 	cmd := r.db.XReadGroup(ctx, args)
@@ -85,3 +97,56 @@ func (r *repository) TrimStreamByAge(ctx context.Context, group string, maxAge t
 	cmd := r.db.XTrim(ctx, group, fmt.Sprintf("%d-0", time.Now().Add(-maxAge).UnixMilli()), 0)
 	return cmd.Err()
 }
+
+// RangeMessages returns up to count messages with IDs between start and end, both inclusive ("-" and "+" for the ends).
+func (r *repository) RangeMessages(ctx context.Context, stream, start, end string, count int64) ([]redisclient.XMessage, error) {
+	cmd := r.db.XRangeN(ctx, stream, start, end, count)
+	if cmd.Err() != nil {
+		return nil, fmt.Errorf("failed to range stream: %w", cmd.Err())
+	}
+	return cmd.Val(), nil
+}
+
+func (r *repository) DeleteMessages(ctx context.Context, stream string, ids []string) (int64, error) {
+	cmd := r.db.XDel(ctx, stream, ids...)
+	if cmd.Err() != nil {
+		return 0, fmt.Errorf("failed to delete messages: %w", cmd.Err())
+	}
+	return cmd.Val(), nil
+}
+
+func (r *repository) StreamLength(ctx context.Context, stream string) (int64, error) {
+	cmd := r.db.XLen(ctx, stream)
+	if cmd.Err() != nil {
+		return 0, fmt.Errorf("failed to get stream length: %w", cmd.Err())
+	}
+	return cmd.Val(), nil
+}
+
+func (r *repository) DeleteStream(ctx context.Context, stream string) error {
+	if err := r.db.Del(ctx, stream); err != nil {
+		return fmt.Errorf("failed to delete stream: %w", err)
+	}
+	return nil
+}
+
+// SetMessageError remembers the last error a message failed with, keyed by message ID in the hash.
+func (r *repository) SetMessageError(ctx context.Context, key, messageID, errText string) error {
+	return r.db.HSet(ctx, key, messageID, errText)
+}
+
+// PopMessageError returns and forgets the message's last error; empty when it has none.
+func (r *repository) PopMessageError(ctx context.Context, key, messageID string) (string, error) {
+	errText, err := r.db.HGet(ctx, key, messageID)
+	if err != nil {
+		return "", err
+	}
+	if errText == "" {
+		return "", nil
+	}
+	return errText, r.db.HDel(ctx, key, messageID)
+}
+
+func (r *repository) ClearMessageError(ctx context.Context, key, messageID string) error {
+	return r.db.HDel(ctx, key, messageID)
+}
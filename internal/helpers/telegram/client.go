@@ -19,8 +19,9 @@ import (
 type TelegramPath string
 
 const (
-	telegramBasePath                                = "https://api.telegram.org/bot"
-	telegramFileBasePath                            = "https://api.telegram.org/file/bot"
+	telegramDefaultBaseURL                          = "https://api.telegram.org"
+	telegramBotPathPrefix                           = "/bot"
+	telegramFilePathPrefix                          = "/file/bot"
 	telegramPathSendMessage            TelegramPath = "/sendMessage"
 	telegramPathSendVideo              TelegramPath = "/sendVideo"
 	telegramPathGetFile                TelegramPath = "/getFile"
@@ -46,6 +47,7 @@ type redisClient interface {
 }
 
 type APIClient struct {
+	baseURL       string
 	token         string
 	botUsername   string
 	botWebAppName string
@@ -55,7 +57,12 @@ type APIClient struct {
 }
 
 func NewAPIClient(ctx context.Context, cfg config.Config, redisClient redisClient) *APIClient {
+	baseURL := strings.TrimRight(cfg.APIBaseURL, "/")
+	if baseURL == "" {
+		baseURL = telegramDefaultBaseURL
+	}
 	return &APIClient{
+		baseURL:       baseURL,
 		token:         cfg.Token,
 		botUsername:   cfg.BotUsername,
 		botWebAppName: cfg.BotWebAppName,
@@ -204,8 +211,9 @@ func (c *APIClient) RefundStarPayment(ctx context.Context, userID int64, telegra
 
 func (c *APIClient) buildTelegramURL(path TelegramPath) string { //nolint:unparam
 	b := strings.Builder{}
-	b.Grow(len(telegramBasePath) + len(c.token) + len(path))
-	b.WriteString(telegramBasePath)
+	b.Grow(len(c.baseURL) + len(telegramBotPathPrefix) + len(c.token) + len(path))
+	b.WriteString(c.baseURL)
+	b.WriteString(telegramBotPathPrefix)
 	b.WriteString(c.token)
 	b.WriteString(string(path))
 	return b.String()
@@ -0,0 +1,112 @@
+package telegram_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"ads-mrkt/internal/helpers/telegram"
+	"ads-mrkt/internal/helpers/telegram/telegramtest"
+)
+
+func newClient(t *testing.T) (*telegram.APIClient, *telegramtest.Server) {
+	t.Helper()
+	srv := telegramtest.NewServer()
+	t.Cleanup(srv.Close)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return telegram.NewAPIClient(ctx, srv.Config(), telegramtest.RateLimitStore{}), srv
+}
+
+func TestCreateForumTopicAndCopyMessage(t *testing.T) {
+	client, srv := newClient(t)
+	ctx := context.Background()
+
+	threadID, err := client.CreateForumTopic(ctx, 42, "Deal #7")
+	if err != nil {
+		t.Fatalf("CreateForumTopic: %v", err)
+	}
+	if threadID != 1 {
+		t.Fatalf("thread id = %d, want 1", threadID)
+	}
+
+	copied, err := client.CopyMessage(ctx, 42, 10, 43, &threadID)
+	if err != nil {
+		t.Fatalf("CopyMessage: %v", err)
+	}
+	if copied == 0 {
+		t.Fatal("copied message id is zero")
+	}
+
+	topics := srv.Calls("createForumTopic")
+	if len(topics) != 1 || topics[0].Int64("chat_id") != 42 || topics[0].String("name") != "Deal #7" {
+		t.Fatalf("unexpected createForumTopic calls: %+v", topics)
+	}
+	copies := srv.Calls("copyMessage")
+	if len(copies) != 1 {
+		t.Fatalf("got %d copyMessage calls, want 1", len(copies))
+	}
+	c := copies[0]
+	if c.Int64("chat_id") != 43 || c.Int64("from_chat_id") != 42 || c.Int64("message_id") != 10 || c.Int64("message_thread_id") != threadID {
+		t.Fatalf("unexpected copyMessage payload: %+v", c.Payload)
+	}
+}
+
+func TestCopyMessageWithoutThread(t *testing.T) {
+	client, srv := newClient(t)
+	if _, err := client.CopyMessage(context.Background(), 1, 2, 3, nil); err != nil {
+		t.Fatalf("CopyMessage: %v", err)
+	}
+	if _, ok := srv.Calls("copyMessage")[0].Payload["message_thread_id"]; ok {
+		t.Fatal("message_thread_id must be omitted")
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	client, srv := newClient(t)
+	msg, err := client.SendMessage(context.Background(), 100, "hello")
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if msg.MessageID == 0 {
+		t.Fatal("message id is zero")
+	}
+	calls := srv.Calls("sendMessage")
+	if len(calls) != 1 || calls[0].Int64("chat_id") != 100 || calls[0].String("text") != "hello" {
+		t.Fatalf("unexpected sendMessage calls: %+v", calls)
+	}
+}
+
+func TestSendMessageMapsErrors(t *testing.T) {
+	client, srv := newClient(t)
+	ctx := context.Background()
+
+	srv.RateLimitNext("sendMessage", 7)
+	_, err := client.SendMessage(ctx, 1, "x")
+	var retryErr *telegram.RetryAfterError
+	if !errors.As(err, &retryErr) || retryErr.RetryAfter != 7 {
+		t.Fatalf("err = %v, want RetryAfterError(7)", err)
+	}
+
+	srv.FailNext("sendMessage", http.StatusForbidden, "Forbidden: bot was blocked by the user")
+	if _, err = client.SendMessage(ctx, 1, "x"); !errors.Is(err, telegram.ErrUserForbidden) {
+		t.Fatalf("err = %v, want ErrUserForbidden", err)
+	}
+
+	srv.FailNext("sendMessage", http.StatusBadRequest, "Bad Request: chat not found")
+	if _, err = client.SendMessage(ctx, 1, "x"); !errors.Is(err, telegram.ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetFileURLUsesBaseURL(t *testing.T) {
+	client, srv := newClient(t)
+	url, err := client.GetFileURL("abc")
+	if err != nil {
+		t.Fatalf("GetFileURL: %v", err)
+	}
+	if want := srv.URL() + "/file/bot" + telegramtest.Token + "/files/abc"; url != want {
+		t.Fatalf("url = %s, want %s", url, want)
+	}
+}
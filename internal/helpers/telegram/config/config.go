@@ -6,4 +6,6 @@ type Config struct {
 	BotWebAppName string `env:"BOT_WEB_APP_NAME"`
 	SecretToken   string `env:"SECRET_TOKEN"`
 	RateLimit     int    `env:"RATE_LIMIT" env-default:"30"`
+	// APIBaseURL is the Bot API server root; override it to point the client at a local Bot API server or a test fake.
+	APIBaseURL string `env:"API_BASE_URL" env-default:"https://api.telegram.org"`
 }
@@ -51,8 +51,9 @@ func (c *APIClient) getFile(fileID string) (*File, error) {
 
 func (c *APIClient) buildTelegramFileURL(path string) string { //nolint:unparam
 	b := strings.Builder{}
-	b.Grow(len(telegramFileBasePath) + len(c.token) + len(path))
-	b.WriteString(telegramFileBasePath)
+	b.Grow(len(c.baseURL) + len(telegramFilePathPrefix) + len(c.token) + len(path))
+	b.WriteString(c.baseURL)
+	b.WriteString(telegramFilePathPrefix)
 	b.WriteString(c.token)
 	b.WriteString(path)
 	return b.String()
@@ -0,0 +1,182 @@
+// Package telegramtest provides an in-process fake of the Telegram Bot API for tests.
+// Point telegram.APIClient at it through config.Config.APIBaseURL (see Server.Config).
+package telegramtest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"ads-mrkt/internal/helpers/telegram/config"
+)
+
+const Token = "123456:TEST"
+
+// Call is a single Bot API request received by the fake.
+type Call struct {
+	Method  string
+	Payload map[string]any
+}
+
+// Int64 returns a numeric payload field (JSON numbers decode as float64).
+func (c Call) Int64(field string) int64 {
+	v, _ := c.Payload[field].(float64)
+	return int64(v)
+}
+
+// String returns a string payload field.
+func (c Call) String(field string) string {
+	v, _ := c.Payload[field].(string)
+	return v
+}
+
+type scriptedError struct {
+	code        int
+	description string
+	retryAfter  int
+}
+
+// Server records Bot API calls and answers them with plausible results.
+// Message ids and forum topic thread ids are allocated sequentially starting at 1.
+type Server struct {
+	srv *httptest.Server
+
+	mu           sync.Mutex
+	calls        []Call
+	errors       map[string][]scriptedError
+	nextMessage  int64
+	nextThreadID int64
+}
+
+func NewServer() *Server {
+	s := &Server{errors: make(map[string][]scriptedError)}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Config returns a bot config targeting this server with a generous rate limit.
+func (s *Server) Config() config.Config {
+	return config.Config{
+		Token:       Token,
+		BotUsername: "TestBot",
+		RateLimit:   1000,
+		APIBaseURL:  s.srv.URL,
+	}
+}
+
+// FailNext makes the next call of method fail with the given Bot API error code and description.
+func (s *Server) FailNext(method string, code int, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[method] = append(s.errors[method], scriptedError{code: code, description: description})
+}
+
+// RateLimitNext makes the next call of method fail with 429 and retry_after.
+func (s *Server) RateLimitNext(method string, retryAfter int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[method] = append(s.errors[method], scriptedError{code: http.StatusTooManyRequests, description: "Too Many Requests", retryAfter: retryAfter})
+}
+
+// Calls returns recorded calls of method, or all calls when method is empty.
+func (s *Server) Calls(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Call
+	for _, c := range s.calls {
+		if method == "" || c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	prefix := "/bot" + Token + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{"ok": false, "error_code": http.StatusUnauthorized, "description": "Unauthorized"})
+		return
+	}
+	method := strings.TrimPrefix(r.URL.Path, prefix)
+
+	payload := map[string]any{}
+	if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+		if err := json.Unmarshal(body, &payload); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error_code": http.StatusBadRequest, "description": "Bad Request: can't parse JSON"})
+			return
+		}
+	}
+	for k, v := range r.URL.Query() {
+		payload[k] = v[0]
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: method, Payload: payload})
+	if queue := s.errors[method]; len(queue) > 0 {
+		e := queue[0]
+		s.errors[method] = queue[1:]
+		s.mu.Unlock()
+		resp := map[string]any{"ok": false, "error_code": e.code, "description": e.description}
+		if e.retryAfter > 0 {
+			resp["parameters"] = map[string]int{"retry_after": e.retryAfter}
+		}
+		writeJSON(w, e.code, resp)
+		return
+	}
+	result := s.result(method, payload)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "result": result})
+}
+
+// result must be called with mu held.
+func (s *Server) result(method string, payload map[string]any) any {
+	switch method {
+	case "sendMessage", "sendVideo":
+		s.nextMessage++
+		return map[string]any{"message_id": s.nextMessage, "chat": map[string]any{"id": payload["chat_id"]}}
+	case "copyMessage":
+		s.nextMessage++
+		return map[string]any{"message_id": s.nextMessage}
+	case "createForumTopic":
+		s.nextThreadID++
+		return map[string]any{"message_thread_id": s.nextThreadID, "name": payload["name"]}
+	case "getFile":
+		fileID, _ := payload["file_id"].(string)
+		return map[string]any{"file_id": fileID, "file_path": "files/" + fileID}
+	case "createInvoiceLink":
+		return "https://t.me/$invoice"
+	default:
+		return true
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// RateLimitStore satisfies the redis client used by telegram.NewAPIClient and never throttles.
+type RateLimitStore struct{}
+
+func (RateLimitStore) Decr(ctx context.Context, key string) (int64, error) {
+	return 1, nil
+}
+
+func (RateLimitStore) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return nil
+}
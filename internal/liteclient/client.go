@@ -2,6 +2,7 @@ package liteclient
 
 import (
 	"context"
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -19,6 +20,7 @@ import (
 	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
 )
 
 const (
@@ -37,11 +39,10 @@ const (
 )
 
 type client struct {
-	api ton.APIClientWrapped
+	nodes *nodePool
 }
 
 func NewClient(ctx context.Context, cfg config.Config, isTestnet bool, public bool) (*client, error) {
-	pool := liteclient.NewConnectionPool()
 	var globalConfig *liteclient.GlobalConfig
 	var err error
 	if cfg.GlobalConfigDir != "" {
@@ -59,28 +60,14 @@ func NewClient(ctx context.Context, cfg config.Config, isTestnet bool, public bo
 			return nil, fmt.Errorf("failed to get global config: %w", err)
 		}
 	}
-	if !public {
-		if err = pool.AddConnection(ctx, cfg.LiteserverHost, cfg.LiteserverKey); err != nil {
-			return nil, fmt.Errorf("failed to add connection: %w", err)
-		}
-
-	} else {
-		err = pool.AddConnectionsFromConfig(ctx, globalConfig)
-		if err != nil {
-			return nil, fmt.Errorf("failed to add connections from config: %w", err)
-		}
-	}
-
-	api := ton.NewAPIClient(pool, ton.ProofCheckPolicyFast)
-	api.SetTrustedBlockFromConfig(globalConfig)
-
-	slog.Info("fetching and checking proofs since config init block ...")
-	_, err = api.CurrentMasterchainInfo(ctx)
+	nodes, err := newNodePool(ctx, cfg, globalConfig, public)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get current masterchain info: %w", err)
+		return nil, err
 	}
+	go nodes.Run(ctx)
+
 	return &client{
-		api: api,
+		nodes: nodes,
 	}, nil
 }
 
@@ -140,8 +127,9 @@ func fetchGlobalConfig(ctx context.Context, url string) (*liteclient.GlobalConfi
 	return nil, lastErr
 }
 
+// Client returns the API client of the currently healthiest liteserver.
 func (c *client) Client() ton.APIClientWrapped {
-	return c.api
+	return c.nodes.API()
 }
 
 func (c *client) GetTransactionIDsFromBlock(ctx context.Context, blockID *ton.BlockIDExt) ([]ton.TransactionShortInfo, error) {
@@ -185,33 +173,33 @@ func (c *client) GetTransactionIDsFromBlock(ctx context.Context, blockID *ton.Bl
 }
 
 func (c *client) GetBlockTransactionsV2(ctx context.Context, block *ton.BlockIDExt, count uint32, after ...*ton.TransactionID3) ([]ton.TransactionShortInfo, bool, error) {
-	return c.api.WithRetry().GetBlockTransactionsV2(ctx, block, count, after...)
+	return c.nodes.API().WithRetry().GetBlockTransactionsV2(ctx, block, count, after...)
 }
 
 func (c *client) GetMasterchainInfo(ctx context.Context, timeout time.Duration) (*ton.BlockIDExt, error) {
 	if timeout == 0 {
 		timeout = 3 * time.Second
 	}
-	return c.api.WithTimeout(timeout).WithRetry().CurrentMasterchainInfo(ctx)
+	return c.nodes.API().WithTimeout(timeout).WithRetry().CurrentMasterchainInfo(ctx)
 }
 
 func (c *client) GetBlockShardsInfo(ctx context.Context, master *ton.BlockIDExt) ([]*ton.BlockIDExt, error) {
-	return c.api.WithRetry().GetBlockShardsInfo(ctx, master)
+	return c.nodes.API().WithRetry().GetBlockShardsInfo(ctx, master)
 }
 
 func (c *client) GetBlockData(ctx context.Context, block *ton.BlockIDExt) (*tlb.Block, error) {
-	return c.api.WithRetry().GetBlockData(ctx, block)
+	return c.nodes.API().WithRetry().GetBlockData(ctx, block)
 }
 
 func (c *client) GetTransaction(ctx context.Context, block *ton.BlockIDExt, addr *address.Address, lt uint64) (*tlb.Transaction, error) {
-	return c.api.WithRetry().GetTransaction(ctx, block, addr, lt)
+	return c.nodes.API().WithRetry().GetTransaction(ctx, block, addr, lt)
 }
 
 func (c *client) LookupBlock(ctx context.Context, timeout time.Duration, workchain int32, shard int64, seqno uint32) (*ton.BlockIDExt, error) {
 	if timeout == 0 {
 		timeout = 3 * time.Second
 	}
-	return c.api.WithTimeout(timeout).WithRetry().LookupBlock(ctx, workchain, shard, seqno)
+	return c.nodes.API().WithTimeout(timeout).WithRetry().LookupBlock(ctx, workchain, shard, seqno)
 }
 
 func IsNotReadyError(err error) bool {
@@ -222,18 +210,19 @@ func IsNotReadyError(err error) bool {
 // with the given amount (nanoton) to the given destination address.
 // Used e.g. to recover when a previous run transferred but crashed before updating status.
 func (c *client) HasOutgoingTxTo(ctx context.Context, fromAddr *address.Address, amountNanoton int64, toAddr *address.Address) (bool, error) {
-	block, err := c.api.CurrentMasterchainInfo(ctx)
+	api := c.nodes.API()
+	block, err := api.CurrentMasterchainInfo(ctx)
 	if err != nil {
 		return false, err
 	}
-	account, err := c.api.GetAccount(ctx, block, fromAddr)
+	account, err := api.GetAccount(ctx, block, fromAddr)
 	if err != nil {
 		return false, err
 	}
 	if account == nil || account.LastTxLT == 0 {
 		return false, nil
 	}
-	txs, err := c.api.ListTransactions(ctx, fromAddr, 20, account.LastTxLT, account.LastTxHash)
+	txs, err := api.ListTransactions(ctx, fromAddr, 20, account.LastTxLT, account.LastTxHash)
 	if err != nil {
 		return false, err
 	}
@@ -265,3 +254,25 @@ func (c *client) HasOutgoingTxTo(ctx context.Context, fromAddr *address.Address,
 	}
 	return false, nil
 }
+
+// GetAccountBalance returns the current balance (nanoton) of the account; missing accounts have zero balance.
+func (c *client) GetAccountBalance(ctx context.Context, addr *address.Address) (int64, error) {
+	api := c.nodes.API()
+	block, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	account, err := api.WithRetry().GetAccount(ctx, block, addr)
+	if err != nil {
+		return 0, err
+	}
+	if account == nil || account.State == nil {
+		return 0, nil
+	}
+	return account.State.Balance.Nano().Int64(), nil
+}
+
+// GetWalletPublicKey calls the get_public_key getter of a deployed wallet contract.
+func (c *client) GetWalletPublicKey(ctx context.Context, addr *address.Address) (ed25519.PublicKey, error) {
+	return wallet.GetPublicKey(ctx, c.nodes.API().WithRetry(), addr)
+}
@@ -1,5 +1,11 @@
 package config
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 var (
 	// GlobalConfigURL is used when GlobalConfigDir is not set (e.g. local dev).
 	GlobalConfigURL map[bool]string = map[bool]string{
@@ -14,7 +20,37 @@ var (
 )
 
 type Config struct {
-	LiteserverHost  string `env:"HOST"`
-	LiteserverKey   string `env:"KEY"`
-	GlobalConfigDir string `env:"GLOBAL_CONFIG_DIR"` // If set, load global config from this dir; otherwise fetch from URL
+	LiteserverHost  string   `env:"HOST"`
+	LiteserverKey   string   `env:"KEY"`
+	Liteservers     []string `env:"LITESERVERS" env-separator:","` // Extra private liteservers as "host:port|base64key"
+	GlobalConfigDir string   `env:"GLOBAL_CONFIG_DIR"`             // If set, load global config from this dir; otherwise fetch from URL
+
+	HealthCheckInterval time.Duration `env:"HEALTH_CHECK_INTERVAL" env-default:"15s"`
+	HealthCheckTimeout  time.Duration `env:"HEALTH_CHECK_TIMEOUT" env-default:"3s"`
+	MaxSeqnoLag         uint32        `env:"MAX_SEQNO_LAG" env-default:"3"` // Node is unhealthy when its masterchain seqno is behind the best one by more than this
+}
+
+type Liteserver struct {
+	Host string
+	Key  string
+}
+
+// PrivateLiteservers returns HOST/KEY (if set) followed by every entry of LITESERVERS.
+func (c Config) PrivateLiteservers() ([]Liteserver, error) {
+	var out []Liteserver
+	if c.LiteserverHost != "" {
+		out = append(out, Liteserver{Host: c.LiteserverHost, Key: c.LiteserverKey})
+	}
+	for _, raw := range c.Liteservers {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		host, key, ok := strings.Cut(raw, "|")
+		if !ok || host == "" || key == "" {
+			return nil, fmt.Errorf("invalid liteserver %q, expected host:port|key", raw)
+		}
+		out = append(out, Liteserver{Host: host, Key: key})
+	}
+	return out, nil
 }
@@ -0,0 +1,55 @@
+package liteclient
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus data collector definitions
+
+var promLiteserverUp = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "ads_mrkt_liteclient_liteserver_up",
+		Help: "Whether the liteserver passed the last health probe (1) or not (0)",
+	},
+	[]string{"node"},
+)
+
+var promLiteserverActive = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "ads_mrkt_liteclient_liteserver_active",
+		Help: "Whether the liteserver is currently used for requests",
+	},
+	[]string{"node"},
+)
+
+var promLiteserverSeqno = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "ads_mrkt_liteclient_liteserver_masterchain_seqno",
+		Help: "Last masterchain seqno reported by the liteserver",
+	},
+	[]string{"node"},
+)
+
+var promLiteserverSeqnoLag = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "ads_mrkt_liteclient_liteserver_seqno_lag",
+		Help: "Number of masterchain blocks the liteserver is behind the best known one",
+	},
+	[]string{"node"},
+)
+
+var promLiteserverLatency = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "ads_mrkt_liteclient_liteserver_probe_latency_seconds",
+		Help: "Latency of the last health probe",
+	},
+	[]string{"node"},
+)
+
+var promLiteserverFailoversTotal = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Name: "ads_mrkt_liteclient_failovers_total",
+		Help: "Total number of active liteserver switches",
+	},
+)
@@ -0,0 +1,249 @@
+package liteclient
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ads-mrkt/internal/liteclient/config"
+
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+const publicNodeName = "public"
+
+// A healthy active node is replaced by a faster one only when the other is faster by more than minSwitchMargin and
+// by more than 1/switchMarginDivisor of the active latency, so probe jitter does not make the pool flap.
+const (
+	minSwitchMargin     = 50 * time.Millisecond
+	switchMarginDivisor = 4
+)
+
+// node is a single API client backed either by one private liteserver or by the public config pool.
+type node struct {
+	name    string
+	public  bool
+	api     ton.APIClientWrapped
+	mu      sync.RWMutex
+	ok      bool
+	seqno   uint32
+	latency time.Duration
+}
+
+func (n *node) setProbe(ok bool, seqno uint32, latency time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.ok = ok
+	n.seqno = seqno
+	n.latency = latency
+}
+
+func (n *node) probe() (ok bool, seqno uint32, latency time.Duration) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.ok, n.seqno, n.latency
+}
+
+// nodePool probes every node periodically and keeps a healthy one as active.
+// Private nodes are preferred; the public pool is used only when no private node is healthy. The active node is
+// kept while it is healthy unless a private node replaces the public pool or another node is clearly faster.
+type nodePool struct {
+	nodes       []*node
+	active      atomic.Pointer[node]
+	interval    time.Duration
+	timeout     time.Duration
+	maxSeqnoLag uint32
+}
+
+func newNodePool(ctx context.Context, cfg config.Config, globalConfig *liteclient.GlobalConfig, public bool) (*nodePool, error) {
+	p := &nodePool{
+		interval:    cfg.HealthCheckInterval,
+		timeout:     cfg.HealthCheckTimeout,
+		maxSeqnoLag: cfg.MaxSeqnoLag,
+	}
+
+	if !public {
+		servers, err := cfg.PrivateLiteservers()
+		if err != nil {
+			return nil, err
+		}
+		for _, ls := range servers {
+			pool := liteclient.NewConnectionPool()
+			if err := pool.AddConnection(ctx, ls.Host, ls.Key); err != nil {
+				slog.Error("failed to add liteserver connection", "host", ls.Host, "error", err)
+				promLiteserverUp.WithLabelValues(ls.Host).Set(0)
+				continue
+			}
+			p.nodes = append(p.nodes, newNode(ls.Host, false, pool, globalConfig))
+		}
+	}
+
+	pool := liteclient.NewConnectionPool()
+	if err := pool.AddConnectionsFromConfig(ctx, globalConfig); err != nil {
+		if len(p.nodes) == 0 {
+			return nil, fmt.Errorf("failed to add connections from config: %w", err)
+		}
+		slog.Error("failed to add public liteserver connections, continuing without fallback", "error", err)
+	} else {
+		p.nodes = append(p.nodes, newNode(publicNodeName, true, pool, globalConfig))
+	}
+
+	if len(p.nodes) == 0 {
+		return nil, fmt.Errorf("no liteserver connections available")
+	}
+
+	slog.Info("fetching and checking proofs since config init block ...")
+	// The first probe verifies proofs from the config init block, which may take long, so it is not time limited.
+	p.probeAll(ctx, 0)
+	if !p.selectActive() {
+		return nil, fmt.Errorf("failed to get current masterchain info: no healthy liteserver")
+	}
+	return p, nil
+}
+
+func newNode(name string, public bool, pool *liteclient.ConnectionPool, globalConfig *liteclient.GlobalConfig) *node {
+	api := ton.NewAPIClient(pool, ton.ProofCheckPolicyFast)
+	api.SetTrustedBlockFromConfig(globalConfig)
+	return &node{name: name, public: public, api: api}
+}
+
+func (p *nodePool) API() ton.APIClientWrapped {
+	return p.active.Load().api
+}
+
+// Run probes all nodes every interval and switches the active node when needed.
+func (p *nodePool) Run(ctx context.Context) {
+	logger := slog.With("component", "liteserver_health")
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("liteserver health checker stopped")
+			return
+		case <-ticker.C:
+			p.probeAll(ctx, p.timeout)
+			if !p.selectActive() {
+				logger.Warn("no healthy liteserver, keeping current", "node", p.active.Load().name)
+			}
+		}
+	}
+}
+
+func (p *nodePool) probeAll(ctx context.Context, timeout time.Duration) {
+	var wg sync.WaitGroup
+	for _, n := range p.nodes {
+		wg.Add(1)
+		go func(n *node) {
+			defer wg.Done()
+			api := n.api
+			if timeout > 0 {
+				api = api.WithTimeout(timeout)
+			}
+			start := time.Now()
+			block, err := api.CurrentMasterchainInfo(ctx)
+			latency := time.Since(start)
+			if err != nil {
+				slog.Debug("liteserver probe failed", "node", n.name, "error", err)
+				n.setProbe(false, 0, latency)
+				return
+			}
+			n.setProbe(true, block.SeqNo, latency)
+		}(n)
+	}
+	wg.Wait()
+}
+
+// selectActive marks nodes healthy or not by seqno lag and picks the active node: the current one while it is
+// healthy and not clearly beaten, otherwise the fastest healthy node. It returns false when no node is healthy; the
+// active node is left unchanged in that case.
+func (p *nodePool) selectActive() bool {
+	current := p.active.Load()
+	currentHealthy := false
+
+	var best uint32
+	for _, n := range p.nodes {
+		if ok, seqno, _ := n.probe(); ok && seqno > best {
+			best = seqno
+		}
+	}
+
+	var chosen *node
+	for _, n := range p.nodes {
+		ok, seqno, latency := n.probe()
+		healthy := ok && best-seqno <= p.maxSeqnoLag
+
+		promLiteserverUp.WithLabelValues(n.name).Set(boolToFloat(healthy))
+		promLiteserverLatency.WithLabelValues(n.name).Set(latency.Seconds())
+		if ok {
+			promLiteserverSeqno.WithLabelValues(n.name).Set(float64(seqno))
+			promLiteserverSeqnoLag.WithLabelValues(n.name).Set(float64(best - seqno))
+		}
+
+		if !healthy {
+			continue
+		}
+		if n == current {
+			currentHealthy = true
+		}
+		if chosen == nil || betterNode(n, latency, chosen) {
+			chosen = n
+		}
+	}
+	if chosen == nil {
+		return false
+	}
+	if currentHealthy && !clearlyBetter(chosen, current) {
+		chosen = current
+	}
+
+	prev := p.active.Swap(chosen)
+	if prev != chosen {
+		if prev != nil {
+			promLiteserverFailoversTotal.Inc()
+			slog.Warn("switched active liteserver", "from", prev.name, "to", chosen.name)
+		} else {
+			slog.Info("active liteserver selected", "node", chosen.name)
+		}
+	}
+	for _, n := range p.nodes {
+		promLiteserverActive.WithLabelValues(n.name).Set(boolToFloat(n == chosen))
+	}
+	return true
+}
+
+// betterNode reports whether n (with the given latency) should be preferred over current.
+func betterNode(n *node, latency time.Duration, current *node) bool {
+	if n.public != current.public {
+		return !n.public
+	}
+	_, _, currentLatency := current.probe()
+	return latency < currentLatency
+}
+
+// clearlyBetter reports whether candidate is worth switching to from the healthy current node: it is private and
+// current is the public pool, or it is faster by a clear margin.
+func clearlyBetter(candidate, current *node) bool {
+	if candidate == current {
+		return false
+	}
+	if candidate.public != current.public {
+		return !candidate.public
+	}
+	_, _, candidateLatency := candidate.probe()
+	_, _, currentLatency := current.probe()
+	margin := max(minSwitchMargin, currentLatency/switchMarginDivisor)
+	return candidateLatency+margin < currentLatency
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+package liteclient
+
+import (
+	"testing"
+	"time"
+)
+
+// probe is the result of a health probe of a test node; a zero probe is a failed one.
+type probe struct {
+	ok      bool
+	seqno   uint32
+	latency time.Duration
+}
+
+func TestSelectActive(t *testing.T) {
+	const ms = time.Millisecond
+	up := func(seqno uint32, latency time.Duration) probe {
+		return probe{ok: true, seqno: seqno, latency: latency}
+	}
+
+	tests := []struct {
+		name   string
+		active string // "" before the first selection
+		probes map[string]probe
+		want   string
+		ok     bool
+	}{
+		{"first selection takes the fastest private", "", map[string]probe{"a": up(100, 80*ms), "b": up(100, 40*ms), "public": up(100, 10*ms)}, "b", true},
+		{"jitter keeps the active node", "a", map[string]probe{"a": up(100, 60*ms), "b": up(100, 40*ms), "public": up(100, 10*ms)}, "a", true},
+		{"small relative gain keeps the active node", "a", map[string]probe{"a": up(100, 400*ms), "b": up(100, 320*ms), "public": up(100, 10*ms)}, "a", true},
+		{"clearly faster node takes over", "a", map[string]probe{"a": up(100, 200*ms), "b": up(100, 40*ms), "public": up(100, 10*ms)}, "b", true},
+		{"failed active node is replaced", "a", map[string]probe{"a": {}, "b": up(100, 300*ms), "public": up(100, 10*ms)}, "b", true},
+		{"lagging active node is replaced", "a", map[string]probe{"a": up(96, 10*ms), "b": up(100, 300*ms), "public": up(100, 10*ms)}, "b", true},
+		{"lag within the limit keeps the active node", "a", map[string]probe{"a": up(97, 60*ms), "b": up(100, 40*ms), "public": up(100, 10*ms)}, "a", true},
+		{"public pool only when no private node is healthy", "a", map[string]probe{"a": {}, "b": up(90, 10*ms), "public": up(100, 500*ms)}, "public", true},
+		{"private node replaces the public pool", "public", map[string]probe{"a": up(100, 500*ms), "b": {}, "public": up(100, 10*ms)}, "a", true},
+		{"no healthy node keeps the active one", "b", map[string]probe{"a": {}, "b": {}, "public": {}}, "b", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &nodePool{maxSeqnoLag: 3}
+			byName := make(map[string]*node)
+			for _, name := range []string{"a", "b", publicNodeName} {
+				n := &node{name: name, public: name == publicNodeName}
+				pr := tt.probes[name]
+				n.setProbe(pr.ok, pr.seqno, pr.latency)
+				p.nodes = append(p.nodes, n)
+				byName[name] = n
+			}
+			if tt.active != "" {
+				p.active.Store(byName[tt.active])
+			}
+
+			if ok := p.selectActive(); ok != tt.ok {
+				t.Fatalf("selectActive = %v, want %v", ok, tt.ok)
+			}
+			if got := p.active.Load().name; got != tt.want {
+				t.Fatalf("active = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	v5SignOpcode     = 0x7369676e
+	v5ActionSendMsg  = 0x0ec3c86d
+	commentOpcode    = 0
+	v5HeaderBitsSize = 32 * 4 // op, wallet id, valid until, seqno
+)
+
+// apiClient implements the part of ton.APIClientWrapped used by wallet v5r1 transfers.
+// Calling any other method panics, which is intended: the simulator must be extended explicitly.
+type apiClient struct {
+	ton.APIClientWrapped
+	chain *Chain
+}
+
+func (a *apiClient) CurrentMasterchainInfo(ctx context.Context) (*ton.BlockIDExt, error) {
+	a.chain.mu.Lock()
+	defer a.chain.mu.Unlock()
+	return a.chain.master, nil
+}
+
+func (a *apiClient) WaitForBlock(seqno uint32) ton.APIClientWrapped {
+	return a
+}
+
+func (a *apiClient) WithRetry(maxRetries ...int) ton.APIClientWrapped {
+	return a
+}
+
+func (a *apiClient) WithTimeout(timeout time.Duration) ton.APIClientWrapped {
+	return a
+}
+
+// GetAccount reports a wallet as active once it has sent at least one external message.
+func (a *apiClient) GetAccount(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+	a.chain.mu.Lock()
+	defer a.chain.mu.Unlock()
+	if a.chain.seqnos[addr.StringRaw()] == 0 {
+		return &tlb.Account{IsActive: false}, nil
+	}
+	return &tlb.Account{
+		IsActive: true,
+		State: &tlb.AccountState{
+			IsValid:        true,
+			Address:        addr,
+			AccountStorage: tlb.AccountStorage{Status: tlb.AccountStatusActive},
+		},
+	}, nil
+}
+
+func (a *apiClient) RunGetMethod(ctx context.Context, block *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+	if method != "seqno" {
+		return nil, fmt.Errorf("simulator: get method %q is not supported", method)
+	}
+	a.chain.mu.Lock()
+	defer a.chain.mu.Unlock()
+	seqno, ok := a.chain.seqnos[addr.StringRaw()]
+	if !ok {
+		return nil, ton.ContractExecError{Code: ton.ErrCodeContractNotInitialized}
+	}
+	return ton.NewExecutionResult([]any{big.NewInt(int64(seqno))}), nil
+}
+
+// SendExternalMessage decodes a wallet v5r1 external message and records its outgoing transfers.
+func (a *apiClient) SendExternalMessage(ctx context.Context, msg *tlb.ExternalMessage) error {
+	transfers, err := decodeV5Transfers(msg)
+	if err != nil {
+		return fmt.Errorf("simulator: %w", err)
+	}
+	a.chain.mu.Lock()
+	defer a.chain.mu.Unlock()
+	for _, t := range transfers {
+		if a.chain.rejected[t.To.StringRaw()] {
+			return fmt.Errorf("simulator: transfer to %s rejected", t.To.StringRaw())
+		}
+	}
+	a.chain.transfers = append(a.chain.transfers, transfers...)
+	for _, t := range transfers {
+		a.chain.balances[t.From.StringRaw()] -= t.Amount
+	}
+	a.chain.seqnos[msg.DstAddr.StringRaw()]++
+	return nil
+}
+
+func decodeV5Transfers(msg *tlb.ExternalMessage) ([]Transfer, error) {
+	if msg.Body == nil {
+		return nil, fmt.Errorf("empty external message body")
+	}
+	body := msg.Body.BeginParse()
+	op, err := body.LoadUInt(32)
+	if err != nil || op != v5SignOpcode {
+		return nil, fmt.Errorf("not a wallet v5r1 signed message")
+	}
+	if _, err = body.LoadSlice(v5HeaderBitsSize - 32); err != nil {
+		return nil, fmt.Errorf("load header: %w", err)
+	}
+	hasActions, err := body.LoadUInt(1)
+	if err != nil {
+		return nil, fmt.Errorf("load actions flag: %w", err)
+	}
+	if hasActions == 0 {
+		return nil, nil
+	}
+	list, err := body.LoadRef()
+	if err != nil {
+		return nil, fmt.Errorf("load action list: %w", err)
+	}
+
+	var transfers []Transfer
+	for list.RefsNum() > 0 {
+		prev, err := list.LoadRef()
+		if err != nil {
+			return nil, err
+		}
+		tag, err := list.LoadUInt(32)
+		if err != nil {
+			return nil, err
+		}
+		if tag != v5ActionSendMsg {
+			return nil, fmt.Errorf("unsupported out action %x", tag)
+		}
+		if _, err = list.LoadUInt(8); err != nil { // mode
+			return nil, err
+		}
+		outMsg, err := list.LoadRef()
+		if err != nil {
+			return nil, err
+		}
+		var internal tlb.InternalMessage
+		if err = tlb.LoadFromCell(&internal, outMsg); err != nil {
+			return nil, fmt.Errorf("load internal message: %w", err)
+		}
+		transfers = append(transfers, Transfer{
+			From:    msg.DstAddr,
+			To:      internal.DstAddr,
+			Amount:  internal.Amount.Nano().Int64(),
+			Comment: loadComment(internal.Body),
+		})
+		list = prev
+	}
+	// Actions are stored newest first.
+	for i, j := 0, len(transfers)-1; i < j; i, j = i+1, j-1 {
+		transfers[i], transfers[j] = transfers[j], transfers[i]
+	}
+	return transfers, nil
+}
+
+func loadComment(body *cell.Cell) string {
+	if body == nil {
+		return ""
+	}
+	s := body.BeginParse()
+	op, err := s.LoadUInt(32)
+	if err != nil || op != commentOpcode {
+		return ""
+	}
+	comment, err := s.LoadStringSnake()
+	if err != nil {
+		return ""
+	}
+	return comment
+}
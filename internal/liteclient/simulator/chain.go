@@ -0,0 +1,246 @@
+// Package simulator is an in-process TON chain used to run escrow and observer flows offline.
+// Chain implements the observer's lt interface and the escrow's liteclient interface.
+package simulator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+// Shard is the single basechain shard produced by the simulator.
+const Shard int64 = -0x8000000000000000
+
+// Transfer is an outgoing internal message sent by a wallet through SendExternalMessage.
+type Transfer struct {
+	From    *address.Address
+	To      *address.Address
+	Amount  int64
+	Comment string
+}
+
+type deposit struct {
+	to     *address.Address
+	amount int64
+}
+
+type shardBlock struct {
+	id  *ton.BlockIDExt
+	txs []*tlb.Transaction
+}
+
+type Chain struct {
+	mu          sync.Mutex
+	master      *ton.BlockIDExt
+	shards      map[uint32]*shardBlock
+	lastShard   *ton.BlockIDExt
+	pending     []deposit
+	transfers   []Transfer
+	seqnos      map[string]uint32
+	balances    map[string]int64
+	rejected    map[string]bool
+	nextLT      uint64
+	masterPolls int
+}
+
+func New() *Chain {
+	c := &Chain{
+		shards:   make(map[uint32]*shardBlock),
+		seqnos:   make(map[string]uint32),
+		balances: make(map[string]int64),
+		rejected: make(map[string]bool),
+		nextLT:   1_000_000,
+	}
+	c.master = blockID(-1, -0x8000000000000000, 1)
+	c.lastShard = blockID(0, Shard, 1)
+	c.shards[1] = &shardBlock{id: c.lastShard}
+	return c
+}
+
+func blockID(workchain int32, shard int64, seqno uint32) *ton.BlockIDExt {
+	seed := make([]byte, 16)
+	binary.BigEndian.PutUint32(seed[0:4], uint32(workchain))
+	binary.BigEndian.PutUint64(seed[4:12], uint64(shard))
+	binary.BigEndian.PutUint32(seed[12:16], seqno)
+	root := sha256.Sum256(append([]byte("root"), seed...))
+	file := sha256.Sum256(append([]byte("file"), seed...))
+	return &ton.BlockIDExt{
+		Workchain: workchain,
+		Shard:     shard,
+		SeqNo:     seqno,
+		RootHash:  root[:],
+		FileHash:  file[:],
+	}
+}
+
+// Deposit queues an incoming internal transfer to addr; it is included in the next block.
+func (c *Chain) Deposit(addr *address.Address, amountNanoton int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending = append(c.pending, deposit{to: addr, amount: amountNanoton})
+}
+
+// NextBlock produces a new master block with one new shard block holding all queued deposits.
+func (c *Chain) NextBlock() *ton.BlockIDExt {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	shard := &shardBlock{id: blockID(0, Shard, c.lastShard.SeqNo+1)}
+	now := uint32(time.Now().Unix())
+	for _, d := range c.pending {
+		c.nextLT++
+		tx := &tlb.Transaction{
+			AccountAddr: d.to.Data(),
+			LT:          c.nextLT,
+			Now:         now,
+		}
+		tx.IO.In = &tlb.Message{
+			MsgType: tlb.MsgTypeInternal,
+			Msg: &tlb.InternalMessage{
+				Bounce:    true,
+				SrcAddr:   address.NewAddressNone(),
+				DstAddr:   d.to,
+				Amount:    tlb.FromNanoTON(big.NewInt(d.amount)),
+				CreatedLT: c.nextLT,
+				CreatedAt: now,
+			},
+		}
+		c.balances[d.to.StringRaw()] += d.amount
+		hash := sha256.Sum256(fmt.Appendf(nil, "%s:%d", d.to.StringRaw(), c.nextLT))
+		tx.Hash = hash[:]
+		shard.txs = append(shard.txs, tx)
+	}
+	c.pending = nil
+	c.shards[shard.id.SeqNo] = shard
+	c.lastShard = shard.id
+	c.master = blockID(-1, -0x8000000000000000, c.master.SeqNo+1)
+	return c.master
+}
+
+// RejectTransfersTo makes SendExternalMessage fail for messages with a transfer to addr,
+// like a destination the payout can never reach. AcceptTransfersTo undoes it.
+func (c *Chain) RejectTransfersTo(addr *address.Address) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rejected[addr.StringRaw()] = true
+}
+
+func (c *Chain) AcceptTransfersTo(addr *address.Address) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.rejected, addr.StringRaw())
+}
+
+// Transfers returns all outgoing transfers sent so far.
+func (c *Chain) Transfers() []Transfer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Transfer(nil), c.transfers...)
+}
+
+// MasterchainPolls returns how many times GetMasterchainInfo was called; observers poll it on start.
+func (c *Chain) MasterchainPolls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.masterPolls
+}
+
+func (c *Chain) GetMasterchainInfo(ctx context.Context, timeout time.Duration) (*ton.BlockIDExt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.masterPolls++
+	return c.master, nil
+}
+
+func (c *Chain) GetBlockShardsInfo(ctx context.Context, master *ton.BlockIDExt) ([]*ton.BlockIDExt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Master N always references shard N.
+	if _, ok := c.shards[master.SeqNo]; !ok {
+		return nil, fmt.Errorf("unknown master block %d", master.SeqNo)
+	}
+	return []*ton.BlockIDExt{c.shards[master.SeqNo].id}, nil
+}
+
+func (c *Chain) GetBlockData(ctx context.Context, block *ton.BlockIDExt) (*tlb.Block, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.shards[block.SeqNo]; !ok {
+		return nil, fmt.Errorf("unknown shard block %d", block.SeqNo)
+	}
+	prev := blockID(0, Shard, block.SeqNo-1)
+	header := tlb.BlockHeader{
+		PrevRef: tlb.BlkPrevInfo{
+			Prev1: tlb.ExtBlkRef{SeqNo: prev.SeqNo, RootHash: prev.RootHash, FileHash: prev.FileHash},
+		},
+	}
+	header.NotMaster = true
+	header.SeqNo = block.SeqNo
+	header.Shard = tlb.ShardIdent{WorkchainID: 0, PrefixBits: 0, ShardPrefix: 0}
+	return &tlb.Block{BlockInfo: header}, nil
+}
+
+func (c *Chain) GetTransactionIDsFromBlock(ctx context.Context, blockID *ton.BlockIDExt) ([]ton.TransactionShortInfo, error) {
+	list, _, err := c.GetBlockTransactionsV2(ctx, blockID, 0)
+	return list, err
+}
+
+func (c *Chain) GetBlockTransactionsV2(ctx context.Context, block *ton.BlockIDExt, count uint32, after ...*ton.TransactionID3) ([]ton.TransactionShortInfo, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sb, ok := c.shards[block.SeqNo]
+	if !ok {
+		return nil, false, fmt.Errorf("unknown shard block %d", block.SeqNo)
+	}
+	list := make([]ton.TransactionShortInfo, 0, len(sb.txs))
+	for _, tx := range sb.txs {
+		list = append(list, ton.TransactionShortInfo{Account: tx.AccountAddr, LT: tx.LT, Hash: tx.Hash})
+	}
+	return list, false, nil
+}
+
+func (c *Chain) GetTransaction(ctx context.Context, block *ton.BlockIDExt, addr *address.Address, lt uint64) (*tlb.Transaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sb, ok := c.shards[block.SeqNo]
+	if !ok {
+		return nil, fmt.Errorf("unknown shard block %d", block.SeqNo)
+	}
+	for _, tx := range sb.txs {
+		if tx.LT == lt && addr.Equals(address.NewAddress(0, 0, tx.AccountAddr)) {
+			return tx, nil
+		}
+	}
+	return nil, fmt.Errorf("transaction %d not found", lt)
+}
+
+// Client returns a fake API client sufficient for wallet.FromSeed / wallet.Transfer.
+func (c *Chain) Client() ton.APIClientWrapped {
+	return &apiClient{chain: c}
+}
+
+func (c *Chain) HasOutgoingTxTo(ctx context.Context, fromAddr *address.Address, amountNanoton int64, toAddr *address.Address) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range c.transfers {
+		if t.From.Equals(fromAddr) && t.To.Equals(toAddr) && t.Amount == amountNanoton {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetAccountBalance returns deposits included in blocks minus transfers sent by the account.
+func (c *Chain) GetAccountBalance(ctx context.Context, addr *address.Address) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.balances[addr.StringRaw()], nil
+}
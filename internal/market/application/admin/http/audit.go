@@ -0,0 +1,44 @@
+package http
+
+import (
+	"net/http"
+
+	"ads-mrkt/internal/market/domain/entity"
+	_ "ads-mrkt/internal/server/templates/response"
+)
+
+// @Tags		Admin
+// @Summary	List admin console changes, newest first. Admins only.
+// @Produce	json
+// @Param		admin_id	query		int												false	"Staff member who made the change"
+// @Param		action		query		string											false	"e.g. user.ban, listing.block, deal.escrow_refund"
+// @Param		target_type	query		string											false	"user | listing | deal | escrow"
+// @Param		target_id	query		int												false	"ID of the user, listing or deal"
+// @Param		limit		query		int												false	"Page size, default 50, at most 200"
+// @Param		offset		query		int												false	"Entries to skip"
+// @Success	200			{object}	response.Template{data=[]entity.AdminAuditEntry}	"Audit log entries"
+// @Failure	400			{object}	response.Template{data=string}					"Bad request"
+// @Router		/admin/audit-log [get]
+func (h *handler) ListAuditLog(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	filter := entity.AdminAuditFilter{
+		Action:     entity.AdminAction(q.Get("action")),
+		TargetType: entity.AdminTargetType(q.Get("target_type")),
+	}
+	var err error
+	if filter.AdminID, err = parseQueryID(r, "admin_id"); err != nil {
+		return nil, err
+	}
+	if filter.TargetID, err = parseQueryID(r, "target_id"); err != nil {
+		return nil, err
+	}
+	if filter.Limit, filter.Offset, err = parsePage(r); err != nil {
+		return nil, err
+	}
+
+	entries, err := h.adminService.ListAuditLog(r.Context(), filter)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return entries, nil
+}
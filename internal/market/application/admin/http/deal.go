@@ -0,0 +1,79 @@
+package http
+
+import (
+	"net/http"
+
+	apperrors "ads-mrkt/internal/errors"
+	"ads-mrkt/internal/market/application/admin/http/model"
+	marketmodel "ads-mrkt/internal/market/application/market/http/model"
+	"ads-mrkt/internal/market/domain"
+	"ads-mrkt/internal/market/domain/entity"
+	_ "ads-mrkt/internal/server/templates/response"
+)
+
+// @Tags		Admin
+// @Summary	Search deals of any users
+// @Produce	json
+// @Param		status		query		string												false	"Deal status"
+// @Param		user_id		query		int													false	"Lessor or lessee"
+// @Param		listing_id	query		int													false	"Listing"
+// @Param		channel_id	query		int													false	"Channel"
+// @Param		limit		query		int													false	"Page size, default 50, at most 200"
+// @Param		offset		query		int													false	"Deals to skip"
+// @Success	200			{object}	response.Template{data=[]marketmodel.DealResponse}	"Deals, most recently updated first"
+// @Failure	400			{object}	response.Template{data=string}						"Bad request"
+// @Router		/admin/deals [get]
+func (h *handler) SearchDeals(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	search := entity.DealSearch{Status: entity.DealStatus(r.URL.Query().Get("status"))}
+	if search.Status != "" && !isDealStatus(search.Status) {
+		return nil, apperrors.ServiceError{Err: nil, Message: "invalid status", Code: apperrors.ErrorCodeBadRequest}
+	}
+	var err error
+	if search.UserID, err = parseQueryID(r, "user_id"); err != nil {
+		return nil, err
+	}
+	if search.ListingID, err = parseQueryID(r, "listing_id"); err != nil {
+		return nil, err
+	}
+	if search.ChannelID, err = parseQueryID(r, "channel_id"); err != nil {
+		return nil, err
+	}
+	if search.Limit, search.Offset, err = parsePage(r); err != nil {
+		return nil, err
+	}
+
+	deals, err := h.adminService.SearchDeals(r.Context(), search)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return marketmodel.DealsToResponses(deals), nil
+}
+
+// @Tags		Admin
+// @Summary	Get any deal with its full history and the state of its escrow wallet and transfers
+// @Produce	json
+// @Param		id	path		int												true	"Deal ID"
+// @Success	200	{object}	response.Template{data=model.AdminDealResponse}	"Deal"
+// @Failure	404	{object}	response.Template{data=string}					"Not found"
+// @Router		/admin/deals/{id} [get]
+func (h *handler) GetDeal(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	d, err := h.adminService.GetDeal(r.Context(), id)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return model.AdminDealToResponse(d), nil
+}
+
+// isDealStatus reports whether the deal state machine knows the status.
+func isDealStatus(status entity.DealStatus) bool {
+	for _, rule := range domain.DealTransitionRules() {
+		if rule.To == status {
+			return true
+		}
+	}
+	return false
+}
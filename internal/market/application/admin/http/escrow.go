@@ -0,0 +1,133 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	apperrors "ads-mrkt/internal/errors"
+	"ads-mrkt/internal/market/application/admin/http/model"
+	marketmodel "ads-mrkt/internal/market/application/market/http/model"
+	_ "ads-mrkt/internal/server/templates/response"
+)
+
+// @Tags		Admin
+// @Summary	Get the last escrow reconciliation report (runs one if none exists yet)
+// @Produce	json
+// @Success	200	{object}	response.Template{data=model.EscrowReconciliationResponse}	"Reconciliation report"
+// @Failure	500	{object}	response.Template{data=string}								"Internal error"
+// @Router		/admin/escrow/reconciliation [get]
+func (h *handler) GetEscrowReconciliation(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	report, err := h.escrowService.GetEscrowReconciliationReport(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	return model.EscrowReconciliationToResponse(report), nil
+}
+
+// @Tags		Admin
+// @Summary	Run escrow reconciliation now and return the report
+// @Produce	json
+// @Success	200	{object}	response.Template{data=model.EscrowReconciliationResponse}	"Reconciliation report"
+// @Failure	500	{object}	response.Template{data=string}								"Internal error"
+// @Router		/admin/escrow/reconciliation [post]
+func (h *handler) RunEscrowReconciliation(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	actor, err := requireActor(r)
+	if err != nil {
+		return nil, err
+	}
+	report, err := h.adminService.ReconcileEscrows(r.Context(), actor)
+	if err != nil {
+		return nil, err
+	}
+	return model.EscrowReconciliationToResponse(report), nil
+}
+
+// @Tags		Admin
+// @Summary	List deals whose escrow release/refund exhausted its attempts (status escrow_transfer_failed)
+// @Produce	json
+// @Success	200	{object}	response.Template{data=[]marketmodel.DealResponse}	"Deals"
+// @Failure	500	{object}	response.Template{data=string}						"Internal error"
+// @Router		/admin/escrow/failed-transfers [get]
+func (h *handler) ListFailedEscrowTransfers(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	deals, err := h.escrowService.ListFailedEscrowTransfers(r.Context())
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	resp := make([]*marketmodel.DealResponse, 0, len(deals))
+	for _, d := range deals {
+		resp = append(resp, marketmodel.DealToResponse(d))
+	}
+	return resp, nil
+}
+
+// @Tags		Admin
+// @Summary	Retry a failed escrow release/refund to the payout address on the deal. Only the user it pays can change the address, with their ton_proof-verified wallet.
+// @Produce	json
+// @Param		id		path		int													true	"Deal ID"
+// @Success	200		{object}	response.Template{data=marketmodel.DealResponse}	"Deal waiting for escrow release/refund again"
+// @Failure	400		{object}	response.Template{data=string}						"Bad request"
+// @Failure	404		{object}	response.Template{data=string}						"Not found"
+// @Router		/admin/deals/{id}/escrow/retry [post]
+func (h *handler) RetryEscrowTransfer(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	actor, err := requireActor(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	deal, err := h.adminService.RetryEscrowTransfer(r.Context(), actor, id)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return marketmodel.DealToResponse(deal), nil
+}
+
+// @Tags		Admin
+// @Summary	Release the escrow of a deal to the lessor by hand: from in_progress or escrow_transfer_failed. The release worker makes the transfer.
+// @Accept		json
+// @Produce	json
+// @Param		id		path		int													true	"Deal ID"
+// @Param		request	body		model.ReasonRequest									true	"Reason, recorded in the deal history and the audit log"
+// @Success	200		{object}	response.Template{data=marketmodel.DealResponse}	"Deal waiting for escrow release"
+// @Failure	400		{object}	response.Template{data=string}						"Bad request or deal status does not allow it"
+// @Failure	404		{object}	response.Template{data=string}						"Not found"
+// @Router		/admin/deals/{id}/escrow/release [post]
+func (h *handler) ReleaseEscrow(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	return h.settleEscrow(r, true)
+}
+
+// @Tags		Admin
+// @Summary	Refund the escrow of a deal to the lessee by hand: from escrow_deposit_confirmed, in_progress or escrow_transfer_failed. The refund worker makes the transfer.
+// @Accept		json
+// @Produce	json
+// @Param		id		path		int													true	"Deal ID"
+// @Param		request	body		model.ReasonRequest									true	"Reason, recorded in the deal history and the audit log"
+// @Success	200		{object}	response.Template{data=marketmodel.DealResponse}	"Deal waiting for escrow refund"
+// @Failure	400		{object}	response.Template{data=string}						"Bad request or deal status does not allow it"
+// @Failure	404		{object}	response.Template{data=string}						"Not found"
+// @Router		/admin/deals/{id}/escrow/refund [post]
+func (h *handler) RefundEscrow(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	return h.settleEscrow(r, false)
+}
+
+func (h *handler) settleEscrow(r *http.Request, release bool) (interface{}, error) {
+	actor, err := requireActor(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	var req model.ReasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, apperrors.ServiceError{Err: err, Message: "invalid body", Code: apperrors.ErrorCodeBadRequest}
+	}
+	deal, err := h.adminService.SettleEscrow(r.Context(), actor, id, release, req.Reason)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return marketmodel.DealToResponse(deal), nil
+}
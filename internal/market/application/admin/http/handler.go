@@ -0,0 +1,47 @@
+package http
+
+import (
+	"context"
+
+	"ads-mrkt/internal/market/domain/entity"
+	"ads-mrkt/pkg/auth/role"
+)
+
+type escrowService interface {
+	GetEscrowReconciliationReport(ctx context.Context) (*entity.EscrowReconciliationReport, error)
+	ListFailedEscrowTransfers(ctx context.Context) ([]*entity.Deal, error)
+}
+
+// adminService makes the admin console changes and records them in the audit log.
+type adminService interface {
+	SearchUsers(ctx context.Context, search entity.UserSearch) ([]*entity.User, error)
+	GetUser(ctx context.Context, id int64) (*entity.User, error)
+	SearchListings(ctx context.Context, search entity.ListingSearch) ([]*entity.Listing, error)
+	SearchDeals(ctx context.Context, search entity.DealSearch) ([]*entity.Deal, error)
+	GetDeal(ctx context.Context, id int64) (*entity.AdminDeal, error)
+	ListAuditLog(ctx context.Context, filter entity.AdminAuditFilter) ([]*entity.AdminAuditEntry, error)
+	SetUserRole(ctx context.Context, actor entity.AdminActor, userID int64, userRole role.Role, reason string) (*entity.User, error)
+	BanUser(ctx context.Context, actor entity.AdminActor, userID int64, reason string) (*entity.User, error)
+	UnbanUser(ctx context.Context, actor entity.AdminActor, userID int64, reason string) (*entity.User, error)
+	BlockListing(ctx context.Context, actor entity.AdminActor, listingID int64, reason string) (*entity.Listing, error)
+	UnblockListing(ctx context.Context, actor entity.AdminActor, listingID int64, reason string) (*entity.Listing, error)
+	ListModerationQueue(ctx context.Context, limit, offset int) ([]*entity.ModerationQueueItem, error)
+	ListListingReports(ctx context.Context, listingID int64) ([]*entity.ListingReport, error)
+	DismissListingReports(ctx context.Context, actor entity.AdminActor, listingID int64, reason string) (int64, error)
+	RetryEscrowTransfer(ctx context.Context, actor entity.AdminActor, dealID int64) (*entity.Deal, error)
+	SettleEscrow(ctx context.Context, actor entity.AdminActor, dealID int64, release bool, reason string) (*entity.Deal, error)
+	ReconcileEscrows(ctx context.Context, actor entity.AdminActor) (*entity.EscrowReconciliationReport, error)
+}
+
+// handler serves the admin API; the router restricts each route to the staff roles that may use it.
+type handler struct {
+	escrowService escrowService
+	adminService  adminService
+}
+
+func NewHandler(escrowService escrowService, adminService adminService) *handler {
+	return &handler{
+		escrowService: escrowService,
+		adminService:  adminService,
+	}
+}
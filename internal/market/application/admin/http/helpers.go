@@ -0,0 +1,84 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	apperrors "ads-mrkt/internal/errors"
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+	"ads-mrkt/pkg/auth"
+)
+
+// requireActor returns the staff member making the request, with the role the auth middleware checked.
+func requireActor(r *http.Request) (entity.AdminActor, error) {
+	id, ok := auth.GetTelegramID(r.Context())
+	if !ok {
+		return entity.AdminActor{}, apperrors.ServiceError{Err: nil, Message: "unauthorized", Code: apperrors.ErrorCodeUnauthorized}
+	}
+	userRole, _ := auth.GetRole(r.Context())
+	return entity.AdminActor{ID: id, Role: userRole}, nil
+}
+
+func parsePathID(r *http.Request, paramName string) (int64, error) {
+	s := r.PathValue(paramName)
+	if s == "" {
+		return 0, apperrors.ServiceError{Err: nil, Message: paramName + " required", Code: apperrors.ErrorCodeBadRequest}
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, apperrors.ServiceError{Err: err, Message: "invalid " + paramName, Code: apperrors.ErrorCodeBadRequest}
+	}
+	return id, nil
+}
+
+// parseQueryID returns the optional ID query parameter, or nil when it is absent.
+func parseQueryID(r *http.Request, paramName string) (*int64, error) {
+	s := r.URL.Query().Get(paramName)
+	if s == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, apperrors.ServiceError{Err: err, Message: "invalid " + paramName, Code: apperrors.ErrorCodeBadRequest}
+	}
+	return &id, nil
+}
+
+// parsePage returns the limit and offset query parameters; zero means the service default.
+func parsePage(r *http.Request) (limit, offset int, err error) {
+	q := r.URL.Query()
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &limit}, {"offset", &offset}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, 0, apperrors.ServiceError{Err: err, Message: "invalid " + p.name, Code: apperrors.ErrorCodeBadRequest}
+		}
+		*p.dst = n
+	}
+	return limit, offset, nil
+}
+
+func toServiceError(err error) apperrors.ServiceError {
+	switch {
+	case errors.Is(err, marketerrors.ErrNotFound):
+		return apperrors.ServiceError{Err: err, Message: err.Error(), Code: apperrors.ErrorCodeNotFound}
+	case errors.Is(err, marketerrors.ErrAdminActionNotAllowed):
+		return apperrors.ServiceError{Err: err, Message: err.Error(), Code: apperrors.ErrorCodeForbidden}
+	case errors.Is(err, marketerrors.ErrDealNotEscrowTransferFailed), errors.Is(err, marketerrors.ErrInvalidWalletAddress),
+		errors.Is(err, marketerrors.ErrInvalidDealTransition), errors.Is(err, marketerrors.ErrDealTransitionNotAllowed),
+		errors.Is(err, marketerrors.ErrInvalidRole), errors.Is(err, marketerrors.ErrInvalidAdminReason):
+		return apperrors.ServiceError{Err: err, Message: err.Error(), Code: apperrors.ErrorCodeBadRequest}
+	case errors.Is(err, marketerrors.ErrDealChanged):
+		return apperrors.ServiceError{Err: err, Message: err.Error(), Code: apperrors.ErrorCodeConflict}
+	default:
+		return apperrors.ServiceError{Err: err, Message: err.Error(), Code: apperrors.ErrorCodeInternalServerError}
+	}
+}
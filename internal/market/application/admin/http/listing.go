@@ -0,0 +1,131 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	apperrors "ads-mrkt/internal/errors"
+	"ads-mrkt/internal/market/application/admin/http/model"
+	marketmodel "ads-mrkt/internal/market/application/market/http/model"
+	"ads-mrkt/internal/market/domain/entity"
+	_ "ads-mrkt/internal/server/templates/response"
+)
+
+// @Tags		Admin
+// @Summary	Search listings of any status, including inactive and blocked ones
+// @Produce	json
+// @Param		q			query		string										false	"Listing ID, or part of the description"
+// @Param		status		query		string										false	"active | inactive | blocked"
+// @Param		type		query		string										false	"lessor | lessee"
+// @Param		user_id		query		int											false	"Owner"
+// @Param		channel_id	query		int											false	"Channel"
+// @Param		limit		query		int											false	"Page size, default 50, at most 200"
+// @Param		offset		query		int											false	"Listings to skip"
+// @Success	200			{object}	response.Template{data=[]entity.Listing}	"Listings, newest first"
+// @Failure	400			{object}	response.Template{data=string}				"Bad request"
+// @Router		/admin/listings [get]
+func (h *handler) SearchListings(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	search := entity.ListingSearch{
+		Query:  q.Get("q"),
+		Status: entity.ListingStatus(q.Get("status")),
+		Type:   entity.ListingType(q.Get("type")),
+	}
+	switch search.Status {
+	case "", entity.ListingStatusActive, entity.ListingStatusInactive, entity.ListingStatusBlocked:
+	default:
+		return nil, apperrors.ServiceError{Err: nil, Message: "invalid status", Code: apperrors.ErrorCodeBadRequest}
+	}
+	switch search.Type {
+	case "", entity.ListingTypeLessor, entity.ListingTypeLessee:
+	default:
+		return nil, apperrors.ServiceError{Err: nil, Message: "invalid type", Code: apperrors.ErrorCodeBadRequest}
+	}
+	var err error
+	if search.UserID, err = parseQueryID(r, "user_id"); err != nil {
+		return nil, err
+	}
+	if search.ChannelID, err = parseQueryID(r, "channel_id"); err != nil {
+		return nil, err
+	}
+	if search.Limit, search.Offset, err = parsePage(r); err != nil {
+		return nil, err
+	}
+
+	list, err := h.adminService.SearchListings(r.Context(), search)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return marketmodel.ListingsWithPricesInTON(list), nil
+}
+
+// @Tags		Admin
+// @Summary	Block a listing: it leaves discovery and its owner cannot reactivate it
+// @Accept		json
+// @Produce	json
+// @Param		id		path		int										true	"Listing ID"
+// @Param		request	body		model.ReasonRequest						true	"Reason"
+// @Success	200		{object}	response.Template{data=entity.Listing}	"Blocked listing"
+// @Failure	400		{object}	response.Template{data=string}			"Bad request"
+// @Failure	404		{object}	response.Template{data=string}			"Not found"
+// @Router		/admin/listings/{id}/block [post]
+func (h *handler) BlockListing(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	return h.moderateListing(r, h.adminService.BlockListing)
+}
+
+// @Tags		Admin
+// @Summary	Unblock a listing; it stays inactive until its owner reactivates it
+// @Accept		json
+// @Produce	json
+// @Param		id		path		int										true	"Listing ID"
+// @Param		request	body		model.ReasonRequest						false	"Optional reason"
+// @Success	200		{object}	response.Template{data=entity.Listing}	"Unblocked listing"
+// @Failure	404		{object}	response.Template{data=string}			"Not found"
+// @Router		/admin/listings/{id}/block [delete]
+func (h *handler) UnblockListing(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	return h.moderateListing(r, h.adminService.UnblockListing)
+}
+
+func (h *handler) moderateListing(r *http.Request, change func(ctx context.Context, actor entity.AdminActor, id int64, reason string) (*entity.Listing, error)) (interface{}, error) {
+	actor, id, reason, err := parseModeration(r)
+	if err != nil {
+		return nil, err
+	}
+	l, err := change(r.Context(), actor, id, reason)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return marketmodel.ListingWithPricesInTON(l), nil
+}
+
+func (h *handler) moderateUser(r *http.Request, change func(ctx context.Context, actor entity.AdminActor, id int64, reason string) (*entity.User, error)) (interface{}, error) {
+	actor, id, reason, err := parseModeration(r)
+	if err != nil {
+		return nil, err
+	}
+	u, err := change(r.Context(), actor, id, reason)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return u, nil
+}
+
+// parseModeration returns the actor, the path ID and the reason of a moderation request; the body may be empty.
+func parseModeration(r *http.Request) (entity.AdminActor, int64, string, error) {
+	actor, err := requireActor(r)
+	if err != nil {
+		return actor, 0, "", err
+	}
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return actor, 0, "", err
+	}
+	var req model.ReasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return actor, 0, "", apperrors.ServiceError{Err: err, Message: "invalid body", Code: apperrors.ErrorCodeBadRequest}
+	}
+	return actor, id, req.Reason, nil
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	marketmodel "ads-mrkt/internal/market/application/market/http/model"
+	"ads-mrkt/internal/market/domain"
+	"ads-mrkt/internal/market/domain/entity"
+)
+
+// ReasonRequest carries the reason of a moderation or escrow decision; it is recorded in the audit log.
+type ReasonRequest struct {
+	Reason string `json:"reason"`
+}
+
+type SetUserRoleRequest struct {
+	Role   string `json:"role"` // user | admin | support | moderator | finance
+	Reason string `json:"reason,omitempty"`
+}
+
+type EscrowStateResponse struct {
+	Address      *string                 `json:"address,omitempty"`
+	Held         bool                    `json:"held"` // the wallet is expected to hold the deposit
+	BalanceTON   *float64                `json:"balance_ton,omitempty"`
+	BalanceError string                  `json:"balance_error,omitempty"`
+	PayoutTON    float64                 `json:"payout_ton"`
+	Mismatch     *EscrowMismatchResponse `json:"mismatch,omitempty"`
+	LastRelease  *entity.DealActionLock  `json:"last_release,omitempty"`
+	LastRefund   *entity.DealActionLock  `json:"last_refund,omitempty"`
+}
+
+// AdminDealResponse is a deal with its full history, including the staff who moved it, and its escrow.
+type AdminDealResponse struct {
+	Deal   *marketmodel.DealResponse        `json:"deal"`
+	Events []*marketmodel.DealEventResponse `json:"events"`
+	Escrow *EscrowStateResponse             `json:"escrow"`
+}
+
+func AdminDealToResponse(d *entity.AdminDeal) *AdminDealResponse {
+	events := marketmodel.DealEventsToResponse(d.Events)
+	for i, e := range d.Events {
+		events[i].ActorUserID = e.ActorUserID
+	}
+	return &AdminDealResponse{
+		Deal:   marketmodel.DealToResponse(d.Deal),
+		Events: events,
+		Escrow: EscrowStateToResponse(d.Escrow),
+	}
+}
+
+func EscrowStateToResponse(s *entity.EscrowState) *EscrowStateResponse {
+	resp := &EscrowStateResponse{
+		Address:      s.Address,
+		Held:         s.Held,
+		BalanceError: s.BalanceError,
+		PayoutTON:    domain.NanotonToTON(s.PayoutNanoton),
+		LastRelease:  s.LastRelease,
+		LastRefund:   s.LastRefund,
+	}
+	if s.BalanceNanoton != nil {
+		balance := domain.NanotonToTON(*s.BalanceNanoton)
+		resp.BalanceTON = &balance
+	}
+	if m := s.Mismatch; m != nil {
+		resp.Mismatch = &EscrowMismatchResponse{
+			Kind:            m.Kind,
+			DealID:          m.DealID,
+			DealStatus:      m.DealStatus,
+			EscrowAddress:   m.EscrowAddress,
+			BalanceTON:      domain.NanotonToTON(m.BalanceNanoton),
+			ExpectedTON:     domain.NanotonToTON(m.ExpectedNanoton),
+			FailedTransfers: m.FailedTransfers,
+			Detail:          m.Detail,
+		}
+	}
+	return resp
+}
+
+type DismissReportsResponse struct {
+	Dismissed int64 `json:"dismissed"`
+}
+
+// ModerationQueueToResponse converts listing prices to TON.
+func ModerationQueueToResponse(items []*entity.ModerationQueueItem) []*entity.ModerationQueueItem {
+	out := make([]*entity.ModerationQueueItem, len(items))
+	for i, item := range items {
+		converted := *item
+		converted.Listing = marketmodel.ListingWithPricesInTON(item.Listing)
+		out[i] = &converted
+	}
+	return out
+}
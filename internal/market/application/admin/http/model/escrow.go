@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"ads-mrkt/internal/market/domain"
+	"ads-mrkt/internal/market/domain/entity"
+)
+
+type EscrowMismatchResponse struct {
+	Kind            entity.EscrowMismatchKind `json:"kind"`
+	DealID          int64                     `json:"deal_id"`
+	DealStatus      entity.DealStatus         `json:"deal_status,omitempty"`
+	EscrowAddress   string                    `json:"escrow_address,omitempty"`
+	BalanceTON      float64                   `json:"balance_ton"`
+	ExpectedTON     float64                   `json:"expected_ton"`
+	FailedTransfers int64                     `json:"failed_transfers,omitempty"`
+	Detail          string                    `json:"detail,omitempty"`
+}
+
+type EscrowReconciliationResponse struct {
+	StartedAt       time.Time                 `json:"started_at"`
+	FinishedAt      time.Time                 `json:"finished_at"`
+	DealsChecked    int                       `json:"deals_checked"`
+	HeldBalanceTON  float64                   `json:"held_balance_ton"`
+	MismatchesCount map[string]int            `json:"mismatches_count"`
+	Mismatches      []*EscrowMismatchResponse `json:"mismatches"`
+}
+
+func EscrowReconciliationToResponse(r *entity.EscrowReconciliationReport) *EscrowReconciliationResponse {
+	resp := &EscrowReconciliationResponse{
+		StartedAt:       r.StartedAt,
+		FinishedAt:      r.FinishedAt,
+		DealsChecked:    r.DealsChecked,
+		HeldBalanceTON:  domain.NanotonToTON(r.HeldBalanceNanoton),
+		MismatchesCount: make(map[string]int),
+		Mismatches:      make([]*EscrowMismatchResponse, 0, len(r.Mismatches)),
+	}
+	for _, m := range r.Mismatches {
+		resp.MismatchesCount[string(m.Kind)]++
+		resp.Mismatches = append(resp.Mismatches, &EscrowMismatchResponse{
+			Kind:            m.Kind,
+			DealID:          m.DealID,
+			DealStatus:      m.DealStatus,
+			EscrowAddress:   m.EscrowAddress,
+			BalanceTON:      domain.NanotonToTON(m.BalanceNanoton),
+			ExpectedTON:     domain.NanotonToTON(m.ExpectedNanoton),
+			FailedTransfers: m.FailedTransfers,
+			Detail:          m.Detail,
+		})
+	}
+	return resp
+}
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"net/http"
+
+	"ads-mrkt/internal/market/application/admin/http/model"
+	_ "ads-mrkt/internal/market/domain/entity"
+	_ "ads-mrkt/internal/server/templates/response"
+)
+
+// @Tags		Admin
+// @Summary	List listings with open user reports, most reported first
+// @Produce	json
+// @Param		limit	query		int														false	"Page size, default 50, at most 200"
+// @Param		offset	query		int														false	"Listings to skip"
+// @Success	200		{object}	response.Template{data=[]entity.ModerationQueueItem}	"Reported listings"
+// @Failure	400		{object}	response.Template{data=string}							"Bad request"
+// @Router		/admin/moderation/queue [get]
+func (h *handler) ListModerationQueue(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		return nil, err
+	}
+	items, err := h.adminService.ListModerationQueue(r.Context(), limit, offset)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return model.ModerationQueueToResponse(items), nil
+}
+
+// @Tags		Admin
+// @Summary	List all reports on a listing, newest first
+// @Produce	json
+// @Param		id	path		int												true	"Listing ID"
+// @Success	200	{object}	response.Template{data=[]entity.ListingReport}	"Reports"
+// @Router		/admin/listings/{id}/reports [get]
+func (h *handler) ListListingReports(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	reports, err := h.adminService.ListListingReports(r.Context(), id)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return reports, nil
+}
+
+// @Tags		Admin
+// @Summary	Dismiss the open reports on a listing, taking it off the moderation queue. Blocking the listing closes them too.
+// @Accept		json
+// @Produce	json
+// @Param		id		path		int													true	"Listing ID"
+// @Param		request	body		model.ReasonRequest									false	"Optional reason"
+// @Success	200		{object}	response.Template{data=model.DismissReportsResponse}	"Dismissed reports"
+// @Failure	400		{object}	response.Template{data=string}						"Bad request"
+// @Router		/admin/listings/{id}/reports/dismiss [post]
+func (h *handler) DismissListingReports(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	actor, id, reason, err := parseModeration(r)
+	if err != nil {
+		return nil, err
+	}
+	dismissed, err := h.adminService.DismissListingReports(r.Context(), actor, id, reason)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return model.DismissReportsResponse{Dismissed: dismissed}, nil
+}
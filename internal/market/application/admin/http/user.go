@@ -0,0 +1,130 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	apperrors "ads-mrkt/internal/errors"
+	"ads-mrkt/internal/market/application/admin/http/model"
+	"ads-mrkt/internal/market/domain/entity"
+	_ "ads-mrkt/internal/server/templates/response"
+	"ads-mrkt/pkg/auth/role"
+)
+
+// @Tags		Admin
+// @Summary	Search users by ID, username or name
+// @Produce	json
+// @Param		q		query		string									false	"User ID, or part of the username or name"
+// @Param		role	query		string									false	"user | admin | support | moderator | finance"
+// @Param		banned	query		bool									false	"Only banned (true) or not banned (false) users"
+// @Param		limit	query		int										false	"Page size, default 50, at most 200"
+// @Param		offset	query		int										false	"Users to skip"
+// @Success	200		{object}	response.Template{data=[]entity.User}	"Users, newest first"
+// @Failure	400		{object}	response.Template{data=string}			"Bad request"
+// @Router		/admin/users [get]
+func (h *handler) SearchUsers(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	search := entity.UserSearch{Query: q.Get("q")}
+	if v := q.Get("role"); v != "" {
+		if search.Role = role.FromString(v); search.Role == role.EmptyRole {
+			return nil, apperrors.ServiceError{Err: nil, Message: "invalid role", Code: apperrors.ErrorCodeBadRequest}
+		}
+	}
+	if v := q.Get("banned"); v != "" {
+		banned, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, apperrors.ServiceError{Err: err, Message: "invalid banned", Code: apperrors.ErrorCodeBadRequest}
+		}
+		search.Banned = &banned
+	}
+	var err error
+	if search.Limit, search.Offset, err = parsePage(r); err != nil {
+		return nil, err
+	}
+
+	users, err := h.adminService.SearchUsers(r.Context(), search)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return users, nil
+}
+
+// @Tags		Admin
+// @Summary	Get a user with their role and ban
+// @Produce	json
+// @Param		id	path		int									true	"User ID"
+// @Success	200	{object}	response.Template{data=entity.User}	"User"
+// @Failure	404	{object}	response.Template{data=string}		"Not found"
+// @Router		/admin/users/{id} [get]
+func (h *handler) GetUser(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	u, err := h.adminService.GetUser(r.Context(), id)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return u, nil
+}
+
+// @Tags		Admin
+// @Summary	Change the role of a user. Admins only; nobody can change their own role.
+// @Accept		json
+// @Produce	json
+// @Param		id		path		int									true	"User ID"
+// @Param		request	body		model.SetUserRoleRequest			true	"New role and optional reason"
+// @Success	200		{object}	response.Template{data=entity.User}	"Updated user"
+// @Failure	400		{object}	response.Template{data=string}		"Bad request"
+// @Failure	403		{object}	response.Template{data=string}		"Not allowed"
+// @Failure	404		{object}	response.Template{data=string}		"Not found"
+// @Router		/admin/users/{id}/role [put]
+func (h *handler) SetUserRole(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	actor, err := requireActor(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	var req model.SetUserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, apperrors.ServiceError{Err: err, Message: "invalid body", Code: apperrors.ErrorCodeBadRequest}
+	}
+	u, err := h.adminService.SetUserRole(r.Context(), actor, id, role.Role(req.Role), req.Reason)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return u, nil
+}
+
+// @Tags		Admin
+// @Summary	Ban a user and end all their sessions. Only admins can ban staff.
+// @Accept		json
+// @Produce	json
+// @Param		id		path		int									true	"User ID"
+// @Param		request	body		model.ReasonRequest					true	"Reason"
+// @Success	200		{object}	response.Template{data=entity.User}	"Banned user"
+// @Failure	400		{object}	response.Template{data=string}		"Bad request"
+// @Failure	403		{object}	response.Template{data=string}		"Not allowed"
+// @Failure	404		{object}	response.Template{data=string}		"Not found"
+// @Router		/admin/users/{id}/ban [post]
+func (h *handler) BanUser(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	return h.moderateUser(r, h.adminService.BanUser)
+}
+
+// @Tags		Admin
+// @Summary	Lift the ban of a user
+// @Accept		json
+// @Produce	json
+// @Param		id		path		int									true	"User ID"
+// @Param		request	body		model.ReasonRequest					false	"Optional reason"
+// @Success	200		{object}	response.Template{data=entity.User}	"User"
+// @Failure	403		{object}	response.Template{data=string}		"Not allowed"
+// @Failure	404		{object}	response.Template{data=string}		"Not found"
+// @Router		/admin/users/{id}/ban [delete]
+func (h *handler) UnbanUser(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	return h.moderateUser(r, h.adminService.UnbanUser)
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	apperrors "ads-mrkt/internal/errors"
+	"ads-mrkt/internal/market/application/market/http/model"
+	_ "ads-mrkt/internal/market/domain/entity"
+	_ "ads-mrkt/internal/server/templates/response"
+)
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	Create an API key for scripts. Send it in the X-API-Key header instead of a Bearer token; it can call only the endpoints of its scopes (listings:read, listings:write, deals:write), up to its rate limit. The key is returned only here. API keys cannot manage API keys or webhooks.
+// @Accept		json
+// @Produce	json
+// @Param		request	body		model.CreateAPIKeyRequest							true	"name, scopes and optional rate_limit_per_minute"
+// @Success	200		{object}	response.Template{data=model.CreateAPIKeyResponse}	"Created key with the key itself"
+// @Failure	400		{object}	response.Template{data=string}						"Bad request"
+// @Failure	401		{object}	response.Template{data=string}						"Unauthorized"
+// @Router		/market/api-keys [post]
+func (h *handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req model.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, apperrors.ServiceError{Err: err, Message: "invalid body", Code: apperrors.ErrorCodeBadRequest}
+	}
+	k, err := h.apiKeyService.CreateAPIKey(r.Context(), userID, req.Name, req.ScopeList(), req.RateLimitPerMinute)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return model.APIKeyToCreateResponse(k), nil
+}
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	List your API keys with their scopes and last use
+// @Produce	json
+// @Success	200	{object}	response.Template{data=[]entity.APIKey}	"API keys"
+// @Failure	401	{object}	response.Template{data=string}			"Unauthorized"
+// @Router		/market/api-keys [get]
+func (h *handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := h.apiKeyService.ListAPIKeys(r.Context(), userID)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return list, nil
+}
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	Revoke your API key
+// @Produce	json
+// @Param		id	path		int								true	"API key ID"
+// @Success	200	{object}	response.Template{data=string}	"Deleted"
+// @Failure	400	{object}	response.Template{data=string}	"Bad request"
+// @Failure	401	{object}	response.Template{data=string}	"Unauthorized"
+// @Failure	404	{object}	response.Template{data=string}	"Not found"
+// @Router		/market/api-keys/{id} [delete]
+func (h *handler) DeleteAPIKey(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.apiKeyService.DeleteAPIKey(r.Context(), userID, id); err != nil {
+		return nil, toServiceError(err)
+	}
+	return map[string]string{"status": "deleted"}, nil
+}
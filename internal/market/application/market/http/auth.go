@@ -2,24 +2,29 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	apperrors "ads-mrkt/internal/errors"
 	"ads-mrkt/internal/market/application/market/http/model"
+	_ "ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+	"ads-mrkt/pkg/auth"
 
 	_ "ads-mrkt/internal/server/templates/response"
 )
 
 // @Security
 // @Tags		Market
-// @Summary	Authenticate user
+// @Summary	Authenticate user. Starts a session: the access token is short-lived, exchange the refresh token at /market/auth/refresh for the next one. Send "tokens": true to get both tokens; otherwise data is the bare access token string.
 // @Accept		json
 // @Produce	json
-// @Param		request				body		AuthUserRequest					true	"request body"
-// @Param		X-Telegram-InitData	header		string							true	"Telegram init data"
-// @Success	200					{object}	response.Template{data=string}	"JWT token"
-// @Failure	401					{object}	response.Template{data=string}	"Unauthorized"
+// @Param		request				body		AuthUserRequest								true	"request body"
+// @Param		X-Telegram-InitData	header		string										true	"Telegram init data"
+// @Success	200					{object}	response.Template{data=entity.AuthTokens}	"Access and refresh tokens"
+// @Failure	401					{object}	response.Template{data=string}				"Unauthorized"
+// @Failure	403					{object}	response.Template{data=string}				"Account is banned"
 // @Router		/market/auth [post]
 func (h *handler) AuthUser(w http.ResponseWriter, r *http.Request) (interface{}, error) {
 	initDataStr := r.Header.Get("X-Telegram-InitData")
@@ -37,6 +42,9 @@ func (h *handler) AuthUser(w http.ResponseWriter, r *http.Request) (interface{},
 	}
 
 	user, err := h.userService.AuthUser(r.Context(), initDataStr, req.Referrer)
+	if errors.Is(err, marketerrors.ErrUserBanned) {
+		return nil, toServiceError(err)
+	}
 	if err != nil {
 		return nil, apperrors.ServiceError{
 			Err:     err,
@@ -45,26 +53,118 @@ func (h *handler) AuthUser(w http.ResponseWriter, r *http.Request) (interface{},
 		}
 	}
 
-	token, err := h.jwtManager.GenerateToken(user.ID, user.Role)
+	var tokens interface{}
+	if req.Tokens {
+		tokens, err = h.sessionService.StartSession(r.Context(), user.ID, user.Role)
+	} else {
+		tokens, err = h.sessionService.StartAccessOnlySession(r.Context(), user.ID, user.Role)
+	}
 	if err != nil {
 		return nil, apperrors.ServiceError{
 			Err:     err,
-			Message: "failed to generate JWT token",
+			Message: "failed to start session",
 			Code:    apperrors.ErrorCodeUnauthorized,
 		}
 	}
 
-	return token, nil
+	return tokens, nil
+}
+
+// @Tags		Market
+// @Summary	Exchange a refresh token for a new access token and a new refresh token. Each refresh token works once; reusing one revokes its session.
+// @Accept		json
+// @Produce	json
+// @Param		request	body		model.RefreshSessionRequest					true	"refresh_token"
+// @Success	200		{object}	response.Template{data=entity.AuthTokens}	"Access and refresh tokens"
+// @Failure	401		{object}	response.Template{data=string}				"Invalid, expired or revoked refresh token"
+// @Failure	403		{object}	response.Template{data=string}				"Account is banned"
+// @Router		/market/auth/refresh [post]
+func (h *handler) RefreshSession(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	var req model.RefreshSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, apperrors.ServiceError{Err: err, Message: "invalid body", Code: apperrors.ErrorCodeBadRequest}
+	}
+	if req.RefreshToken == "" {
+		return nil, apperrors.ServiceError{Err: nil, Message: "refresh_token is required", Code: apperrors.ErrorCodeBadRequest}
+	}
+
+	tokens, err := h.sessionService.RefreshSession(r.Context(), req.RefreshToken)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return tokens, nil
+}
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	Log out: revoke the session of the access token. Its access and refresh tokens stop working.
+// @Produce	json
+// @Success	200	{object}	response.Template{data=object}	"ok"
+// @Failure	401	{object}	response.Template{data=string}	"Unauthorized"
+// @Router		/market/auth/logout [post]
+func (h *handler) Logout(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+	sessionID, ok := auth.GetSessionID(r.Context())
+	if !ok {
+		return nil, apperrors.ServiceError{Err: nil, Message: "not a session token", Code: apperrors.ErrorCodeBadRequest}
+	}
+
+	if err := h.sessionService.Logout(r.Context(), userID, sessionID); err != nil {
+		return nil, toServiceError(err)
+	}
+	return map[string]string{"status": "ok"}, nil
+}
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	Log out everywhere: revoke every session of the current user, including this one.
+// @Produce	json
+// @Success	200	{object}	response.Template{data=model.LogoutAllResponse}	"Number of revoked sessions"
+// @Failure	401	{object}	response.Template{data=string}					"Unauthorized"
+// @Router		/market/auth/logout-all [post]
+func (h *handler) LogoutAll(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := h.sessionService.LogoutAll(r.Context(), userID)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &model.LogoutAllResponse{Revoked: n}, nil
+}
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	Issue a one-time ton_proof payload for linking a TON wallet. Pass it to TON Connect as tonProof.
+// @Produce	json
+// @Success	200	{object}	response.Template{data=entity.TonProofChallenge}	"Payload and its expiry"
+// @Failure	401	{object}	response.Template{data=string}						"Unauthorized"
+// @Router		/market/me/wallet/challenge [post]
+func (h *handler) CreateWalletChallenge(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := h.userService.CreateWalletChallenge(r.Context(), userID)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return challenge, nil
 }
 
 // @Security	JWT
 // @Tags		Market
-// @Summary	Set current user's TON wallet (raw format) for deal payouts.
+// @Summary	Link current user's TON wallet for deal payouts. Requires a TON Connect ton_proof signed over a payload from /market/me/wallet/challenge.
 // @Accept		json
 // @Produce	json
-// @Param		request	body		SetWalletRequest				true	"wallet_address (raw)"
+// @Param		request	body		SetWalletRequest				true	"wallet_address, ton_proof and optional public_key / state_init"
 // @Success	200		{object}	response.Template{data=string}	"ok"
-// @Failure	400		{object}	response.Template{data=string}	"Bad request"
+// @Failure	400		{object}	response.Template{data=string}	"Bad request or invalid proof"
 // @Failure	401		{object}	response.Template{data=string}	"Unauthorized"
 // @Router		/market/me/wallet [put]
 func (h *handler) SetWallet(w http.ResponseWriter, r *http.Request) (interface{}, error) {
@@ -80,8 +180,15 @@ func (h *handler) SetWallet(w http.ResponseWriter, r *http.Request) (interface{}
 	if req.WalletAddress == "" {
 		return nil, apperrors.ServiceError{Err: nil, Message: "wallet_address is required", Code: apperrors.ErrorCodeBadRequest}
 	}
+	if req.Proof == nil {
+		return nil, apperrors.ServiceError{Err: nil, Message: "proof is required", Code: apperrors.ErrorCodeBadRequest}
+	}
+	proof, err := model.SetWalletRequestToTonProof(&req)
+	if err != nil {
+		return nil, apperrors.ServiceError{Err: err, Message: err.Error(), Code: apperrors.ErrorCodeBadRequest}
+	}
 
-	if err := h.userService.SetWallet(r.Context(), userID, req.WalletAddress); err != nil {
+	if err := h.userService.SetWallet(r.Context(), userID, proof); err != nil {
 		return nil, toServiceError(err)
 	}
 	return map[string]string{"status": "ok"}, nil
@@ -3,8 +3,11 @@ package http
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	apperrors "ads-mrkt/internal/errors"
+	"ads-mrkt/internal/market/application/market/http/model"
 	marketerrors "ads-mrkt/internal/market/domain/errors"
 	_ "ads-mrkt/internal/market/domain/entity"
 	_ "ads-mrkt/internal/server/templates/response"
@@ -73,7 +76,7 @@ func (h *handler) RefreshChannel(w http.ResponseWriter, r *http.Request) (interf
 // @Summary	Get channel statistics. Allowed for channel admins or users who have a listing with this channel.
 // @Produce	json
 // @Param		id	path		int	true	"Channel ID"
-// @Success	200	{object}	response.Template{data=object}	"Channel stats (Telegram broadcast stats shape)"
+// @Success	200	{object}	response.Template{data=entity.ChannelStats}	"Channel stats; the shape version is in data.version"
 // @Failure	400	{object}	response.Template{data=string}	"Bad request"
 // @Failure	401	{object}	response.Template{data=string}	"Unauthorized"
 // @Failure	403	{object}	response.Template{data=string}	"Forbidden"
@@ -97,3 +100,44 @@ func (h *handler) GetChannelStats(w http.ResponseWriter, r *http.Request) (inter
 	}
 	return stats, nil
 }
+
+const (
+	defaultStatsHistoryDays = 90
+	maxStatsHistoryDays     = 365
+)
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	Get channel stats history (followers, views/shares/reactions per post, enabled notifications %) as time series. Same access as channel stats.
+// @Produce	json
+// @Param		id		path		int													true	"Channel ID"
+// @Param		days	query		int													false	"History window in days (default 90, max 365)"
+// @Success	200		{object}	response.Template{data=model.ChannelStatsHistoryResponse}	"Stats series"
+// @Failure	400		{object}	response.Template{data=string}							"Bad request"
+// @Failure	401		{object}	response.Template{data=string}							"Unauthorized"
+// @Failure	403		{object}	response.Template{data=string}							"Forbidden"
+// @Router		/market/channels/{id}/stats/history [get]
+func (h *handler) GetChannelStatsHistory(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	days := defaultStatsHistoryDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		days, err = strconv.Atoi(d)
+		if err != nil || days <= 0 || days > maxStatsHistoryDays {
+			return nil, apperrors.ServiceError{Err: err, Message: "days must be between 1 and 365", Code: apperrors.ErrorCodeBadRequest}
+		}
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	snapshots, err := h.channelService.GetChannelStatsHistory(r.Context(), id, userID, since)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return model.ChannelStatsHistoryToResponse(id, since, snapshots), nil
+}
@@ -276,14 +276,43 @@ func (h *handler) UpdateDealDraft(w http.ResponseWriter, r *http.Request) (inter
 
 // @Security	JWT
 // @Tags		Market
-// @Summary	Sign deal (lessor or lessee). When both have signed same terms, status becomes approved.
+// @Summary	Get the canonical deal terms document to sign with TON Connect signData (text payload).
 // @Produce	json
-// @Param		id	path		int									true	"Deal ID"
-// @Success	200	{object}	response.Template{data=entity.Deal}	"Deal (possibly approved)"
-// @Failure	400	{object}	response.Template{data=string}		"Bad request"
-// @Failure	401	{object}	response.Template{data=string}		"Unauthorized"
-// @Failure	403	{object}	response.Template{data=string}		"Forbidden"
-// @Failure	404	{object}	response.Template{data=string}		"Not found"
+// @Param		id	path		int										true	"Deal ID"
+// @Success	200	{object}	response.Template{data=entity.DealTerms}	"Terms document and its hash"
+// @Failure	401	{object}	response.Template{data=string}			"Unauthorized"
+// @Failure	403	{object}	response.Template{data=string}			"Forbidden"
+// @Failure	404	{object}	response.Template{data=string}			"Not found"
+// @Router		/market/deals/{id}/terms [get]
+func (h *handler) GetDealTerms(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	terms, err := h.dealService.GetDealTerms(r.Context(), userID, id)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return terms, nil
+}
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	Sign deal (lessor or lessee) with the linked TON wallet: body is the signData result over the terms from /market/deals/{id}/terms. When both have signed same terms, status becomes approved.
+// @Accept		json
+// @Produce	json
+// @Param		id		path		int									true	"Deal ID"
+// @Param		request	body		model.SignDealRequest				true	"TON Connect signData result"
+// @Success	200		{object}	response.Template{data=entity.Deal}	"Deal (possibly approved)"
+// @Failure	400		{object}	response.Template{data=string}		"Bad request or invalid signature"
+// @Failure	401		{object}	response.Template{data=string}		"Unauthorized"
+// @Failure	403		{object}	response.Template{data=string}		"Forbidden"
+// @Failure	404		{object}	response.Template{data=string}		"Not found"
 // @Router		/market/deals/{id}/sign [post]
 func (h *handler) SignDeal(w http.ResponseWriter, r *http.Request) (interface{}, error) {
 	userID, err := requireUserID(r)
@@ -295,7 +324,16 @@ func (h *handler) SignDeal(w http.ResponseWriter, r *http.Request) (interface{},
 		return nil, err
 	}
 
-	if err := h.dealService.SignDeal(r.Context(), userID, id); err != nil {
+	var req model.SignDealRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, apperrors.ServiceError{Err: err, Message: "invalid body", Code: apperrors.ErrorCodeBadRequest}
+	}
+	signed, err := model.SignDealRequestToSignedText(&req)
+	if err != nil {
+		return nil, apperrors.ServiceError{Err: err, Message: err.Error(), Code: apperrors.ErrorCodeBadRequest}
+	}
+
+	if err := h.dealService.SignDeal(r.Context(), userID, id, signed); err != nil {
 		return nil, toServiceError(err)
 	}
 	updated, err := h.dealService.GetDeal(r.Context(), id)
@@ -307,7 +345,61 @@ func (h *handler) SignDeal(w http.ResponseWriter, r *http.Request) (interface{},
 
 // @Security	JWT
 // @Tags		Market
-// @Summary	Set your payout address on the deal (lessor or lessee). Required before signing. Draft only.
+// @Summary	Export the deal terms with both parties' wallet signatures for independent verification.
+// @Produce	json
+// @Param		id	path		int													true	"Deal ID"
+// @Success	200	{object}	response.Template{data=model.SignedDealTermsResponse}	"Signed terms"
+// @Failure	401	{object}	response.Template{data=string}						"Unauthorized"
+// @Failure	403	{object}	response.Template{data=string}						"Forbidden"
+// @Failure	404	{object}	response.Template{data=string}						"Not found"
+// @Router		/market/deals/{id}/signatures [get]
+func (h *handler) ExportSignedDealTerms(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := h.dealService.ExportSignedDealTerms(r.Context(), userID, id)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return model.SignedDealTermsToResponse(signed), nil
+}
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	Deal status history, oldest first: who moved the deal to each status and why. Caller must be lessor or lessee.
+// @Produce	json
+// @Param		id	path		int													true	"Deal ID"
+// @Success	200	{object}	response.Template{data=[]model.DealEventResponse}	"Status transitions"
+// @Failure	401	{object}	response.Template{data=string}						"Unauthorized"
+// @Failure	403	{object}	response.Template{data=string}						"Forbidden"
+// @Failure	404	{object}	response.Template{data=string}						"Not found"
+// @Router		/market/deals/{id}/timeline [get]
+func (h *handler) GetDealTimeline(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := h.dealService.GetDealTimeline(r.Context(), userID, id)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return model.DealEventsToResponse(events), nil
+}
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	Set your payout address on the deal (lessor or lessee). Required before signing. In draft any address; after a failed escrow transfer only your ton_proof-linked wallet.
 // @Accept		json
 // @Produce	json
 // @Param		id	path		int									true	"Deal ID"
@@ -15,10 +15,21 @@ func toServiceError(err error) apperrors.ServiceError {
 	switch {
 	case errors.Is(err, marketerrors.ErrNotFound):
 		return apperrors.ServiceError{Err: err, Message: err.Error(), Code: apperrors.ErrorCodeNotFound}
-	case errors.Is(err, marketerrors.ErrNotChannelAdmin), errors.Is(err, marketerrors.ErrUnauthorizedSide), errors.Is(err, marketerrors.ErrChannelStatsDenied):
+	case errors.Is(err, marketerrors.ErrInvalidRefreshToken):
+		return apperrors.ServiceError{Err: err, Message: err.Error(), Code: apperrors.ErrorCodeUnauthorized}
+	case errors.Is(err, marketerrors.ErrNotChannelAdmin), errors.Is(err, marketerrors.ErrUnauthorizedSide), errors.Is(err, marketerrors.ErrChannelStatsDenied),
+		errors.Is(err, marketerrors.ErrListingBlocked), errors.Is(err, marketerrors.ErrUserBanned):
 		return apperrors.ServiceError{Err: err, Message: err.Error(), Code: apperrors.ErrorCodeForbidden}
-	case errors.Is(err, marketerrors.ErrDealNotDraft), errors.Is(err, marketerrors.ErrWalletNotSet), errors.Is(err, marketerrors.ErrPayoutNotSet), errors.Is(err, marketerrors.ErrDealDetailsMessageRequired):
+	case errors.Is(err, marketerrors.ErrDealNotDraft), errors.Is(err, marketerrors.ErrWalletNotSet), errors.Is(err, marketerrors.ErrWalletNotVerified), errors.Is(err, marketerrors.ErrPayoutNotSet), errors.Is(err, marketerrors.ErrDealDetailsMessageRequired),
+		errors.Is(err, marketerrors.ErrInvalidWalletAddress), errors.Is(err, marketerrors.ErrInvalidWalletProof), errors.Is(err, marketerrors.ErrInvalidDealSignature),
+		errors.Is(err, marketerrors.ErrChannelNotConnected), errors.Is(err, marketerrors.ErrInvalidDealTransition), errors.Is(err, marketerrors.ErrDealTransitionNotAllowed),
+		errors.Is(err, marketerrors.ErrInvalidWebhookURL), errors.Is(err, marketerrors.ErrInvalidWebhookSecret), errors.Is(err, marketerrors.ErrWebhookLimitReached),
+		errors.Is(err, marketerrors.ErrInvalidAPIKeyName), errors.Is(err, marketerrors.ErrInvalidAPIKeyScope), errors.Is(err, marketerrors.ErrInvalidAPIKeyRateLimit),
+		errors.Is(err, marketerrors.ErrAPIKeyLimitReached), errors.Is(err, marketerrors.ErrContentBlocked), errors.Is(err, marketerrors.ErrInvalidListingReport),
+		errors.Is(err, marketerrors.ErrOwnListingReport):
 		return apperrors.ServiceError{Err: err, Message: err.Error(), Code: apperrors.ErrorCodeBadRequest}
+	case errors.Is(err, marketerrors.ErrDealChanged), errors.Is(err, marketerrors.ErrListingAlreadyReported):
+		return apperrors.ServiceError{Err: err, Message: err.Error(), Code: apperrors.ErrorCodeConflict}
 	case errors.Is(err, deal_chat.ErrForumNotConfigured):
 		return apperrors.ServiceError{Err: err, Message: "deal chat forum not configured", Code: apperrors.ErrorCodeInternalServerError}
 	default:
@@ -2,14 +2,17 @@ package http
 
 import (
 	"context"
+	"time"
 
 	"ads-mrkt/internal/market/domain/entity"
-	"ads-mrkt/pkg/auth"
+	"ads-mrkt/pkg/auth/role"
+	"ads-mrkt/pkg/auth/scope"
 )
 
 type userService interface {
 	AuthUser(ctx context.Context, initDataStr string, referrerID int64) (*entity.User, error)
-	SetWallet(ctx context.Context, userID int64, walletAddressRaw string) error
+	CreateWalletChallenge(ctx context.Context, userID int64) (*entity.TonProofChallenge, error)
+	SetWallet(ctx context.Context, userID int64, proof *entity.TonProof) error
 	ClearWallet(ctx context.Context, userID int64) error
 }
 
@@ -19,7 +22,8 @@ type listingService interface {
 	UpdateListing(ctx context.Context, userID int64, l *entity.Listing) error
 	DeleteListing(ctx context.Context, userID int64, id int64) error
 	ListListingsByUserID(ctx context.Context, userID int64, typ *entity.ListingType) ([]*entity.Listing, error)
-	ListListingsAll(ctx context.Context, typ *entity.ListingType, categories []string, minFollowers *int64) ([]*entity.Listing, error)
+	ListListingsAll(ctx context.Context, filter entity.ListingFilter) ([]*entity.Listing, error)
+	ReportListing(ctx context.Context, userID int64, listingID int64, reason entity.ListingReportReason, comment string) (*entity.ListingReport, error)
 }
 
 type dealService interface {
@@ -30,7 +34,10 @@ type dealService interface {
 	GetDealsByListingIDForUser(ctx context.Context, listingID int64, userID int64) ([]*entity.Deal, error)
 	GetDealsByUserID(ctx context.Context, userID int64) ([]*entity.Deal, error)
 	UpdateDealDraft(ctx context.Context, userID int64, d *entity.Deal) error
-	SignDeal(ctx context.Context, userID int64, dealID int64) error
+	GetDealTerms(ctx context.Context, userID int64, dealID int64) (*entity.DealTerms, error)
+	SignDeal(ctx context.Context, userID int64, dealID int64, signed *entity.SignedText) error
+	ExportSignedDealTerms(ctx context.Context, userID int64, dealID int64) (*entity.SignedDealTerms, error)
+	GetDealTimeline(ctx context.Context, userID int64, dealID int64) ([]*entity.DealEvent, error)
 	SetDealPayoutAddress(ctx context.Context, userID int64, dealID int64, payoutAddressRaw string) error
 	RejectDeal(ctx context.Context, userID int64, dealID int64) error
 }
@@ -42,7 +49,30 @@ type dealChatService interface {
 type channelService interface {
 	ListMyChannels(ctx context.Context, userID int64) ([]*entity.Channel, error)
 	RequestStatsRefresh(ctx context.Context, channelID int64, userID int64) (*entity.Channel, error)
-	GetChannelStats(ctx context.Context, channelID int64, userID int64) (interface{}, error)
+	GetChannelStats(ctx context.Context, channelID int64, userID int64) (*entity.ChannelStats, error)
+	GetChannelStatsHistory(ctx context.Context, channelID int64, userID int64, since time.Time) ([]*entity.ChannelStatsSnapshot, error)
+}
+
+type webhookService interface {
+	CreateWebhook(ctx context.Context, userID int64, url, secret string) (*entity.Webhook, error)
+	ListWebhooks(ctx context.Context, userID int64) ([]*entity.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID int64, id int64) error
+	PingWebhook(ctx context.Context, userID int64, id int64) (*entity.WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, userID int64, id int64) ([]*entity.WebhookDelivery, error)
+}
+
+type apiKeyService interface {
+	CreateAPIKey(ctx context.Context, userID int64, name string, scopes []scope.Scope, rateLimitPerMinute int) (*entity.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID int64) ([]*entity.APIKey, error)
+	DeleteAPIKey(ctx context.Context, userID int64, id int64) error
+}
+
+type sessionService interface {
+	StartSession(ctx context.Context, userID int64, userRole role.Role) (*entity.AuthTokens, error)
+	StartAccessOnlySession(ctx context.Context, userID int64, userRole role.Role) (string, error)
+	RefreshSession(ctx context.Context, refreshToken string) (*entity.AuthTokens, error)
+	Logout(ctx context.Context, userID int64, sessionID int64) error
+	LogoutAll(ctx context.Context, userID int64) (int, error)
 }
 
 type handler struct {
@@ -51,16 +81,20 @@ type handler struct {
 	dealService     dealService
 	dealChatService dealChatService
 	channelService  channelService
-	jwtManager      *auth.JWTManager
+	webhookService  webhookService
+	apiKeyService   apiKeyService
+	sessionService  sessionService
 }
 
-func NewHandler(userService userService, listingService listingService, dealService dealService, dealChatService dealChatService, channelService channelService, jwtManager *auth.JWTManager) *handler {
+func NewHandler(userService userService, listingService listingService, dealService dealService, dealChatService dealChatService, channelService channelService, webhookService webhookService, apiKeyService apiKeyService, sessionService sessionService) *handler {
 	return &handler{
 		userService:     userService,
 		listingService:  listingService,
 		dealService:     dealService,
 		dealChatService: dealChatService,
 		channelService:  channelService,
-		jwtManager:      jwtManager,
+		webhookService:  webhookService,
+		apiKeyService:   apiKeyService,
+		sessionService:  sessionService,
 	}
 }
@@ -43,6 +43,41 @@ func parseListListingsQuery(r *http.Request) (typ *entity.ListingType, categorie
 	return typ, categories, minFollowers
 }
 
+// parseListingFilter extends parseListListingsQuery with channel quality filters and sort.
+func parseListingFilter(r *http.Request) (entity.ListingFilter, error) {
+	typ, categories, minFollowers := parseListListingsQuery(r)
+	filter := entity.ListingFilter{Type: typ, Categories: categories, MinFollowers: minFollowers}
+	q := r.URL.Query()
+	for _, p := range []struct {
+		name string
+		dst  **float64
+	}{{"min_quality", &filter.MinQualityScore}, {"min_err", &filter.MinERRPercent}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 {
+			return filter, apperrors.ServiceError{Err: err, Message: "invalid " + p.name, Code: apperrors.ErrorCodeBadRequest}
+		}
+		*p.dst = &f
+	}
+	if v := q.Get("hide_suspicious"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, apperrors.ServiceError{Err: err, Message: "invalid hide_suspicious", Code: apperrors.ErrorCodeBadRequest}
+		}
+		filter.HideSuspicious = b
+	}
+	switch sort := entity.ListingSort(q.Get("sort")); sort {
+	case "", entity.ListingSortUpdated, entity.ListingSortFollowers, entity.ListingSortQuality, entity.ListingSortERR, entity.ListingSortReach:
+		filter.Sort = sort
+	default:
+		return filter, apperrors.ServiceError{Err: nil, Message: "invalid sort", Code: apperrors.ErrorCodeBadRequest}
+	}
+	return filter, nil
+}
+
 func mergeListingWithUpdate(existing *entity.Listing, id int64, req *model.UpdateListingRequest) (*entity.Listing, error) {
 	if len(req.Prices) > 0 {
 		if err := domain.ValidateListingPrices(req.Prices); err != nil {
@@ -153,16 +188,24 @@ func (h *handler) GetListing(w http.ResponseWriter, r *http.Request) (interface{
 }
 
 // @Tags		Market
-// @Summary	List all listings with optional type, categories, and min_followers filter (public, no auth)
+// @Summary	List all listings with optional type, categories, followers and channel quality filters (public, no auth)
 // @Produce	json
 // @Param		type	query		string										false	"Filter by type: lessor | lessee"
 // @Param		categories	query		string									false	"Comma-separated categories (e.g. Tech,Crypto)"
 // @Param		min_followers	query		int									false	"Min channel followers (only lessor listings with stats)"
+// @Param		min_quality	query		number									false	"Min channel quality score 0..100"
+// @Param		min_err	query		number										false	"Min channel ERR (views per post / followers), percent"
+// @Param		hide_suspicious	query		bool								false	"Hide channels flagged for a suspicious audience"
+// @Param		sort	query		string										false	"Sort: updated (default) | followers | quality | err | reach"
 // @Success	200		{object}	response.Template{data=[]entity.Listing}	"List of listings"
+// @Failure	400		{object}	response.Template{data=string}				"Bad request"
 // @Router		/market/listings [get]
 func (h *handler) ListListings(w http.ResponseWriter, r *http.Request) (interface{}, error) {
-	typ, categories, minFollowers := parseListListingsQuery(r)
-	list, err := h.listingService.ListListingsAll(r.Context(), typ, categories, minFollowers)
+	filter, err := parseListingFilter(r)
+	if err != nil {
+		return nil, err
+	}
+	list, err := h.listingService.ListListingsAll(r.Context(), filter)
 	if err != nil {
 		return nil, toServiceError(err)
 	}
@@ -260,3 +303,38 @@ func (h *handler) DeleteListing(w http.ResponseWriter, r *http.Request) (interfa
 	}
 	return map[string]string{"status": "deleted"}, nil
 }
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	Report someone else's listing to moderators. One open report per listing and user.
+// @Accept		json
+// @Produce	json
+// @Param		id		path		int											true	"Listing ID"
+// @Param		request	body		ReportListingRequest						true	"Reason and optional comment"
+// @Success	200		{object}	response.Template{data=entity.ListingReport}	"Report"
+// @Failure	400		{object}	response.Template{data=string}				"Bad request"
+// @Failure	401		{object}	response.Template{data=string}				"Unauthorized"
+// @Failure	403		{object}	response.Template{data=string}				"Listing is already blocked"
+// @Failure	404		{object}	response.Template{data=string}				"Not found"
+// @Failure	409		{object}	response.Template{data=string}				"Already reported"
+// @Router		/market/listings/{id}/report [post]
+func (h *handler) ReportListing(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var req model.ReportListingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, apperrors.ServiceError{Err: err, Message: "invalid body", Code: apperrors.ErrorCodeBadRequest}
+	}
+	report, err := h.listingService.ReportListing(r.Context(), userID, id, entity.ListingReportReason(req.Reason), req.Comment)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return report, nil
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"ads-mrkt/internal/market/domain/entity"
+	"ads-mrkt/pkg/auth/scope"
+)
+
+type CreateAPIKeyRequest struct {
+	Name               string   `json:"name"`
+	Scopes             []string `json:"scopes"`                          // listings:read, listings:write, deals:write
+	RateLimitPerMinute int      `json:"rate_limit_per_minute,omitempty"` // 1..600, default 60
+}
+
+func (req *CreateAPIKeyRequest) ScopeList() []scope.Scope {
+	out := make([]scope.Scope, 0, len(req.Scopes))
+	for _, s := range req.Scopes {
+		out = append(out, scope.Scope(s))
+	}
+	return out
+}
+
+// CreateAPIKeyResponse is the created key with the key itself, which is not shown again.
+type CreateAPIKeyResponse struct {
+	*entity.APIKey
+	Key string `json:"key"`
+}
+
+func APIKeyToCreateResponse(k *entity.APIKey) *CreateAPIKeyResponse {
+	return &CreateAPIKeyResponse{APIKey: k, Key: k.Key}
+}
@@ -1,9 +1,66 @@
 package model
 
+import (
+	"encoding/base64"
+	"fmt"
+
+	"ads-mrkt/internal/market/domain/entity"
+)
+
 type AuthUserRequest struct {
 	Referrer int64 `json:"referrer"`
+	// Tokens asks for the access and refresh tokens. Without it only the access token string is returned, as before
+	// refresh tokens, and the session ends with that token.
+	Tokens bool `json:"tokens"`
+}
+
+type RefreshSessionRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutAllResponse struct {
+	Revoked int `json:"revoked"` // sessions that were live
 }
 
+// SetWalletRequest is the wallet account and ton_proof from the TON Connect connect event.
 type SetWalletRequest struct {
-	WalletAddress string `json:"wallet_address"`
+	WalletAddress string         `json:"wallet_address"`
+	PublicKey     string         `json:"public_key,omitempty"` // hex
+	StateInit     string         `json:"state_init,omitempty"` // base64 BOC
+	Proof         *TonProofModel `json:"proof"`
+}
+
+type TonProofModel struct {
+	Timestamp int64               `json:"timestamp"`
+	Domain    TonProofDomainModel `json:"domain"`
+	Signature string              `json:"signature"` // base64
+	Payload   string              `json:"payload"`
+}
+
+type TonProofDomainModel struct {
+	LengthBytes uint32 `json:"lengthBytes"`
+	Value       string `json:"value"`
+}
+
+func SetWalletRequestToTonProof(req *SetWalletRequest) (*entity.TonProof, error) {
+	signature, err := base64.StdEncoding.DecodeString(req.Proof.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	var stateInit []byte
+	if req.StateInit != "" {
+		if stateInit, err = base64.StdEncoding.DecodeString(req.StateInit); err != nil {
+			return nil, fmt.Errorf("invalid state_init: %w", err)
+		}
+	}
+	return &entity.TonProof{
+		Address:           req.WalletAddress,
+		PublicKey:         req.PublicKey,
+		Timestamp:         req.Proof.Timestamp,
+		DomainLengthBytes: req.Proof.Domain.LengthBytes,
+		Domain:            req.Proof.Domain.Value,
+		Signature:         signature,
+		Payload:           req.Proof.Payload,
+		StateInit:         stateInit,
+	}, nil
 }
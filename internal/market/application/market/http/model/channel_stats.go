@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+)
+
+type StatsPoint struct {
+	Time  time.Time `json:"t"`
+	Value float64   `json:"v"`
+}
+
+type ChannelStatsSeries struct {
+	Followers                   []StatsPoint `json:"followers"`
+	ViewsPerPost                []StatsPoint `json:"views_per_post"`
+	SharesPerPost               []StatsPoint `json:"shares_per_post"`
+	ReactionsPerPost            []StatsPoint `json:"reactions_per_post"`
+	EnabledNotificationsPercent []StatsPoint `json:"enabled_notifications_percent"`
+}
+
+type ChannelStatsHistoryResponse struct {
+	ChannelID int64              `json:"channel_id"`
+	From      time.Time          `json:"from"`
+	Series    ChannelStatsSeries `json:"series"`
+}
+
+func ChannelStatsHistoryToResponse(channelID int64, from time.Time, snapshots []*entity.ChannelStatsSnapshot) *ChannelStatsHistoryResponse {
+	n := len(snapshots)
+	series := ChannelStatsSeries{
+		Followers:                   make([]StatsPoint, 0, n),
+		ViewsPerPost:                make([]StatsPoint, 0, n),
+		SharesPerPost:               make([]StatsPoint, 0, n),
+		ReactionsPerPost:            make([]StatsPoint, 0, n),
+		EnabledNotificationsPercent: make([]StatsPoint, 0, n),
+	}
+	for _, s := range snapshots {
+		series.Followers = append(series.Followers, StatsPoint{Time: s.FetchedAt, Value: s.Followers})
+		series.ViewsPerPost = append(series.ViewsPerPost, StatsPoint{Time: s.FetchedAt, Value: s.ViewsPerPost})
+		series.SharesPerPost = append(series.SharesPerPost, StatsPoint{Time: s.FetchedAt, Value: s.SharesPerPost})
+		series.ReactionsPerPost = append(series.ReactionsPerPost, StatsPoint{Time: s.FetchedAt, Value: s.ReactionsPerPost})
+		series.EnabledNotificationsPercent = append(series.EnabledNotificationsPercent, StatsPoint{Time: s.FetchedAt, Value: s.EnabledNotificationsPercent})
+	}
+	return &ChannelStatsHistoryResponse{ChannelID: channelID, From: from, Series: series}
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+)
+
+// DealEventResponse is a status transition of the deal. Admins are not identified to the deal sides.
+type DealEventResponse struct {
+	FromStatus  *entity.DealStatus   `json:"from_status,omitempty"` // empty for the deal creation
+	ToStatus    entity.DealStatus    `json:"to_status"`
+	ActorType   entity.DealActorType `json:"actor_type"`              // user, admin or worker
+	ActorUserID *int64               `json:"actor_user_id,omitempty"` // deal side, for user transitions
+	ActorName   string               `json:"actor_name,omitempty"`    // worker name
+	Reason      string               `json:"reason"`
+	TxHash      *string              `json:"tx_hash,omitempty"`
+	MessageID   *int64               `json:"message_id,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+func DealEventsToResponse(events []*entity.DealEvent) []*DealEventResponse {
+	out := make([]*DealEventResponse, 0, len(events))
+	for _, e := range events {
+		resp := &DealEventResponse{
+			FromStatus: e.FromStatus,
+			ToStatus:   e.ToStatus,
+			ActorType:  e.ActorType,
+			ActorName:  e.ActorName,
+			Reason:     e.Reason,
+			TxHash:     e.TxHash,
+			MessageID:  e.MessageID,
+			CreatedAt:  e.CreatedAt,
+		}
+		if e.ActorType == entity.DealActorUser {
+			resp.ActorUserID = e.ActorUserID
+		}
+		out = append(out, resp)
+	}
+	return out
+}
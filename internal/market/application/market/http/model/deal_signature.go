@@ -0,0 +1,95 @@
+package model
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+)
+
+// SignDealRequest is the TON Connect signData result as returned by the wallet.
+type SignDealRequest struct {
+	Signature string               `json:"signature"` // base64
+	Address   string               `json:"address"`
+	Timestamp int64                `json:"timestamp"`
+	Domain    string               `json:"domain"`
+	Payload   SignDataPayloadModel `json:"payload"`
+}
+
+type SignDataPayloadModel struct {
+	Type string `json:"type"` // only "text" is accepted
+	Text string `json:"text"`
+}
+
+func SignDealRequestToSignedText(req *SignDealRequest) (*entity.SignedText, error) {
+	if req.Payload.Type != "text" {
+		return nil, fmt.Errorf("unsupported payload type %q", req.Payload.Type)
+	}
+	signature, err := base64.StdEncoding.DecodeString(req.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	return &entity.SignedText{
+		Address:   req.Address,
+		Domain:    req.Domain,
+		Timestamp: req.Timestamp,
+		Text:      req.Payload.Text,
+		Signature: signature,
+	}, nil
+}
+
+type DealSignatureResponse struct {
+	UserID        int64     `json:"user_id"`
+	Side          string    `json:"side"` // lessor or lessee
+	Terms         string    `json:"terms"`
+	TermsHash     string    `json:"terms_hash"`
+	WalletAddress string    `json:"wallet_address"`
+	PublicKey     string    `json:"public_key"`
+	Domain        string    `json:"domain"`
+	Timestamp     int64     `json:"timestamp"`
+	SignedAt      time.Time `json:"signed_at"`
+	Signature     string    `json:"signature"`
+}
+
+// SignedDealTermsResponse is the export of the signed deal terms. Each signature verifies as
+// ed25519(public_key, sha256(0xffff ++ "ton-connect/sign-data/" ++ workchain (int32 BE) ++ address hash ++
+// domain length (uint32 BE) ++ domain ++ timestamp (uint64 BE) ++ "txt" ++ terms length (uint32 BE) ++ terms)).
+type SignedDealTermsResponse struct {
+	DealID     int64                    `json:"deal_id"`
+	Status     entity.DealStatus        `json:"status"`
+	Terms      string                   `json:"terms"`
+	TermsHash  string                   `json:"terms_hash"`
+	Scheme     string                   `json:"scheme"`
+	Signatures []*DealSignatureResponse `json:"signatures"`
+}
+
+func SignedDealTermsToResponse(st *entity.SignedDealTerms) *SignedDealTermsResponse {
+	out := &SignedDealTermsResponse{
+		DealID:     st.Deal.ID,
+		Status:     st.Deal.Status,
+		Terms:      st.Terms.Terms,
+		TermsHash:  st.Terms.TermsHash,
+		Scheme:     "ton-connect/sign-data text",
+		Signatures: make([]*DealSignatureResponse, 0, len(st.Signatures)),
+	}
+	for _, sig := range st.Signatures {
+		side := "lessee"
+		if sig.UserID == st.Deal.LessorID {
+			side = "lessor"
+		}
+		out.Signatures = append(out.Signatures, &DealSignatureResponse{
+			UserID:        sig.UserID,
+			Side:          side,
+			Terms:         sig.Terms,
+			TermsHash:     sig.TermsHash,
+			WalletAddress: sig.WalletAddress,
+			PublicKey:     sig.PublicKey,
+			Domain:        sig.Domain,
+			Timestamp:     sig.SignedAt.Unix(),
+			SignedAt:      sig.SignedAt,
+			Signature:     sig.Signature,
+		})
+	}
+	return out
+}
@@ -24,6 +24,11 @@ type UpdateListingRequest struct {
 	Description *string         `json:"description,omitempty"`
 }
 
+type ReportListingRequest struct {
+	Reason  string `json:"reason"` // spam | scam | gambling | adult | prohibited | other
+	Comment string `json:"comment,omitempty"`
+}
+
 func ListingWithPricesInTON(l *entity.Listing) *entity.Listing {
 	if l == nil {
 		return nil
@@ -0,0 +1,18 @@
+package model
+
+import "ads-mrkt/internal/market/domain/entity"
+
+type CreateWebhookRequest struct {
+	URL    string `json:"url"`              // https only
+	Secret string `json:"secret,omitempty"` // 16 to 256 characters; generated when empty
+}
+
+// CreateWebhookResponse is the created webhook with its signing secret, which is not shown again.
+type CreateWebhookResponse struct {
+	*entity.Webhook
+	Secret string `json:"secret"`
+}
+
+func WebhookToCreateResponse(w *entity.Webhook) *CreateWebhookResponse {
+	return &CreateWebhookResponse{Webhook: w, Secret: w.Secret}
+}
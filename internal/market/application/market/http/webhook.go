@@ -0,0 +1,138 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	apperrors "ads-mrkt/internal/errors"
+	"ads-mrkt/internal/market/application/market/http/model"
+	_ "ads-mrkt/internal/market/domain/entity"
+	_ "ads-mrkt/internal/server/templates/response"
+)
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	Register an HTTPS webhook for the events of your deals: deal.signed, deal.funded, deal.posted, deal.completed, deal.refunded. Requests carry X-Webhook-Signature: sha256=hex(HMAC-SHA256(secret, X-Webhook-Timestamp + "." + body)). The secret is returned only here.
+// @Accept		json
+// @Produce	json
+// @Param		request	body		model.CreateWebhookRequest							true	"url (https) and optional secret"
+// @Success	200		{object}	response.Template{data=model.CreateWebhookResponse}	"Created webhook with its secret"
+// @Failure	400		{object}	response.Template{data=string}						"Bad request"
+// @Failure	401		{object}	response.Template{data=string}						"Unauthorized"
+// @Router		/market/webhooks [post]
+func (h *handler) CreateWebhook(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req model.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, apperrors.ServiceError{Err: err, Message: "invalid body", Code: apperrors.ErrorCodeBadRequest}
+	}
+	webhook, err := h.webhookService.CreateWebhook(r.Context(), userID, req.URL, req.Secret)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return model.WebhookToCreateResponse(webhook), nil
+}
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	List your webhooks
+// @Produce	json
+// @Success	200	{object}	response.Template{data=[]entity.Webhook}	"Webhooks"
+// @Failure	401	{object}	response.Template{data=string}				"Unauthorized"
+// @Router		/market/webhooks [get]
+func (h *handler) ListWebhooks(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := h.webhookService.ListWebhooks(r.Context(), userID)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return list, nil
+}
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	Delete your webhook with its delivery log; pending deliveries are dropped
+// @Produce	json
+// @Param		id	path		int								true	"Webhook ID"
+// @Success	200	{object}	response.Template{data=string}	"Deleted"
+// @Failure	400	{object}	response.Template{data=string}	"Bad request"
+// @Failure	401	{object}	response.Template{data=string}	"Unauthorized"
+// @Failure	404	{object}	response.Template{data=string}	"Not found"
+// @Router		/market/webhooks/{id} [delete]
+func (h *handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.webhookService.DeleteWebhook(r.Context(), userID, id); err != nil {
+		return nil, toServiceError(err)
+	}
+	return map[string]string{"status": "deleted"}, nil
+}
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	Send a signed ping event to your webhook now, without retries, and return the logged delivery
+// @Produce	json
+// @Param		id	path		int												true	"Webhook ID"
+// @Success	200	{object}	response.Template{data=entity.WebhookDelivery}	"Delivery: delivered or failed"
+// @Failure	400	{object}	response.Template{data=string}					"Bad request"
+// @Failure	401	{object}	response.Template{data=string}					"Unauthorized"
+// @Failure	404	{object}	response.Template{data=string}					"Not found"
+// @Router		/market/webhooks/{id}/ping [post]
+func (h *handler) PingWebhook(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := h.webhookService.PingWebhook(r.Context(), userID, id)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return delivery, nil
+}
+
+// @Security	JWT
+// @Tags		Market
+// @Summary	Delivery log of your webhook, newest first: status, attempts, last response and next retry
+// @Produce	json
+// @Param		id	path		int												true	"Webhook ID"
+// @Success	200	{object}	response.Template{data=[]entity.WebhookDelivery}	"Deliveries"
+// @Failure	400	{object}	response.Template{data=string}						"Bad request"
+// @Failure	401	{object}	response.Template{data=string}						"Unauthorized"
+// @Failure	404	{object}	response.Template{data=string}						"Not found"
+// @Router		/market/webhooks/{id}/deliveries [get]
+func (h *handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := h.webhookService.ListWebhookDeliveries(r.Context(), userID, id)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return list, nil
+}
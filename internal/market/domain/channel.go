@@ -0,0 +1,9 @@
+package domain
+
+import "ads-mrkt/internal/market/domain/entity"
+
+// ChannelConnected reports whether the userbot's rights in the channel are enough to run ads: publish posts
+// and remove them. Listings of channels that are not connected cannot be active.
+func ChannelConnected(rights entity.AdminRights) bool {
+	return rights.PostMessages && rights.DeleteMessages
+}
@@ -0,0 +1,197 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"ads-mrkt/internal/market/domain/entity"
+)
+
+const (
+	// A day is a follower spike when growth is at least spikeMinGrowthPercent of followers and
+	// spikeMedianFactor times the median daily change.
+	spikeMinGrowthPercent = 3.0
+	spikeMedianFactor     = 5.0
+	// A spike is backed by views when views around it rise by at least this share of the relative follower growth.
+	spikeViewsShare = 0.25
+
+	lowERRPercent           = 2.0
+	lowERRMinFollowers      = 1000
+	lowNotificationsPercent = 2.0
+
+	msPerDay = 24 * 60 * 60 * 1000
+)
+
+// broadcastStats is the part of the stored tg.StatsBroadcastStats JSON the quality metrics use.
+type broadcastStats struct {
+	Followers            statsAbsValue `json:"Followers"`
+	ViewsPerPost         statsAbsValue `json:"ViewsPerPost"`
+	SharesPerPost        statsAbsValue `json:"SharesPerPost"`
+	ReactionsPerPost     statsAbsValue `json:"ReactionsPerPost"`
+	EnabledNotifications struct {
+		Part  float64 `json:"Part"`
+		Total float64 `json:"Total"`
+	} `json:"EnabledNotifications"`
+	GrowthGraph       statsGraph `json:"GrowthGraph"`
+	InteractionsGraph statsGraph `json:"InteractionsGraph"`
+}
+
+type statsAbsValue struct {
+	Current  float64 `json:"Current"`
+	Previous float64 `json:"Previous"`
+}
+
+type statsGraph struct {
+	JSON struct {
+		Data string `json:"Data"`
+	} `json:"JSON"`
+}
+
+// series decodes the graph's chart data into x (unix ms) and the first y column.
+func (g statsGraph) series() (x, y []float64) {
+	_, x, ys, ok := ParseStatsGraph(g.JSON.Data)
+	if !ok {
+		return nil, nil
+	}
+	return x, ys[0].Values
+}
+
+// ComputeChannelQuality derives quality metrics from stored broadcast stats and flags patterns typical for
+// inflated audiences: follower jumps not followed by views, low ERR on a large channel, almost nobody with
+// notifications on. Returns nil when stats have no followers.
+func ComputeChannelQuality(raw json.RawMessage) *entity.ChannelQuality {
+	var stats broadcastStats
+	if err := json.Unmarshal(raw, &stats); err != nil || stats.Followers.Current <= 0 {
+		return nil
+	}
+
+	followers := stats.Followers.Current
+	views := stats.ViewsPerPost.Current
+	q := &entity.ChannelQuality{
+		ERRPercent: views / followers * 100,
+		AvgReach:   views,
+	}
+	if views > 0 {
+		q.SharesRatioPercent = stats.SharesPerPost.Current / views * 100
+		q.ReactionsRatioPercent = stats.ReactionsPerPost.Current / views * 100
+	}
+	if stats.EnabledNotifications.Total > 0 {
+		q.NotificationsEnabledPercent = stats.EnabledNotifications.Part / stats.EnabledNotifications.Total * 100
+	}
+
+	var spikeWithoutViews bool
+	q.MaxDailyGrowthPercent, spikeWithoutViews = followerSpikes(stats.GrowthGraph, stats.InteractionsGraph)
+	if spikeWithoutViews {
+		q.Flags = append(q.Flags, entity.ChannelQualityFlagFollowerSpikeWithoutViews)
+	}
+	if followers >= lowERRMinFollowers && q.ERRPercent < lowERRPercent {
+		q.Flags = append(q.Flags, entity.ChannelQualityFlagLowERR)
+	}
+	if stats.EnabledNotifications.Total > 0 && q.NotificationsEnabledPercent < lowNotificationsPercent {
+		q.Flags = append(q.Flags, entity.ChannelQualityFlagLowNotifications)
+	}
+	q.Suspicious = spikeWithoutViews || (followers >= lowERRMinFollowers && q.ERRPercent < lowERRPercent)
+
+	q.Score = qualityScore(q)
+	q.ERRPercent = round1(q.ERRPercent)
+	q.SharesRatioPercent = round1(q.SharesRatioPercent)
+	q.ReactionsRatioPercent = round1(q.ReactionsRatioPercent)
+	q.NotificationsEnabledPercent = round1(q.NotificationsEnabledPercent)
+	return q
+}
+
+// followerSpikes returns the largest daily follower growth in percent and whether some spike day had
+// no matching rise in views.
+func followerSpikes(growth, interactions statsGraph) (maxGrowthPercent float64, spikeWithoutViews bool) {
+	days, totals := growth.series()
+	if len(totals) < 3 {
+		return 0, false
+	}
+	deltas := make([]float64, 0, len(totals)-1)
+	for i := 1; i < len(totals); i++ {
+		deltas = append(deltas, totals[i]-totals[i-1])
+	}
+	medianDelta := median(absAll(deltas))
+
+	viewDays, dayViews := interactions.series()
+	viewsByDay := make(map[int64]float64, len(viewDays))
+	for i, x := range viewDays {
+		viewsByDay[int64(x)/msPerDay] = dayViews[i]
+	}
+	medianViews := median(dayViews)
+
+	for i, delta := range deltas {
+		prev := totals[i]
+		if prev <= 0 {
+			continue
+		}
+		growthPercent := delta / prev * 100
+		maxGrowthPercent = math.Max(maxGrowthPercent, growthPercent)
+		if growthPercent < spikeMinGrowthPercent || delta < spikeMedianFactor*medianDelta {
+			continue
+		}
+		if medianViews <= 0 {
+			continue // no views data to compare with
+		}
+		day := int64(days[i+1]) / msPerDay
+		spikeViews, ok := viewsByDay[day]
+		if next, okNext := viewsByDay[day+1]; okNext {
+			spikeViews = math.Max(spikeViews, next)
+			ok = true
+		}
+		if !ok {
+			continue
+		}
+		if spikeViews/medianViews-1 < growthPercent/100*spikeViewsShare {
+			spikeWithoutViews = true
+		}
+	}
+	return round1(maxGrowthPercent), spikeWithoutViews
+}
+
+// qualityScore weighs ERR (40), engagement (20), notifications (20) and organic growth (20) into 0..100;
+// suspicious channels get half.
+func qualityScore(q *entity.ChannelQuality) float64 {
+	score := math.Min(q.ERRPercent/20, 1)*40 +
+		math.Min((q.SharesRatioPercent+q.ReactionsRatioPercent)/5, 1)*20 +
+		math.Min(q.NotificationsEnabledPercent/30, 1)*20
+	organic := true
+	for _, f := range q.Flags {
+		if f == entity.ChannelQualityFlagFollowerSpikeWithoutViews {
+			organic = false
+		}
+	}
+	if organic {
+		score += 20
+	}
+	if q.Suspicious {
+		score /= 2
+	}
+	return round1(score)
+}
+
+func absAll(values []float64) []float64 {
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[i] = math.Abs(v)
+	}
+	return out
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
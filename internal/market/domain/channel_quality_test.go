@@ -0,0 +1,184 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"ads-mrkt/internal/market/domain/entity"
+)
+
+// day0 is the x value (unix ms) of the first day of test graphs.
+const day0 = 20000 * msPerDay
+
+// dailyGraph returns a stats graph with one y column, one value per day from day0.
+func dailyGraph(values ...float64) statsGraph {
+	x := make([]string, 0, len(values)+1)
+	y := make([]string, 0, len(values)+1)
+	x = append(x, `"x"`)
+	y = append(y, `"y0"`)
+	for i, v := range values {
+		x = append(x, fmt.Sprint(day0+i*msPerDay))
+		y = append(y, fmt.Sprint(v))
+	}
+	var g statsGraph
+	g.JSON.Data = `{"columns":[[` + strings.Join(x, ",") + `],[` + strings.Join(y, ",") + `]],"types":{"x":"x","y0":"line"}}`
+	return g
+}
+
+func rawGraph(data string) statsGraph {
+	var g statsGraph
+	g.JSON.Data = data
+	return g
+}
+
+type testStats struct {
+	followers, views, shares, reactions float64
+	notificationsPart, notificationsAll float64
+	growth, interactions                statsGraph
+}
+
+func (s testStats) json(t *testing.T) json.RawMessage {
+	t.Helper()
+	stats := broadcastStats{
+		Followers:         statsAbsValue{Current: s.followers},
+		ViewsPerPost:      statsAbsValue{Current: s.views},
+		SharesPerPost:     statsAbsValue{Current: s.shares},
+		ReactionsPerPost:  statsAbsValue{Current: s.reactions},
+		GrowthGraph:       s.growth,
+		InteractionsGraph: s.interactions,
+	}
+	stats.EnabledNotifications.Part = s.notificationsPart
+	stats.EnabledNotifications.Total = s.notificationsAll
+	raw, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// Follower totals with a jump of 1000 (about 10%) on the fifth day, and daily views around it.
+var (
+	spikeGrowth  = dailyGraph(10000, 10010, 10020, 10030, 11030, 11040)
+	flatViews    = dailyGraph(2000, 2000, 2000, 2000, 2000, 2000)
+	spikingViews = dailyGraph(2000, 2000, 2000, 2000, 3000, 2500)
+)
+
+func TestComputeChannelQuality(t *testing.T) {
+	cases := []struct {
+		name       string
+		stats      testStats
+		want       entity.ChannelQuality
+		checkScore bool
+	}{
+		{
+			name:       "healthy channel",
+			stats:      testStats{followers: 10000, views: 2000, shares: 50, reactions: 50, notificationsPart: 3000, notificationsAll: 10000},
+			want:       entity.ChannelQuality{Score: 100, ERRPercent: 20, AvgReach: 2000, SharesRatioPercent: 2.5, ReactionsRatioPercent: 2.5, NotificationsEnabledPercent: 30},
+			checkScore: true,
+		},
+		{
+			name:       "low ERR on a large channel",
+			stats:      testStats{followers: 10000, views: 100},
+			want:       entity.ChannelQuality{Score: 11, ERRPercent: 1, AvgReach: 100, Suspicious: true, Flags: []string{entity.ChannelQualityFlagLowERR}},
+			checkScore: true,
+		},
+		{
+			name:       "low ERR on a small channel",
+			stats:      testStats{followers: 500, views: 5},
+			want:       entity.ChannelQuality{Score: 22, ERRPercent: 1, AvgReach: 5},
+			checkScore: true,
+		},
+		{
+			name:       "low notifications",
+			stats:      testStats{followers: 10000, views: 2000, notificationsPart: 100, notificationsAll: 10000},
+			want:       entity.ChannelQuality{Score: 60.7, ERRPercent: 20, AvgReach: 2000, NotificationsEnabledPercent: 1, Flags: []string{entity.ChannelQualityFlagLowNotifications}},
+			checkScore: true,
+		},
+		{
+			name:  "follower spike without views",
+			stats: testStats{followers: 11040, views: 2000, growth: spikeGrowth, interactions: flatViews},
+			want:  entity.ChannelQuality{ERRPercent: 18.1, AvgReach: 2000, MaxDailyGrowthPercent: 10, Suspicious: true, Flags: []string{entity.ChannelQualityFlagFollowerSpikeWithoutViews}},
+		},
+		{
+			name:  "follower spike backed by views",
+			stats: testStats{followers: 11040, views: 2000, growth: spikeGrowth, interactions: spikingViews},
+			want:  entity.ChannelQuality{ERRPercent: 18.1, AvgReach: 2000, MaxDailyGrowthPercent: 10},
+		},
+		{
+			name:  "follower spike without views data",
+			stats: testStats{followers: 11040, views: 2000, growth: spikeGrowth},
+			want:  entity.ChannelQuality{ERRPercent: 18.1, AvgReach: 2000, MaxDailyGrowthPercent: 10},
+		},
+		{
+			name:  "steady growth",
+			stats: testStats{followers: 10050, views: 2000, growth: dailyGraph(10000, 10010, 10020, 10030, 10040, 10050), interactions: flatViews},
+			want:  entity.ChannelQuality{ERRPercent: 19.9, AvgReach: 2000, MaxDailyGrowthPercent: 0.1},
+		},
+		{
+			name:  "malformed growth graph",
+			stats: testStats{followers: 10000, views: 2000, growth: rawGraph(`{"columns":`), interactions: flatViews},
+			want:  entity.ChannelQuality{ERRPercent: 20, AvgReach: 2000},
+		},
+		{
+			name:  "growth graph columns of different lengths",
+			stats: testStats{followers: 10000, views: 2000, growth: rawGraph(`{"columns":[["x",1,2,3],["y0",1,2]],"types":{"x":"x"}}`), interactions: flatViews},
+			want:  entity.ChannelQuality{ERRPercent: 20, AvgReach: 2000},
+		},
+		{
+			name:  "growth graph too short",
+			stats: testStats{followers: 10000, views: 2000, growth: dailyGraph(10000, 20000)},
+			want:  entity.ChannelQuality{ERRPercent: 20, AvgReach: 2000},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := ComputeChannelQuality(tc.stats.json(t))
+			if q == nil {
+				t.Fatal("quality = nil")
+			}
+			if !tc.checkScore {
+				q.Score = 0
+			}
+			if q.Score != tc.want.Score || q.ERRPercent != tc.want.ERRPercent || q.AvgReach != tc.want.AvgReach ||
+				q.SharesRatioPercent != tc.want.SharesRatioPercent || q.ReactionsRatioPercent != tc.want.ReactionsRatioPercent ||
+				q.NotificationsEnabledPercent != tc.want.NotificationsEnabledPercent || q.MaxDailyGrowthPercent != tc.want.MaxDailyGrowthPercent ||
+				q.Suspicious != tc.want.Suspicious || !slices.Equal(q.Flags, tc.want.Flags) {
+				t.Fatalf("quality = %+v, want %+v", *q, tc.want)
+			}
+		})
+	}
+}
+
+func TestComputeChannelQualityWithoutFollowers(t *testing.T) {
+	for name, raw := range map[string]json.RawMessage{
+		"malformed":    json.RawMessage(`{"Followers":`),
+		"empty":        json.RawMessage(`{}`),
+		"no followers": testStats{views: 100}.json(t),
+	} {
+		if q := ComputeChannelQuality(raw); q != nil {
+			t.Errorf("%s: quality = %+v, want nil", name, *q)
+		}
+	}
+}
+
+func TestQualityScore(t *testing.T) {
+	cases := []struct {
+		name string
+		q    entity.ChannelQuality
+		want float64
+	}{
+		{"nothing but organic growth", entity.ChannelQuality{}, 20},
+		{"capped", entity.ChannelQuality{ERRPercent: 80, SharesRatioPercent: 10, ReactionsRatioPercent: 10, NotificationsEnabledPercent: 90}, 100},
+		{"half of each", entity.ChannelQuality{ERRPercent: 10, SharesRatioPercent: 1, ReactionsRatioPercent: 1.5, NotificationsEnabledPercent: 15}, 60},
+		{"spike without views", entity.ChannelQuality{ERRPercent: 20, Flags: []string{entity.ChannelQualityFlagFollowerSpikeWithoutViews}}, 40},
+		{"suspicious", entity.ChannelQuality{ERRPercent: 20, Suspicious: true, Flags: []string{entity.ChannelQualityFlagFollowerSpikeWithoutViews}}, 20},
+	}
+	for _, tc := range cases {
+		if got := qualityScore(&tc.q); got != tc.want {
+			t.Errorf("%s: score = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
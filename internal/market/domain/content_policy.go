@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+)
+
+// linkHost matches hosts of links in free text, with or without a scheme: "https://t.me/x", "casino.com/promo".
+var linkHost = regexp.MustCompile(`(?i)(?:[a-z][a-z0-9+.-]*://)?((?:[\pL\pN](?:[\pL\pN-]*[\pL\pN])?\.)+[\pL]{2,})(?::\d+)?`)
+
+// ContentPolicy refuses user text with blocked words, matching blocked patterns or linking to blocked domains.
+// The zero policy allows everything.
+type ContentPolicy struct {
+	words    []blockedWord
+	patterns []*regexp.Regexp
+	domains  []string
+}
+
+type blockedWord struct {
+	word string
+	re   *regexp.Regexp
+}
+
+// NewContentPolicy compiles the blocklists. Words match whole words in any case; patterns are regular expressions
+// matched as given; domains match their subdomains too. Empty entries are skipped.
+func NewContentPolicy(words, patterns, domains []string) (*ContentPolicy, error) {
+	p := &ContentPolicy{}
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w == "" {
+			continue
+		}
+		// \b is ASCII only in RE2, so word boundaries are spelled out to work for any script.
+		re, err := regexp.Compile(`(?i)(?:^|[^\pL\pN_])` + regexp.QuoteMeta(w) + `(?:[^\pL\pN_]|$)`)
+		if err != nil {
+			return nil, fmt.Errorf("blocked word %q: %w", w, err)
+		}
+		p.words = append(p.words, blockedWord{word: w, re: re})
+	}
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("blocked pattern %q: %w", pattern, err)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+	for _, d := range domains {
+		if d = strings.Trim(strings.ToLower(strings.TrimSpace(d)), "."); d != "" {
+			p.domains = append(p.domains, d)
+		}
+	}
+	return p, nil
+}
+
+// Check returns ErrContentBlocked, naming the word or domain that matched, when text breaks the policy. Matching
+// patterns are not named.
+func (p *ContentPolicy) Check(text string) error {
+	if p == nil || text == "" {
+		return nil
+	}
+	for _, w := range p.words {
+		if w.re.MatchString(text) {
+			return fmt.Errorf("%w: contains %q", marketerrors.ErrContentBlocked, w.word)
+		}
+	}
+	for _, re := range p.patterns {
+		if re.MatchString(text) {
+			return fmt.Errorf("%w: matches a blocked pattern", marketerrors.ErrContentBlocked)
+		}
+	}
+	if len(p.domains) == 0 {
+		return nil
+	}
+	for _, m := range linkHost.FindAllStringSubmatch(text, -1) {
+		host := strings.ToLower(m[1])
+		for _, d := range p.domains {
+			if host == d || strings.HasSuffix(host, "."+d) {
+				return fmt.Errorf("%w: links to %s", marketerrors.ErrContentBlocked, d)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+)
+
+func TestContentPolicyCheck(t *testing.T) {
+	p, err := NewContentPolicy([]string{"casino", " ", "казино"}, []string{`(?i)guaranteed\s+\d+%\s+profit`}, []string{"Scam.example", ".bet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for text, blocked := range map[string]bool{
+		"":                                    false,
+		"Tech news channel, 50k subscribers":  false,
+		"Best CASINO bonuses":                 true,
+		"casino.":                             true,
+		"casinos and poker":                   false,
+		"Лучшее казино!":                      true,
+		"Guaranteed 300% profit in a week":    true,
+		"Join https://scam.example/join":      true,
+		"see promo.scam.example for details":  true,
+		"notscam.example is fine":             false,
+		"sports at lucky.bet:8080":            true,
+		"write to me at https://t.me/channel": false,
+	} {
+		err := p.Check(text)
+		if got := errors.Is(err, marketerrors.ErrContentBlocked); got != blocked {
+			t.Errorf("%q: err = %v, want blocked %v", text, err, blocked)
+		}
+	}
+
+	if err := (*ContentPolicy)(nil).Check("casino"); err != nil {
+		t.Errorf("nil policy: %v", err)
+	}
+	if _, err := NewContentPolicy(nil, []string{"("}, nil); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
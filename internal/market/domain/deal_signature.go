@@ -4,21 +4,45 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
 
 	"ads-mrkt/internal/market/domain/entity"
 )
 
-func ComputeDealSignature(dealType string, duration int64, priceNanoton int64, details json.RawMessage, userID int64, lessorPayoutRaw, lesseePayoutRaw string) string {
-	h := sha256.New()
-	h.Write([]byte(dealType))
-	h.Write([]byte(fmt.Sprintf("%d", duration)))
-	h.Write([]byte(fmt.Sprintf("%d", priceNanoton)))
-	h.Write(details)
-	h.Write([]byte(fmt.Sprintf("%d", userID)))
-	h.Write([]byte(lessorPayoutRaw))
-	h.Write([]byte(lesseePayoutRaw))
-	return hex.EncodeToString(h.Sum(nil))
+const dealTermsDocumentVersion = "ads-mrkt/deal-terms/v1"
+
+// dealTermsDocument is what parties sign with TON Connect signData. Field order is fixed by the struct,
+// so the marshaled JSON is canonical for the same deal terms.
+type dealTermsDocument struct {
+	Document            string `json:"document"`
+	DealID              int64  `json:"deal_id"`
+	Type                string `json:"type"`
+	Duration            int64  `json:"duration"`
+	PriceNanoton        int64  `json:"price_nanoton"`
+	DetailsSHA256       string `json:"details_sha256"`
+	LessorPayoutAddress string `json:"lessor_payout_address"`
+	LesseePayoutAddress string `json:"lessee_payout_address"`
+}
+
+// ComputeDealTerms builds the canonical terms document of the deal and its hash.
+func ComputeDealTerms(d *entity.Deal) *entity.DealTerms {
+	lessorPayout, lesseePayout := dealPayoutAddresses(d)
+	detailsHash := sha256.Sum256(d.Details)
+	doc, _ := json.Marshal(dealTermsDocument{
+		Document:            dealTermsDocumentVersion,
+		DealID:              d.ID,
+		Type:                d.Type,
+		Duration:            d.Duration,
+		PriceNanoton:        d.Price,
+		DetailsSHA256:       hex.EncodeToString(detailsHash[:]),
+		LessorPayoutAddress: lessorPayout,
+		LesseePayoutAddress: lesseePayout,
+	})
+	termsHash := sha256.Sum256(doc)
+	return &entity.DealTerms{
+		DealID:    d.ID,
+		Terms:     string(doc),
+		TermsHash: hex.EncodeToString(termsHash[:]),
+	}
 }
 
 func dealPayoutAddresses(d *entity.Deal) (lessorPayout, lesseePayout string) {
@@ -31,12 +55,12 @@ func dealPayoutAddresses(d *entity.Deal) (lessorPayout, lesseePayout string) {
 	return lessorPayout, lesseePayout
 }
 
+// DealSignaturesMatch reports whether both parties signed the current terms. Signatures on the deal hold the
+// hash of the terms each party signed; the wallet signatures themselves are kept as entity.DealSignature.
 func DealSignaturesMatch(d *entity.Deal) bool {
 	if d.LessorSignature == nil || d.LesseeSignature == nil {
 		return false
 	}
-	lessorPayout, lesseePayout := dealPayoutAddresses(d)
-	expectedLessor := ComputeDealSignature(d.Type, d.Duration, d.Price, d.Details, d.LessorID, lessorPayout, lesseePayout)
-	expectedLessee := ComputeDealSignature(d.Type, d.Duration, d.Price, d.Details, d.LesseeID, lessorPayout, lesseePayout)
-	return *d.LessorSignature == expectedLessor && *d.LesseeSignature == expectedLessee
+	expected := ComputeDealTerms(d).TermsHash
+	return *d.LessorSignature == expected && *d.LesseeSignature == expected
 }
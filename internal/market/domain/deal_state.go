@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+)
+
+// Workers that move deals, as recorded in the deal history.
+const (
+	DealWorkerEscrow             = "escrow_worker"
+	DealWorkerEscrowDeposit      = "escrow_deposit_worker"
+	DealWorkerEscrowTransfer     = "escrow_release_refund_worker"
+	DealWorkerDepositExpiry      = "deal_deposit_expiry"
+	DealWorkerBlockchainObserver = "blockchain_observer"
+	DealWorkerCompleted          = "deal_completed_worker"
+	DealWorkerPostMessage        = "deal_post_message_worker"
+	DealWorkerUserbotPost        = "userbot_post_worker"
+	DealWorkerChannelConnection  = "userbot_channel_connection"
+)
+
+// DealEffect is what has to happen once a deal made a transition.
+type DealEffect string
+
+const (
+	DealEffectNotifyOtherSide  DealEffect = "notify_other_side" // the side that did not make the transition
+	DealEffectNotifyAdmins     DealEffect = "notify_admins"
+	DealEffectDeleteForumTopic DealEffect = "delete_forum_topic"
+)
+
+// DealTransitionRule allows a deal to move From one status To another when made by one of Actors; worker
+// transitions are further limited to the named Workers. Transitions with a Webhook event are sent to the webhooks
+// of both deal sides.
+type DealTransitionRule struct {
+	From    entity.DealStatus // empty for the deal creation
+	To      entity.DealStatus
+	Actors  []entity.DealActorType
+	Workers []string
+	Effects []DealEffect
+	Webhook entity.WebhookEvent
+}
+
+var (
+	byUser   = []entity.DealActorType{entity.DealActorUser}
+	byAdmin  = []entity.DealActorType{entity.DealActorAdmin}
+	byWorker = []entity.DealActorType{entity.DealActorWorker}
+	// byWorkerOrAdmin edges are made by workers and, to settle a deal by hand, by staff.
+	byWorkerOrAdmin = []entity.DealActorType{entity.DealActorWorker, entity.DealActorAdmin}
+)
+
+// dealTransitions is the deal state machine: every status change a deal can make.
+var dealTransitions = []DealTransitionRule{
+	{From: "", To: entity.DealStatusDraft, Actors: byUser, Effects: []DealEffect{DealEffectNotifyOtherSide}},
+	{From: entity.DealStatusDraft, To: entity.DealStatusApproved, Actors: byUser, Webhook: entity.WebhookEventDealSigned},
+	{From: entity.DealStatusDraft, To: entity.DealStatusRejected, Actors: byUser, Effects: []DealEffect{DealEffectNotifyOtherSide}},
+	{From: entity.DealStatusApproved, To: entity.DealStatusWaitingEscrowDeposit, Actors: byWorker, Workers: []string{DealWorkerEscrow}},
+	{From: entity.DealStatusApproved, To: entity.DealStatusExpired, Actors: byWorker, Workers: []string{DealWorkerChannelConnection}},
+	{From: entity.DealStatusWaitingEscrowDeposit, To: entity.DealStatusEscrowDepositConfirmed, Actors: byWorker, Workers: []string{DealWorkerEscrowDeposit}, Webhook: entity.WebhookEventDealFunded},
+	{From: entity.DealStatusWaitingEscrowDeposit, To: entity.DealStatusExpired, Actors: byWorker, Workers: []string{DealWorkerDepositExpiry, DealWorkerBlockchainObserver, DealWorkerChannelConnection}},
+	{From: entity.DealStatusEscrowDepositConfirmed, To: entity.DealStatusInProgress, Actors: byWorker, Workers: []string{DealWorkerUserbotPost}, Webhook: entity.WebhookEventDealPosted},
+	{From: entity.DealStatusEscrowDepositConfirmed, To: entity.DealStatusWaitingEscrowRefund, Actors: byWorkerOrAdmin, Workers: []string{DealWorkerChannelConnection}},
+	{From: entity.DealStatusInProgress, To: entity.DealStatusWaitingEscrowRelease, Actors: byWorkerOrAdmin, Workers: []string{DealWorkerPostMessage}},
+	{From: entity.DealStatusInProgress, To: entity.DealStatusWaitingEscrowRefund, Actors: byWorkerOrAdmin, Workers: []string{DealWorkerPostMessage, DealWorkerChannelConnection}},
+	{From: entity.DealStatusWaitingEscrowRelease, To: entity.DealStatusEscrowReleaseConfirmed, Actors: byWorker, Workers: []string{DealWorkerEscrowTransfer}, Effects: []DealEffect{DealEffectDeleteForumTopic}, Webhook: entity.WebhookEventDealCompleted},
+	{From: entity.DealStatusWaitingEscrowRefund, To: entity.DealStatusEscrowRefundConfirmed, Actors: byWorker, Workers: []string{DealWorkerEscrowTransfer}, Effects: []DealEffect{DealEffectDeleteForumTopic}, Webhook: entity.WebhookEventDealRefunded},
+	{From: entity.DealStatusWaitingEscrowRelease, To: entity.DealStatusEscrowTransferFailed, Actors: byWorker, Workers: []string{DealWorkerEscrowTransfer}, Effects: []DealEffect{DealEffectNotifyAdmins}},
+	{From: entity.DealStatusWaitingEscrowRefund, To: entity.DealStatusEscrowTransferFailed, Actors: byWorker, Workers: []string{DealWorkerEscrowTransfer}, Effects: []DealEffect{DealEffectNotifyAdmins}},
+	{From: entity.DealStatusEscrowTransferFailed, To: entity.DealStatusWaitingEscrowRelease, Actors: byAdmin},
+	{From: entity.DealStatusEscrowTransferFailed, To: entity.DealStatusWaitingEscrowRefund, Actors: byAdmin},
+	{From: entity.DealStatusEscrowReleaseConfirmed, To: entity.DealStatusCompleted, Actors: byWorker, Workers: []string{DealWorkerCompleted}},
+	{From: entity.DealStatusEscrowRefundConfirmed, To: entity.DealStatusCompleted, Actors: byWorker, Workers: []string{DealWorkerCompleted}},
+}
+
+// DealTransitionRules returns every transition of the deal state machine.
+func DealTransitionRules() []DealTransitionRule {
+	return slices.Clone(dealTransitions)
+}
+
+// CheckDealTransition returns the rule that lets t move a deal from one status to another. It fails with
+// ErrInvalidDealTransition when the state machine has no such edge and with ErrDealTransitionNotAllowed when the
+// actor of t may not make it.
+func CheckDealTransition(from, to entity.DealStatus, t entity.DealTransition) (*DealTransitionRule, error) {
+	i := slices.IndexFunc(dealTransitions, func(rule DealTransitionRule) bool { return rule.From == from && rule.To == to })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %q to %q", marketerrors.ErrInvalidDealTransition, from, to)
+	}
+	rule := dealTransitions[i]
+	if !slices.Contains(rule.Actors, t.ActorType) {
+		return nil, fmt.Errorf("%w: %q to %q by %s", marketerrors.ErrDealTransitionNotAllowed, from, to, t.ActorType)
+	}
+	if t.ActorType == entity.DealActorWorker && !slices.Contains(rule.Workers, t.ActorName) {
+		return nil, fmt.Errorf("%w: %q to %q by worker %q", marketerrors.ErrDealTransitionNotAllowed, from, to, t.ActorName)
+	}
+	if t.ActorType != entity.DealActorWorker && t.ActorUserID == nil {
+		return nil, fmt.Errorf("%w: %q to %q by unknown %s", marketerrors.ErrDealTransitionNotAllowed, from, to, t.ActorType)
+	}
+	return &rule, nil
+}
+
+// CanDealTransition reports whether the state machine has an edge from one status to another.
+func CanDealTransition(from, to entity.DealStatus) bool {
+	return slices.ContainsFunc(dealTransitions, func(rule DealTransitionRule) bool { return rule.From == from && rule.To == to })
+}
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+)
+
+var allDealStatuses = []entity.DealStatus{
+	entity.DealStatusDraft,
+	entity.DealStatusApproved,
+	entity.DealStatusWaitingEscrowDeposit,
+	entity.DealStatusEscrowDepositConfirmed,
+	entity.DealStatusInProgress,
+	entity.DealStatusWaitingEscrowRelease,
+	entity.DealStatusEscrowReleaseConfirmed,
+	entity.DealStatusCompleted,
+	entity.DealStatusWaitingEscrowRefund,
+	entity.DealStatusEscrowRefundConfirmed,
+	entity.DealStatusEscrowTransferFailed,
+	entity.DealStatusExpired,
+	entity.DealStatusRejected,
+}
+
+var allDealWorkers = []string{
+	DealWorkerEscrow,
+	DealWorkerEscrowDeposit,
+	DealWorkerEscrowTransfer,
+	DealWorkerDepositExpiry,
+	DealWorkerBlockchainObserver,
+	DealWorkerCompleted,
+	DealWorkerPostMessage,
+	DealWorkerUserbotPost,
+	DealWorkerChannelConnection,
+}
+
+// dealEdge is an expected transition: by is "user", "admin" or the name of the worker allowed to make it.
+type dealEdge struct {
+	from, to entity.DealStatus
+	by       []string
+	effects  []DealEffect
+}
+
+var dealEdges = []dealEdge{
+	{"", entity.DealStatusDraft, []string{"user"}, []DealEffect{DealEffectNotifyOtherSide}},
+	{entity.DealStatusDraft, entity.DealStatusApproved, []string{"user"}, nil},
+	{entity.DealStatusDraft, entity.DealStatusRejected, []string{"user"}, []DealEffect{DealEffectNotifyOtherSide}},
+	{entity.DealStatusApproved, entity.DealStatusWaitingEscrowDeposit, []string{DealWorkerEscrow}, nil},
+	{entity.DealStatusApproved, entity.DealStatusExpired, []string{DealWorkerChannelConnection}, nil},
+	{entity.DealStatusWaitingEscrowDeposit, entity.DealStatusEscrowDepositConfirmed, []string{DealWorkerEscrowDeposit}, nil},
+	{entity.DealStatusWaitingEscrowDeposit, entity.DealStatusExpired, []string{DealWorkerDepositExpiry, DealWorkerBlockchainObserver, DealWorkerChannelConnection}, nil},
+	{entity.DealStatusEscrowDepositConfirmed, entity.DealStatusInProgress, []string{DealWorkerUserbotPost}, nil},
+	{entity.DealStatusEscrowDepositConfirmed, entity.DealStatusWaitingEscrowRefund, []string{DealWorkerChannelConnection, "admin"}, nil},
+	{entity.DealStatusInProgress, entity.DealStatusWaitingEscrowRelease, []string{DealWorkerPostMessage, "admin"}, nil},
+	{entity.DealStatusInProgress, entity.DealStatusWaitingEscrowRefund, []string{DealWorkerPostMessage, DealWorkerChannelConnection, "admin"}, nil},
+	{entity.DealStatusWaitingEscrowRelease, entity.DealStatusEscrowReleaseConfirmed, []string{DealWorkerEscrowTransfer}, []DealEffect{DealEffectDeleteForumTopic}},
+	{entity.DealStatusWaitingEscrowRefund, entity.DealStatusEscrowRefundConfirmed, []string{DealWorkerEscrowTransfer}, []DealEffect{DealEffectDeleteForumTopic}},
+	{entity.DealStatusWaitingEscrowRelease, entity.DealStatusEscrowTransferFailed, []string{DealWorkerEscrowTransfer}, []DealEffect{DealEffectNotifyAdmins}},
+	{entity.DealStatusWaitingEscrowRefund, entity.DealStatusEscrowTransferFailed, []string{DealWorkerEscrowTransfer}, []DealEffect{DealEffectNotifyAdmins}},
+	{entity.DealStatusEscrowTransferFailed, entity.DealStatusWaitingEscrowRelease, []string{"admin"}, nil},
+	{entity.DealStatusEscrowTransferFailed, entity.DealStatusWaitingEscrowRefund, []string{"admin"}, nil},
+	{entity.DealStatusEscrowReleaseConfirmed, entity.DealStatusCompleted, []string{DealWorkerCompleted}, nil},
+	{entity.DealStatusEscrowRefundConfirmed, entity.DealStatusCompleted, []string{DealWorkerCompleted}, nil},
+}
+
+// dealActors returns a transition by every possible actor, keyed like dealEdge.by.
+func dealActors() map[string]entity.DealTransition {
+	actors := map[string]entity.DealTransition{
+		"user":  entity.UserDealTransition(1, "test"),
+		"admin": entity.AdminDealTransition(2, "test"),
+	}
+	for _, name := range allDealWorkers {
+		actors[name] = entity.WorkerDealTransition(name, "test")
+	}
+	return actors
+}
+
+func TestCheckDealTransitionEdges(t *testing.T) {
+	for _, edge := range dealEdges {
+		t.Run(string(edge.from)+"->"+string(edge.to), func(t *testing.T) {
+			if !CanDealTransition(edge.from, edge.to) {
+				t.Fatal("CanDealTransition = false")
+			}
+			for by, transition := range dealActors() {
+				rule, err := CheckDealTransition(edge.from, edge.to, transition)
+				if !slices.Contains(edge.by, by) {
+					if !errors.Is(err, marketerrors.ErrDealTransitionNotAllowed) {
+						t.Errorf("by %s: err = %v, want ErrDealTransitionNotAllowed", by, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("by %s: %v", by, err)
+					continue
+				}
+				if !slices.Equal(rule.Effects, edge.effects) {
+					t.Errorf("by %s: effects = %v, want %v", by, rule.Effects, edge.effects)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckDealTransitionRejectsOtherEdges(t *testing.T) {
+	for _, from := range append([]entity.DealStatus{""}, allDealStatuses...) {
+		for _, to := range allDealStatuses {
+			if slices.ContainsFunc(dealEdges, func(edge dealEdge) bool { return edge.from == from && edge.to == to }) {
+				continue
+			}
+			if CanDealTransition(from, to) {
+				t.Errorf("%q -> %q: CanDealTransition = true", from, to)
+			}
+			for by, transition := range dealActors() {
+				if _, err := CheckDealTransition(from, to, transition); !errors.Is(err, marketerrors.ErrInvalidDealTransition) {
+					t.Errorf("%q -> %q by %s: err = %v, want ErrInvalidDealTransition", from, to, by, err)
+				}
+			}
+		}
+	}
+}
+
+func TestDealTransitionRulesAreCovered(t *testing.T) {
+	rules := DealTransitionRules()
+	if len(rules) != len(dealEdges) {
+		t.Fatalf("%d rules, %d tested edges", len(rules), len(dealEdges))
+	}
+	for _, rule := range rules {
+		for _, name := range rule.Workers {
+			if !slices.Contains(allDealWorkers, name) {
+				t.Errorf("%q -> %q: unknown worker %q", rule.From, rule.To, name)
+			}
+		}
+	}
+}
+
+func TestCheckDealTransitionRequiresUserID(t *testing.T) {
+	_, err := CheckDealTransition(entity.DealStatusDraft, entity.DealStatusRejected, entity.DealTransition{ActorType: entity.DealActorUser})
+	if !errors.Is(err, marketerrors.ErrDealTransitionNotAllowed) {
+		t.Fatalf("err = %v, want ErrDealTransitionNotAllowed", err)
+	}
+}
+
+func TestDealTransitionWebhooks(t *testing.T) {
+	want := map[[2]entity.DealStatus]entity.WebhookEvent{
+		{entity.DealStatusDraft, entity.DealStatusApproved}:                              entity.WebhookEventDealSigned,
+		{entity.DealStatusWaitingEscrowDeposit, entity.DealStatusEscrowDepositConfirmed}: entity.WebhookEventDealFunded,
+		{entity.DealStatusEscrowDepositConfirmed, entity.DealStatusInProgress}:           entity.WebhookEventDealPosted,
+		{entity.DealStatusWaitingEscrowRelease, entity.DealStatusEscrowReleaseConfirmed}: entity.WebhookEventDealCompleted,
+		{entity.DealStatusWaitingEscrowRefund, entity.DealStatusEscrowRefundConfirmed}:   entity.WebhookEventDealRefunded,
+	}
+	for _, rule := range DealTransitionRules() {
+		if got := rule.Webhook; got != want[[2]entity.DealStatus{rule.From, rule.To}] {
+			t.Errorf("%q -> %q: webhook = %q, want %q", rule.From, rule.To, got, want[[2]entity.DealStatus{rule.From, rule.To}])
+		}
+	}
+}
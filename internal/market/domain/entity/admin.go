@@ -0,0 +1,110 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"ads-mrkt/pkg/auth/role"
+)
+
+// AdminActor is the staff member making an admin console change, with the role checked for the request.
+type AdminActor struct {
+	ID   int64
+	Role role.Role
+}
+
+type AdminAction string
+
+const (
+	AdminActionSetUserRole      AdminAction = "user.set_role"
+	AdminActionBanUser          AdminAction = "user.ban"
+	AdminActionUnbanUser        AdminAction = "user.unban"
+	AdminActionBlockListing     AdminAction = "listing.block"
+	AdminActionUnblockListing   AdminAction = "listing.unblock"
+	AdminActionDismissReports   AdminAction = "listing.dismiss_reports"
+	AdminActionRetryEscrow      AdminAction = "deal.escrow_retry"
+	AdminActionReleaseEscrow    AdminAction = "deal.escrow_release"
+	AdminActionRefundEscrow     AdminAction = "deal.escrow_refund"
+	AdminActionReconcileEscrows AdminAction = "escrow.reconcile"
+)
+
+type AdminTargetType string
+
+const (
+	AdminTargetUser    AdminTargetType = "user"
+	AdminTargetListing AdminTargetType = "listing"
+	AdminTargetDeal    AdminTargetType = "deal"
+	AdminTargetEscrow  AdminTargetType = "escrow" // all escrows; TargetID is nil
+)
+
+// AdminAuditEntry records a change made through the admin console.
+type AdminAuditEntry struct {
+	ID         int64           `json:"id"`
+	AdminID    int64           `json:"admin_id"`
+	AdminRole  role.Role       `json:"admin_role"`
+	Action     AdminAction     `json:"action"`
+	TargetType AdminTargetType `json:"target_type"`
+	TargetID   *int64          `json:"target_id,omitempty"`
+	Reason     string          `json:"reason,omitempty"`
+	Details    json.RawMessage `json:"details,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AdminAuditFilter narrows the audit log. Zero values mean no filter.
+type AdminAuditFilter struct {
+	AdminID    *int64
+	Action     AdminAction
+	TargetType AdminTargetType
+	TargetID   *int64
+	Limit      int
+	Offset     int
+}
+
+// UserSearch finds users by ID, username or name containing Query.
+type UserSearch struct {
+	Query  string
+	Role   role.Role
+	Banned *bool
+	Limit  int
+	Offset int
+}
+
+// DealSearch finds deals; UserID matches either side.
+type DealSearch struct {
+	Status    DealStatus
+	UserID    *int64
+	ListingID *int64
+	ChannelID *int64
+	Limit     int
+	Offset    int
+}
+
+// ListingSearch finds listings of any status by ID or description containing Query.
+type ListingSearch struct {
+	Query     string
+	Status    ListingStatus
+	Type      ListingType
+	UserID    *int64
+	ChannelID *int64
+	Limit     int
+	Offset    int
+}
+
+// EscrowState is what the escrow of a deal holds and what was tried to pay it out.
+type EscrowState struct {
+	Address        *string         `json:"address,omitempty"`
+	Held           bool            `json:"held"`                      // the wallet is expected to hold the deposit
+	BalanceNanoton *int64          `json:"balance_nanoton,omitempty"` // nil when there is no escrow or it could not be read
+	BalanceError   string          `json:"balance_error,omitempty"`
+	PayoutNanoton  int64           `json:"payout_nanoton"`
+	Mismatch       *EscrowMismatch `json:"mismatch,omitempty"`
+	LastRelease    *DealActionLock `json:"last_release,omitempty"`
+	LastRefund     *DealActionLock `json:"last_refund,omitempty"`
+}
+
+// AdminDeal is a deal with its history and escrow, as staff see it.
+type AdminDeal struct {
+	Deal   *Deal
+	Events []*DealEvent
+	Escrow *EscrowState
+}
@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	"ads-mrkt/pkg/auth/scope"
+)
+
+// APIKey lets scripts of a user call the API with the granted scopes. Only the hash of the key is stored; Key is set
+// once, when the key is created.
+type APIKey struct {
+	ID                 int64         `json:"id"`
+	UserID             int64         `json:"user_id"`
+	Name               string        `json:"name"`
+	Prefix             string        `json:"prefix"` // first characters of the key, to tell keys apart
+	Key                string        `json:"-"`
+	KeyHash            string        `json:"-"`
+	Scopes             []scope.Scope `json:"scopes"`
+	RateLimitPerMinute int           `json:"rate_limit_per_minute"`
+	LastUsedAt         *time.Time    `json:"last_used_at,omitempty"`
+	CreatedAt          time.Time     `json:"created_at"`
+}
@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// AuthSession is a login of a user. Access tokens carry its ID; its refresh token, stored as a hash, is replaced
+// on every refresh.
+type AuthSession struct {
+	ID                       int64
+	UserID                   int64
+	RefreshTokenHash         string
+	PreviousRefreshTokenHash *string
+	ExpiresAt                time.Time
+	RevokedAt                *time.Time
+	CreatedAt                time.Time
+	RefreshedAt              *time.Time
+}
+
+// AuthTokens is a short-lived access token with the refresh token to get the next one.
+type AuthTokens struct {
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
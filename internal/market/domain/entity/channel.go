@@ -17,3 +17,15 @@ type Channel struct {
 	Username    string      `json:"username"`
 	Photo       string      `json:"photo"`
 }
+
+type ChannelAdminRole string
+
+const (
+	ChannelAdminRoleOwner ChannelAdminRole = "owner"
+	ChannelAdminRoleAdmin ChannelAdminRole = "admin"
+)
+
+type ChannelAdmin struct {
+	UserID int64            `json:"user_id"`
+	Role   ChannelAdminRole `json:"role"`
+}
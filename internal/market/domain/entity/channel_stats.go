@@ -0,0 +1,132 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ChannelStatsSnapshot is one fetch of the channel's Telegram broadcast stats. Key metrics are extracted
+// from Stats so the history can be queried without decoding the JSON.
+type ChannelStatsSnapshot struct {
+	ID                          int64           `json:"id"`
+	ChannelID                   int64           `json:"channel_id"`
+	FetchedAt                   time.Time       `json:"fetched_at"`
+	Followers                   float64         `json:"followers"`
+	ViewsPerPost                float64         `json:"views_per_post"`
+	SharesPerPost               float64         `json:"shares_per_post"`
+	ReactionsPerPost            float64         `json:"reactions_per_post"`
+	EnabledNotificationsPercent float64         `json:"enabled_notifications_percent"`
+	Estimated                   bool            `json:"estimated"`
+	Stats                       json.RawMessage `json:"-"` // StatsBroadcastStats as fetched or estimated
+}
+
+// Channel quality flags raised by domain.ComputeChannelQuality.
+const (
+	ChannelQualityFlagFollowerSpikeWithoutViews = "follower_spike_without_views"
+	ChannelQualityFlagLowERR                    = "low_err"
+	ChannelQualityFlagLowNotifications          = "low_notifications"
+)
+
+// ChannelQuality is derived from the channel's broadcast stats to tell real audiences from inflated ones.
+type ChannelQuality struct {
+	Score                       float64  `json:"score"`       // 0..100
+	ERRPercent                  float64  `json:"err_percent"` // views per post / followers
+	AvgReach                    float64  `json:"avg_reach"`   // views per post
+	SharesRatioPercent          float64  `json:"shares_ratio_percent"`
+	ReactionsRatioPercent       float64  `json:"reactions_ratio_percent"`
+	NotificationsEnabledPercent float64  `json:"notifications_enabled_percent"`
+	MaxDailyGrowthPercent       float64  `json:"max_daily_growth_percent"`
+	Suspicious                  bool     `json:"suspicious"`
+	Flags                       []string `json:"flags,omitempty"`
+}
+
+// ChannelStatsVersion is the version of the ChannelStats shape served by the API. Bump it on breaking changes.
+const ChannelStatsVersion = 1
+
+// ChannelStats is the stable model of a channel's Telegram broadcast stats, built by the userbot so the API
+// does not depend on gotd types or Telegram's chart format.
+type ChannelStats struct {
+	Version                     int                  `json:"version"`
+	FetchedAt                   time.Time            `json:"fetched_at"`
+	Estimated                   bool                 `json:"estimated"`               // approximated from recent posts, Telegram gives no stats for the channel
+	PostsPerDay                 float64              `json:"posts_per_day,omitempty"` // set for estimated stats
+	Period                      ChannelStatsPeriod   `json:"period"`
+	Followers                   ChannelStatsValue    `json:"followers"`
+	ViewsPerPost                ChannelStatsValue    `json:"views_per_post"`
+	SharesPerPost               ChannelStatsValue    `json:"shares_per_post"`
+	ReactionsPerPost            ChannelStatsValue    `json:"reactions_per_post"`
+	ViewsPerStory               ChannelStatsValue    `json:"views_per_story"`
+	SharesPerStory              ChannelStatsValue    `json:"shares_per_story"`
+	ReactionsPerStory           ChannelStatsValue    `json:"reactions_per_story"`
+	EnabledNotificationsPercent float64              `json:"enabled_notifications_percent"`
+	TopPosts                    []ChannelStatsPost   `json:"top_posts"`           // recent posts and stories by views
+	Languages                   []ChannelStatsShare  `json:"languages"`           // views by language over the period
+	ViewsBySource               []ChannelStatsShare  `json:"views_by_source"`     // views by source over the period
+	FollowersBySource           []ChannelStatsShare  `json:"followers_by_source"` // new followers by source over the period
+	Graphs                      ChannelStatsGraphs   `json:"graphs"`
+	TopHours                    []ChannelStatsHourly `json:"top_hours"`
+}
+
+type ChannelStatsPeriod struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// ChannelStatsValue is a metric over the period and over the period before it.
+type ChannelStatsValue struct {
+	Current  float64 `json:"current"`
+	Previous float64 `json:"previous"`
+}
+
+// ChannelStatsPost is the interaction counters of a recent post (MessageID) or story (StoryID).
+type ChannelStatsPost struct {
+	MessageID int `json:"message_id,omitempty"`
+	StoryID   int `json:"story_id,omitempty"`
+	Views     int `json:"views"`
+	Forwards  int `json:"forwards"`
+	Reactions int `json:"reactions"`
+}
+
+// ChannelStatsShare is one item of a breakdown (a language, a source) with its share of the total.
+type ChannelStatsShare struct {
+	Name    string  `json:"name"`
+	Value   float64 `json:"value"`
+	Percent float64 `json:"percent"`
+}
+
+// ChannelStatsGraphs holds the daily graphs Telegram provides; a graph is nil when Telegram did not return it.
+type ChannelStatsGraphs struct {
+	Growth                  *ChannelStatsGraph `json:"growth,omitempty"`
+	Followers               *ChannelStatsGraph `json:"followers,omitempty"`
+	Mute                    *ChannelStatsGraph `json:"mute,omitempty"`
+	Interactions            *ChannelStatsGraph `json:"interactions,omitempty"`
+	ViewsBySource           *ChannelStatsGraph `json:"views_by_source,omitempty"`
+	FollowersBySource       *ChannelStatsGraph `json:"followers_by_source,omitempty"`
+	Languages               *ChannelStatsGraph `json:"languages,omitempty"`
+	ReactionsByEmotion      *ChannelStatsGraph `json:"reactions_by_emotion,omitempty"`
+	StoryInteractions       *ChannelStatsGraph `json:"story_interactions,omitempty"`
+	StoryReactionsByEmotion *ChannelStatsGraph `json:"story_reactions_by_emotion,omitempty"`
+}
+
+type ChannelStatsGraph struct {
+	Title  string               `json:"title,omitempty"`
+	Series []ChannelStatsSeries `json:"series"`
+}
+
+type ChannelStatsSeries struct {
+	Key    string              `json:"key"`
+	Name   string              `json:"name"`
+	Type   string              `json:"type"` // line, step, bar or area
+	Points []ChannelStatsPoint `json:"points"`
+}
+
+type ChannelStatsPoint struct {
+	T time.Time `json:"t"`
+	V float64   `json:"v"`
+}
+
+// ChannelStatsHourly is views by UTC hour of day; Values has 24 items.
+type ChannelStatsHourly struct {
+	Name   string    `json:"name"`
+	Values []float64 `json:"values"`
+}
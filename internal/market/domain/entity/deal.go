@@ -18,13 +18,14 @@ const (
 	DealStatusCompleted              DealStatus = "completed"
 	DealStatusWaitingEscrowRefund    DealStatus = "waiting_escrow_refund"
 	DealStatusEscrowRefundConfirmed  DealStatus = "escrow_refund_confirmed"
+	DealStatusEscrowTransferFailed   DealStatus = "escrow_transfer_failed" // release/refund gave up after max attempts; admin action required
 	DealStatusExpired                DealStatus = "expired"
 	DealStatusRejected               DealStatus = "rejected"
 )
 
 // Deal represents a deal between lessor and lessee. In draft, both can edit type, duration, price, details;
-// any edit clears both signatures. When both signatures are valid for current [type, duration, price, details],
-// status becomes approved.
+// any edit clears both signatures. Each party signs the canonical terms document with their TON wallet; the deal
+// keeps the hash of the terms each party signed. When both match the current terms, status becomes approved.
 type Deal struct {
 	ID                  int64           `json:"id"`
 	ListingID           int64           `json:"listing_id"`
@@ -43,6 +44,7 @@ type Deal struct {
 	EscrowReleaseTime   *time.Time      `json:"escrow_release_time,omitempty"`
 	LessorPayoutAddress *string         `json:"lessor_payout_address,omitempty"`
 	LesseePayoutAddress *string         `json:"lessee_payout_address,omitempty"`
+	Version             int64           `json:"version"` // bumped by every change; status transitions apply only to the version they were checked against
 	CreatedAt           time.Time       `json:"created_at,omitempty"`
 	UpdatedAt           time.Time       `json:"updated_at,omitempty"`
 }
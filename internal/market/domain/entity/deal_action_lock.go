@@ -22,11 +22,16 @@ const (
 
 // DealActionLock represents a short-lived lock for a deal action (escrow release/refund or post message).
 // Used for concurrency safety and recovery: expire_at allows retry after service restart.
+// Attempt is 1 for the first lock and grows by one for every lock taken after a failed one;
+// RetryAt is set on failed locks and holds back the next attempt (exponential backoff).
 type DealActionLock struct {
 	ID         string               `json:"id"`
 	DealID     int64                `json:"deal_id"`
 	ActionType DealActionType       `json:"action_type"`
 	Status     DealActionLockStatus `json:"status"`
+	Attempt    int                  `json:"attempt"`
+	RetryAt    *time.Time           `json:"retry_at,omitempty"`
+	LastError  *string              `json:"last_error,omitempty"`
 	ExpireAt   time.Time            `json:"expire_at"`
 	CreatedAt  time.Time            `json:"created_at"`
 	UpdatedAt  time.Time            `json:"updated_at"`
@@ -0,0 +1,44 @@
+package entity
+
+import "time"
+
+type DealActorType string
+
+const (
+	DealActorUser   DealActorType = "user"   // a side of the deal
+	DealActorAdmin  DealActorType = "admin"  // an admin acting on the deal
+	DealActorWorker DealActorType = "worker" // a background worker, by name
+)
+
+// DealTransition says who moves a deal to another status and why. Repositories record it as a DealEvent in the
+// statement that changes the status.
+type DealTransition struct {
+	ActorType   DealActorType
+	ActorUserID *int64 // user or admin
+	ActorName   string // worker
+	Reason      string
+	TxHash      *string // blockchain transaction that caused the transition
+	MessageID   *int64  // channel post that caused the transition
+}
+
+func UserDealTransition(userID int64, reason string) DealTransition {
+	return DealTransition{ActorType: DealActorUser, ActorUserID: &userID, Reason: reason}
+}
+
+func AdminDealTransition(userID int64, reason string) DealTransition {
+	return DealTransition{ActorType: DealActorAdmin, ActorUserID: &userID, Reason: reason}
+}
+
+func WorkerDealTransition(name string, reason string) DealTransition {
+	return DealTransition{ActorType: DealActorWorker, ActorName: name, Reason: reason}
+}
+
+// DealEvent is an entry of the append-only deal status history. FromStatus is nil for the deal creation.
+type DealEvent struct {
+	ID         int64
+	DealID     int64
+	FromStatus *DealStatus
+	ToStatus   DealStatus
+	DealTransition
+	CreatedAt time.Time
+}
@@ -0,0 +1,41 @@
+package entity
+
+import "time"
+
+// SignedText is a TON Connect signData result for a "text" payload.
+// See https://docs.ton.org/develop/dapps/ton-connect/sign-data.
+type SignedText struct {
+	Address   string // raw or user-friendly wallet address that signed
+	Domain    string // dApp domain the wallet put into the signed message
+	Timestamp int64  // unix seconds
+	Text      string // signed text; must be the deal terms document
+	Signature []byte
+}
+
+// DealTerms is the canonical terms document both parties sign with their wallets.
+type DealTerms struct {
+	DealID    int64  `json:"deal_id"`
+	Terms     string `json:"terms"`
+	TermsHash string `json:"terms_hash"` // hex sha256 of Terms; stored as the deal's lessor/lessee signature
+}
+
+// DealSignature is a party's wallet signature over the deal terms, kept so the signed terms can be verified later.
+type DealSignature struct {
+	DealID        int64     `json:"deal_id"`
+	UserID        int64     `json:"user_id"`
+	Terms         string    `json:"terms"`
+	TermsHash     string    `json:"terms_hash"`
+	WalletAddress string    `json:"wallet_address"` // raw
+	PublicKey     string    `json:"public_key"`     // hex
+	Domain        string    `json:"domain"`
+	SignedAt      time.Time `json:"signed_at"` // signData timestamp
+	Signature     string    `json:"signature"` // base64
+	CreatedAt     time.Time `json:"created_at,omitempty"`
+}
+
+// SignedDealTerms is the export of a deal's current terms with the wallet signatures recorded for it.
+type SignedDealTerms struct {
+	Deal       *Deal
+	Terms      *DealTerms
+	Signatures []*DealSignature
+}
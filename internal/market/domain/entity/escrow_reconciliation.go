@@ -0,0 +1,58 @@
+package entity
+
+import "time"
+
+type EscrowMismatchKind string
+
+const (
+	// EscrowMismatchUnfunded: deal is past deposit confirmation but the escrow wallet cannot cover the payout.
+	EscrowMismatchUnfunded EscrowMismatchKind = "unfunded"
+	// EscrowMismatchDepositNotConfirmed: the wallet holds the escrow amount but the deal still waits for the deposit.
+	EscrowMismatchDepositNotConfirmed EscrowMismatchKind = "deposit_not_confirmed"
+	// EscrowMismatchFundsLeftAfterPayout: release/refund is confirmed but the payout amount is still on the wallet.
+	EscrowMismatchFundsLeftAfterPayout EscrowMismatchKind = "funds_left_after_payout"
+	// EscrowMismatchFundsOnClosedDeal: the deal was expired or rejected but its escrow wallet holds funds.
+	EscrowMismatchFundsOnClosedDeal EscrowMismatchKind = "funds_on_closed_deal"
+	// EscrowMismatchRepeatedTransferFailures: release/refund transfer failed several times without success.
+	EscrowMismatchRepeatedTransferFailures EscrowMismatchKind = "repeated_transfer_failures"
+	// EscrowMismatchBalanceUnavailable: the on-chain balance could not be read.
+	EscrowMismatchBalanceUnavailable EscrowMismatchKind = "balance_unavailable"
+)
+
+var EscrowMismatchKinds = []EscrowMismatchKind{
+	EscrowMismatchUnfunded,
+	EscrowMismatchDepositNotConfirmed,
+	EscrowMismatchFundsLeftAfterPayout,
+	EscrowMismatchFundsOnClosedDeal,
+	EscrowMismatchRepeatedTransferFailures,
+	EscrowMismatchBalanceUnavailable,
+}
+
+// EscrowMismatch is a single discrepancy between a deal and its escrow wallet.
+type EscrowMismatch struct {
+	Kind            EscrowMismatchKind `json:"kind"`
+	DealID          int64              `json:"deal_id"`
+	DealStatus      DealStatus         `json:"deal_status"`
+	EscrowAddress   string             `json:"escrow_address"`
+	BalanceNanoton  int64              `json:"balance_nanoton"`
+	ExpectedNanoton int64              `json:"expected_nanoton"`
+	FailedTransfers int64              `json:"failed_transfers,omitempty"`
+	Detail          string             `json:"detail,omitempty"`
+}
+
+// EscrowReconciliationReport is the result of one reconciliation pass over escrow wallets.
+type EscrowReconciliationReport struct {
+	StartedAt          time.Time         `json:"started_at"`
+	FinishedAt         time.Time         `json:"finished_at"`
+	DealsChecked       int               `json:"deals_checked"`
+	HeldBalanceNanoton int64             `json:"held_balance_nanoton"` // sum of balances of escrows for active deals
+	Mismatches         []*EscrowMismatch `json:"mismatches"`
+}
+
+// DealActionFailures is the number of failed locks for a deal action that never completed.
+type DealActionFailures struct {
+	DealID        int64
+	ActionType    DealActionType
+	Failures      int64
+	LastFailureAt time.Time
+}
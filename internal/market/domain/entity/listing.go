@@ -17,6 +17,7 @@ type ListingStatus string
 const (
 	ListingStatusActive   ListingStatus = "active"
 	ListingStatusInactive ListingStatus = "inactive"
+	ListingStatusBlocked  ListingStatus = "blocked" // taken down by moderation; only staff can lift it
 )
 
 type Listing struct {
@@ -28,6 +29,7 @@ type Listing struct {
 	ChannelUsername  *string         `json:"channel_username,omitempty"`
 	ChannelPhoto     *string         `json:"channel_photo,omitempty"`
 	ChannelFollowers *int64          `json:"channel_followers,omitempty"`
+	ChannelQuality   *ChannelQuality `json:"channel_quality,omitempty"`
 	Type             ListingType     `json:"type"`
 	Prices           json.RawMessage `json:"prices"`
 	Categories       json.RawMessage `json:"categories,omitempty"` // JSON array of strings from predefined set
@@ -35,3 +37,24 @@ type Listing struct {
 	CreatedAt        time.Time       `json:"created_at,omitempty"`
 	UpdatedAt        time.Time       `json:"updated_at,omitempty"`
 }
+
+type ListingSort string
+
+const (
+	ListingSortUpdated   ListingSort = "updated"
+	ListingSortFollowers ListingSort = "followers"
+	ListingSortQuality   ListingSort = "quality"
+	ListingSortERR       ListingSort = "err"
+	ListingSortReach     ListingSort = "reach"
+)
+
+// ListingFilter narrows public listing discovery. Zero values mean no filter; Sort defaults to ListingSortUpdated.
+type ListingFilter struct {
+	Type            *ListingType
+	Categories      []string
+	MinFollowers    *int64
+	MinQualityScore *float64
+	MinERRPercent   *float64
+	HideSuspicious  bool
+	Sort            ListingSort
+}
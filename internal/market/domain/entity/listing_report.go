@@ -0,0 +1,44 @@
+package entity
+
+import "time"
+
+type ListingReportReason string
+
+const (
+	ListingReportReasonSpam       ListingReportReason = "spam"
+	ListingReportReasonScam       ListingReportReason = "scam"
+	ListingReportReasonGambling   ListingReportReason = "gambling"
+	ListingReportReasonAdult      ListingReportReason = "adult"
+	ListingReportReasonProhibited ListingReportReason = "prohibited" // other illegal goods or services
+	ListingReportReasonOther      ListingReportReason = "other"
+)
+
+type ListingReportStatus string
+
+const (
+	ListingReportStatusOpen      ListingReportStatus = "open"
+	ListingReportStatusDismissed ListingReportStatus = "dismissed" // staff found nothing wrong
+	ListingReportStatusActioned  ListingReportStatus = "actioned"  // the listing was blocked
+)
+
+// ListingReport is a complaint of a user about a listing. A user has at most one open report per listing.
+type ListingReport struct {
+	ID         int64               `json:"id"`
+	ListingID  int64               `json:"listing_id"`
+	ReporterID int64               `json:"reporter_id"`
+	Reason     ListingReportReason `json:"reason"`
+	Comment    string              `json:"comment,omitempty"`
+	Status     ListingReportStatus `json:"status"`
+	ResolvedBy *int64              `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time          `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+}
+
+// ModerationQueueItem is a listing with open reports, as shown in the moderation queue.
+type ModerationQueueItem struct {
+	Listing         *Listing                    `json:"listing"`
+	OpenReports     int                         `json:"open_reports"`
+	Reasons         map[ListingReportReason]int `json:"reasons"`
+	FirstReportedAt time.Time                   `json:"first_reported_at"`
+	LastReportedAt  time.Time                   `json:"last_reported_at"`
+}
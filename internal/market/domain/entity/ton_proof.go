@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+// TonProof is a TON Connect ton_proof sent by the wallet when it is connected.
+// See https://docs.ton.org/develop/dapps/ton-connect/sign.
+type TonProof struct {
+	Address           string // raw or user-friendly wallet address
+	PublicKey         string // hex, optional; must match the key that signed the proof when set
+	Timestamp         int64  // unix seconds
+	DomainLengthBytes uint32
+	Domain            string
+	Signature         []byte
+	Payload           string // challenge issued by the backend
+	StateInit         []byte // BOC, present when the wallet is not deployed yet
+}
+
+// WalletProof is the verified wallet with the metadata of its ton_proof.
+type WalletProof struct {
+	AddressRaw     string
+	PublicKey      string // hex
+	Domain         string
+	ProofTimestamp time.Time
+	VerifiedAt     time.Time
+}
+
+// TonProofChallenge is the payload the wallet must sign in its ton_proof.
+type TonProofChallenge struct {
+	Payload   string    `json:"payload"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -1,6 +1,10 @@
 package entity
 
-import "ads-mrkt/pkg/auth/role"
+import (
+	"time"
+
+	"ads-mrkt/pkg/auth/role"
+)
 
 type User struct {
 	ID            int64     `json:"id"`
@@ -11,6 +15,13 @@ type User struct {
 	Locale        string    `json:"locale"`
 	ReferrerID    int64     `json:"-"`
 	AllowsPM      bool      `json:"-"`
-	WalletAddress *string   `json:"wallet_address,omitempty"` // TON address in raw format
-	Role          role.Role `json:"role"`                     // user | admin
+	WalletAddress *string   `json:"wallet_address,omitempty"` // TON address in raw format, linked with a verified ton_proof
+	Role          role.Role `json:"role"`                     // user | admin | support | moderator | finance
+
+	WalletPublicKey  *string    `json:"wallet_public_key,omitempty"` // hex ed25519 key of the linked wallet
+	WalletVerifiedAt *time.Time `json:"wallet_verified_at,omitempty"`
+
+	BannedAt  *time.Time `json:"banned_at,omitempty"`
+	BannedBy  *int64     `json:"banned_by,omitempty"` // staff member who banned the user
+	BanReason *string    `json:"ban_reason,omitempty"`
 }
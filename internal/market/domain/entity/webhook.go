@@ -0,0 +1,103 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type WebhookEvent string
+
+const (
+	WebhookEventPing          WebhookEvent = "ping" // sent on request to test the endpoint
+	WebhookEventDealSigned    WebhookEvent = "deal.signed"
+	WebhookEventDealFunded    WebhookEvent = "deal.funded"
+	WebhookEventDealPosted    WebhookEvent = "deal.posted"
+	WebhookEventDealCompleted WebhookEvent = "deal.completed"
+	WebhookEventDealRefunded  WebhookEvent = "deal.refunded"
+)
+
+// Webhook is an HTTPS endpoint of a user that receives the events of the user's deals, signed with Secret.
+type Webhook struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"` // shown once, when the webhook is created
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed" // gave up after the last attempt
+)
+
+// WebhookDelivery is an event sent, or still to be sent, to a webhook. DealID is nil for pings.
+type WebhookDelivery struct {
+	ID             int64                 `json:"id"`
+	WebhookID      int64                 `json:"webhook_id"`
+	DealID         *int64                `json:"deal_id,omitempty"`
+	Event          WebhookEvent          `json:"event"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	LastStatusCode *int                  `json:"last_status_code,omitempty"`
+	LastError      *string               `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
+
+// WebhookDeliveryAttempt is a claimed delivery with the endpoint to send it to.
+type WebhookDeliveryAttempt struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
+
+// WebhookDealPayload is the body of a deal event. Amounts are in nanoton.
+type WebhookDealPayload struct {
+	Event         WebhookEvent `json:"event"`
+	DealID        int64        `json:"deal_id"`
+	ListingID     int64        `json:"listing_id"`
+	ChannelID     *int64       `json:"channel_id,omitempty"`
+	LessorID      int64        `json:"lessor_id"`
+	LesseeID      int64        `json:"lessee_id"`
+	Type          string       `json:"type"`
+	Duration      int64        `json:"duration"`
+	PriceNanoton  int64        `json:"price_nanoton"`
+	EscrowNanoton int64        `json:"escrow_amount_nanoton"`
+	EscrowAddress *string      `json:"escrow_address,omitempty"`
+	FromStatus    DealStatus   `json:"from_status"`
+	Status        DealStatus   `json:"status"`
+	OccurredAt    time.Time    `json:"occurred_at"`
+}
+
+// NewWebhookDealPayload describes d moving from one status to another.
+func NewWebhookDealPayload(event WebhookEvent, d *Deal, from, to DealStatus, at time.Time) *WebhookDealPayload {
+	return &WebhookDealPayload{
+		Event:         event,
+		DealID:        d.ID,
+		ListingID:     d.ListingID,
+		ChannelID:     d.ChannelID,
+		LessorID:      d.LessorID,
+		LesseeID:      d.LesseeID,
+		Type:          d.Type,
+		Duration:      d.Duration,
+		PriceNanoton:  d.Price,
+		EscrowNanoton: d.EscrowAmount,
+		EscrowAddress: d.EscrowAddress,
+		FromStatus:    from,
+		Status:        to,
+		OccurredAt:    at,
+	}
+}
+
+// WebhookPingPayload is the body of a test ping.
+type WebhookPingPayload struct {
+	Event     WebhookEvent `json:"event"`
+	WebhookID int64        `json:"webhook_id"`
+	SentAt    time.Time    `json:"sent_at"`
+}
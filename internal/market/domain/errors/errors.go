@@ -6,14 +6,40 @@ import (
 )
 
 var (
-	ErrNotFound                   = errors.New("market: not found")
-	ErrNotChannelAdmin            = errors.New("market: user is not admin of the channel")
-	ErrChannelStatsDenied         = errors.New("market: channel stats only for admins or users who listed this channel")
-	ErrDealNotDraft               = errors.New("market: deal is not in draft status")
-	ErrUnauthorizedSide           = errors.New("market: user is not lessor or lessee of this deal")
-	ErrWalletNotSet               = errors.New("market: connect wallet before signing")
-	ErrPayoutNotSet               = errors.New("market: both parties must set payout address before signing")
-	ErrDealDetailsMessageRequired = errors.New("market: deal details message must be set before signing")
+	ErrNotFound                    = errors.New("market: not found")
+	ErrNotChannelAdmin             = errors.New("market: user is not admin of the channel")
+	ErrChannelStatsDenied          = errors.New("market: channel stats only for admins or users who listed this channel")
+	ErrDealNotDraft                = errors.New("market: deal is not in draft status")
+	ErrUnauthorizedSide            = errors.New("market: user is not lessor or lessee of this deal")
+	ErrWalletNotSet                = errors.New("market: connect wallet before signing")
+	ErrWalletNotVerified           = errors.New("market: reconnect wallet with ton_proof before signing")
+	ErrPayoutNotSet                = errors.New("market: both parties must set payout address before signing")
+	ErrDealDetailsMessageRequired  = errors.New("market: deal details message must be set before signing")
+	ErrDealNotEscrowTransferFailed = errors.New("market: deal escrow transfer has not failed")
+	ErrInvalidWalletAddress        = errors.New("market: invalid wallet address")
+	ErrInvalidWalletProof          = errors.New("market: invalid wallet ton_proof")
+	ErrInvalidDealSignature        = errors.New("market: invalid deal signature")
+	ErrChannelNotConnected         = errors.New("market: marketplace account has no posting rights in the channel")
+	ErrInvalidDealTransition       = errors.New("market: deal cannot move to this status")
+	ErrDealTransitionNotAllowed    = errors.New("market: deal status change not allowed for this actor")
+	ErrDealChanged                 = errors.New("market: deal was changed concurrently")
+	ErrInvalidWebhookURL           = errors.New("market: webhook url must be a public https url")
+	ErrInvalidWebhookSecret        = errors.New("market: webhook secret must be 16 to 256 characters")
+	ErrWebhookLimitReached         = errors.New("market: webhook limit reached")
+	ErrInvalidAPIKeyName           = errors.New("market: api key name must be 1 to 64 characters")
+	ErrInvalidAPIKeyScope          = errors.New("market: api key needs one or more known scopes")
+	ErrInvalidAPIKeyRateLimit      = errors.New("market: api key rate limit must be 1 to 600 requests per minute")
+	ErrAPIKeyLimitReached          = errors.New("market: api key limit reached")
+	ErrInvalidRefreshToken         = errors.New("market: refresh token is invalid, expired or revoked")
+	ErrListingBlocked              = errors.New("market: listing is blocked by moderation")
+	ErrInvalidRole                 = errors.New("market: unknown role")
+	ErrInvalidAdminReason          = errors.New("market: reason must be 1 to 500 characters")
+	ErrAdminActionNotAllowed       = errors.New("market: your role cannot make this change")
+	ErrUserBanned                  = errors.New("market: account is banned")
+	ErrContentBlocked              = errors.New("market: content is not allowed")
+	ErrInvalidListingReport        = errors.New("market: report needs a known reason and a comment of at most 500 characters")
+	ErrOwnListingReport            = errors.New("market: cannot report your own listing")
+	ErrListingAlreadyReported      = errors.New("market: you already reported this listing")
 )
 
 // ErrStatsRefreshTooSoon is returned when channel stats refresh is requested within the cooldown period.
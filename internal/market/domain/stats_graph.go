@@ -0,0 +1,53 @@
+package domain
+
+import "encoding/json"
+
+// StatsGraphData is Telegram's chart format carried in the JSON data of a loaded stats graph.
+type StatsGraphData struct {
+	Title   string            `json:"title"`
+	Columns [][]interface{}   `json:"columns"`
+	Types   map[string]string `json:"types"`
+	Names   map[string]string `json:"names"`
+}
+
+// StatsColumn is a y column of a stats graph.
+type StatsColumn struct {
+	Key    string
+	Values []float64
+}
+
+// ParseStatsGraph decodes chart JSON into its data, the x column and the y columns in order. Empty or malformed
+// JSON, a missing x or y column and columns of different lengths yield ok=false.
+func ParseStatsGraph(raw string) (data StatsGraphData, x []float64, ys []StatsColumn, ok bool) {
+	if raw == "" {
+		return data, nil, nil, false
+	}
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return data, nil, nil, false
+	}
+	for _, col := range data.Columns {
+		if len(col) < 1 {
+			continue
+		}
+		key, _ := col[0].(string)
+		values := make([]float64, 0, len(col)-1)
+		for _, v := range col[1:] {
+			f, _ := v.(float64)
+			values = append(values, f)
+		}
+		if key == "x" || data.Types[key] == "x" {
+			x = values
+			continue
+		}
+		ys = append(ys, StatsColumn{Key: key, Values: values})
+	}
+	if x == nil || len(ys) == 0 {
+		return data, nil, nil, false
+	}
+	for _, y := range ys {
+		if len(y.Values) != len(x) {
+			return data, nil, nil, false
+		}
+	}
+	return data, x, ys, true
+}
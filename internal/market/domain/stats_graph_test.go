@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseStatsGraph(t *testing.T) {
+	cases := []struct {
+		name   string
+		data   string
+		wantOK bool
+		wantX  []float64
+		wantYs []StatsColumn
+	}{
+		{"empty", "", false, nil, nil},
+		{"malformed", `{"columns":[`, false, nil, nil},
+		{"no x column", `{"columns":[["y0",1,2]]}`, false, nil, nil},
+		{"no y column", `{"columns":[["x",1,2]]}`, false, nil, nil},
+		{"different lengths", `{"columns":[["x",1,2],["y0",1,2],["y1",1]]}`, false, nil, nil},
+		{"x by type", `{"columns":[["t",1,2],["y0",3,4]],"types":{"t":"x"}}`, true, []float64{1, 2}, []StatsColumn{{Key: "y0", Values: []float64{3, 4}}}},
+		{"two series", `{"columns":[["x",1,2],["y0",3,4],["y1",5,6]]}`, true, []float64{1, 2}, []StatsColumn{{Key: "y0", Values: []float64{3, 4}}, {Key: "y1", Values: []float64{5, 6}}}},
+	}
+	for _, tc := range cases {
+		_, x, ys, ok := ParseStatsGraph(tc.data)
+		if ok != tc.wantOK || !slices.Equal(x, tc.wantX) || len(ys) != len(tc.wantYs) {
+			t.Errorf("%s: x = %v, ys = %v, ok = %v", tc.name, x, ys, ok)
+			continue
+		}
+		for i, y := range ys {
+			if y.Key != tc.wantYs[i].Key || !slices.Equal(y.Values, tc.wantYs[i].Values) {
+				t.Errorf("%s: y[%d] = %+v, want %+v", tc.name, i, y, tc.wantYs[i])
+			}
+		}
+	}
+}
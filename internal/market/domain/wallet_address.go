@@ -0,0 +1,11 @@
+package domain
+
+import "github.com/xssnick/tonutils-go/address"
+
+// ParseWalletAddress parses a TON address in user-friendly or raw ("0:<hex>") form.
+func ParseWalletAddress(s string) (*address.Address, error) {
+	if addr, err := address.ParseAddr(s); err == nil {
+		return addr, nil
+	}
+	return address.ParseRawAddr(s)
+}
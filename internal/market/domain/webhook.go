@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+)
+
+const (
+	MaxWebhookURLLength    = 2048
+	MinWebhookSecretLength = 16
+	MaxWebhookSecretLength = 256
+)
+
+// cgnat is the shared address space of carrier-grade NAT, not reachable from the internet.
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// ValidateWebhookURL returns nil for an absolute https URL without credentials whose host, when it is an IP
+// address, is public; otherwise ErrInvalidWebhookURL. Hostnames are checked when the webhook is called.
+func ValidateWebhookURL(raw string) error {
+	if len(raw) > MaxWebhookURLLength {
+		return marketerrors.ErrInvalidWebhookURL
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil || u.Fragment != "" {
+		return marketerrors.ErrInvalidWebhookURL
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !PublicIP(ip) {
+		return marketerrors.ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+// ValidateWebhookSecret returns nil for a secret of allowed length; otherwise ErrInvalidWebhookSecret.
+func ValidateWebhookSecret(secret string) error {
+	if len(secret) < MinWebhookSecretLength || len(secret) > MaxWebhookSecretLength {
+		return marketerrors.ErrInvalidWebhookSecret
+	}
+	return nil
+}
+
+// PublicIP reports whether ip is a global unicast address outside private, loopback, link-local and CGNAT ranges;
+// webhooks are only sent to such addresses.
+func PublicIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !cgnat.Contains(addr)
+}
@@ -0,0 +1,380 @@
+package e2e
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"ads-mrkt/internal/blockchain_observer"
+	"ads-mrkt/internal/liteclient/simulator"
+	"ads-mrkt/internal/market/domain"
+	"ads-mrkt/internal/market/domain/entity"
+	dealservice "ads-mrkt/internal/market/service/deal"
+	dealpostmessageservice "ads-mrkt/internal/market/service/deal_post_message"
+	dealstateservice "ads-mrkt/internal/market/service/deal_state"
+	escrowservice "ads-mrkt/internal/market/service/escrow"
+	"ads-mrkt/pkg/auth/role"
+
+	"github.com/xssnick/tonutils-go/address"
+)
+
+const (
+	lessorID = int64(1001)
+	lesseeID = int64(1002)
+
+	dealPriceNanoton  = int64(10_000_000_000)
+	gasTON            = 0.05
+	commissionPercent = 5.0
+	signDataDomain    = "market.test"
+
+	waitTimeout = 10 * time.Second
+	waitStep    = 50 * time.Millisecond
+)
+
+type env struct {
+	ctx       context.Context
+	st        *store
+	chain     *simulator.Chain
+	deposits  *depositStream
+	escrowSvc interface {
+		CreateEscrow(ctx context.Context, dealID int64) error
+		ReleaseOrRefundEscrow(ctx context.Context, logger *slog.Logger, dealID int64, release bool) error
+		GetAmountWithoutGasAndCommission(amountNanoton int64) int64
+		ReconcileEscrows(ctx context.Context) (*entity.EscrowReconciliationReport, error)
+		RetryEscrowTransfer(ctx context.Context, adminID int64, dealID int64) (*entity.Deal, error)
+	}
+	dealSvc interface {
+		CreateDeal(ctx context.Context, d *entity.Deal, otherSideID int64) error
+		SetDealPayoutAddress(ctx context.Context, userID int64, dealID int64, payoutAddressRaw string) error
+		GetDealTerms(ctx context.Context, userID int64, dealID int64) (*entity.DealTerms, error)
+		SignDeal(ctx context.Context, userID int64, dealID int64, signed *entity.SignedText) error
+		ExportSignedDealTerms(ctx context.Context, userID int64, dealID int64) (*entity.SignedDealTerms, error)
+		GetDealTimeline(ctx context.Context, userID int64, dealID int64) ([]*entity.DealEvent, error)
+		CompleteConfirmedDeals(ctx context.Context)
+	}
+	postSvc interface {
+		ProcessFinishedPosts(ctx context.Context)
+	}
+	lessorPayout string
+	lesseePayout string
+	walletKeys   map[int64]ed25519.PrivateKey
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	st := newStore()
+	chain := simulator.New()
+	deposits := newDepositStream()
+
+	observer := blockchain_observer.New(chain, nil, st, deposits, 0)
+	dealStateSvc := dealstateservice.NewService(st, st, st, st, st)
+	escrowSvc := escrowservice.NewService(st, st, st, chain, &watchCache{watch: observer.WatchAddress}, dealStateSvc, gasTON, commissionPercent)
+	dealSvc := dealservice.NewDealService(st, st, escrowSvc, dealStateSvc, st, st, &domain.ContentPolicy{}, signDataDomain, time.Minute)
+	postSvc := dealpostmessageservice.NewService(st, st, dealStateSvc, st)
+
+	go func() { _ = observer.Start(ctx) }()
+	go escrowSvc.DepositStreamWorker(ctx, deposits)
+
+	e := &env{
+		ctx:          ctx,
+		st:           st,
+		chain:        chain,
+		deposits:     deposits,
+		escrowSvc:    escrowSvc,
+		dealSvc:      dealSvc,
+		postSvc:      postSvc,
+		lessorPayout: randomRawAddress(t),
+		lesseePayout: randomRawAddress(t),
+	}
+	e.walletKeys = map[int64]ed25519.PrivateKey{lessorID: newWalletKey(t), lesseeID: newWalletKey(t)}
+	lessorKey := hex.EncodeToString(e.walletKeys[lessorID].Public().(ed25519.PublicKey))
+	lesseeKey := hex.EncodeToString(e.walletKeys[lesseeID].Public().(ed25519.PublicKey))
+	verifiedAt := time.Now()
+	st.users[lessorID] = &entity.User{ID: lessorID, WalletAddress: &e.lessorPayout, WalletPublicKey: &lessorKey, WalletVerifiedAt: &verifiedAt, Role: role.UserRole}
+	st.users[lesseeID] = &entity.User{ID: lesseeID, WalletAddress: &e.lesseePayout, WalletPublicKey: &lesseeKey, WalletVerifiedAt: &verifiedAt, Role: role.UserRole}
+
+	// The observer only reacts to master blocks newer than the one it sees on start.
+	waitFor(t, "observer start", func() bool { return chain.MasterchainPolls() > 0 })
+	return e
+}
+
+func randomRawAddress(t *testing.T) string {
+	t.Helper()
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	return address.NewAddress(0, 0, data).StringRaw()
+}
+
+func newWalletKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// signText signs text the way a TON wallet answers a signData "text" request.
+func signText(t *testing.T, key ed25519.PrivateKey, walletAddress, text string) *entity.SignedText {
+	t.Helper()
+	addr, err := address.ParseRawAddr(walletAddress)
+	if err != nil {
+		t.Fatalf("parse wallet address: %v", err)
+	}
+	ts := time.Now().Unix()
+	hash := sha256.Sum256(dealservice.SignDataTextMessage(addr, signDataDomain, ts, text))
+	return &entity.SignedText{
+		Address:   walletAddress,
+		Domain:    signDataDomain,
+		Timestamp: ts,
+		Text:      text,
+		Signature: ed25519.Sign(key, hash[:]),
+	}
+}
+
+// signDeal signs the current deal terms with the user's wallet.
+func (e *env) signDeal(t *testing.T, userID, dealID int64) error {
+	t.Helper()
+	terms, err := e.dealSvc.GetDealTerms(e.ctx, userID, dealID)
+	if err != nil {
+		t.Fatalf("get deal terms: %v", err)
+	}
+	user, _ := e.st.GetUserByID(e.ctx, userID)
+	return e.dealSvc.SignDeal(e.ctx, userID, dealID, signText(t, e.walletKeys[userID], *user.WalletAddress, terms.Terms))
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(waitStep)
+	}
+}
+
+func (e *env) requireStatus(t *testing.T, dealID int64, want entity.DealStatus) {
+	t.Helper()
+	if got := e.st.dealStatus(dealID); got != want {
+		t.Fatalf("deal %d status = %s, want %s", dealID, got, want)
+	}
+}
+
+// draftDeal creates a draft deal with both payout addresses set, ready to be signed.
+func (e *env) draftDeal(t *testing.T) *entity.Deal {
+	t.Helper()
+	d := &entity.Deal{
+		ListingID: 1,
+		LessorID:  lessorID,
+		LesseeID:  lesseeID,
+		Type:      "post",
+		Duration:  24,
+		Price:     dealPriceNanoton,
+		Details:   json.RawMessage(`{"message":"Buy our coin"}`),
+	}
+	if err := e.dealSvc.CreateDeal(e.ctx, d, lessorID); err != nil {
+		t.Fatalf("create deal: %v", err)
+	}
+	e.requireStatus(t, d.ID, entity.DealStatusDraft)
+
+	if err := e.dealSvc.SetDealPayoutAddress(e.ctx, lessorID, d.ID, e.lessorPayout); err != nil {
+		t.Fatalf("set lessor payout: %v", err)
+	}
+	if err := e.dealSvc.SetDealPayoutAddress(e.ctx, lesseeID, d.ID, e.lesseePayout); err != nil {
+		t.Fatalf("set lessee payout: %v", err)
+	}
+	return d
+}
+
+// approvedDeal creates a draft deal, sets both payout addresses and signs it by both sides.
+func (e *env) approvedDeal(t *testing.T) *entity.Deal {
+	t.Helper()
+	d := e.draftDeal(t)
+	if err := e.signDeal(t, lessorID, d.ID); err != nil {
+		t.Fatalf("lessor sign: %v", err)
+	}
+	e.requireStatus(t, d.ID, entity.DealStatusDraft)
+	if err := e.signDeal(t, lesseeID, d.ID); err != nil {
+		t.Fatalf("lessee sign: %v", err)
+	}
+	e.requireStatus(t, d.ID, entity.DealStatusApproved)
+
+	deal, _ := e.st.GetDealByID(e.ctx, d.ID)
+	return deal
+}
+
+// escrowAddress creates the escrow wallet for an approved deal.
+func (e *env) escrowAddress(t *testing.T, dealID int64) *address.Address {
+	t.Helper()
+	if err := e.escrowSvc.CreateEscrow(e.ctx, dealID); err != nil {
+		t.Fatalf("create escrow: %v", err)
+	}
+	e.requireStatus(t, dealID, entity.DealStatusWaitingEscrowDeposit)
+	deal, _ := e.st.GetDealByID(e.ctx, dealID)
+	addr, err := address.ParseRawAddr(*deal.EscrowAddress)
+	if err != nil {
+		t.Fatalf("parse escrow address: %v", err)
+	}
+	return addr
+}
+
+// deposit sends amount to the escrow wallet, mines a block and waits until the deposit event is consumed.
+func (e *env) deposit(t *testing.T, escrow *address.Address, amount int64) {
+	t.Helper()
+	e.deposits.mu.Lock()
+	before := len(e.deposits.events)
+	e.deposits.mu.Unlock()
+
+	e.chain.Deposit(escrow, amount)
+	e.chain.NextBlock()
+	waitFor(t, "deposit event", func() bool {
+		e.deposits.mu.Lock()
+		defer e.deposits.mu.Unlock()
+		return len(e.deposits.events) > before && len(e.deposits.acked) == len(e.deposits.events)
+	})
+}
+
+func (e *env) fundedDeal(t *testing.T) (*entity.Deal, *address.Address) {
+	t.Helper()
+	deal := e.approvedDeal(t)
+	escrow := e.escrowAddress(t, deal.ID)
+	e.deposit(t, escrow, deal.EscrowAmount)
+	e.requireStatus(t, deal.ID, entity.DealStatusEscrowDepositConfirmed)
+	return deal, escrow
+}
+
+func (e *env) requireSingleTransfer(t *testing.T, from *address.Address, to string, amount int64, comment string) {
+	t.Helper()
+	transfers := e.chain.Transfers()
+	if len(transfers) != 1 {
+		t.Fatalf("got %d transfers, want 1", len(transfers))
+	}
+	tr := transfers[0]
+	if !tr.From.Equals(from) {
+		t.Errorf("transfer from %s, want %s", tr.From.StringRaw(), from.StringRaw())
+	}
+	if tr.To.StringRaw() != to {
+		t.Errorf("transfer to %s, want %s", tr.To.StringRaw(), to)
+	}
+	if tr.Amount != amount {
+		t.Errorf("transfer amount %d, want %d", tr.Amount, amount)
+	}
+	if tr.Comment != comment {
+		t.Errorf("transfer comment %q, want %q", tr.Comment, comment)
+	}
+}
+
+func TestDealFlowRelease(t *testing.T) {
+	e := newEnv(t)
+	deal, escrow := e.fundedDeal(t)
+
+	e.st.publishPost(deal.ID, entity.DealPostMessageStatusPassed)
+	e.requireStatus(t, deal.ID, entity.DealStatusInProgress)
+	e.postSvc.ProcessFinishedPosts(e.ctx)
+	e.requireStatus(t, deal.ID, entity.DealStatusWaitingEscrowRelease)
+
+	if err := e.escrowSvc.ReleaseOrRefundEscrow(e.ctx, slog.Default(), deal.ID, true); err != nil {
+		t.Fatalf("release escrow: %v", err)
+	}
+	e.requireStatus(t, deal.ID, entity.DealStatusEscrowReleaseConfirmed)
+	e.requireSingleTransfer(t, escrow, e.lessorPayout, dealPriceNanoton, string(entity.DealActionTypeEscrowRelease))
+
+	e.dealSvc.CompleteConfirmedDeals(e.ctx)
+	e.requireStatus(t, deal.ID, entity.DealStatusCompleted)
+
+	timeline, err := e.dealSvc.GetDealTimeline(e.ctx, lesseeID, deal.ID)
+	if err != nil {
+		t.Fatalf("get deal timeline: %v", err)
+	}
+	want := []entity.DealStatus{
+		entity.DealStatusDraft,
+		entity.DealStatusApproved,
+		entity.DealStatusWaitingEscrowDeposit,
+		entity.DealStatusEscrowDepositConfirmed,
+		entity.DealStatusInProgress,
+		entity.DealStatusWaitingEscrowRelease,
+		entity.DealStatusEscrowReleaseConfirmed,
+		entity.DealStatusCompleted,
+	}
+	if len(timeline) != len(want) {
+		t.Fatalf("timeline has %d transitions, want %d", len(timeline), len(want))
+	}
+	for i, ev := range timeline {
+		if ev.ToStatus != want[i] || (i > 0 && *ev.FromStatus != want[i-1]) {
+			t.Errorf("transition %d: %v -> %s, want %s", i, ev.FromStatus, ev.ToStatus, want[i])
+		}
+	}
+	if timeline[0].FromStatus != nil || timeline[0].ActorType != entity.DealActorUser || *timeline[0].ActorUserID != lesseeID {
+		t.Errorf("creation = %+v, want by lessee %d", timeline[0], lesseeID)
+	}
+	if deposit := timeline[3]; deposit.ActorType != entity.DealActorWorker || deposit.TxHash == nil {
+		t.Errorf("deposit confirmation = %+v, want by a worker with the deposit tx hash", deposit)
+	}
+	if _, err := e.dealSvc.GetDealTimeline(e.ctx, 999, deal.ID); err == nil {
+		t.Error("timeline returned to a user who is not a side of the deal")
+	}
+}
+
+func TestDealFlowRefund(t *testing.T) {
+	e := newEnv(t)
+	deal, escrow := e.fundedDeal(t)
+
+	e.st.publishPost(deal.ID, entity.DealPostMessageStatusDeleted)
+	e.postSvc.ProcessFinishedPosts(e.ctx)
+	e.requireStatus(t, deal.ID, entity.DealStatusWaitingEscrowRefund)
+
+	if err := e.escrowSvc.ReleaseOrRefundEscrow(e.ctx, slog.Default(), deal.ID, false); err != nil {
+		t.Fatalf("refund escrow: %v", err)
+	}
+	e.requireStatus(t, deal.ID, entity.DealStatusEscrowRefundConfirmed)
+	e.requireSingleTransfer(t, escrow, e.lesseePayout, dealPriceNanoton, string(entity.DealActionTypeEscrowRefund))
+
+	e.dealSvc.CompleteConfirmedDeals(e.ctx)
+	e.requireStatus(t, deal.ID, entity.DealStatusCompleted)
+}
+
+func TestDealFlowUnderpaidDepositIsIgnored(t *testing.T) {
+	e := newEnv(t)
+	deal := e.approvedDeal(t)
+	escrow := e.escrowAddress(t, deal.ID)
+
+	e.deposit(t, escrow, deal.EscrowAmount-1)
+	e.requireStatus(t, deal.ID, entity.DealStatusWaitingEscrowDeposit)
+}
+
+func TestReleaseRecoversTransferFromExpiredLock(t *testing.T) {
+	e := newEnv(t)
+	deal, escrow := e.fundedDeal(t)
+	e.st.publishPost(deal.ID, entity.DealPostMessageStatusPassed)
+	e.postSvc.ProcessFinishedPosts(e.ctx)
+
+	// Simulate a crash after the transfer was sent but before the status was updated.
+	if err := e.escrowSvc.ReleaseOrRefundEscrow(e.ctx, slog.Default(), deal.ID, true); err != nil {
+		t.Fatalf("release escrow: %v", err)
+	}
+	e.st.mu.Lock()
+	e.st.deals[deal.ID].Status = entity.DealStatusWaitingEscrowRelease
+	for _, l := range e.st.locks {
+		l.Status = entity.DealActionLockStatusLocked
+		l.ExpireAt = time.Now().Add(-time.Minute)
+	}
+	e.st.mu.Unlock()
+
+	if err := e.escrowSvc.ReleaseOrRefundEscrow(e.ctx, slog.Default(), deal.ID, true); err != nil {
+		t.Fatalf("recover release: %v", err)
+	}
+	e.requireStatus(t, deal.ID, entity.DealStatusEscrowReleaseConfirmed)
+	e.requireSingleTransfer(t, escrow, e.lessorPayout, e.escrowSvc.GetAmountWithoutGasAndCommission(deal.EscrowAmount), string(entity.DealActionTypeEscrowRelease))
+}
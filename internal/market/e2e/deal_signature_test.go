@@ -0,0 +1,93 @@
+package e2e
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+	dealservice "ads-mrkt/internal/market/service/deal"
+
+	"github.com/xssnick/tonutils-go/address"
+)
+
+func TestSignDealRejectsSignaturesNotMadeByLinkedWalletOverCurrentTerms(t *testing.T) {
+	e := newEnv(t)
+	d := e.draftDeal(t)
+	terms, err := e.dealSvc.GetDealTerms(e.ctx, lessorID, d.ID)
+	if err != nil {
+		t.Fatalf("get deal terms: %v", err)
+	}
+
+	cases := map[string]*entity.SignedText{
+		"other key":      signText(t, newWalletKey(t), e.lessorPayout, terms.Terms),
+		"other text":     signText(t, e.walletKeys[lessorID], e.lessorPayout, strings.Replace(terms.Terms, `"duration":24`, `"duration":1`, 1)),
+		"other wallet":   signText(t, e.walletKeys[lessorID], e.lesseePayout, terms.Terms),
+		"lessee's terms": signText(t, e.walletKeys[lesseeID], e.lesseePayout, terms.Terms),
+	}
+	for name, signed := range cases {
+		if err := e.dealSvc.SignDeal(e.ctx, lessorID, d.ID, signed); !errors.Is(err, marketerrors.ErrInvalidDealSignature) {
+			t.Errorf("%s: err = %v, want %v", name, err, marketerrors.ErrInvalidDealSignature)
+		}
+	}
+	deal, _ := e.st.GetDealByID(e.ctx, d.ID)
+	if deal.LessorSignature != nil {
+		t.Fatalf("lessor signature stored after rejected attempts")
+	}
+}
+
+func TestSignDealRequiresWalletVerifiedWithTonProof(t *testing.T) {
+	e := newEnv(t)
+	d := e.draftDeal(t)
+	terms, err := e.dealSvc.GetDealTerms(e.ctx, lessorID, d.ID)
+	if err != nil {
+		t.Fatalf("get deal terms: %v", err)
+	}
+	// A wallet linked before ton_proof verification keeps its address, without a public key or verification time.
+	e.st.mu.Lock()
+	e.st.users[lessorID].WalletPublicKey = nil
+	e.st.users[lessorID].WalletVerifiedAt = nil
+	e.st.mu.Unlock()
+
+	signed := signText(t, e.walletKeys[lessorID], e.lessorPayout, terms.Terms)
+	if err := e.dealSvc.SignDeal(e.ctx, lessorID, d.ID, signed); !errors.Is(err, marketerrors.ErrWalletNotVerified) {
+		t.Fatalf("err = %v, want %v", err, marketerrors.ErrWalletNotVerified)
+	}
+}
+
+func TestExportedDealSignaturesVerifyIndependently(t *testing.T) {
+	e := newEnv(t)
+	d := e.approvedDeal(t)
+
+	export, err := e.dealSvc.ExportSignedDealTerms(e.ctx, lesseeID, d.ID)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if len(export.Signatures) != 2 {
+		t.Fatalf("signatures = %d, want 2", len(export.Signatures))
+	}
+	for _, sig := range export.Signatures {
+		if sig.Terms != export.Terms.Terms || sig.TermsHash != export.Terms.TermsHash {
+			t.Fatalf("user %d signed other terms", sig.UserID)
+		}
+		pubKey, _ := hex.DecodeString(sig.PublicKey)
+		signature, _ := base64.StdEncoding.DecodeString(sig.Signature)
+		addr, err := address.ParseRawAddr(sig.WalletAddress)
+		if err != nil {
+			t.Fatalf("parse wallet address: %v", err)
+		}
+		hash := sha256.Sum256(dealservice.SignDataTextMessage(addr, sig.Domain, sig.SignedAt.Unix(), sig.Terms))
+		if !ed25519.Verify(pubKey, hash[:], signature) {
+			t.Fatalf("signature of user %d does not verify", sig.UserID)
+		}
+	}
+
+	if _, err := e.dealSvc.ExportSignedDealTerms(e.ctx, 9999, d.ID); !errors.Is(err, marketerrors.ErrUnauthorizedSide) {
+		t.Fatalf("export by outsider: err = %v, want %v", err, marketerrors.ErrUnauthorizedSide)
+	}
+}
@@ -0,0 +1,3 @@
+// Package e2e runs deal flows end to end against the offline chain simulator and in-memory repositories.
+// It contains tests only; run them with `go test ./internal/market/e2e/`.
+package e2e
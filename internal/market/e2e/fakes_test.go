@@ -0,0 +1,587 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"ads-mrkt/internal/event/application/consumer"
+	evententity "ads-mrkt/internal/event/domain/entity"
+	"ads-mrkt/internal/market/domain"
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+	"ads-mrkt/pkg/auth/role"
+)
+
+// store is an in-memory replacement for the Postgres repositories used by the deal flow.
+type store struct {
+	mu        sync.Mutex
+	deals     map[int64]*entity.Deal
+	users     map[int64]*entity.User
+	posts     map[int64]*entity.DealPostMessage
+	locks     map[string]*entity.DealActionLock
+	sigs      map[int64][]*entity.DealSignature
+	seeds     map[int64]string
+	nextID    int64
+	notifyLog []string
+	events    []*entity.DealEvent
+}
+
+func newStore() *store {
+	return &store{
+		deals: make(map[int64]*entity.Deal),
+		users: make(map[int64]*entity.User),
+		posts: make(map[int64]*entity.DealPostMessage),
+		locks: make(map[string]*entity.DealActionLock),
+		sigs:  make(map[int64][]*entity.DealSignature),
+		seeds: make(map[int64]string),
+	}
+}
+
+func (s *store) id() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func copyDeal(d *entity.Deal) *entity.Deal {
+	c := *d
+	return &c
+}
+
+func (s *store) dealsWhere(match func(d *entity.Deal) bool) []*entity.Deal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []*entity.Deal
+	for id := int64(1); id <= s.nextID; id++ {
+		if d, ok := s.deals[id]; ok && match(d) {
+			list = append(list, copyDeal(d))
+		}
+	}
+	return list
+}
+
+// moveDeal sets the deal status, bumps its version and records the transition; s.mu must be held.
+func (s *store) moveDeal(d *entity.Deal, status entity.DealStatus, t entity.DealTransition) {
+	from := d.Status
+	s.events = append(s.events, &entity.DealEvent{DealID: d.ID, FromStatus: &from, ToStatus: status, DealTransition: t, CreatedAt: time.Now()})
+	d.Status = status
+	d.Version++
+	d.UpdatedAt = time.Now()
+}
+
+// forceStatus sets the deal status unconditionally, to put the store into states the services would not produce.
+func (s *store) forceStatus(dealID int64, status entity.DealStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deals[dealID].Status = status
+	s.deals[dealID].UpdatedAt = time.Now()
+}
+
+func (s *store) dealStatus(dealID int64) entity.DealStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deals[dealID].Status
+}
+
+// users
+
+func (s *store) GetUserByID(ctx context.Context, id int64) (*entity.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *u
+	return &c, nil
+}
+
+func (s *store) ListUserIDsByRole(ctx context.Context, userRole role.Role) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []int64
+	for id, u := range s.users {
+		if u.Role == userRole {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// deals
+
+func (s *store) CreateDeal(ctx context.Context, d *entity.Deal, t entity.DealTransition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d.ID = s.id()
+	d.CreatedAt = time.Now()
+	d.UpdatedAt = d.CreatedAt
+	s.deals[d.ID] = copyDeal(d)
+	s.events = append(s.events, &entity.DealEvent{DealID: d.ID, ToStatus: d.Status, DealTransition: t, CreatedAt: d.CreatedAt})
+	return nil
+}
+
+func (s *store) ListDealEvents(ctx context.Context, dealID int64) ([]*entity.DealEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []*entity.DealEvent
+	for _, e := range s.events {
+		if e.DealID == dealID {
+			list = append(list, e)
+		}
+	}
+	return list, nil
+}
+
+func (s *store) GetDealByID(ctx context.Context, id int64) (*entity.Deal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.deals[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyDeal(d), nil
+}
+
+func (s *store) GetDealsByListingID(ctx context.Context, listingID int64) ([]*entity.Deal, error) {
+	return s.dealsWhere(func(d *entity.Deal) bool { return d.ListingID == listingID }), nil
+}
+
+func (s *store) GetDealsByListingIDForUser(ctx context.Context, listingID int64, userID int64) ([]*entity.Deal, error) {
+	return s.dealsWhere(func(d *entity.Deal) bool {
+		return d.ListingID == listingID && (d.LessorID == userID || d.LesseeID == userID)
+	}), nil
+}
+
+func (s *store) ListDealsByUserID(ctx context.Context, userID int64) ([]*entity.Deal, error) {
+	return s.dealsWhere(func(d *entity.Deal) bool { return d.LessorID == userID || d.LesseeID == userID }), nil
+}
+
+func (s *store) UpdateDealDraftFieldsAndClearSignatures(ctx context.Context, d *entity.Deal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.deals[d.ID]
+	if !ok || existing.Status != entity.DealStatusDraft {
+		return nil
+	}
+	existing.Type = d.Type
+	existing.Duration = d.Duration
+	existing.Price = d.Price
+	existing.EscrowAmount = d.EscrowAmount
+	existing.Details = d.Details
+	existing.LessorSignature = nil
+	existing.LesseeSignature = nil
+	existing.Version++
+	delete(s.sigs, d.ID)
+	return nil
+}
+
+func (s *store) SignDealInTx(ctx context.Context, dealID int64, userID int64, sig *entity.DealSignature) error {
+	s.mu.Lock()
+	d, ok := s.deals[dealID]
+	if !ok {
+		s.mu.Unlock()
+		return marketerrors.ErrNotFound
+	}
+	if d.Status != entity.DealStatusDraft {
+		s.mu.Unlock()
+		return marketerrors.ErrDealNotDraft
+	}
+	if domain.ComputeDealTerms(d).TermsHash != sig.TermsHash {
+		s.mu.Unlock()
+		return marketerrors.ErrInvalidDealSignature
+	}
+	hash := sig.TermsHash
+	switch userID {
+	case d.LessorID:
+		d.LessorSignature = &hash
+	case d.LesseeID:
+		d.LesseeSignature = &hash
+	default:
+		s.mu.Unlock()
+		return marketerrors.ErrUnauthorizedSide
+	}
+	kept := s.sigs[dealID][:0]
+	for _, existing := range s.sigs[dealID] {
+		if existing.UserID != userID {
+			kept = append(kept, existing)
+		}
+	}
+	s.sigs[dealID] = append(kept, sig)
+	d.Version++
+	signed := copyDeal(d)
+	s.mu.Unlock()
+	if domain.DealSignaturesMatch(signed) {
+		return s.TransitionDeal(ctx, signed, entity.DealStatusApproved, entity.UserDealTransition(userID, "both parties signed the deal terms"))
+	}
+	return nil
+}
+
+// TransitionDeal applies the transition, like the repository, only while the stored deal has the status and version
+// d was read with.
+func (s *store) TransitionDeal(ctx context.Context, d *entity.Deal, to entity.DealStatus, t entity.DealTransition) error {
+	if _, err := domain.CheckDealTransition(d.Status, to, t); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.deals[d.ID]
+	if !ok || stored.Status != d.Status || stored.Version != d.Version {
+		return marketerrors.ErrDealChanged
+	}
+	stored.EscrowAddress = d.EscrowAddress
+	stored.LessorPayoutAddress = d.LessorPayoutAddress
+	stored.LesseePayoutAddress = d.LesseePayoutAddress
+	s.moveDeal(stored, to, t)
+	d.Status, d.Version = stored.Status, stored.Version
+	return nil
+}
+
+func (s *store) ListDealSignatures(ctx context.Context, dealID int64) ([]*entity.DealSignature, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*entity.DealSignature(nil), s.sigs[dealID]...), nil
+}
+
+func (s *store) SetDealPayoutAddress(ctx context.Context, dealID int64, userID int64, payoutAddressRaw string, status entity.DealStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.deals[dealID]
+	if !ok || d.Status != status {
+		return nil
+	}
+	addr := payoutAddressRaw
+	if userID == d.LessorID {
+		d.LessorPayoutAddress = &addr
+	}
+	if userID == d.LesseeID {
+		d.LesseePayoutAddress = &addr
+	}
+	d.Version++
+	return nil
+}
+
+func (s *store) ListDealsWaitingEscrowDepositOlderThan(ctx context.Context, before time.Time) ([]*entity.Deal, error) {
+	return s.dealsWhere(func(d *entity.Deal) bool {
+		return d.Status == entity.DealStatusWaitingEscrowDeposit && d.UpdatedAt.Before(before)
+	}), nil
+}
+
+func (s *store) ListDealsEscrowConfirmedToComplete(ctx context.Context) ([]*entity.Deal, error) {
+	return s.dealsWhere(func(d *entity.Deal) bool {
+		return d.Status == entity.DealStatusEscrowReleaseConfirmed || d.Status == entity.DealStatusEscrowRefundConfirmed
+	}), nil
+}
+
+func (s *store) GetDealByEscrowAddress(ctx context.Context, escrowAddress string) (*entity.Deal, error) {
+	list := s.dealsWhere(func(d *entity.Deal) bool {
+		return d.Status == entity.DealStatusWaitingEscrowDeposit && d.EscrowAddress != nil && *d.EscrowAddress == escrowAddress
+	})
+	if len(list) == 0 {
+		return nil, nil
+	}
+	return list[0], nil
+}
+
+func (s *store) ListDealsApprovedWithoutEscrow(ctx context.Context) ([]*entity.Deal, error) {
+	return s.dealsWhere(func(d *entity.Deal) bool {
+		return d.Status == entity.DealStatusApproved && d.EscrowAddress == nil
+	}), nil
+}
+
+func (s *store) ListDealsWaitingEscrowRelease(ctx context.Context) ([]*entity.Deal, error) {
+	return s.dealsWhere(func(d *entity.Deal) bool { return d.Status == entity.DealStatusWaitingEscrowRelease }), nil
+}
+
+func (s *store) ListDealsWaitingEscrowRefund(ctx context.Context) ([]*entity.Deal, error) {
+	return s.dealsWhere(func(d *entity.Deal) bool { return d.Status == entity.DealStatusWaitingEscrowRefund }), nil
+}
+
+func (s *store) ListDealsWithEscrowForReconciliation(ctx context.Context, closedAfter time.Time) ([]*entity.Deal, error) {
+	return s.dealsWhere(func(d *entity.Deal) bool {
+		if d.EscrowAddress == nil {
+			return false
+		}
+		switch d.Status {
+		case entity.DealStatusCompleted, entity.DealStatusExpired, entity.DealStatusRejected:
+			return d.UpdatedAt.After(closedAfter)
+		}
+		return true
+	}), nil
+}
+
+func (s *store) ListDealsEscrowTransferFailed(ctx context.Context) ([]*entity.Deal, error) {
+	return s.dealsWhere(func(d *entity.Deal) bool { return d.Status == entity.DealStatusEscrowTransferFailed }), nil
+}
+
+// deal post messages
+
+// publishPost stands in for the userbot: it records a post in the channel and moves the deal to in_progress.
+func (s *store) publishPost(dealID int64, status entity.DealPostMessageStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.id()
+	s.posts[id] = &entity.DealPostMessage{ID: id, DealID: dealID, Status: status}
+	if d := s.deals[dealID]; d.Status == entity.DealStatusEscrowDepositConfirmed {
+		s.moveDeal(d, entity.DealStatusInProgress, entity.WorkerDealTransition(domain.DealWorkerUserbotPost, "ad posted to the channel"))
+	}
+}
+
+func (s *store) ListDealPostMessageByStatus(ctx context.Context, status entity.DealPostMessageStatus) ([]*entity.DealPostMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []*entity.DealPostMessage
+	for _, m := range s.posts {
+		if m.Status == status {
+			c := *m
+			list = append(list, &c)
+		}
+	}
+	return list, nil
+}
+
+func (s *store) UpdateDealPostMessageStatus(ctx context.Context, id int64, status entity.DealPostMessageStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.posts[id].Status = status
+	return nil
+}
+
+// deal action locks
+
+func (s *store) TakeDealActionLock(ctx context.Context, dealID int64, actionType entity.DealActionType) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempt := 1
+	if last := s.lastLockLocked(dealID, actionType); last != nil {
+		if last.Status == entity.DealActionLockStatusLocked && last.ExpireAt.After(time.Now()) {
+			return "", errors.New("lock is already taken")
+		}
+		if last.Status == entity.DealActionLockStatusFailed {
+			attempt = last.Attempt + 1
+		}
+	}
+	id := strconv.FormatInt(s.id(), 10)
+	s.locks[id] = &entity.DealActionLock{
+		ID:         id,
+		DealID:     dealID,
+		ActionType: actionType,
+		Status:     entity.DealActionLockStatusLocked,
+		Attempt:    attempt,
+		ExpireAt:   time.Now().Add(5 * time.Minute),
+		CreatedAt:  time.Now(),
+	}
+	return id, nil
+}
+
+func (s *store) ReleaseDealActionLock(ctx context.Context, lockID string, status entity.DealActionLockStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.locks[lockID]; ok {
+		l.Status = status
+		l.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// lastLockLocked returns the newest lock for the action; ids grow with every lock. Must be called with mu held.
+func (s *store) lastLockLocked(dealID int64, actionType entity.DealActionType) *entity.DealActionLock {
+	var last *entity.DealActionLock
+	var lastID int64
+	for id, l := range s.locks {
+		n, _ := strconv.ParseInt(id, 10, 64)
+		if l.DealID == dealID && l.ActionType == actionType && n > lastID {
+			last, lastID = l, n
+		}
+	}
+	return last
+}
+
+func (s *store) GetLastDealActionLock(ctx context.Context, dealID int64, actionType entity.DealActionType) (*entity.DealActionLock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last := s.lastLockLocked(dealID, actionType)
+	if last == nil {
+		return nil, nil
+	}
+	c := *last
+	return &c, nil
+}
+
+func (s *store) FailDealActionLock(ctx context.Context, lockID string, lastError string, baseBackoff, maxBackoff time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.locks[lockID]
+	if !ok {
+		return 0, errors.New("lock not found")
+	}
+	retryAt := time.Now().Add(min(baseBackoff<<(l.Attempt-1), maxBackoff))
+	l.Status = entity.DealActionLockStatusFailed
+	l.LastError = &lastError
+	l.RetryAt = &retryAt
+	l.UpdatedAt = time.Now()
+	return l.Attempt, nil
+}
+
+func (s *store) ResetDealActionAttempts(ctx context.Context, dealID int64, actionType entity.DealActionType) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l := s.lastLockLocked(dealID, actionType); l != nil && l.Status == entity.DealActionLockStatusFailed {
+		l.Attempt = 0
+		l.RetryAt = nil
+	}
+	return nil
+}
+
+// skipBackoff makes failed locks of the deal immediately retryable, standing in for the passage of time.
+func (s *store) skipBackoff(dealID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, l := range s.locks {
+		if l.DealID == dealID {
+			l.RetryAt = nil
+		}
+	}
+}
+
+func (s *store) ListDealActionFailures(ctx context.Context, minFailures int) ([]*entity.DealActionFailures, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	type key struct {
+		dealID     int64
+		actionType entity.DealActionType
+	}
+	failed := make(map[key]*entity.DealActionFailures)
+	completed := make(map[key]bool)
+	for _, l := range s.locks {
+		k := key{l.DealID, l.ActionType}
+		switch l.Status {
+		case entity.DealActionLockStatusCompleted:
+			completed[k] = true
+		case entity.DealActionLockStatusFailed:
+			if failed[k] == nil {
+				failed[k] = &entity.DealActionFailures{DealID: l.DealID, ActionType: l.ActionType}
+			}
+			failed[k].Failures++
+			if l.UpdatedAt.After(failed[k].LastFailureAt) {
+				failed[k].LastFailureAt = l.UpdatedAt
+			}
+		}
+	}
+	var list []*entity.DealActionFailures
+	for k, f := range failed {
+		if !completed[k] && f.Failures >= int64(minFailures) {
+			list = append(list, f)
+		}
+	}
+	return list, nil
+}
+
+// vault
+
+func (s *store) PutEscrowSeed(ctx context.Context, dealID int64, seedPhrase string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seeds[dealID] = seedPhrase
+	return nil
+}
+
+func (s *store) GetEscrowSeed(ctx context.Context, dealID int64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seed, ok := s.seeds[dealID]
+	if !ok {
+		return "", errors.New("secret not found")
+	}
+	return seed, nil
+}
+
+// transactions run their function directly; the store has no rollback.
+
+func (s *store) InTx(ctx context.Context, source string, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (s *store) InSerializableTx(ctx context.Context, source string, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// notifications and deal chat
+
+func (s *store) AddTelegramNotificationEvent(ctx context.Context, chatID int64, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifyLog = append(s.notifyLog, message)
+	return nil
+}
+
+func (s *store) DeleteDealForumTopic(ctx context.Context, dealID int64) error {
+	return nil
+}
+
+// watchCache replaces Redis for escrow: setting a key registers the escrow address with the observer,
+// which in production happens through keyspace notifications.
+type watchCache struct {
+	watch func(rawAddress string) error
+}
+
+func (c *watchCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return c.watch(key)
+}
+
+func (c *watchCache) Del(ctx context.Context, keys ...string) error {
+	return nil
+}
+
+// depositStream is an in-memory escrow deposit stream shared by the observer and the escrow deposit worker.
+type depositStream struct {
+	mu     sync.Mutex
+	events []*evententity.EventEscrowDeposit
+	acked  map[string]bool
+	nextID int
+}
+
+func newDepositStream() *depositStream {
+	return &depositStream{acked: make(map[string]bool)}
+}
+
+func (d *depositStream) AddEscrowDepositEvent(ctx context.Context, event *evententity.EventEscrowDeposit) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nextID++
+	ev := *event
+	ev.ID = strconv.Itoa(d.nextID)
+	d.events = append(d.events, &ev)
+	return nil
+}
+
+// ConsumeEscrowDepositEvents polls for unacked events until ctx is done, acking the handled ones.
+func (d *depositStream) ConsumeEscrowDepositEvents(ctx context.Context, cfg consumer.Config, handler consumer.Handler[*evententity.EventEscrowDeposit]) error {
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		d.mu.Lock()
+		var list []*evententity.EventEscrowDeposit
+		for _, ev := range d.events {
+			if !d.acked[ev.ID] && int64(len(list)) < cfg.BatchSize {
+				list = append(list, ev)
+			}
+		}
+		d.mu.Unlock()
+		for _, ev := range list {
+			if handler(ctx, ev) != nil {
+				continue
+			}
+			d.mu.Lock()
+			d.acked[ev.ID] = true
+			d.mu.Unlock()
+		}
+	}
+}
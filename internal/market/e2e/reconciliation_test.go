@@ -0,0 +1,101 @@
+package e2e
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+)
+
+func (e *env) reconcile(t *testing.T) *entity.EscrowReconciliationReport {
+	t.Helper()
+	report, err := e.escrowSvc.ReconcileEscrows(e.ctx)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	return report
+}
+
+func mismatchKinds(report *entity.EscrowReconciliationReport) map[int64]entity.EscrowMismatchKind {
+	kinds := make(map[int64]entity.EscrowMismatchKind, len(report.Mismatches))
+	for _, m := range report.Mismatches {
+		kinds[m.DealID] = m.Kind
+	}
+	return kinds
+}
+
+func TestReconciliationMatchesHealthyFlow(t *testing.T) {
+	e := newEnv(t)
+	deal, _ := e.fundedDeal(t)
+
+	report := e.reconcile(t)
+	if len(report.Mismatches) != 0 {
+		t.Fatalf("unexpected mismatches: %+v", report.Mismatches[0])
+	}
+	if report.DealsChecked != 1 || report.HeldBalanceNanoton != deal.EscrowAmount {
+		t.Fatalf("report = %+v, want 1 deal holding %d", report, deal.EscrowAmount)
+	}
+
+	e.st.publishPost(deal.ID, entity.DealPostMessageStatusPassed)
+	e.postSvc.ProcessFinishedPosts(e.ctx)
+	if err := e.escrowSvc.ReleaseOrRefundEscrow(e.ctx, slog.Default(), deal.ID, true); err != nil {
+		t.Fatalf("release escrow: %v", err)
+	}
+	e.dealSvc.CompleteConfirmedDeals(e.ctx)
+
+	report = e.reconcile(t)
+	if len(report.Mismatches) != 0 {
+		t.Fatalf("unexpected mismatches after payout: %+v", report.Mismatches[0])
+	}
+	if report.HeldBalanceNanoton != 0 {
+		t.Fatalf("held balance = %d, want 0", report.HeldBalanceNanoton)
+	}
+}
+
+func TestReconciliationReportsMismatches(t *testing.T) {
+	e := newEnv(t)
+
+	// Confirmed in the database, but the wallet was never funded.
+	unfunded := e.approvedDeal(t)
+	e.escrowAddress(t, unfunded.ID)
+	e.st.forceStatus(unfunded.ID, entity.DealStatusEscrowDepositConfirmed)
+
+	// Deposit arrived after the deal had expired.
+	late := e.approvedDeal(t)
+	lateEscrow := e.escrowAddress(t, late.ID)
+	e.st.forceStatus(late.ID, entity.DealStatusExpired)
+	e.chain.Deposit(lateEscrow, late.EscrowAmount)
+	e.chain.NextBlock()
+
+	// Release marked confirmed while the wallet still holds the deposit.
+	leftover, _ := e.fundedDeal(t)
+	e.st.forceStatus(leftover.ID, entity.DealStatusEscrowReleaseConfirmed)
+
+	// Release keeps failing.
+	failing, _ := e.fundedDeal(t)
+	for range 3 {
+		lockID, err := e.st.TakeDealActionLock(e.ctx, failing.ID, entity.DealActionTypeEscrowRelease)
+		if err != nil {
+			t.Fatalf("take lock: %v", err)
+		}
+		_ = e.st.ReleaseDealActionLock(e.ctx, lockID, entity.DealActionLockStatusFailed)
+		time.Sleep(time.Millisecond)
+	}
+
+	kinds := mismatchKinds(e.reconcile(t))
+	want := map[int64]entity.EscrowMismatchKind{
+		unfunded.ID: entity.EscrowMismatchUnfunded,
+		late.ID:     entity.EscrowMismatchFundsOnClosedDeal,
+		leftover.ID: entity.EscrowMismatchFundsLeftAfterPayout,
+		failing.ID:  entity.EscrowMismatchRepeatedTransferFailures,
+	}
+	if len(kinds) != len(want) {
+		t.Fatalf("mismatches = %v, want %v", kinds, want)
+	}
+	for id, kind := range want {
+		if kinds[id] != kind {
+			t.Errorf("deal %d: kind = %q, want %q", id, kinds[id], kind)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package e2e
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+	escrowservice "ads-mrkt/internal/market/service/escrow"
+	"ads-mrkt/pkg/auth/role"
+
+	"github.com/xssnick/tonutils-go/address"
+)
+
+const adminID = int64(1)
+
+// waitingReleaseDeal returns a funded deal whose post passed and that waits for the escrow release.
+func (e *env) waitingReleaseDeal(t *testing.T) (*entity.Deal, *address.Address) {
+	t.Helper()
+	deal, escrow := e.fundedDeal(t)
+	e.st.publishPost(deal.ID, entity.DealPostMessageStatusPassed)
+	e.postSvc.ProcessFinishedPosts(e.ctx)
+	e.requireStatus(t, deal.ID, entity.DealStatusWaitingEscrowRelease)
+	return deal, escrow
+}
+
+func TestReleaseBacksOffAndGivesUpAfterMaxAttempts(t *testing.T) {
+	e := newEnv(t)
+	e.st.users[adminID] = &entity.User{ID: adminID, Role: role.AdminRole}
+	deal, _ := e.waitingReleaseDeal(t)
+	e.chain.RejectTransfersTo(address.MustParseRawAddr(e.lessorPayout))
+
+	if err := e.escrowSvc.ReleaseOrRefundEscrow(e.ctx, slog.Default(), deal.ID, true); err == nil {
+		t.Fatal("release to a rejected address succeeded")
+	}
+	if err := e.escrowSvc.ReleaseOrRefundEscrow(e.ctx, slog.Default(), deal.ID, true); !errors.Is(err, escrowservice.ErrTransferBackoff) {
+		t.Fatalf("second attempt error = %v, want backoff", err)
+	}
+	lock, _ := e.st.GetLastDealActionLock(e.ctx, deal.ID, entity.DealActionTypeEscrowRelease)
+	if lock.Attempt != 1 || lock.RetryAt == nil || lock.LastError == nil {
+		t.Fatalf("failed lock = %+v, want attempt 1 with retry_at and last_error", lock)
+	}
+
+	for attempt := 2; attempt <= 5; attempt++ {
+		e.requireStatus(t, deal.ID, entity.DealStatusWaitingEscrowRelease)
+		e.st.skipBackoff(deal.ID)
+		if err := e.escrowSvc.ReleaseOrRefundEscrow(e.ctx, slog.Default(), deal.ID, true); err == nil {
+			t.Fatalf("attempt %d succeeded", attempt)
+		}
+	}
+	e.requireStatus(t, deal.ID, entity.DealStatusEscrowTransferFailed)
+	if len(e.chain.Transfers()) != 0 {
+		t.Fatalf("got %d transfers, want none", len(e.chain.Transfers()))
+	}
+
+	e.st.mu.Lock()
+	notified := false
+	for _, msg := range e.st.notifyLog {
+		notified = notified || strings.Contains(msg, "needs admin action")
+	}
+	e.st.mu.Unlock()
+	if !notified {
+		t.Fatal("admin was not notified")
+	}
+}
+
+func TestAdminRetryToPayoutAddressSetByUser(t *testing.T) {
+	e := newEnv(t)
+	deal, escrow := e.waitingReleaseDeal(t)
+	e.chain.RejectTransfersTo(address.MustParseRawAddr(e.lessorPayout))
+	for i := 0; i < 5; i++ {
+		e.st.skipBackoff(deal.ID)
+		_ = e.escrowSvc.ReleaseOrRefundEscrow(e.ctx, slog.Default(), deal.ID, true)
+	}
+	e.requireStatus(t, deal.ID, entity.DealStatusEscrowTransferFailed)
+
+	// The new payout must be the lessor's own wallet, linked with ton_proof.
+	newPayout := randomRawAddress(t)
+	friendly := address.MustParseRawAddr(newPayout).Bounce(false).String()
+	if err := e.dealSvc.SetDealPayoutAddress(e.ctx, lessorID, deal.ID, friendly); !errors.Is(err, marketerrors.ErrInvalidWalletAddress) {
+		t.Fatalf("payout to a wallet the lessor did not link: err = %v, want %v", err, marketerrors.ErrInvalidWalletAddress)
+	}
+	e.st.mu.Lock()
+	e.st.users[lessorID].WalletAddress = &newPayout
+	e.st.mu.Unlock()
+	if err := e.dealSvc.SetDealPayoutAddress(e.ctx, lessorID, deal.ID, friendly); err != nil {
+		t.Fatalf("set payout to the linked wallet: %v", err)
+	}
+
+	updated, err := e.escrowSvc.RetryEscrowTransfer(e.ctx, adminID, deal.ID)
+	if err != nil {
+		t.Fatalf("retry escrow transfer: %v", err)
+	}
+	if updated.Status != entity.DealStatusWaitingEscrowRelease || *updated.LessorPayoutAddress != newPayout {
+		t.Fatalf("retried deal status %s payout %s, want waiting_escrow_release to %s", updated.Status, *updated.LessorPayoutAddress, newPayout)
+	}
+	if _, err := e.escrowSvc.RetryEscrowTransfer(e.ctx, adminID, deal.ID); err == nil {
+		t.Fatal("retry of a deal that is not escrow_transfer_failed succeeded")
+	}
+
+	// The counter starts over, so the next attempt is not held back by the last backoff.
+	if err := e.escrowSvc.ReleaseOrRefundEscrow(e.ctx, slog.Default(), deal.ID, true); err != nil {
+		t.Fatalf("release after retry: %v", err)
+	}
+	e.requireStatus(t, deal.ID, entity.DealStatusEscrowReleaseConfirmed)
+	e.requireSingleTransfer(t, escrow, newPayout, e.escrowSvc.GetAmountWithoutGasAndCommission(deal.EscrowAmount), string(entity.DealActionTypeEscrowRelease))
+}
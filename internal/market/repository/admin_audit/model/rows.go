@@ -0,0 +1,40 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+	"ads-mrkt/pkg/auth/role"
+)
+
+type AdminAuditRow struct {
+	ID         int64           `db:"id"`
+	AdminID    int64           `db:"admin_id"`
+	AdminRole  string          `db:"admin_role"`
+	Action     string          `db:"action"`
+	TargetType string          `db:"target_type"`
+	TargetID   *int64          `db:"target_id"`
+	Reason     string          `db:"reason"`
+	Details    json.RawMessage `db:"details"`
+	CreatedAt  time.Time       `db:"created_at"`
+}
+
+type AdminAuditReturnRow struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func AdminAuditRowToEntity(row AdminAuditRow) *entity.AdminAuditEntry {
+	return &entity.AdminAuditEntry{
+		ID:         row.ID,
+		AdminID:    row.AdminID,
+		AdminRole:  role.Role(row.AdminRole),
+		Action:     entity.AdminAction(row.Action),
+		TargetType: entity.AdminTargetType(row.TargetType),
+		TargetID:   row.TargetID,
+		Reason:     row.Reason,
+		Details:    row.Details,
+		CreatedAt:  row.CreatedAt,
+	}
+}
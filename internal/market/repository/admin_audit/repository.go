@@ -0,0 +1,95 @@
+package admin_audit
+
+import (
+	"context"
+
+	"ads-mrkt/internal/market/domain/entity"
+	"ads-mrkt/internal/market/repository/admin_audit/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type database interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+type repository struct {
+	db database
+}
+
+func New(db database) *repository {
+	return &repository{db: db}
+}
+
+// CreateAdminAuditEntry appends e to the audit log; call it in the transaction of the change it records.
+func (r *repository) CreateAdminAuditEntry(ctx context.Context, e *entity.AdminAuditEntry) error {
+	rows, err := r.db.Query(ctx, `
+		INSERT INTO market.admin_audit_log (admin_id, admin_role, action, target_type, target_id, reason, details)
+		VALUES (@admin_id, @admin_role, @action, @target_type, @target_id, @reason, @details::jsonb)
+		RETURNING id, created_at`,
+		pgx.NamedArgs{
+			"admin_id":    e.AdminID,
+			"admin_role":  string(e.AdminRole),
+			"action":      string(e.Action),
+			"target_type": string(e.TargetType),
+			"target_id":   e.TargetID,
+			"reason":      e.Reason,
+			"details":     e.Details,
+		})
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.AdminAuditReturnRow])
+	if err != nil {
+		return err
+	}
+	e.ID = row.ID
+	e.CreatedAt = row.CreatedAt
+	return nil
+}
+
+// ListAdminAuditEntries returns entries matching the filter, newest first.
+func (r *repository) ListAdminAuditEntries(ctx context.Context, filter *entity.AdminAuditFilter) ([]*entity.AdminAuditEntry, error) {
+	q := `
+		SELECT id, admin_id, admin_role, action, target_type, target_id, reason, details, created_at
+		FROM market.admin_audit_log
+		WHERE TRUE`
+	args := pgx.NamedArgs{"limit": filter.Limit, "offset": filter.Offset}
+	if filter.AdminID != nil {
+		q += ` AND admin_id = @admin_id`
+		args["admin_id"] = *filter.AdminID
+	}
+	if filter.Action != "" {
+		q += ` AND action = @action`
+		args["action"] = string(filter.Action)
+	}
+	if filter.TargetType != "" {
+		q += ` AND target_type = @target_type`
+		args["target_type"] = string(filter.TargetType)
+	}
+	if filter.TargetID != nil {
+		q += ` AND target_id = @target_id`
+		args["target_id"] = *filter.TargetID
+	}
+	q += ` ORDER BY id DESC LIMIT @limit OFFSET @offset`
+
+	rows, err := r.db.Query(ctx, q, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.AdminAuditRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.AdminAuditEntry, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.AdminAuditRowToEntity(row))
+	}
+	return list, nil
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+	"ads-mrkt/pkg/auth/scope"
+)
+
+type APIKeyRow struct {
+	ID                 int64      `db:"id"`
+	UserID             int64      `db:"user_id"`
+	Name               string     `db:"name"`
+	Prefix             string     `db:"prefix"`
+	KeyHash            string     `db:"key_hash"`
+	Scopes             []string   `db:"scopes"`
+	RateLimitPerMinute int        `db:"rate_limit_per_minute"`
+	LastUsedAt         *time.Time `db:"last_used_at"`
+	CreatedAt          time.Time  `db:"created_at"`
+}
+
+type APIKeyReturnRow struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func APIKeyRowToEntity(row APIKeyRow) *entity.APIKey {
+	scopes := make([]scope.Scope, 0, len(row.Scopes))
+	for _, s := range row.Scopes {
+		scopes = append(scopes, scope.Scope(s))
+	}
+	return &entity.APIKey{
+		ID:                 row.ID,
+		UserID:             row.UserID,
+		Name:               row.Name,
+		Prefix:             row.Prefix,
+		KeyHash:            row.KeyHash,
+		Scopes:             scopes,
+		RateLimitPerMinute: row.RateLimitPerMinute,
+		LastUsedAt:         row.LastUsedAt,
+		CreatedAt:          row.CreatedAt,
+	}
+}
@@ -0,0 +1,118 @@
+package api_key
+
+import (
+	"context"
+	"errors"
+
+	"ads-mrkt/internal/market/domain/entity"
+	"ads-mrkt/internal/market/repository/api_key/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type database interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+type repository struct {
+	db database
+}
+
+func New(db database) *repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateAPIKey(ctx context.Context, k *entity.APIKey) error {
+	scopes := make([]string, 0, len(k.Scopes))
+	for _, s := range k.Scopes {
+		scopes = append(scopes, string(s))
+	}
+	rows, err := r.db.Query(ctx, `
+		INSERT INTO market.api_key (user_id, name, prefix, key_hash, scopes, rate_limit_per_minute)
+		VALUES (@user_id, @name, @prefix, @key_hash, @scopes, @rate_limit_per_minute)
+		RETURNING id, created_at`,
+		pgx.NamedArgs{
+			"user_id":               k.UserID,
+			"name":                  k.Name,
+			"prefix":                k.Prefix,
+			"key_hash":              k.KeyHash,
+			"scopes":                scopes,
+			"rate_limit_per_minute": k.RateLimitPerMinute,
+		})
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.APIKeyReturnRow])
+	if err != nil {
+		return err
+	}
+	k.ID = row.ID
+	k.CreatedAt = row.CreatedAt
+	return nil
+}
+
+func (r *repository) GetAPIKeyByID(ctx context.Context, id int64) (*entity.APIKey, error) {
+	return r.getAPIKey(ctx, `WHERE id = @id`, pgx.NamedArgs{"id": id})
+}
+
+// GetAPIKeyByHash returns the key with the given SHA-256 hash, or nil.
+func (r *repository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	return r.getAPIKey(ctx, `WHERE key_hash = @key_hash`, pgx.NamedArgs{"key_hash": keyHash})
+}
+
+func (r *repository) getAPIKey(ctx context.Context, where string, args pgx.NamedArgs) (*entity.APIKey, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, name, prefix, key_hash, scopes, rate_limit_per_minute, last_used_at, created_at
+		FROM market.api_key
+		`+where, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.APIKeyRow])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return model.APIKeyRowToEntity(row), nil
+}
+
+func (r *repository) ListAPIKeysByUserID(ctx context.Context, userID int64) ([]*entity.APIKey, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, name, prefix, key_hash, scopes, rate_limit_per_minute, last_used_at, created_at
+		FROM market.api_key
+		WHERE user_id = @user_id
+		ORDER BY id`,
+		pgx.NamedArgs{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.APIKeyRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.APIKey, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.APIKeyRowToEntity(row))
+	}
+	return list, nil
+}
+
+func (r *repository) DeleteAPIKey(ctx context.Context, id int64) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM market.api_key WHERE id = @id`, pgx.NamedArgs{"id": id})
+	return err
+}
+
+func (r *repository) TouchAPIKey(ctx context.Context, id int64) error {
+	_, err := r.db.Exec(ctx, `UPDATE market.api_key SET last_used_at = NOW() WHERE id = @id`, pgx.NamedArgs{"id": id})
+	return err
+}
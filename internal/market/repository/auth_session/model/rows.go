@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+)
+
+type AuthSessionRow struct {
+	ID                       int64      `db:"id"`
+	UserID                   int64      `db:"user_id"`
+	RefreshTokenHash         string     `db:"refresh_token_hash"`
+	PreviousRefreshTokenHash *string    `db:"previous_refresh_token_hash"`
+	ExpiresAt                time.Time  `db:"expires_at"`
+	RevokedAt                *time.Time `db:"revoked_at"`
+	CreatedAt                time.Time  `db:"created_at"`
+	RefreshedAt              *time.Time `db:"refreshed_at"`
+}
+
+type AuthSessionReturnRow struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func AuthSessionRowToEntity(row AuthSessionRow) *entity.AuthSession {
+	return &entity.AuthSession{
+		ID:                       row.ID,
+		UserID:                   row.UserID,
+		RefreshTokenHash:         row.RefreshTokenHash,
+		PreviousRefreshTokenHash: row.PreviousRefreshTokenHash,
+		ExpiresAt:                row.ExpiresAt,
+		RevokedAt:                row.RevokedAt,
+		CreatedAt:                row.CreatedAt,
+		RefreshedAt:              row.RefreshedAt,
+	}
+}
@@ -0,0 +1,130 @@
+package auth_session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+	"ads-mrkt/internal/market/repository/auth_session/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type database interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+type repository struct {
+	db database
+}
+
+func New(db database) *repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateAuthSession(ctx context.Context, s *entity.AuthSession) error {
+	rows, err := r.db.Query(ctx, `
+		INSERT INTO market.auth_session (user_id, refresh_token_hash, expires_at)
+		VALUES (@user_id, @refresh_token_hash, @expires_at)
+		RETURNING id, created_at`,
+		pgx.NamedArgs{
+			"user_id":            s.UserID,
+			"refresh_token_hash": s.RefreshTokenHash,
+			"expires_at":         s.ExpiresAt,
+		})
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.AuthSessionReturnRow])
+	if err != nil {
+		return err
+	}
+	s.ID = row.ID
+	s.CreatedAt = row.CreatedAt
+	return nil
+}
+
+// GetAuthSessionByRefreshTokenHash returns the session whose current or previous refresh token has the hash, or nil.
+func (r *repository) GetAuthSessionByRefreshTokenHash(ctx context.Context, tokenHash string) (*entity.AuthSession, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, refresh_token_hash, previous_refresh_token_hash, expires_at, revoked_at, created_at, refreshed_at
+		FROM market.auth_session
+		WHERE refresh_token_hash = @token_hash OR previous_refresh_token_hash = @token_hash`,
+		pgx.NamedArgs{"token_hash": tokenHash})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.AuthSessionRow])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return model.AuthSessionRowToEntity(row), nil
+}
+
+// RotateAuthSessionRefreshToken replaces the refresh token of a live session and extends it to expiresAt. It applies
+// only while the session still has the refresh token oldHash and reports whether it did.
+func (r *repository) RotateAuthSessionRefreshToken(ctx context.Context, id int64, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE market.auth_session
+		SET previous_refresh_token_hash = refresh_token_hash, refresh_token_hash = @new_hash,
+		    expires_at = @expires_at, refreshed_at = NOW()
+		WHERE id = @id AND refresh_token_hash = @old_hash AND revoked_at IS NULL AND expires_at > NOW()`,
+		pgx.NamedArgs{
+			"id":         id,
+			"old_hash":   oldHash,
+			"new_hash":   newHash,
+			"expires_at": expiresAt,
+		})
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// RevokeAuthSession revokes the session of the user and reports whether it was live.
+func (r *repository) RevokeAuthSession(ctx context.Context, id int64, userID int64) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE market.auth_session SET revoked_at = NOW()
+		WHERE id = @id AND user_id = @user_id AND revoked_at IS NULL`,
+		pgx.NamedArgs{"id": id, "user_id": userID})
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// RevokeUserAuthSessions revokes every live session of the user and returns their IDs.
+func (r *repository) RevokeUserAuthSessions(ctx context.Context, userID int64) ([]int64, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE market.auth_session SET revoked_at = NOW()
+		WHERE user_id = @user_id AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING id`,
+		pgx.NamedArgs{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+// DeleteEndedAuthSessions removes sessions that expired or were revoked before the given time and returns how many
+// were removed.
+func (r *repository) DeleteEndedAuthSessions(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `
+		DELETE FROM market.auth_session
+		WHERE expires_at < @before OR revoked_at < @before`,
+		pgx.NamedArgs{"before": before})
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
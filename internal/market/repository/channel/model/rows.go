@@ -2,6 +2,7 @@ package model
 
 import (
 	"encoding/json"
+	"time"
 
 	"ads-mrkt/internal/market/domain/entity"
 )
@@ -19,6 +20,21 @@ type ChannelStatsRow struct {
 	Stats json.RawMessage `db:"stats"`
 }
 
+type ChannelStatsModelRow struct {
+	StatsModel json.RawMessage `db:"stats_model"`
+}
+
+func ChannelStatsModelRowToEntity(row ChannelStatsModelRow) (*entity.ChannelStats, error) {
+	if len(row.StatsModel) == 0 {
+		return nil, nil
+	}
+	var stats entity.ChannelStats
+	if err := json.Unmarshal(row.StatsModel, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
 func ChannelRowToEntity(row ChannelRow) (*entity.Channel, error) {
 	var rights entity.AdminRights
 	if len(row.AdminRights) > 0 {
@@ -35,3 +51,29 @@ func ChannelRowToEntity(row ChannelRow) (*entity.Channel, error) {
 		Photo:       row.Photo,
 	}, nil
 }
+
+type ChannelStatsSnapshotRow struct {
+	ID                          int64     `db:"id"`
+	ChannelID                   int64     `db:"channel_id"`
+	FetchedAt                   time.Time `db:"fetched_at"`
+	Followers                   float64   `db:"followers"`
+	ViewsPerPost                float64   `db:"views_per_post"`
+	SharesPerPost               float64   `db:"shares_per_post"`
+	ReactionsPerPost            float64   `db:"reactions_per_post"`
+	EnabledNotificationsPercent float64   `db:"enabled_notifications_percent"`
+	Estimated                   bool      `db:"estimated"`
+}
+
+func ChannelStatsSnapshotRowToEntity(row ChannelStatsSnapshotRow) *entity.ChannelStatsSnapshot {
+	return &entity.ChannelStatsSnapshot{
+		ID:                          row.ID,
+		ChannelID:                   row.ChannelID,
+		FetchedAt:                   row.FetchedAt,
+		Followers:                   row.Followers,
+		ViewsPerPost:                row.ViewsPerPost,
+		SharesPerPost:               row.SharesPerPost,
+		ReactionsPerPost:            row.ReactionsPerPost,
+		EnabledNotificationsPercent: row.EnabledNotificationsPercent,
+		Estimated:                   row.Estimated,
+	}
+}
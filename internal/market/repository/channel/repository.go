@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"ads-mrkt/internal/market/domain/entity"
 	"ads-mrkt/internal/market/repository/channel/model"
@@ -75,6 +76,41 @@ func (r *repository) ListChannelsByAdminUserID(ctx context.Context, userID int64
 	return list, nil
 }
 
+// ListChannelsInUse returns channels with an active listing or a deal that still needs the channel
+// (from approval until the ad has run).
+func (r *repository) ListChannelsInUse(ctx context.Context) ([]*entity.Channel, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT c.id, c.access_hash, c.admin_rights, c.title, c.username, c.photo
+		FROM market.channel c
+		WHERE EXISTS (SELECT 1 FROM market.listing l WHERE l.channel_id = c.id AND l.status = 'active')
+		   OR EXISTS (SELECT 1 FROM market.deal d WHERE d.channel_id = c.id AND d.status = ANY(@deal_statuses))
+		ORDER BY c.id`,
+		pgx.NamedArgs{"deal_statuses": []string{
+			string(entity.DealStatusApproved),
+			string(entity.DealStatusWaitingEscrowDeposit),
+			string(entity.DealStatusEscrowDepositConfirmed),
+			string(entity.DealStatusInProgress),
+		}})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.ChannelRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.Channel, 0, len(slice))
+	for _, row := range slice {
+		ch, err := model.ChannelRowToEntity(row)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, ch)
+	}
+	return list, nil
+}
+
 func (r *repository) UpsertChannel(ctx context.Context, channel *entity.Channel) error {
 	adminRightsJSON, err := json.Marshal(channel.AdminRights)
 	if err != nil {
@@ -122,6 +158,139 @@ func (r *repository) UpsertChannelStats(ctx context.Context, channelID int64, st
 	return err
 }
 
+// SaveChannelStatsSnapshot stores a fetch of the channel stats as the latest stats with its typed model and
+// quality metrics (nil clears them) and appends it to the history.
+func (r *repository) SaveChannelStatsSnapshot(ctx context.Context, snapshot *entity.ChannelStatsSnapshot, latest json.RawMessage, statsModel *entity.ChannelStats, quality *entity.ChannelQuality) (err error) {
+	txCtx, beginErr := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if beginErr != nil {
+		return beginErr
+	}
+	defer func() {
+		_ = r.db.EndTx(txCtx, err, "SaveChannelStatsSnapshot")
+	}()
+
+	if err = r.UpsertChannelStats(txCtx, snapshot.ChannelID, latest); err != nil {
+		return err
+	}
+	if err = r.setChannelStatsModel(txCtx, snapshot.ChannelID, statsModel); err != nil {
+		return err
+	}
+	if err = r.setChannelQuality(txCtx, snapshot.ChannelID, quality); err != nil {
+		return err
+	}
+	_, err = r.db.Exec(txCtx, `
+		INSERT INTO market.channel_stats_snapshot (channel_id, fetched_at, followers, views_per_post, shares_per_post,
+		                                           reactions_per_post, enabled_notifications_percent, estimated, stats)
+		VALUES (@channel_id, @fetched_at, @followers, @views_per_post, @shares_per_post,
+		        @reactions_per_post, @enabled_notifications_percent, @estimated, @stats)`,
+		pgx.NamedArgs{
+			"channel_id":                    snapshot.ChannelID,
+			"fetched_at":                    snapshot.FetchedAt,
+			"followers":                     snapshot.Followers,
+			"views_per_post":                snapshot.ViewsPerPost,
+			"shares_per_post":               snapshot.SharesPerPost,
+			"reactions_per_post":            snapshot.ReactionsPerPost,
+			"enabled_notifications_percent": snapshot.EnabledNotificationsPercent,
+			"estimated":                     snapshot.Estimated,
+			"stats":                         snapshot.Stats,
+		})
+	return err
+}
+
+func (r *repository) setChannelStatsModel(ctx context.Context, channelID int64, statsModel *entity.ChannelStats) error {
+	var raw json.RawMessage
+	if statsModel != nil {
+		var err error
+		if raw, err = json.Marshal(statsModel); err != nil {
+			return err
+		}
+	}
+	_, err := r.db.Exec(ctx, `
+		UPDATE market.channel_stats SET stats_model = @stats_model WHERE channel_id = @channel_id`,
+		pgx.NamedArgs{"channel_id": channelID, "stats_model": raw})
+	return err
+}
+
+// GetChannelStatsModel returns the typed stats of the channel, or nil when they were not built yet.
+func (r *repository) GetChannelStatsModel(ctx context.Context, channelID int64) (*entity.ChannelStats, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT stats_model FROM market.channel_stats WHERE channel_id = @channel_id`,
+		pgx.NamedArgs{"channel_id": channelID})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.ChannelStatsModelRow])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return model.ChannelStatsModelRowToEntity(row)
+}
+
+func (r *repository) setChannelQuality(ctx context.Context, channelID int64, q *entity.ChannelQuality) error {
+	args := pgx.NamedArgs{"channel_id": channelID}
+	if q != nil {
+		flags := q.Flags
+		if flags == nil {
+			flags = []string{}
+		}
+		args["quality_score"] = q.Score
+		args["err_percent"] = q.ERRPercent
+		args["avg_reach"] = q.AvgReach
+		args["shares_ratio_percent"] = q.SharesRatioPercent
+		args["reactions_ratio_percent"] = q.ReactionsRatioPercent
+		args["notifications_enabled_percent"] = q.NotificationsEnabledPercent
+		args["max_daily_growth_percent"] = q.MaxDailyGrowthPercent
+		args["suspicious"] = q.Suspicious
+		args["quality_flags"] = flags
+	} else {
+		for _, k := range []string{"quality_score", "err_percent", "avg_reach", "shares_ratio_percent", "reactions_ratio_percent",
+			"notifications_enabled_percent", "max_daily_growth_percent"} {
+			args[k] = nil
+		}
+		args["suspicious"] = false
+		args["quality_flags"] = []string{}
+	}
+	_, err := r.db.Exec(ctx, `
+		UPDATE market.channel_stats
+		SET quality_score = @quality_score, err_percent = @err_percent, avg_reach = @avg_reach,
+		    shares_ratio_percent = @shares_ratio_percent, reactions_ratio_percent = @reactions_ratio_percent,
+		    notifications_enabled_percent = @notifications_enabled_percent, max_daily_growth_percent = @max_daily_growth_percent,
+		    suspicious = @suspicious, quality_flags = @quality_flags
+		WHERE channel_id = @channel_id`,
+		args)
+	return err
+}
+
+// ListChannelStatsSnapshots returns the channel's stats history since the given time, oldest first, without the raw stats.
+func (r *repository) ListChannelStatsSnapshots(ctx context.Context, channelID int64, since time.Time) ([]*entity.ChannelStatsSnapshot, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, channel_id, fetched_at, followers, views_per_post, shares_per_post, reactions_per_post, enabled_notifications_percent,
+		       estimated
+		FROM market.channel_stats_snapshot
+		WHERE channel_id = @channel_id AND fetched_at >= @since
+		ORDER BY fetched_at`,
+		pgx.NamedArgs{"channel_id": channelID, "since": since})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.ChannelStatsSnapshotRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.ChannelStatsSnapshot, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.ChannelStatsSnapshotRowToEntity(row))
+	}
+	return list, nil
+}
+
 func (r *repository) GetChannelStats(ctx context.Context, channelID int64) (json.RawMessage, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT stats FROM market.channel_stats WHERE channel_id = @channel_id`,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"ads-mrkt/internal/market/domain/entity"
 	"ads-mrkt/internal/market/repository/channel_admin/model"
 
 	"github.com/jackc/pgx/v5"
@@ -73,3 +74,22 @@ func (r *repository) IsChannelAdmin(ctx context.Context, userID, channelID int64
 	}
 	return true, nil
 }
+
+// ReplaceChannelAdmins replaces the channel's admins with the given ones in one transaction.
+func (r *repository) ReplaceChannelAdmins(ctx context.Context, channelID int64, admins []*entity.ChannelAdmin) (err error) {
+	txCtx, beginErr := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if beginErr != nil {
+		return beginErr
+	}
+	defer func() { _ = r.db.EndTx(txCtx, err, "ReplaceChannelAdmins") }()
+
+	if err = r.DeleteChannelAdmins(txCtx, channelID); err != nil {
+		return err
+	}
+	for _, a := range admins {
+		if err = r.UpsertChannelAdmin(txCtx, a.UserID, channelID, string(a.Role)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
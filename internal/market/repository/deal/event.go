@@ -0,0 +1,87 @@
+package deal
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"ads-mrkt/internal/market/domain"
+	"ads-mrkt/internal/market/domain/entity"
+	"ads-mrkt/internal/market/repository/deal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// withDealEvent turns a status update of market.deal d joined to its previous row old, returning d.id,
+// old.status AS from_status and d.status AS to_status, into a statement that also appends each transition to
+// market.deal_event and, when @webhook_event is set, queues @webhook_payload for the webhooks of @lessor_id and
+// @lessee_id. The statement returns the IDs of the moved deals; old.status is exact because every update checks the
+// status it moves the deal from.
+func withDealEvent(update string) string {
+	return `
+		WITH moved AS (` + update + `
+		), webhook_deliveries AS (
+			INSERT INTO market.webhook_delivery (webhook_id, deal_id, event, payload)
+			SELECT w.id, moved.id, @webhook_event::text, @webhook_payload::jsonb
+			FROM moved
+			JOIN market.webhook w ON w.user_id IN (@lessor_id, @lessee_id)
+			WHERE @webhook_event::text <> ''
+		)
+		INSERT INTO market.deal_event (deal_id, from_status, to_status, actor_type, actor_user_id, actor_name, reason, tx_hash, message_id)
+		SELECT id, from_status, to_status, @actor_type, @actor_user_id, @actor_name, @reason, @tx_hash, @message_id
+		FROM moved
+		RETURNING deal_id AS id`
+}
+
+// transitionArgs adds the deal_event columns of the transition to args.
+func transitionArgs(args pgx.NamedArgs, t entity.DealTransition) pgx.NamedArgs {
+	args["actor_type"] = string(t.ActorType)
+	args["actor_user_id"] = t.ActorUserID
+	args["actor_name"] = t.ActorName
+	args["reason"] = t.Reason
+	args["tx_hash"] = t.TxHash
+	args["message_id"] = t.MessageID
+	return args
+}
+
+// webhookArgs adds the webhook delivery queued by the transition of d to status to to args; rules without a
+// webhook event queue nothing.
+func webhookArgs(args pgx.NamedArgs, d *entity.Deal, to entity.DealStatus, rule *domain.DealTransitionRule) (pgx.NamedArgs, error) {
+	args["webhook_event"] = string(rule.Webhook)
+	args["lessor_id"] = d.LessorID
+	args["lessee_id"] = d.LesseeID
+	args["webhook_payload"] = nil
+	if rule.Webhook == "" {
+		return args, nil
+	}
+	payload, err := json.Marshal(entity.NewWebhookDealPayload(rule.Webhook, d, d.Status, to, time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	args["webhook_payload"] = payload
+	return args, nil
+}
+
+// ListDealEvents returns the status history of the deal, oldest first.
+func (r *repository) ListDealEvents(ctx context.Context, dealID int64) ([]*entity.DealEvent, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, deal_id, from_status, to_status, actor_type, actor_user_id, actor_name, reason, tx_hash, message_id, created_at
+		FROM market.deal_event
+		WHERE deal_id = @deal_id
+		ORDER BY id`,
+		pgx.NamedArgs{"deal_id": dealID})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.DealEventRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.DealEvent, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.DealEventRowToEntity(row))
+	}
+	return list, nil
+}
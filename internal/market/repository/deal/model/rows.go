@@ -25,6 +25,7 @@ type DealRow struct {
 	EscrowReleaseTime   *time.Time      `db:"escrow_release_time"`
 	LessorPayoutAddress *string         `db:"lessor_payout_address"`
 	LesseePayoutAddress *string         `db:"lessee_payout_address"`
+	Version             int64           `db:"version"`
 	CreatedAt           time.Time       `db:"created_at"`
 	UpdatedAt           time.Time       `db:"updated_at"`
 }
@@ -54,7 +55,73 @@ func DealRowToEntity(row DealRow) *entity.Deal {
 		EscrowReleaseTime:   row.EscrowReleaseTime,
 		LessorPayoutAddress: row.LessorPayoutAddress,
 		LesseePayoutAddress: row.LesseePayoutAddress,
+		Version:             row.Version,
 		CreatedAt:           row.CreatedAt,
 		UpdatedAt:           row.UpdatedAt,
 	}
 }
+
+type DealSignatureRow struct {
+	DealID        int64     `db:"deal_id"`
+	UserID        int64     `db:"user_id"`
+	Terms         string    `db:"terms"`
+	TermsHash     string    `db:"terms_hash"`
+	WalletAddress string    `db:"wallet_address"`
+	PublicKey     string    `db:"public_key"`
+	Domain        string    `db:"domain"`
+	SignedAt      time.Time `db:"signed_at"`
+	Signature     string    `db:"signature"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+func DealSignatureRowToEntity(row DealSignatureRow) *entity.DealSignature {
+	return &entity.DealSignature{
+		DealID:        row.DealID,
+		UserID:        row.UserID,
+		Terms:         row.Terms,
+		TermsHash:     row.TermsHash,
+		WalletAddress: row.WalletAddress,
+		PublicKey:     row.PublicKey,
+		Domain:        row.Domain,
+		SignedAt:      row.SignedAt,
+		Signature:     row.Signature,
+		CreatedAt:     row.CreatedAt,
+	}
+}
+
+type DealEventRow struct {
+	ID          int64     `db:"id"`
+	DealID      int64     `db:"deal_id"`
+	FromStatus  *string   `db:"from_status"`
+	ToStatus    string    `db:"to_status"`
+	ActorType   string    `db:"actor_type"`
+	ActorUserID *int64    `db:"actor_user_id"`
+	ActorName   string    `db:"actor_name"`
+	Reason      string    `db:"reason"`
+	TxHash      *string   `db:"tx_hash"`
+	MessageID   *int64    `db:"message_id"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+func DealEventRowToEntity(row DealEventRow) *entity.DealEvent {
+	var from *entity.DealStatus
+	if row.FromStatus != nil {
+		status := entity.DealStatus(*row.FromStatus)
+		from = &status
+	}
+	return &entity.DealEvent{
+		ID:         row.ID,
+		DealID:     row.DealID,
+		FromStatus: from,
+		ToStatus:   entity.DealStatus(row.ToStatus),
+		DealTransition: entity.DealTransition{
+			ActorType:   entity.DealActorType(row.ActorType),
+			ActorUserID: row.ActorUserID,
+			ActorName:   row.ActorName,
+			Reason:      row.Reason,
+			TxHash:      row.TxHash,
+			MessageID:   row.MessageID,
+		},
+		CreatedAt: row.CreatedAt,
+	}
+}
@@ -29,12 +29,22 @@ func New(db database) *repository {
 	return &repository{db: db}
 }
 
-func (r *repository) CreateDeal(ctx context.Context, d *entity.Deal) error {
+// CreateDeal inserts the deal and records its creation, with no previous status, in the deal history.
+func (r *repository) CreateDeal(ctx context.Context, d *entity.Deal, t entity.DealTransition) error {
+	if _, err := domain.CheckDealTransition("", d.Status, t); err != nil {
+		return err
+	}
 	rows, err := r.db.Query(ctx, `
-		INSERT INTO market.deal (listing_id, lessor_id, lessee_id, channel_id, type, duration, price, escrow_amount, details, status)
-		VALUES (@listing_id, @lessor_id, @lessee_id, @channel_id, @type, @duration, @price, @escrow_amount, @details, @status)
-		RETURNING id, created_at, updated_at`,
-		pgx.NamedArgs{
+		WITH created AS (
+			INSERT INTO market.deal (listing_id, lessor_id, lessee_id, channel_id, type, duration, price, escrow_amount, details, status)
+			VALUES (@listing_id, @lessor_id, @lessee_id, @channel_id, @type, @duration, @price, @escrow_amount, @details, @status)
+			RETURNING id, status, created_at, updated_at
+		), event AS (
+			INSERT INTO market.deal_event (deal_id, to_status, actor_type, actor_user_id, actor_name, reason)
+			SELECT id, status, @actor_type, @actor_user_id, @actor_name, @reason FROM created
+		)
+		SELECT id, created_at, updated_at FROM created`,
+		transitionArgs(pgx.NamedArgs{
 			"listing_id":    d.ListingID,
 			"lessor_id":     d.LessorID,
 			"lessee_id":     d.LesseeID,
@@ -45,7 +55,7 @@ func (r *repository) CreateDeal(ctx context.Context, d *entity.Deal) error {
 			"escrow_amount": d.EscrowAmount,
 			"details":       d.Details,
 			"status":        d.Status,
-		})
+		}, t))
 	if err != nil {
 		return err
 	}
@@ -64,7 +74,7 @@ func (r *repository) CreateDeal(ctx context.Context, d *entity.Deal) error {
 func (r *repository) GetDealByID(ctx context.Context, id int64) (*entity.Deal, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT id, listing_id, lessor_id, lessee_id, channel_id, type, duration, price, escrow_amount, details,
-		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, created_at, updated_at
+		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, version, created_at, updated_at
 		FROM market.deal WHERE id = @id`,
 		pgx.NamedArgs{"id": id})
 	if err != nil {
@@ -85,7 +95,7 @@ func (r *repository) GetDealByID(ctx context.Context, id int64) (*entity.Deal, e
 func (r *repository) ListDealsApprovedWithoutEscrow(ctx context.Context) ([]*entity.Deal, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT id, listing_id, lessor_id, lessee_id, channel_id, type, duration, price, escrow_amount, details,
-		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, created_at, updated_at
+		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, version, created_at, updated_at
 		FROM market.deal
 		WHERE status = @status AND escrow_address IS NULL
 		ORDER BY id ASC`,
@@ -109,7 +119,7 @@ func (r *repository) ListDealsApprovedWithoutEscrow(ctx context.Context) ([]*ent
 func (r *repository) GetDealsByListingID(ctx context.Context, listingID int64) ([]*entity.Deal, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT id, listing_id, lessor_id, lessee_id, channel_id, type, duration, price, escrow_amount, details,
-		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, created_at, updated_at
+		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, version, created_at, updated_at
 		FROM market.deal WHERE listing_id = @listing_id ORDER BY updated_at DESC`,
 		pgx.NamedArgs{"listing_id": listingID})
 	if err != nil {
@@ -131,7 +141,7 @@ func (r *repository) GetDealsByListingID(ctx context.Context, listingID int64) (
 func (r *repository) GetDealsByListingIDForUser(ctx context.Context, listingID int64, userID int64) ([]*entity.Deal, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT id, listing_id, lessor_id, lessee_id, channel_id, type, duration, price, escrow_amount, details,
-		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, created_at, updated_at
+		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, version, created_at, updated_at
 		FROM market.deal
 		WHERE listing_id = @listing_id AND (lessor_id = @user_id OR lessee_id = @user_id)
 		ORDER BY updated_at DESC`,
@@ -160,10 +170,14 @@ func (r *repository) ListDealsWaitingEscrowRefund(ctx context.Context) ([]*entit
 	return r.listDealsByStatus(ctx, entity.DealStatusWaitingEscrowRefund)
 }
 
+func (r *repository) ListDealsEscrowTransferFailed(ctx context.Context) ([]*entity.Deal, error) {
+	return r.listDealsByStatus(ctx, entity.DealStatusEscrowTransferFailed)
+}
+
 func (r *repository) listDealsByStatus(ctx context.Context, status entity.DealStatus) ([]*entity.Deal, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT id, listing_id, lessor_id, lessee_id, channel_id, type, duration, price, escrow_amount, details,
-		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, created_at, updated_at
+		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, version, created_at, updated_at
 		FROM market.deal
 		WHERE status = @status
 		ORDER BY id ASC`,
@@ -186,7 +200,7 @@ func (r *repository) listDealsByStatus(ctx context.Context, status entity.DealSt
 func (r *repository) ListDealsEscrowDepositConfirmedWithoutPostMessage(ctx context.Context) ([]*entity.Deal, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT d.id, d.listing_id, d.lessor_id, d.lessee_id, d.channel_id, d.type, d.duration, d.price, d.escrow_amount, d.details,
-		       d.lessor_signature, d.lessee_signature, d.status, d.escrow_address, d.escrow_release_time, d.lessor_payout_address, d.lessee_payout_address, d.created_at, d.updated_at
+		       d.lessor_signature, d.lessee_signature, d.status, d.escrow_address, d.escrow_release_time, d.lessor_payout_address, d.lessee_payout_address, d.version, d.created_at, d.updated_at
 		FROM market.deal d
 		LEFT JOIN market.deal_post_message dpm ON dpm.deal_id = d.id
 		WHERE d.status = @status AND dpm.id IS NULL
@@ -210,7 +224,7 @@ func (r *repository) ListDealsEscrowDepositConfirmedWithoutPostMessage(ctx conte
 func (r *repository) ListDealsByUserID(ctx context.Context, userID int64) ([]*entity.Deal, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT id, listing_id, lessor_id, lessee_id, channel_id, type, duration, price, escrow_amount, details,
-		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, created_at, updated_at
+		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, version, created_at, updated_at
 		FROM market.deal
 		WHERE lessor_id = @user_id OR lessee_id = @user_id
 		ORDER BY updated_at DESC`,
@@ -233,9 +247,14 @@ func (r *repository) ListDealsByUserID(ctx context.Context, userID int64) ([]*en
 
 func (r *repository) UpdateDealDraftFieldsAndClearSignatures(ctx context.Context, d *entity.Deal) error {
 	_, err := r.db.Exec(ctx, `
+		WITH cleared AS (
+			DELETE FROM market.deal_signature s
+			USING market.deal d
+			WHERE s.deal_id = d.id AND d.id = @id AND d.status = @status_draft
+		)
 		UPDATE market.deal
 		SET type = @type, duration = @duration, price = @price, escrow_amount = @escrow_amount, details = @details,
-		    lessor_signature = NULL, lessee_signature = NULL, updated_at = NOW()
+		    lessor_signature = NULL, lessee_signature = NULL, version = version + 1, updated_at = NOW()
 		WHERE id = @id AND status = @status_draft`,
 		pgx.NamedArgs{
 			"id":            d.ID,
@@ -250,39 +269,32 @@ func (r *repository) UpdateDealDraftFieldsAndClearSignatures(ctx context.Context
 }
 
 func (r *repository) SetDealLessorSignature(ctx context.Context, dealID int64, sig string) error {
-	_, err := r.db.Exec(ctx, `UPDATE market.deal SET lessor_signature = @sig, updated_at = NOW() WHERE id = @id`,
+	_, err := r.db.Exec(ctx, `UPDATE market.deal SET lessor_signature = @sig, version = version + 1, updated_at = NOW() WHERE id = @id`,
 		pgx.NamedArgs{"sig": sig, "id": dealID})
 	return err
 }
 
 func (r *repository) SetDealLesseeSignature(ctx context.Context, dealID int64, sig string) error {
-	_, err := r.db.Exec(ctx, `UPDATE market.deal SET lessee_signature = @sig, updated_at = NOW() WHERE id = @id`,
+	_, err := r.db.Exec(ctx, `UPDATE market.deal SET lessee_signature = @sig, version = version + 1, updated_at = NOW() WHERE id = @id`,
 		pgx.NamedArgs{"sig": sig, "id": dealID})
 	return err
 }
 
-func (r *repository) SetDealPayoutAddress(ctx context.Context, dealID int64, userID int64, payoutAddressRaw string) error {
+// SetDealPayoutAddress sets the user's side payout address while the deal is still in status.
+func (r *repository) SetDealPayoutAddress(ctx context.Context, dealID int64, userID int64, payoutAddressRaw string, status entity.DealStatus) error {
 	_, err := r.db.Exec(ctx, `
 		UPDATE market.deal
 		SET lessor_payout_address = CASE WHEN @user_id = lessor_id THEN @payout ELSE lessor_payout_address END,
 		    lessee_payout_address = CASE WHEN @user_id = lessee_id THEN @payout ELSE lessee_payout_address END,
-		    updated_at = NOW()
-		WHERE id = @deal_id AND status = @status_draft AND (@user_id = lessor_id OR @user_id = lessee_id)`,
-		pgx.NamedArgs{"deal_id": dealID, "user_id": userID, "payout": payoutAddressRaw, "status_draft": string(entity.DealStatusDraft)})
-	return err
-}
-
-func (r *repository) SetDealStatusApproved(ctx context.Context, dealID int64) error {
-	_, err := r.db.Exec(ctx, `
-		UPDATE market.deal SET status = @status, updated_at = NOW() WHERE id = @id`,
-		pgx.NamedArgs{
-			"id":     dealID,
-			"status": string(entity.DealStatusApproved),
-		})
+		    version = version + 1, updated_at = NOW()
+		WHERE id = @deal_id AND status = @status AND (@user_id = lessor_id OR @user_id = lessee_id)`,
+		pgx.NamedArgs{"deal_id": dealID, "user_id": userID, "payout": payoutAddressRaw, "status": string(status)})
 	return err
 }
 
-func (r *repository) SignDealInTx(ctx context.Context, dealID int64, userID int64, sig string) (err error) {
+// SignDealInTx records the user's wallet signature over the deal terms and approves the deal once both
+// parties signed the current terms.
+func (r *repository) SignDealInTx(ctx context.Context, dealID int64, userID int64, sig *entity.DealSignature) (err error) {
 	txCtx, beginErr := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
 	if beginErr != nil {
 		return beginErr
@@ -304,45 +316,83 @@ func (r *repository) SignDealInTx(ctx context.Context, dealID int64, userID int6
 	if userID != existing.LessorID && userID != existing.LesseeID {
 		return marketerrors.ErrUnauthorizedSide
 	}
+	if domain.ComputeDealTerms(existing).TermsHash != sig.TermsHash {
+		return marketerrors.ErrInvalidDealSignature // terms changed while signing
+	}
 
 	if userID == existing.LessorID {
-		if err = r.SetDealLessorSignature(txCtx, dealID, sig); err != nil {
+		if err = r.SetDealLessorSignature(txCtx, dealID, sig.TermsHash); err != nil {
 			return err
 		}
 	} else {
-		if err = r.SetDealLesseeSignature(txCtx, dealID, sig); err != nil {
+		if err = r.SetDealLesseeSignature(txCtx, dealID, sig.TermsHash); err != nil {
 			return err
 		}
 	}
+	if err = r.upsertDealSignature(txCtx, dealID, userID, sig); err != nil {
+		return err
+	}
 
 	updated, err := r.GetDealByID(txCtx, dealID)
 	if err != nil || updated == nil {
 		return err
 	}
 	if domain.DealSignaturesMatch(updated) {
-		return r.SetDealStatusApproved(txCtx, dealID)
+		return r.TransitionDeal(txCtx, updated, entity.DealStatusApproved, entity.UserDealTransition(userID, "both parties signed the deal terms"))
 	}
 	return nil
 }
 
-func (r *repository) SetDealEscrowAddress(ctx context.Context, dealID int64, address string) error {
+func (r *repository) upsertDealSignature(ctx context.Context, dealID int64, userID int64, sig *entity.DealSignature) error {
 	_, err := r.db.Exec(ctx, `
-		UPDATE market.deal
-		SET escrow_address = @address, status = @status_waiting_escrow_deposit, updated_at = NOW()
-		WHERE id = @id AND status = @status_approved`,
+		INSERT INTO market.deal_signature (deal_id, user_id, terms, terms_hash, wallet_address, public_key, domain, signed_at, signature)
+		VALUES (@deal_id, @user_id, @terms, @terms_hash, @wallet_address, @public_key, @domain, @signed_at, @signature)
+		ON CONFLICT (deal_id, user_id) DO UPDATE
+		SET terms = EXCLUDED.terms, terms_hash = EXCLUDED.terms_hash, wallet_address = EXCLUDED.wallet_address,
+		    public_key = EXCLUDED.public_key, domain = EXCLUDED.domain, signed_at = EXCLUDED.signed_at,
+		    signature = EXCLUDED.signature, created_at = NOW()`,
 		pgx.NamedArgs{
-			"address":                       address,
-			"id":                            dealID,
-			"status_approved":               string(entity.DealStatusApproved),
-			"status_waiting_escrow_deposit": string(entity.DealStatusWaitingEscrowDeposit),
+			"deal_id":        dealID,
+			"user_id":        userID,
+			"terms":          sig.Terms,
+			"terms_hash":     sig.TermsHash,
+			"wallet_address": sig.WalletAddress,
+			"public_key":     sig.PublicKey,
+			"domain":         sig.Domain,
+			"signed_at":      sig.SignedAt,
+			"signature":      sig.Signature,
 		})
 	return err
 }
 
+// ListDealSignatures returns the wallet signatures recorded for the deal.
+func (r *repository) ListDealSignatures(ctx context.Context, dealID int64) ([]*entity.DealSignature, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT deal_id, user_id, terms, terms_hash, wallet_address, public_key, domain, signed_at, signature, created_at
+		FROM market.deal_signature
+		WHERE deal_id = @deal_id
+		ORDER BY created_at`,
+		pgx.NamedArgs{"deal_id": dealID})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.DealSignatureRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.DealSignature, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.DealSignatureRowToEntity(row))
+	}
+	return list, nil
+}
+
 func (r *repository) GetDealByEscrowAddress(ctx context.Context, escrowAddress string) (*entity.Deal, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT id, listing_id, lessor_id, lessee_id, channel_id, type, duration, price, escrow_amount, details,
-		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, created_at, updated_at
+		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, version, created_at, updated_at
 		FROM market.deal
 		WHERE escrow_address = @escrow_address AND status = @status`,
 		pgx.NamedArgs{
@@ -363,22 +413,10 @@ func (r *repository) GetDealByEscrowAddress(ctx context.Context, escrowAddress s
 	return model.DealRowToEntity(row), nil
 }
 
-func (r *repository) SetDealStatusExpiredByEscrowAddress(ctx context.Context, escrowAddress string) error {
-	_, err := r.db.Exec(ctx, `
-		UPDATE market.deal SET status = @status, updated_at = NOW()
-		WHERE escrow_address = @escrow_address AND status = @status_waiting`,
-		pgx.NamedArgs{
-			"escrow_address": escrowAddress,
-			"status":         string(entity.DealStatusExpired),
-			"status_waiting": string(entity.DealStatusWaitingEscrowDeposit),
-		})
-	return err
-}
-
 func (r *repository) ListDealsWaitingEscrowDepositOlderThan(ctx context.Context, before time.Time) ([]*entity.Deal, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT id, listing_id, lessor_id, lessee_id, channel_id, type, duration, price, escrow_amount, details,
-		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, created_at, updated_at
+		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, version, created_at, updated_at
 		FROM market.deal
 		WHERE status = @status AND updated_at < @before
 		ORDER BY id ASC`,
@@ -401,22 +439,41 @@ func (r *repository) ListDealsWaitingEscrowDepositOlderThan(ctx context.Context,
 	return list, nil
 }
 
-func (r *repository) SetDealStatusExpiredByDealID(ctx context.Context, dealID int64) error {
-	_, err := r.db.Exec(ctx, `
-		UPDATE market.deal SET status = @status, updated_at = NOW()
-		WHERE id = @id AND status = @status_waiting`,
+// ListDealsWithEscrowForReconciliation returns deals that have an escrow wallet and are either still open
+// or were closed after closedAfter.
+func (r *repository) ListDealsWithEscrowForReconciliation(ctx context.Context, closedAfter time.Time) ([]*entity.Deal, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, listing_id, lessor_id, lessee_id, channel_id, type, duration, price, escrow_amount, details,
+		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, version, created_at, updated_at
+		FROM market.deal
+		WHERE escrow_address IS NOT NULL
+		  AND (status NOT IN (@completed, @expired, @rejected) OR updated_at > @closed_after)
+		ORDER BY id ASC`,
 		pgx.NamedArgs{
-			"id":             dealID,
-			"status":         string(entity.DealStatusExpired),
-			"status_waiting": string(entity.DealStatusWaitingEscrowDeposit),
+			"completed":    string(entity.DealStatusCompleted),
+			"expired":      string(entity.DealStatusExpired),
+			"rejected":     string(entity.DealStatusRejected),
+			"closed_after": closedAfter,
 		})
-	return err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.DealRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.Deal, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.DealRowToEntity(row))
+	}
+	return list, nil
 }
 
 func (r *repository) ListDealsEscrowConfirmedToComplete(ctx context.Context) ([]*entity.Deal, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT id, listing_id, lessor_id, lessee_id, channel_id, type, duration, price, escrow_amount, details,
-		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, created_at, updated_at
+		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, version, created_at, updated_at
 		FROM market.deal
 		WHERE status = @s1 OR status = @s2
 		ORDER BY id ASC`,
@@ -439,66 +496,148 @@ func (r *repository) ListDealsEscrowConfirmedToComplete(ctx context.Context) ([]
 	return list, nil
 }
 
-func (r *repository) SetDealStatusCompleted(ctx context.Context, dealID int64) error {
-	_, err := r.db.Exec(ctx, `
-		UPDATE market.deal SET status = @status, updated_at = NOW()
-		WHERE id = @id AND (status = @s1 OR status = @s2)`,
-		pgx.NamedArgs{
-			"id":     dealID,
-			"status": string(entity.DealStatusCompleted),
-			"s1":     string(entity.DealStatusEscrowReleaseConfirmed),
-			"s2":     string(entity.DealStatusEscrowRefundConfirmed),
-		})
-	return err
+// TransitionDeal moves d to status to, if the deal state machine lets t make that transition, and records it in the
+// deal history. The update also saves the escrow and payout addresses of d. It applies only while the deal still has
+// the status and version d was read with and returns ErrDealChanged otherwise; on success d has the new status and
+// version. Transitions with a webhook event queue it for the webhooks of both sides in the same statement.
+func (r *repository) TransitionDeal(ctx context.Context, d *entity.Deal, to entity.DealStatus, t entity.DealTransition) error {
+	rule, err := domain.CheckDealTransition(d.Status, to, t)
+	if err != nil {
+		return err
+	}
+	args, err := webhookArgs(pgx.NamedArgs{
+		"id":                    d.ID,
+		"from_status":           string(d.Status),
+		"to_status":             string(to),
+		"version":               d.Version,
+		"escrow_address":        d.EscrowAddress,
+		"lessor_payout_address": d.LessorPayoutAddress,
+		"lessee_payout_address": d.LesseePayoutAddress,
+	}, d, to, rule)
+	if err != nil {
+		return err
+	}
+	cmd, err := r.db.Exec(ctx, withDealEvent(`
+		UPDATE market.deal d
+		SET status = @to_status, version = d.version + 1, escrow_address = @escrow_address,
+		    lessor_payout_address = @lessor_payout_address, lessee_payout_address = @lessee_payout_address, updated_at = NOW()
+		FROM market.deal old
+		WHERE old.id = d.id AND d.id = @id AND d.status = @from_status AND d.version = @version
+		RETURNING d.id, old.status AS from_status, d.status AS to_status`),
+		transitionArgs(args, t))
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return marketerrors.ErrDealChanged
+	}
+	d.Status = to
+	d.Version++
+	return nil
 }
 
-func (r *repository) SetDealStatusEscrowDepositConfirmed(ctx context.Context, dealID int64) error {
-	_, err := r.db.Exec(ctx, `
-		UPDATE market.deal SET status = @status, updated_at = NOW()
-		WHERE id = @id AND status = @status_waiting`,
-		pgx.NamedArgs{
-			"id":             dealID,
-			"status":         string(entity.DealStatusEscrowDepositConfirmed),
-			"status_waiting": string(entity.DealStatusWaitingEscrowDeposit),
-		})
-	return err
-}
+// StopChannelDeals stops the channel's deals that can no longer run once the marketplace lost the channel: funded
+// deals whose ad has not run to its end (escrow deposit confirmed, or in progress without a passed post) move to
+// waiting_escrow_refund and their live posts fail; approved deals and deals waiting for the deposit expire, so they
+// can no longer be funded. Everything happens in one transaction. Returns the refunded and the expired deal IDs.
+func (r *repository) StopChannelDeals(ctx context.Context, channelID int64, t entity.DealTransition) (refunded, expired []int64, err error) {
+	txCtx, beginErr := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if beginErr != nil {
+		return nil, nil, beginErr
+	}
+	defer func() { _ = r.db.EndTx(txCtx, err, "StopChannelDeals") }()
 
-func (r *repository) SetDealStatusEscrowReleaseConfirmed(ctx context.Context, dealID int64) error {
-	_, err := r.db.Exec(ctx, `
-		UPDATE market.deal SET status = @status, updated_at = NOW()
-		WHERE id = @id AND status = @status_waiting`,
+	rows, err := r.db.Query(txCtx, `
+		SELECT id, listing_id, lessor_id, lessee_id, channel_id, type, duration, price, escrow_amount, details,
+		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, version, created_at, updated_at
+		FROM market.deal d
+		WHERE channel_id = @channel_id
+		  AND (status IN (@status_approved, @status_waiting_deposit, @status_deposit_confirmed)
+		       OR (status = @status_in_progress AND NOT EXISTS (
+		           SELECT 1 FROM market.deal_post_message dpm WHERE dpm.deal_id = d.id AND dpm.status = 'passed')))
+		ORDER BY id
+		FOR UPDATE`,
 		pgx.NamedArgs{
-			"id":             dealID,
-			"status":         string(entity.DealStatusEscrowReleaseConfirmed),
-			"status_waiting": string(entity.DealStatusWaitingEscrowRelease),
+			"channel_id":               channelID,
+			"status_approved":          string(entity.DealStatusApproved),
+			"status_waiting_deposit":   string(entity.DealStatusWaitingEscrowDeposit),
+			"status_deposit_confirmed": string(entity.DealStatusEscrowDepositConfirmed),
+			"status_in_progress":       string(entity.DealStatusInProgress),
 		})
-	return err
+	if err != nil {
+		return nil, nil, err
+	}
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.DealRow])
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, row := range slice {
+		d := model.DealRowToEntity(row)
+		to := entity.DealStatusWaitingEscrowRefund
+		if d.Status == entity.DealStatusApproved || d.Status == entity.DealStatusWaitingEscrowDeposit {
+			to = entity.DealStatusExpired
+		}
+		if err = r.TransitionDeal(txCtx, d, to, t); err != nil {
+			return nil, nil, err
+		}
+		if to == entity.DealStatusExpired {
+			expired = append(expired, row.ID)
+		} else {
+			refunded = append(refunded, row.ID)
+		}
+	}
+	if len(refunded) == 0 {
+		return refunded, expired, nil
+	}
+	_, err = r.db.Exec(txCtx, `
+		UPDATE market.deal_post_message SET status = 'failed', updated_at = NOW()
+		WHERE deal_id = ANY(@ids) AND status = 'exists'`,
+		pgx.NamedArgs{"ids": refunded})
+	if err != nil {
+		return nil, nil, err
+	}
+	return refunded, expired, nil
 }
 
-func (r *repository) SetDealStatusEscrowRefundConfirmed(ctx context.Context, dealID int64) error {
-	_, err := r.db.Exec(ctx, `
-		UPDATE market.deal SET status = @status, updated_at = NOW()
-		WHERE id = @id AND status = @status_waiting`,
-		pgx.NamedArgs{
-			"id":             dealID,
-			"status":         string(entity.DealStatusEscrowRefundConfirmed),
-			"status_waiting": string(entity.DealStatusWaitingEscrowRefund),
-		})
-	return err
-}
+// SearchDeals returns deals matching the search, most recently updated first.
+func (r *repository) SearchDeals(ctx context.Context, search *entity.DealSearch) ([]*entity.Deal, error) {
+	q := `
+		SELECT id, listing_id, lessor_id, lessee_id, channel_id, type, duration, price, escrow_amount, details,
+		       lessor_signature, lessee_signature, status, escrow_address, escrow_release_time, lessor_payout_address, lessee_payout_address, version, created_at, updated_at
+		FROM market.deal
+		WHERE TRUE`
+	args := pgx.NamedArgs{"limit": search.Limit, "offset": search.Offset}
+	if search.Status != "" {
+		q += ` AND status = @status`
+		args["status"] = string(search.Status)
+	}
+	if search.UserID != nil {
+		q += ` AND (lessor_id = @user_id OR lessee_id = @user_id)`
+		args["user_id"] = *search.UserID
+	}
+	if search.ListingID != nil {
+		q += ` AND listing_id = @listing_id`
+		args["listing_id"] = *search.ListingID
+	}
+	if search.ChannelID != nil {
+		q += ` AND channel_id = @channel_id`
+		args["channel_id"] = *search.ChannelID
+	}
+	q += ` ORDER BY updated_at DESC, id DESC LIMIT @limit OFFSET @offset`
 
-func (r *repository) SetDealStatusRejected(ctx context.Context, dealID int64) (bool, error) {
-	cmd, err := r.db.Exec(ctx, `
-		UPDATE market.deal SET status = @status, updated_at = NOW()
-		WHERE id = @id AND status = @status_draft`,
-		pgx.NamedArgs{
-			"id":           dealID,
-			"status":       string(entity.DealStatusRejected),
-			"status_draft": string(entity.DealStatusDraft),
-		})
+	rows, err := r.db.Query(ctx, q, args)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	return cmd.RowsAffected() > 0, nil
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.DealRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.Deal, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.DealRowToEntity(row))
+	}
+	return list, nil
 }
@@ -7,13 +7,16 @@ import (
 )
 
 type DealActionLockRow struct {
-	ID         string    `db:"id"`
-	DealID     int64     `db:"deal_id"`
-	ActionType string    `db:"action_type"`
-	Status     string    `db:"status"`
-	ExpireAt   time.Time `db:"expire_at"`
-	CreatedAt  time.Time `db:"created_at"`
-	UpdatedAt  time.Time `db:"updated_at"`
+	ID         string     `db:"id"`
+	DealID     int64      `db:"deal_id"`
+	ActionType string     `db:"action_type"`
+	Status     string     `db:"status"`
+	Attempt    int        `db:"attempt"`
+	RetryAt    *time.Time `db:"retry_at"`
+	LastError  *string    `db:"last_error"`
+	ExpireAt   time.Time  `db:"expire_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at"`
 }
 
 type DealActionLockExistsRow struct {
@@ -24,14 +27,37 @@ type DealActionLockReturnRow struct {
 	ID string `db:"id"`
 }
 
+type DealActionLockAttemptRow struct {
+	Attempt int `db:"attempt"`
+}
+
 func DealActionLockRowToEntity(row DealActionLockRow) *entity.DealActionLock {
 	return &entity.DealActionLock{
 		ID:         row.ID,
 		DealID:     row.DealID,
 		ActionType: entity.DealActionType(row.ActionType),
 		Status:     entity.DealActionLockStatus(row.Status),
+		Attempt:    row.Attempt,
+		RetryAt:    row.RetryAt,
+		LastError:  row.LastError,
 		ExpireAt:   row.ExpireAt,
 		CreatedAt:  row.CreatedAt,
 		UpdatedAt:  row.UpdatedAt,
 	}
 }
+
+type DealActionFailuresRow struct {
+	DealID        int64     `db:"deal_id"`
+	ActionType    string    `db:"action_type"`
+	Failures      int64     `db:"failures"`
+	LastFailureAt time.Time `db:"last_failure_at"`
+}
+
+func DealActionFailuresRowToEntity(row DealActionFailuresRow) *entity.DealActionFailures {
+	return &entity.DealActionFailures{
+		DealID:        row.DealID,
+		ActionType:    entity.DealActionType(row.ActionType),
+		Failures:      row.Failures,
+		LastFailureAt: row.LastFailureAt,
+	}
+}
@@ -59,10 +59,17 @@ func (r *repository) TakeDealActionLock(ctx context.Context, dealID int64, actio
 		return "", err
 	}
 
+	// attempt continues the count of the previous lock when it failed, otherwise starts over.
 	expireAt := time.Now().Add(dealActionLockTTL)
 	insertRows, err := r.db.Query(txCtx, `
-		INSERT INTO market.deal_action_lock (deal_id, action_type, status, expire_at, updated_at)
-		VALUES (@deal_id, @action_type, 'locked', @expire_at, NOW())
+		INSERT INTO market.deal_action_lock (deal_id, action_type, status, attempt, expire_at, updated_at)
+		VALUES (@deal_id, @action_type, 'locked', COALESCE((
+			SELECT CASE WHEN status = 'failed' THEN attempt + 1 ELSE 1 END
+			FROM market.deal_action_lock
+			WHERE deal_id = @deal_id AND action_type = @action_type
+			ORDER BY created_at DESC
+			LIMIT 1
+		), 1), @expire_at, NOW())
 		RETURNING id`,
 		pgx.NamedArgs{
 			"deal_id":     dealID,
@@ -83,7 +90,7 @@ func (r *repository) TakeDealActionLock(ctx context.Context, dealID int64, actio
 
 func (r *repository) GetLastDealActionLock(ctx context.Context, dealID int64, actionType entity.DealActionType) (*entity.DealActionLock, error) {
 	rows, err := r.db.Query(ctx, `
-		SELECT id, deal_id, action_type, status, expire_at, created_at, updated_at
+		SELECT id, deal_id, action_type, status, attempt, retry_at, last_error, expire_at, created_at, updated_at
 		FROM market.deal_action_lock
 		WHERE deal_id = @deal_id AND action_type = @action_type
 		ORDER BY created_at DESC
@@ -128,3 +135,80 @@ func (r *repository) ReleaseDealActionLock(ctx context.Context, lockID string, s
 	)
 	return err
 }
+
+// FailDealActionLock marks the lock failed and schedules the next attempt after an exponential backoff:
+// baseBackoff * 2^(attempt-1), capped at maxBackoff. Returns the attempt number of the failed lock.
+func (r *repository) FailDealActionLock(ctx context.Context, lockID string, lastError string, baseBackoff, maxBackoff time.Duration) (int, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE market.deal_action_lock
+		SET status = 'failed',
+			last_error = @last_error,
+			retry_at = NOW() + make_interval(secs => LEAST(@base_seconds * power(2, attempt - 1), @max_seconds)),
+			updated_at = NOW()
+		WHERE id = @id
+		RETURNING attempt`,
+		pgx.NamedArgs{
+			"id":           lockID,
+			"last_error":   lastError,
+			"base_seconds": baseBackoff.Seconds(),
+			"max_seconds":  maxBackoff.Seconds(),
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.DealActionLockAttemptRow])
+	if err != nil {
+		return 0, err
+	}
+	return row.Attempt, nil
+}
+
+// ResetDealActionAttempts clears the backoff of the last failed lock so the next lock starts again from attempt 1.
+func (r *repository) ResetDealActionAttempts(ctx context.Context, dealID int64, actionType entity.DealActionType) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE market.deal_action_lock SET attempt = 0, retry_at = NULL, updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM market.deal_action_lock
+			WHERE deal_id = @deal_id AND action_type = @action_type
+			ORDER BY created_at DESC
+			LIMIT 1
+		) AND status = 'failed'`,
+		pgx.NamedArgs{
+			"deal_id":     dealID,
+			"action_type": string(actionType),
+		},
+	)
+	return err
+}
+
+// ListDealActionFailures returns deal actions with at least minFailures failed locks and no completed lock.
+func (r *repository) ListDealActionFailures(ctx context.Context, minFailures int) ([]*entity.DealActionFailures, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT l.deal_id, l.action_type, COUNT(*) AS failures, MAX(l.updated_at) AS last_failure_at
+		FROM market.deal_action_lock l
+		WHERE l.status = 'failed'
+		  AND NOT EXISTS (
+		      SELECT 1 FROM market.deal_action_lock c
+		      WHERE c.deal_id = l.deal_id AND c.action_type = l.action_type AND c.status = 'completed'
+		  )
+		GROUP BY l.deal_id, l.action_type
+		HAVING COUNT(*) >= @min_failures
+		ORDER BY l.deal_id ASC`,
+		pgx.NamedArgs{"min_failures": minFailures},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.DealActionFailuresRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.DealActionFailures, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.DealActionFailuresRowToEntity(row))
+	}
+	return list, nil
+}
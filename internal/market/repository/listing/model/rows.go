@@ -26,6 +26,16 @@ type ListingWithChannelRow struct {
 	ChannelUsername  *string `db:"channel_username"`
 	ChannelPhoto     *string `db:"channel_photo"`
 	ChannelFollowers *int64  `db:"channel_followers"`
+
+	QualityScore                *float64 `db:"quality_score"`
+	ERRPercent                  *float64 `db:"err_percent"`
+	AvgReach                    *float64 `db:"avg_reach"`
+	SharesRatioPercent          *float64 `db:"shares_ratio_percent"`
+	ReactionsRatioPercent       *float64 `db:"reactions_ratio_percent"`
+	NotificationsEnabledPercent *float64 `db:"notifications_enabled_percent"`
+	MaxDailyGrowthPercent       *float64 `db:"max_daily_growth_percent"`
+	Suspicious                  *bool    `db:"suspicious"`
+	QualityFlags                []string `db:"quality_flags"`
 }
 
 type ListingReturnRow struct {
@@ -38,6 +48,10 @@ type ListingExistsRow struct {
 	One int `db:"one"`
 }
 
+type ListingChannelIDRow struct {
+	ChannelID int64 `db:"channel_id"`
+}
+
 func stringFromPtr(p *string) string {
 	if p == nil {
 		return ""
@@ -45,6 +59,13 @@ func stringFromPtr(p *string) string {
 	return *p
 }
 
+func floatFromPtr(p *float64) float64 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
 func ListingRowToEntity(row ListingRow) *entity.Listing {
 	l := &entity.Listing{
 		ID:          row.ID,
@@ -69,5 +90,18 @@ func ListingWithChannelRowToEntity(row ListingWithChannelRow) *entity.Listing {
 	l.ChannelUsername = row.ChannelUsername
 	l.ChannelPhoto = row.ChannelPhoto
 	l.ChannelFollowers = row.ChannelFollowers
+	if row.QualityScore != nil {
+		l.ChannelQuality = &entity.ChannelQuality{
+			Score:                       *row.QualityScore,
+			ERRPercent:                  floatFromPtr(row.ERRPercent),
+			AvgReach:                    floatFromPtr(row.AvgReach),
+			SharesRatioPercent:          floatFromPtr(row.SharesRatioPercent),
+			ReactionsRatioPercent:       floatFromPtr(row.ReactionsRatioPercent),
+			NotificationsEnabledPercent: floatFromPtr(row.NotificationsEnabledPercent),
+			MaxDailyGrowthPercent:       floatFromPtr(row.MaxDailyGrowthPercent),
+			Suspicious:                  row.Suspicious != nil && *row.Suspicious,
+			Flags:                       row.QualityFlags,
+		}
+	}
 	return l
 }
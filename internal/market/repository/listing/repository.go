@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"ads-mrkt/internal/market/domain/entity"
 	"ads-mrkt/internal/market/repository/listing/model"
@@ -19,6 +20,10 @@ type database interface {
 	EndTx(ctx context.Context, err error, source string) error
 }
 
+// channelQualityColumns selects the channel_stats quality metrics scanned into model.ListingWithChannelRow.
+const channelQualityColumns = `cs.quality_score, cs.err_percent, cs.avg_reach, cs.shares_ratio_percent, cs.reactions_ratio_percent,
+		       cs.notifications_enabled_percent, cs.max_daily_growth_percent, cs.suspicious, cs.quality_flags`
+
 type repository struct {
 	db database
 }
@@ -64,7 +69,8 @@ func (r *repository) GetListingByID(ctx context.Context, id int64) (*entity.List
 	rows, err := r.db.Query(ctx, `
 		SELECT l.id, l.status, l.user_id, l.channel_id, l.type, l.prices, l.categories, l.description, l.created_at, l.updated_at,
 		       c.title AS channel_title, c.username AS channel_username, c.photo AS channel_photo,
-		       (cs.stats->'Followers'->>'Current')::bigint AS channel_followers
+		       (cs.stats->'Followers'->>'Current')::bigint AS channel_followers,
+		       `+channelQualityColumns+`
 		FROM market.listing l
 		LEFT JOIN market.channel c ON c.id = l.channel_id
 		LEFT JOIN market.channel_stats cs ON cs.channel_id = l.channel_id
@@ -116,7 +122,8 @@ func (r *repository) ListListingsByUserID(ctx context.Context, userID int64, typ
 	q := `
 		SELECT l.id, l.status, l.user_id, l.channel_id, l.type, l.prices, l.categories, l.description, l.created_at, l.updated_at,
 		       c.title AS channel_title, c.username AS channel_username, c.photo AS channel_photo,
-		       (cs.stats->'Followers'->>'Current')::bigint AS channel_followers
+		       (cs.stats->'Followers'->>'Current')::bigint AS channel_followers,
+		       ` + channelQualityColumns + `
 		FROM market.listing l
 		LEFT JOIN market.channel c ON c.id = l.channel_id
 		LEFT JOIN market.channel_stats cs ON cs.channel_id = l.channel_id
@@ -165,29 +172,81 @@ func (r *repository) IsChannelHasActiveListing(ctx context.Context, channelID in
 	return true, nil
 }
 
-func (r *repository) ListListingsAll(ctx context.Context, typ *entity.ListingType, categories []string, minFollowers *int64) ([]*entity.Listing, error) {
+// ListChannelIDsForStatsRefresh returns channels with an active listing whose stats are missing or older than staleAfter,
+// stalest first.
+func (r *repository) ListChannelIDsForStatsRefresh(ctx context.Context, staleAfter time.Duration) ([]int64, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT l.channel_id AS channel_id
+		FROM market.listing l
+		LEFT JOIN market.channel_stats cs ON cs.channel_id = l.channel_id
+		WHERE l.status = 'active' AND l.channel_id IS NOT NULL
+		  AND (cs.channel_id IS NULL OR cs.updated_at < NOW() - make_interval(secs => @stale_seconds))
+		GROUP BY l.channel_id
+		ORDER BY MIN(cs.updated_at) NULLS FIRST`,
+		pgx.NamedArgs{"stale_seconds": staleAfter.Seconds()})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.ListingChannelIDRow])
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(slice))
+	for _, row := range slice {
+		ids = append(ids, row.ChannelID)
+	}
+	return ids, nil
+}
+
+// ListListingsAll returns active listings matching the filter, ordered by filter.Sort.
+func (r *repository) ListListingsAll(ctx context.Context, filter *entity.ListingFilter) ([]*entity.Listing, error) {
 	q := `
 		SELECT l.id, l.status, l.user_id, l.channel_id, l.type, l.prices, l.categories, l.description, l.created_at, l.updated_at,
 		       c.title AS channel_title, c.username AS channel_username, c.photo AS channel_photo,
-		       (cs.stats->'Followers'->>'Current')::bigint AS channel_followers
+		       (cs.stats->'Followers'->>'Current')::bigint AS channel_followers,
+		       ` + channelQualityColumns + `
 		FROM market.listing l
 		LEFT JOIN market.channel c ON c.id = l.channel_id
 		LEFT JOIN market.channel_stats cs ON cs.channel_id = l.channel_id
 		WHERE l.status = 'active'`
 	args := pgx.NamedArgs{}
-	if typ != nil {
+	if filter.Type != nil {
 		q += ` AND l.type = @type`
-		args["type"] = string(*typ)
+		args["type"] = string(*filter.Type)
 	}
-	if len(categories) > 0 {
+	if len(filter.Categories) > 0 {
 		q += ` AND l.categories ?| @categories_filter`
-		args["categories_filter"] = categories
+		args["categories_filter"] = filter.Categories
 	}
-	if minFollowers != nil && *minFollowers > 0 {
+	if filter.MinFollowers != nil && *filter.MinFollowers > 0 {
 		q += ` AND (COALESCE((cs.stats->'Followers'->>'Current')::bigint, 0) >= @min_followers)`
-		args["min_followers"] = *minFollowers
+		args["min_followers"] = *filter.MinFollowers
+	}
+	if filter.MinQualityScore != nil {
+		q += ` AND cs.quality_score >= @min_quality_score`
+		args["min_quality_score"] = *filter.MinQualityScore
+	}
+	if filter.MinERRPercent != nil {
+		q += ` AND cs.err_percent >= @min_err_percent`
+		args["min_err_percent"] = *filter.MinERRPercent
+	}
+	if filter.HideSuspicious {
+		q += ` AND cs.suspicious IS NOT TRUE`
+	}
+	switch filter.Sort {
+	case entity.ListingSortFollowers:
+		q += ` ORDER BY (cs.stats->'Followers'->>'Current')::bigint DESC NULLS LAST, l.updated_at DESC`
+	case entity.ListingSortQuality:
+		q += ` ORDER BY cs.quality_score DESC NULLS LAST, l.updated_at DESC`
+	case entity.ListingSortERR:
+		q += ` ORDER BY cs.err_percent DESC NULLS LAST, l.updated_at DESC`
+	case entity.ListingSortReach:
+		q += ` ORDER BY cs.avg_reach DESC NULLS LAST, l.updated_at DESC`
+	default:
+		q += ` ORDER BY l.updated_at DESC`
 	}
-	q += ` ORDER BY l.updated_at DESC`
 
 	rows, err := r.db.Query(ctx, q, args)
 	if err != nil {
@@ -205,3 +264,135 @@ func (r *repository) ListListingsAll(ctx context.Context, typ *entity.ListingTyp
 	}
 	return list, nil
 }
+
+// ListActiveListingsByChannelID returns the channel's active listings.
+func (r *repository) ListActiveListingsByChannelID(ctx context.Context, channelID int64) ([]*entity.Listing, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, status, user_id, channel_id, type, prices, categories, description, created_at, updated_at
+		FROM market.listing
+		WHERE channel_id = @channel_id AND status = 'active'
+		ORDER BY id`,
+		pgx.NamedArgs{"channel_id": channelID})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.ListingRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.Listing, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.ListingRowToEntity(row))
+	}
+	return list, nil
+}
+
+// DeactivateChannelListings sets the channel's active listings inactive and returns how many were changed.
+func (r *repository) DeactivateChannelListings(ctx context.Context, channelID int64) (int64, error) {
+	cmd, err := r.db.Exec(ctx, `
+		UPDATE market.listing SET status = 'inactive', updated_at = NOW()
+		WHERE channel_id = @channel_id AND status = 'active'`,
+		pgx.NamedArgs{"channel_id": channelID})
+	if err != nil {
+		return 0, err
+	}
+	return cmd.RowsAffected(), nil
+}
+
+// DeactivateListing sets the listing inactive.
+func (r *repository) DeactivateListing(ctx context.Context, id int64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE market.listing SET status = 'inactive', updated_at = NOW() WHERE id = @id`,
+		pgx.NamedArgs{"id": id})
+	return err
+}
+
+// ReassignListing moves the listing from fromUserID to toUserID. Returns false when the listing is no longer owned by fromUserID.
+func (r *repository) ReassignListing(ctx context.Context, id int64, fromUserID, toUserID int64) (bool, error) {
+	cmd, err := r.db.Exec(ctx, `
+		UPDATE market.listing SET user_id = @to_user_id, updated_at = NOW()
+		WHERE id = @id AND user_id = @from_user_id`,
+		pgx.NamedArgs{"id": id, "from_user_id": fromUserID, "to_user_id": toUserID})
+	if err != nil {
+		return false, err
+	}
+	return cmd.RowsAffected() > 0, nil
+}
+
+// SearchListings returns listings of any status matching the search, newest first. Query matches the ID or,
+// case-insensitively, the description.
+func (r *repository) SearchListings(ctx context.Context, search *entity.ListingSearch) ([]*entity.Listing, error) {
+	q := `
+		SELECT l.id, l.status, l.user_id, l.channel_id, l.type, l.prices, l.categories, l.description, l.created_at, l.updated_at,
+		       c.title AS channel_title, c.username AS channel_username, c.photo AS channel_photo,
+		       (cs.stats->'Followers'->>'Current')::bigint AS channel_followers,
+		       ` + channelQualityColumns + `
+		FROM market.listing l
+		LEFT JOIN market.channel c ON c.id = l.channel_id
+		LEFT JOIN market.channel_stats cs ON cs.channel_id = l.channel_id
+		WHERE TRUE`
+	args := pgx.NamedArgs{"limit": search.Limit, "offset": search.Offset}
+	if search.Query != "" {
+		q += ` AND (l.id::text = @query OR strpos(lower(COALESCE(l.description, '')), lower(@query)) > 0)`
+		args["query"] = search.Query
+	}
+	if search.Status != "" {
+		q += ` AND l.status = @status`
+		args["status"] = string(search.Status)
+	}
+	if search.Type != "" {
+		q += ` AND l.type = @type`
+		args["type"] = string(search.Type)
+	}
+	if search.UserID != nil {
+		q += ` AND l.user_id = @user_id`
+		args["user_id"] = *search.UserID
+	}
+	if search.ChannelID != nil {
+		q += ` AND l.channel_id = @channel_id`
+		args["channel_id"] = *search.ChannelID
+	}
+	q += ` ORDER BY l.id DESC LIMIT @limit OFFSET @offset`
+
+	rows, err := r.db.Query(ctx, q, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.ListingWithChannelRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.Listing, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.ListingWithChannelRowToEntity(row))
+	}
+	return list, nil
+}
+
+// BlockListing takes the listing down for moderation. Returns false when there is no such listing or it is
+// already blocked.
+func (r *repository) BlockListing(ctx context.Context, id int64) (bool, error) {
+	cmd, err := r.db.Exec(ctx, `
+		UPDATE market.listing SET status = 'blocked', updated_at = NOW() WHERE id = @id AND status <> 'blocked'`,
+		pgx.NamedArgs{"id": id})
+	if err != nil {
+		return false, err
+	}
+	return cmd.RowsAffected() > 0, nil
+}
+
+// UnblockListing lifts the block and leaves the listing inactive for its owner to reactivate. Returns false when
+// the listing is not blocked.
+func (r *repository) UnblockListing(ctx context.Context, id int64) (bool, error) {
+	cmd, err := r.db.Exec(ctx, `
+		UPDATE market.listing SET status = 'inactive', updated_at = NOW() WHERE id = @id AND status = 'blocked'`,
+		pgx.NamedArgs{"id": id})
+	if err != nil {
+		return false, err
+	}
+	return cmd.RowsAffected() > 0, nil
+}
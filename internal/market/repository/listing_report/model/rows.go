@@ -0,0 +1,62 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+)
+
+type ListingReportRow struct {
+	ID         int64      `db:"id"`
+	ListingID  int64      `db:"listing_id"`
+	ReporterID int64      `db:"reporter_id"`
+	Reason     string     `db:"reason"`
+	Comment    string     `db:"comment"`
+	Status     string     `db:"status"`
+	ResolvedBy *int64     `db:"resolved_by"`
+	ResolvedAt *time.Time `db:"resolved_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+type ListingReportReturnRow struct {
+	ID        int64     `db:"id"`
+	Status    string    `db:"status"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type ModerationQueueRow struct {
+	ListingID       int64           `db:"listing_id"`
+	OpenReports     int             `db:"open_reports"`
+	Reasons         json.RawMessage `db:"reasons"`
+	FirstReportedAt time.Time       `db:"first_reported_at"`
+	LastReportedAt  time.Time       `db:"last_reported_at"`
+}
+
+func ListingReportRowToEntity(row ListingReportRow) *entity.ListingReport {
+	return &entity.ListingReport{
+		ID:         row.ID,
+		ListingID:  row.ListingID,
+		ReporterID: row.ReporterID,
+		Reason:     entity.ListingReportReason(row.Reason),
+		Comment:    row.Comment,
+		Status:     entity.ListingReportStatus(row.Status),
+		ResolvedBy: row.ResolvedBy,
+		ResolvedAt: row.ResolvedAt,
+		CreatedAt:  row.CreatedAt,
+	}
+}
+
+// ModerationQueueRowToEntity converts the row; the listing is filled in by the caller.
+func ModerationQueueRowToEntity(row ModerationQueueRow) (*entity.ModerationQueueItem, error) {
+	item := &entity.ModerationQueueItem{
+		OpenReports:     row.OpenReports,
+		FirstReportedAt: row.FirstReportedAt,
+		LastReportedAt:  row.LastReportedAt,
+	}
+	if err := json.Unmarshal(row.Reasons, &item.Reasons); err != nil {
+		return nil, err
+	}
+	item.Listing = &entity.Listing{ID: row.ListingID}
+	return item, nil
+}
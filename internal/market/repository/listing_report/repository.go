@@ -0,0 +1,136 @@
+package listing_report
+
+import (
+	"context"
+
+	"ads-mrkt/internal/market/domain/entity"
+	"ads-mrkt/internal/market/repository/listing_report/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type database interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+type repository struct {
+	db database
+}
+
+func New(db database) *repository {
+	return &repository{db: db}
+}
+
+// CreateListingReport files the report; it returns false without writing when the reporter already has an open
+// report on the listing.
+func (r *repository) CreateListingReport(ctx context.Context, report *entity.ListingReport) (bool, error) {
+	rows, err := r.db.Query(ctx, `
+		INSERT INTO market.listing_report (listing_id, reporter_id, reason, comment)
+		VALUES (@listing_id, @reporter_id, @reason, @comment)
+		ON CONFLICT (listing_id, reporter_id) WHERE status = 'open' DO NOTHING
+		RETURNING id, status, created_at`,
+		pgx.NamedArgs{
+			"listing_id":  report.ListingID,
+			"reporter_id": report.ReporterID,
+			"reason":      string(report.Reason),
+			"comment":     report.Comment,
+		})
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.ListingReportReturnRow])
+	if err != nil {
+		return false, err
+	}
+	if len(slice) == 0 {
+		return false, nil
+	}
+	report.ID = slice[0].ID
+	report.Status = entity.ListingReportStatus(slice[0].Status)
+	report.CreatedAt = slice[0].CreatedAt
+	return true, nil
+}
+
+// ListListingReports returns every report on the listing, newest first.
+func (r *repository) ListListingReports(ctx context.Context, listingID int64) ([]*entity.ListingReport, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, listing_id, reporter_id, reason, comment, status, resolved_by, resolved_at, created_at
+		FROM market.listing_report
+		WHERE listing_id = @listing_id
+		ORDER BY id DESC`,
+		pgx.NamedArgs{"listing_id": listingID})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.ListingReportRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.ListingReport, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.ListingReportRowToEntity(row))
+	}
+	return list, nil
+}
+
+// ListModerationQueue returns listings with open reports, most reported first, then longest waiting. The items
+// carry only the listing ID.
+func (r *repository) ListModerationQueue(ctx context.Context, limit, offset int) ([]*entity.ModerationQueueItem, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT listing_id,
+			SUM(n)::int AS open_reports,
+			jsonb_object_agg(reason, n) AS reasons,
+			MIN(first_at) AS first_reported_at,
+			MAX(last_at) AS last_reported_at
+		FROM (
+			SELECT listing_id, reason, COUNT(*) AS n, MIN(created_at) AS first_at, MAX(created_at) AS last_at
+			FROM market.listing_report
+			WHERE status = 'open'
+			GROUP BY listing_id, reason
+		) r
+		GROUP BY listing_id
+		ORDER BY open_reports DESC, first_reported_at
+		LIMIT @limit OFFSET @offset`,
+		pgx.NamedArgs{"limit": limit, "offset": offset})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.ModerationQueueRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.ModerationQueueItem, 0, len(slice))
+	for _, row := range slice {
+		item, err := model.ModerationQueueRowToEntity(row)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+// ResolveListingReports closes the open reports on the listing with status and returns how many it closed.
+func (r *repository) ResolveListingReports(ctx context.Context, listingID int64, resolvedBy int64, status entity.ListingReportStatus) (int64, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE market.listing_report
+		SET status = @status, resolved_by = @resolved_by, resolved_at = NOW()
+		WHERE listing_id = @listing_id AND status = 'open'`,
+		pgx.NamedArgs{
+			"listing_id":  listingID,
+			"resolved_by": resolvedBy,
+			"status":      string(status),
+		})
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
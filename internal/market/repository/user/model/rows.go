@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"ads-mrkt/internal/market/domain/entity"
 	"ads-mrkt/pkg/auth/role"
 )
@@ -16,10 +18,22 @@ type UserRow struct {
 	AllowsPM      bool    `db:"allows_pm"`
 	WalletAddress *string `db:"wallet_address"`
 	Role          string  `db:"role"`
+
+	WalletPublicKey  *string    `db:"wallet_public_key"`
+	WalletVerifiedAt *time.Time `db:"wallet_verified_at"`
+
+	BannedAt  *time.Time `db:"banned_at"`
+	BannedBy  *int64     `db:"banned_by"`
+	BanReason *string    `db:"ban_reason"`
 }
 
-type RoleRow struct {
-	Role string `db:"role"`
+type UpsertUserReturnRow struct {
+	Role     string     `db:"role"`
+	BannedAt *time.Time `db:"banned_at"`
+}
+
+type UserIDRow struct {
+	ID int64 `db:"id"`
 }
 
 func UserRowToEntity(row UserRow) *entity.User {
@@ -34,5 +48,12 @@ func UserRowToEntity(row UserRow) *entity.User {
 		AllowsPM:      row.AllowsPM,
 		WalletAddress: row.WalletAddress,
 		Role:          role.Role(row.Role),
+
+		WalletPublicKey:  row.WalletPublicKey,
+		WalletVerifiedAt: row.WalletVerifiedAt,
+
+		BannedAt:  row.BannedAt,
+		BannedBy:  row.BannedBy,
+		BanReason: row.BanReason,
 	}
 }
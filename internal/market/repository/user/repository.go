@@ -19,6 +19,9 @@ type database interface {
 	EndTx(ctx context.Context, err error, source string) error
 }
 
+const userColumns = `id, username, photo, first_name, last_name, locale, referrer_id, allows_pm, wallet_address, role,
+		       wallet_public_key, wallet_verified_at, banned_at, banned_by, ban_reason`
+
 type repository struct {
 	db database
 }
@@ -39,7 +42,7 @@ func (r *repository) UpsertUser(ctx context.Context, u *entity.User) error {
 			locale = EXCLUDED.locale,
 			allows_pm = EXCLUDED.allows_pm,
 			updated_at = NOW()
-		RETURNING role`,
+		RETURNING role, banned_at`,
 		pgx.NamedArgs{
 			"id":          u.ID,
 			"username":    u.Username,
@@ -56,17 +59,18 @@ func (r *repository) UpsertUser(ctx context.Context, u *entity.User) error {
 	}
 	defer rows.Close()
 
-	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.RoleRow])
+	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.UpsertUserReturnRow])
 	if err != nil {
 		return err
 	}
 	u.Role = role.Role(row.Role)
+	u.BannedAt = row.BannedAt
 	return nil
 }
 
 func (r *repository) GetUserByID(ctx context.Context, id int64) (*entity.User, error) {
 	rows, err := r.db.Query(ctx, `
-		SELECT id, username, photo, first_name, last_name, locale, referrer_id, allows_pm, wallet_address, role
+		SELECT `+userColumns+`
 		FROM market.user WHERE id = @id`,
 		pgx.NamedArgs{"id": id})
 	if err != nil {
@@ -84,16 +88,129 @@ func (r *repository) GetUserByID(ctx context.Context, id int64) (*entity.User, e
 	return model.UserRowToEntity(row), nil
 }
 
-func (r *repository) SetUserWallet(ctx context.Context, userID int64, walletAddressRaw string) error {
+// SetUserWallet links the wallet verified by a ton_proof and stores the proof metadata.
+func (r *repository) SetUserWallet(ctx context.Context, userID int64, proof *entity.WalletProof) error {
 	_, err := r.db.Exec(ctx, `
-		UPDATE market.user SET wallet_address = @wallet_address, updated_at = NOW() WHERE id = @id`,
-		pgx.NamedArgs{"wallet_address": walletAddressRaw, "id": userID})
+		UPDATE market.user SET
+			wallet_address = @wallet_address,
+			wallet_public_key = @wallet_public_key,
+			wallet_proof_domain = @wallet_proof_domain,
+			wallet_proof_timestamp = @wallet_proof_timestamp,
+			wallet_verified_at = @wallet_verified_at,
+			updated_at = NOW()
+		WHERE id = @id`,
+		pgx.NamedArgs{
+			"id":                     userID,
+			"wallet_address":         proof.AddressRaw,
+			"wallet_public_key":      proof.PublicKey,
+			"wallet_proof_domain":    proof.Domain,
+			"wallet_proof_timestamp": proof.ProofTimestamp,
+			"wallet_verified_at":     proof.VerifiedAt,
+		})
 	return err
 }
 
 func (r *repository) ClearUserWallet(ctx context.Context, userID int64) error {
 	_, err := r.db.Exec(ctx, `
-		UPDATE market.user SET wallet_address = NULL, updated_at = NOW() WHERE id = @id`,
+		UPDATE market.user SET
+			wallet_address = NULL,
+			wallet_public_key = NULL,
+			wallet_proof_domain = NULL,
+			wallet_proof_timestamp = NULL,
+			wallet_verified_at = NULL,
+			updated_at = NOW()
+		WHERE id = @id`,
 		pgx.NamedArgs{"id": userID})
 	return err
 }
+
+// ListUserIDsByRole returns ids of all users with the role, e.g. admins to notify.
+func (r *repository) ListUserIDsByRole(ctx context.Context, userRole role.Role) ([]int64, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id FROM market.user WHERE role = @role ORDER BY id ASC`,
+		pgx.NamedArgs{"role": string(userRole)})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.UserIDRow])
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(slice))
+	for _, row := range slice {
+		ids = append(ids, row.ID)
+	}
+	return ids, nil
+}
+
+// SearchUsers returns users matching the search, newest first. Query matches the ID or, case-insensitively, the
+// username or name.
+func (r *repository) SearchUsers(ctx context.Context, search *entity.UserSearch) ([]*entity.User, error) {
+	q := `SELECT ` + userColumns + ` FROM market.user WHERE TRUE`
+	args := pgx.NamedArgs{"limit": search.Limit, "offset": search.Offset}
+	if search.Query != "" {
+		q += ` AND (id::text = @query OR strpos(lower(username || ' ' || first_name || ' ' || last_name), lower(@query)) > 0)`
+		args["query"] = search.Query
+	}
+	if search.Role != role.EmptyRole {
+		q += ` AND role = @role`
+		args["role"] = string(search.Role)
+	}
+	if search.Banned != nil {
+		q += ` AND (banned_at IS NOT NULL) = @banned`
+		args["banned"] = *search.Banned
+	}
+	q += ` ORDER BY created_at DESC, id DESC LIMIT @limit OFFSET @offset`
+
+	rows, err := r.db.Query(ctx, q, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.UserRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.User, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.UserRowToEntity(row))
+	}
+	return list, nil
+}
+
+// SetUserRole changes the role of the user. Returns false when there is no such user.
+func (r *repository) SetUserRole(ctx context.Context, userID int64, userRole role.Role) (bool, error) {
+	cmd, err := r.db.Exec(ctx, `
+		UPDATE market.user SET role = @role, updated_at = NOW() WHERE id = @id`,
+		pgx.NamedArgs{"id": userID, "role": string(userRole)})
+	if err != nil {
+		return false, err
+	}
+	return cmd.RowsAffected() > 0, nil
+}
+
+// BanUser bans the user. Returns false when there is no such user or the user is already banned.
+func (r *repository) BanUser(ctx context.Context, userID int64, bannedBy int64, reason string) (bool, error) {
+	cmd, err := r.db.Exec(ctx, `
+		UPDATE market.user SET banned_at = NOW(), banned_by = @banned_by, ban_reason = @reason, updated_at = NOW()
+		WHERE id = @id AND banned_at IS NULL`,
+		pgx.NamedArgs{"id": userID, "banned_by": bannedBy, "reason": reason})
+	if err != nil {
+		return false, err
+	}
+	return cmd.RowsAffected() > 0, nil
+}
+
+// UnbanUser lifts the ban of the user. Returns false when the user is not banned.
+func (r *repository) UnbanUser(ctx context.Context, userID int64) (bool, error) {
+	cmd, err := r.db.Exec(ctx, `
+		UPDATE market.user SET banned_at = NULL, banned_by = NULL, ban_reason = NULL, updated_at = NOW()
+		WHERE id = @id AND banned_at IS NOT NULL`,
+		pgx.NamedArgs{"id": userID})
+	if err != nil {
+		return false, err
+	}
+	return cmd.RowsAffected() > 0, nil
+}
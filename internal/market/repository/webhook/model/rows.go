@@ -0,0 +1,80 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+)
+
+type WebhookRow struct {
+	ID        int64     `db:"id"`
+	UserID    int64     `db:"user_id"`
+	URL       string    `db:"url"`
+	Secret    string    `db:"secret"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type WebhookReturnRow struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type WebhookDeliveryRow struct {
+	ID             int64           `db:"id"`
+	WebhookID      int64           `db:"webhook_id"`
+	DealID         *int64          `db:"deal_id"`
+	Event          string          `db:"event"`
+	Payload        json.RawMessage `db:"payload"`
+	Status         string          `db:"status"`
+	Attempts       int             `db:"attempts"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at"`
+	LastStatusCode *int            `db:"last_status_code"`
+	LastError      *string         `db:"last_error"`
+	DeliveredAt    *time.Time      `db:"delivered_at"`
+	CreatedAt      time.Time       `db:"created_at"`
+}
+
+type WebhookDeliveryAttemptRow struct {
+	WebhookDeliveryRow
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+func WebhookRowToEntity(row WebhookRow) *entity.Webhook {
+	return &entity.Webhook{
+		ID:        row.ID,
+		UserID:    row.UserID,
+		URL:       row.URL,
+		Secret:    row.Secret,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+}
+
+func WebhookDeliveryRowToEntity(row WebhookDeliveryRow) *entity.WebhookDelivery {
+	return &entity.WebhookDelivery{
+		ID:             row.ID,
+		WebhookID:      row.WebhookID,
+		DealID:         row.DealID,
+		Event:          entity.WebhookEvent(row.Event),
+		Payload:        row.Payload,
+		Status:         entity.WebhookDeliveryStatus(row.Status),
+		Attempts:       row.Attempts,
+		NextAttemptAt:  row.NextAttemptAt,
+		LastStatusCode: row.LastStatusCode,
+		LastError:      row.LastError,
+		DeliveredAt:    row.DeliveredAt,
+		CreatedAt:      row.CreatedAt,
+	}
+}
+
+func WebhookDeliveryAttemptRowToEntity(row WebhookDeliveryAttemptRow) *entity.WebhookDeliveryAttempt {
+	return &entity.WebhookDeliveryAttempt{
+		WebhookDelivery: *WebhookDeliveryRowToEntity(row.WebhookDeliveryRow),
+		URL:             row.URL,
+		Secret:          row.Secret,
+	}
+}
@@ -0,0 +1,231 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+	"ads-mrkt/internal/market/repository/webhook/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type database interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+const deliveryColumns = `wd.id, wd.webhook_id, wd.deal_id, wd.event, wd.payload, wd.status, wd.attempts, wd.next_attempt_at,
+		       wd.last_status_code, wd.last_error, wd.delivered_at, wd.created_at`
+
+type repository struct {
+	db database
+}
+
+func New(db database) *repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateWebhook(ctx context.Context, w *entity.Webhook) error {
+	rows, err := r.db.Query(ctx, `
+		INSERT INTO market.webhook (user_id, url, secret)
+		VALUES (@user_id, @url, @secret)
+		RETURNING id, created_at, updated_at`,
+		pgx.NamedArgs{
+			"user_id": w.UserID,
+			"url":     w.URL,
+			"secret":  w.Secret,
+		})
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.WebhookReturnRow])
+	if err != nil {
+		return err
+	}
+	w.ID = row.ID
+	w.CreatedAt = row.CreatedAt
+	w.UpdatedAt = row.UpdatedAt
+	return nil
+}
+
+func (r *repository) GetWebhookByID(ctx context.Context, id int64) (*entity.Webhook, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, url, secret, created_at, updated_at
+		FROM market.webhook
+		WHERE id = @id`,
+		pgx.NamedArgs{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.WebhookRow])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return model.WebhookRowToEntity(row), nil
+}
+
+func (r *repository) ListWebhooksByUserID(ctx context.Context, userID int64) ([]*entity.Webhook, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, url, secret, created_at, updated_at
+		FROM market.webhook
+		WHERE user_id = @user_id
+		ORDER BY id`,
+		pgx.NamedArgs{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.WebhookRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.Webhook, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.WebhookRowToEntity(row))
+	}
+	return list, nil
+}
+
+// DeleteWebhook removes the webhook with its delivery log.
+func (r *repository) DeleteWebhook(ctx context.Context, id int64) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM market.webhook WHERE id = @id`, pgx.NamedArgs{"id": id})
+	return err
+}
+
+// CreateWebhookDelivery queues the delivery, to be attempted from its NextAttemptAt.
+func (r *repository) CreateWebhookDelivery(ctx context.Context, d *entity.WebhookDelivery) error {
+	rows, err := r.db.Query(ctx, `
+		INSERT INTO market.webhook_delivery (webhook_id, deal_id, event, payload, next_attempt_at)
+		VALUES (@webhook_id, @deal_id, @event, @payload, @next_attempt_at)
+		RETURNING id, webhook_id, deal_id, event, payload, status, attempts, next_attempt_at,
+		          last_status_code, last_error, delivered_at, created_at`,
+		pgx.NamedArgs{
+			"webhook_id":      d.WebhookID,
+			"deal_id":         d.DealID,
+			"event":           string(d.Event),
+			"payload":         d.Payload,
+			"next_attempt_at": d.NextAttemptAt,
+		})
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.WebhookDeliveryRow])
+	if err != nil {
+		return err
+	}
+	*d = *model.WebhookDeliveryRowToEntity(row)
+	return nil
+}
+
+// ClaimDueWebhookDeliveries returns pending deliveries whose attempt is due, with their endpoint, and moves their
+// next attempt to leaseUntil so that other workers skip them while they are sent. A worker that dies mid-attempt
+// leaves the delivery to be retried after the lease.
+func (r *repository) ClaimDueWebhookDeliveries(ctx context.Context, limit int, leaseUntil time.Time) ([]*entity.WebhookDeliveryAttempt, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH due AS (
+			SELECT id
+			FROM market.webhook_delivery
+			WHERE status = @status_pending AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT @limit
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE market.webhook_delivery wd
+		SET next_attempt_at = @lease_until
+		FROM due, market.webhook w
+		WHERE wd.id = due.id AND w.id = wd.webhook_id
+		RETURNING `+deliveryColumns+`, w.url, w.secret`,
+		pgx.NamedArgs{
+			"status_pending": string(entity.WebhookDeliveryStatusPending),
+			"limit":          limit,
+			"lease_until":    leaseUntil,
+		})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.WebhookDeliveryAttemptRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.WebhookDeliveryAttempt, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.WebhookDeliveryAttemptRowToEntity(row))
+	}
+	return list, nil
+}
+
+// RecordWebhookDeliveryAttempt counts an attempt of the delivery and saves its outcome: delivered, failed for good,
+// or pending until nextAttemptAt. statusCode is nil when no response was received.
+func (r *repository) RecordWebhookDeliveryAttempt(ctx context.Context, id int64, status entity.WebhookDeliveryStatus, statusCode *int, errText *string, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE market.webhook_delivery
+		SET status = @status, attempts = attempts + 1, last_status_code = @last_status_code, last_error = @last_error,
+		    next_attempt_at = @next_attempt_at,
+		    delivered_at = CASE WHEN @status::text = @status_delivered::text THEN NOW() END
+		WHERE id = @id`,
+		pgx.NamedArgs{
+			"id":               id,
+			"status":           string(status),
+			"status_delivered": string(entity.WebhookDeliveryStatusDelivered),
+			"last_status_code": statusCode,
+			"last_error":       errText,
+			"next_attempt_at":  nextAttemptAt,
+		})
+	return err
+}
+
+// ListWebhookDeliveries returns the latest deliveries of the webhook, newest first.
+func (r *repository) ListWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]*entity.WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM market.webhook_delivery wd
+		WHERE wd.webhook_id = @webhook_id
+		ORDER BY wd.id DESC
+		LIMIT @limit`,
+		pgx.NamedArgs{"webhook_id": webhookID, "limit": limit})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slice, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.WebhookDeliveryRow])
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.WebhookDelivery, 0, len(slice))
+	for _, row := range slice {
+		list = append(list, model.WebhookDeliveryRowToEntity(row))
+	}
+	return list, nil
+}
+
+// DeleteFinishedWebhookDeliveries removes delivered and failed deliveries created before the given time and
+// returns how many were removed.
+func (r *repository) DeleteFinishedWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `
+		DELETE FROM market.webhook_delivery
+		WHERE status <> @status_pending AND created_at < @before`,
+		pgx.NamedArgs{
+			"status_pending": string(entity.WebhookDeliveryStatusPending),
+			"before":         before,
+		})
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
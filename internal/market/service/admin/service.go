@@ -0,0 +1,442 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+	"ads-mrkt/pkg/auth/role"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+	maxReasonLength  = 500
+)
+
+type userRepository interface {
+	GetUserByID(ctx context.Context, id int64) (*entity.User, error)
+	SearchUsers(ctx context.Context, search *entity.UserSearch) ([]*entity.User, error)
+	SetUserRole(ctx context.Context, userID int64, userRole role.Role) (bool, error)
+	BanUser(ctx context.Context, userID int64, bannedBy int64, reason string) (bool, error)
+	UnbanUser(ctx context.Context, userID int64) (bool, error)
+}
+
+type listingRepository interface {
+	GetListingByID(ctx context.Context, id int64) (*entity.Listing, error)
+	SearchListings(ctx context.Context, search *entity.ListingSearch) ([]*entity.Listing, error)
+	BlockListing(ctx context.Context, id int64) (bool, error)
+	UnblockListing(ctx context.Context, id int64) (bool, error)
+}
+
+type dealRepository interface {
+	GetDealByID(ctx context.Context, id int64) (*entity.Deal, error)
+	SearchDeals(ctx context.Context, search *entity.DealSearch) ([]*entity.Deal, error)
+	ListDealEvents(ctx context.Context, dealID int64) ([]*entity.DealEvent, error)
+}
+
+type reportRepository interface {
+	ListModerationQueue(ctx context.Context, limit, offset int) ([]*entity.ModerationQueueItem, error)
+	ListListingReports(ctx context.Context, listingID int64) ([]*entity.ListingReport, error)
+	ResolveListingReports(ctx context.Context, listingID int64, resolvedBy int64, status entity.ListingReportStatus) (int64, error)
+}
+
+type auditRepository interface {
+	CreateAdminAuditEntry(ctx context.Context, e *entity.AdminAuditEntry) error
+	ListAdminAuditEntries(ctx context.Context, filter *entity.AdminAuditFilter) ([]*entity.AdminAuditEntry, error)
+}
+
+type escrowService interface {
+	GetEscrowState(ctx context.Context, deal *entity.Deal) (*entity.EscrowState, error)
+	RetryEscrowTransfer(ctx context.Context, adminID int64, dealID int64) (*entity.Deal, error)
+	SettleEscrow(ctx context.Context, adminID int64, dealID int64, release bool, reason string) (*entity.Deal, error)
+	ReconcileEscrows(ctx context.Context) (*entity.EscrowReconciliationReport, error)
+}
+
+type sessionService interface {
+	LogoutAll(ctx context.Context, userID int64) (int, error)
+}
+
+// transactor runs fn in a database transaction; changes and their audit entries are written in the same one.
+type transactor interface {
+	InTx(ctx context.Context, source string, fn func(ctx context.Context) error) error
+}
+
+// service backs the admin console: staff look up users, listings and deals and make changes, each of which is
+// recorded in the admin audit log. Which roles may call what is decided by the router; the service only adds the
+// rules that depend on the target, such as who may ban staff.
+type service struct {
+	userRepo       userRepository
+	listingRepo    listingRepository
+	dealRepo       dealRepository
+	reportRepo     reportRepository
+	auditRepo      auditRepository
+	escrowService  escrowService
+	sessionService sessionService
+	transactor     transactor
+}
+
+func NewService(userRepo userRepository, listingRepo listingRepository, dealRepo dealRepository, reportRepo reportRepository, auditRepo auditRepository, escrowService escrowService, sessionService sessionService, transactor transactor) *service {
+	return &service{
+		userRepo:       userRepo,
+		listingRepo:    listingRepo,
+		dealRepo:       dealRepo,
+		reportRepo:     reportRepo,
+		auditRepo:      auditRepo,
+		escrowService:  escrowService,
+		sessionService: sessionService,
+		transactor:     transactor,
+	}
+}
+
+func (s *service) SearchUsers(ctx context.Context, search entity.UserSearch) ([]*entity.User, error) {
+	search.Query = strings.TrimSpace(search.Query)
+	search.Limit, search.Offset = page(search.Limit, search.Offset)
+	return s.userRepo.SearchUsers(ctx, &search)
+}
+
+func (s *service) GetUser(ctx context.Context, id int64) (*entity.User, error) {
+	u, err := s.userRepo.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, marketerrors.ErrNotFound
+	}
+	return u, nil
+}
+
+func (s *service) SearchListings(ctx context.Context, search entity.ListingSearch) ([]*entity.Listing, error) {
+	search.Query = strings.TrimSpace(search.Query)
+	search.Limit, search.Offset = page(search.Limit, search.Offset)
+	return s.listingRepo.SearchListings(ctx, &search)
+}
+
+func (s *service) SearchDeals(ctx context.Context, search entity.DealSearch) ([]*entity.Deal, error) {
+	search.Limit, search.Offset = page(search.Limit, search.Offset)
+	return s.dealRepo.SearchDeals(ctx, &search)
+}
+
+// GetDeal returns any deal with its status history and the state of its escrow.
+func (s *service) GetDeal(ctx context.Context, id int64) (*entity.AdminDeal, error) {
+	d, err := s.dealRepo.GetDealByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, marketerrors.ErrNotFound
+	}
+	events, err := s.dealRepo.ListDealEvents(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	escrow, err := s.escrowService.GetEscrowState(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.AdminDeal{Deal: d, Events: events, Escrow: escrow}, nil
+}
+
+func (s *service) ListAuditLog(ctx context.Context, filter entity.AdminAuditFilter) ([]*entity.AdminAuditEntry, error) {
+	filter.Limit, filter.Offset = page(filter.Limit, filter.Offset)
+	return s.auditRepo.ListAdminAuditEntries(ctx, &filter)
+}
+
+// SetUserRole gives the user another role. Staff cannot change their own role.
+func (s *service) SetUserRole(ctx context.Context, actor entity.AdminActor, userID int64, userRole role.Role, reason string) (*entity.User, error) {
+	if role.FromString(string(userRole)) == role.EmptyRole {
+		return nil, marketerrors.ErrInvalidRole
+	}
+	reason, err := optionalReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	if userID == actor.ID {
+		return nil, marketerrors.ErrAdminActionNotAllowed
+	}
+	u, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Role == userRole {
+		return u, nil
+	}
+
+	err = s.transactor.InTx(ctx, "SetUserRole", func(ctx context.Context) error {
+		if _, err := s.userRepo.SetUserRole(ctx, userID, userRole); err != nil {
+			return err
+		}
+		return s.audit(ctx, actor, entity.AdminActionSetUserRole, entity.AdminTargetUser, &userID, reason, map[string]role.Role{"from": u.Role, "to": userRole})
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("user role changed", "user_id", userID, "from", u.Role, "to", userRole, "admin_id", actor.ID)
+	return s.GetUser(ctx, userID)
+}
+
+// BanUser bans the user and ends all their sessions. Only admins may ban staff, and nobody may ban themselves.
+func (s *service) BanUser(ctx context.Context, actor entity.AdminActor, userID int64, reason string) (*entity.User, error) {
+	reason, err := requiredReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.userForModeration(ctx, actor, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.BannedAt != nil {
+		return u, nil
+	}
+
+	err = s.transactor.InTx(ctx, "BanUser", func(ctx context.Context) error {
+		if _, err := s.userRepo.BanUser(ctx, userID, actor.ID, reason); err != nil {
+			return err
+		}
+		return s.audit(ctx, actor, entity.AdminActionBanUser, entity.AdminTargetUser, &userID, reason, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.sessionService.LogoutAll(ctx, userID); err != nil {
+		slog.Error("revoke sessions of banned user", "user_id", userID, "error", err)
+	}
+	slog.Info("user banned", "user_id", userID, "admin_id", actor.ID)
+	return s.GetUser(ctx, userID)
+}
+
+// UnbanUser lifts the ban of the user.
+func (s *service) UnbanUser(ctx context.Context, actor entity.AdminActor, userID int64, reason string) (*entity.User, error) {
+	reason, err := optionalReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.userForModeration(ctx, actor, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.BannedAt == nil {
+		return u, nil
+	}
+
+	err = s.transactor.InTx(ctx, "UnbanUser", func(ctx context.Context) error {
+		if _, err := s.userRepo.UnbanUser(ctx, userID); err != nil {
+			return err
+		}
+		return s.audit(ctx, actor, entity.AdminActionUnbanUser, entity.AdminTargetUser, &userID, reason, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("user unbanned", "user_id", userID, "admin_id", actor.ID)
+	return s.GetUser(ctx, userID)
+}
+
+// userForModeration returns the user if actor may ban or unban them.
+func (s *service) userForModeration(ctx context.Context, actor entity.AdminActor, userID int64) (*entity.User, error) {
+	if userID == actor.ID {
+		return nil, marketerrors.ErrAdminActionNotAllowed
+	}
+	u, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Role != role.UserRole && actor.Role != role.AdminRole {
+		return nil, marketerrors.ErrAdminActionNotAllowed
+	}
+	return u, nil
+}
+
+// BlockListing takes the listing down and closes its open reports as actioned; its owner cannot reactivate it
+// until it is unblocked.
+func (s *service) BlockListing(ctx context.Context, actor entity.AdminActor, listingID int64, reason string) (*entity.Listing, error) {
+	reason, err := requiredReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	block := func(ctx context.Context, id int64) (bool, error) {
+		blocked, err := s.listingRepo.BlockListing(ctx, id)
+		if err != nil || !blocked {
+			return blocked, err
+		}
+		_, err = s.reportRepo.ResolveListingReports(ctx, id, actor.ID, entity.ListingReportStatusActioned)
+		return true, err
+	}
+	return s.changeListing(ctx, actor, listingID, entity.AdminActionBlockListing, reason, block)
+}
+
+// UnblockListing lifts the block; the listing stays inactive until its owner reactivates it.
+func (s *service) UnblockListing(ctx context.Context, actor entity.AdminActor, listingID int64, reason string) (*entity.Listing, error) {
+	reason, err := optionalReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	return s.changeListing(ctx, actor, listingID, entity.AdminActionUnblockListing, reason, s.listingRepo.UnblockListing)
+}
+
+// ListModerationQueue returns listings with open reports, most reported first.
+func (s *service) ListModerationQueue(ctx context.Context, limit, offset int) ([]*entity.ModerationQueueItem, error) {
+	limit, offset = page(limit, offset)
+	items, err := s.reportRepo.ListModerationQueue(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		l, err := s.listingRepo.GetListingByID(ctx, item.Listing.ID)
+		if err != nil {
+			return nil, err
+		}
+		if l != nil {
+			item.Listing = l
+		}
+	}
+	return items, nil
+}
+
+// ListListingReports returns every report on the listing, open and resolved.
+func (s *service) ListListingReports(ctx context.Context, listingID int64) ([]*entity.ListingReport, error) {
+	return s.reportRepo.ListListingReports(ctx, listingID)
+}
+
+// DismissListingReports closes the open reports on the listing without acting on it, taking it off the queue.
+func (s *service) DismissListingReports(ctx context.Context, actor entity.AdminActor, listingID int64, reason string) (int64, error) {
+	reason, err := optionalReason(reason)
+	if err != nil {
+		return 0, err
+	}
+	var dismissed int64
+	err = s.transactor.InTx(ctx, string(entity.AdminActionDismissReports), func(ctx context.Context) (err error) {
+		if dismissed, err = s.reportRepo.ResolveListingReports(ctx, listingID, actor.ID, entity.ListingReportStatusDismissed); err != nil || dismissed == 0 {
+			return err
+		}
+		return s.audit(ctx, actor, entity.AdminActionDismissReports, entity.AdminTargetListing, &listingID, reason, map[string]int64{"reports": dismissed})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return dismissed, nil
+}
+
+func (s *service) changeListing(ctx context.Context, actor entity.AdminActor, listingID int64, action entity.AdminAction, reason string, change func(ctx context.Context, id int64) (bool, error)) (*entity.Listing, error) {
+	l, err := s.listingRepo.GetListingByID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		return nil, marketerrors.ErrNotFound
+	}
+
+	err = s.transactor.InTx(ctx, string(action), func(ctx context.Context) error {
+		changed, err := change(ctx, listingID)
+		if err != nil || !changed {
+			return err
+		}
+		return s.audit(ctx, actor, action, entity.AdminTargetListing, &listingID, reason, map[string]entity.ListingStatus{"from": l.Status})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.listingRepo.GetListingByID(ctx, listingID)
+}
+
+// RetryEscrowTransfer retries a failed escrow release/refund to the payout address the user set on the deal.
+func (s *service) RetryEscrowTransfer(ctx context.Context, actor entity.AdminActor, dealID int64) (*entity.Deal, error) {
+	var d *entity.Deal
+	err := s.transactor.InTx(ctx, "RetryEscrowTransfer", func(ctx context.Context) (err error) {
+		if d, err = s.escrowService.RetryEscrowTransfer(ctx, actor.ID, dealID); err != nil {
+			return err
+		}
+		payout := d.LesseePayoutAddress
+		if d.Status == entity.DealStatusWaitingEscrowRelease {
+			payout = d.LessorPayoutAddress
+		}
+		return s.audit(ctx, actor, entity.AdminActionRetryEscrow, entity.AdminTargetDeal, &dealID, "", map[string]any{"status": d.Status, "payout_address": payout})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// SettleEscrow sends the escrow of the deal to the lessor (release) or back to the lessee (refund).
+func (s *service) SettleEscrow(ctx context.Context, actor entity.AdminActor, dealID int64, release bool, reason string) (*entity.Deal, error) {
+	reason, err := requiredReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	action := entity.AdminActionRefundEscrow
+	if release {
+		action = entity.AdminActionReleaseEscrow
+	}
+
+	var d *entity.Deal
+	err = s.transactor.InTx(ctx, string(action), func(ctx context.Context) (err error) {
+		if d, err = s.escrowService.SettleEscrow(ctx, actor.ID, dealID, release, reason); err != nil {
+			return err
+		}
+		return s.audit(ctx, actor, action, entity.AdminTargetDeal, &dealID, reason, map[string]entity.DealStatus{"status": d.Status})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ReconcileEscrows runs escrow reconciliation now.
+func (s *service) ReconcileEscrows(ctx context.Context, actor entity.AdminActor) (*entity.EscrowReconciliationReport, error) {
+	report, err := s.escrowService.ReconcileEscrows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	details := map[string]int{"deals_checked": report.DealsChecked, "mismatches": len(report.Mismatches)}
+	if err := s.audit(ctx, actor, entity.AdminActionReconcileEscrows, entity.AdminTargetEscrow, nil, "", details); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *service) audit(ctx context.Context, actor entity.AdminActor, action entity.AdminAction, targetType entity.AdminTargetType, targetID *int64, reason string, details any) error {
+	e := &entity.AdminAuditEntry{
+		AdminID:    actor.ID,
+		AdminRole:  actor.Role,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+	}
+	if details != nil {
+		b, err := json.Marshal(details)
+		if err != nil {
+			return err
+		}
+		e.Details = b
+	}
+	return s.auditRepo.CreateAdminAuditEntry(ctx, e)
+}
+
+func requiredReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", marketerrors.ErrInvalidAdminReason
+	}
+	return optionalReason(reason)
+}
+
+func optionalReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > maxReasonLength {
+		return "", marketerrors.ErrInvalidAdminReason
+	}
+	return reason, nil
+}
+
+func page(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	return min(limit, maxPageLimit), max(offset, 0)
+}
@@ -0,0 +1,169 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+	"ads-mrkt/pkg/auth/role"
+)
+
+// users is an in-memory user repository; the listing, deal and escrow dependencies are not used by these tests.
+type users struct {
+	byID map[int64]*entity.User
+}
+
+func (u *users) GetUserByID(ctx context.Context, id int64) (*entity.User, error) {
+	if user, ok := u.byID[id]; ok {
+		copied := *user
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (u *users) SearchUsers(ctx context.Context, search *entity.UserSearch) ([]*entity.User, error) {
+	return nil, nil
+}
+
+func (u *users) SetUserRole(ctx context.Context, userID int64, userRole role.Role) (bool, error) {
+	u.byID[userID].Role = userRole
+	return true, nil
+}
+
+func (u *users) BanUser(ctx context.Context, userID int64, bannedBy int64, reason string) (bool, error) {
+	now := time.Now()
+	u.byID[userID].BannedAt, u.byID[userID].BannedBy, u.byID[userID].BanReason = &now, &bannedBy, &reason
+	return true, nil
+}
+
+func (u *users) UnbanUser(ctx context.Context, userID int64) (bool, error) {
+	u.byID[userID].BannedAt, u.byID[userID].BannedBy, u.byID[userID].BanReason = nil, nil, nil
+	return true, nil
+}
+
+type auditLog struct {
+	entries []*entity.AdminAuditEntry
+}
+
+func (a *auditLog) CreateAdminAuditEntry(ctx context.Context, e *entity.AdminAuditEntry) error {
+	a.entries = append(a.entries, e)
+	return nil
+}
+
+func (a *auditLog) ListAdminAuditEntries(ctx context.Context, filter *entity.AdminAuditFilter) ([]*entity.AdminAuditEntry, error) {
+	return a.entries, nil
+}
+
+type sessions struct {
+	loggedOut []int64
+}
+
+func (s *sessions) LogoutAll(ctx context.Context, userID int64) (int, error) {
+	s.loggedOut = append(s.loggedOut, userID)
+	return 1, nil
+}
+
+type noTx struct{}
+
+func (noTx) InTx(ctx context.Context, source string, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+const (
+	adminID     = 1
+	moderatorID = 2
+	supportID   = 3
+	customerID  = 4
+)
+
+func newTestService() (*service, *users, *auditLog, *sessions) {
+	repo := &users{byID: map[int64]*entity.User{
+		adminID:     {ID: adminID, Role: role.AdminRole},
+		moderatorID: {ID: moderatorID, Role: role.ModeratorRole},
+		supportID:   {ID: supportID, Role: role.SupportRole},
+		customerID:  {ID: customerID, Role: role.UserRole},
+	}}
+	audit, sess := &auditLog{}, &sessions{}
+	return NewService(repo, nil, nil, nil, audit, nil, sess, noTx{}), repo, audit, sess
+}
+
+func TestBanUserIsAuditedAndEndsSessions(t *testing.T) {
+	svc, _, audit, sess := newTestService()
+	moderator := entity.AdminActor{ID: moderatorID, Role: role.ModeratorRole}
+
+	u, err := svc.BanUser(context.Background(), moderator, customerID, "  spam  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.BannedAt == nil || u.BanReason == nil || *u.BanReason != "spam" || *u.BannedBy != moderatorID {
+		t.Fatalf("user = %+v", u)
+	}
+	if len(audit.entries) != 1 {
+		t.Fatalf("%d audit entries, want 1", len(audit.entries))
+	}
+	e := audit.entries[0]
+	if e.Action != entity.AdminActionBanUser || e.AdminID != moderatorID || e.AdminRole != role.ModeratorRole || *e.TargetID != customerID || e.Reason != "spam" {
+		t.Fatalf("audit entry = %+v", e)
+	}
+	if len(sess.loggedOut) != 1 || sess.loggedOut[0] != customerID {
+		t.Fatalf("logged out = %v", sess.loggedOut)
+	}
+
+	// Banning again changes nothing.
+	if _, err := svc.BanUser(context.Background(), moderator, customerID, "spam"); err != nil || len(audit.entries) != 1 {
+		t.Fatalf("second ban: err = %v, %d audit entries", err, len(audit.entries))
+	}
+}
+
+func TestBanUserRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		actor  entity.AdminActor
+		userID int64
+		reason string
+		want   error
+	}{
+		{"no reason", entity.AdminActor{ID: moderatorID, Role: role.ModeratorRole}, customerID, " ", marketerrors.ErrInvalidAdminReason},
+		{"self", entity.AdminActor{ID: adminID, Role: role.AdminRole}, adminID, "test", marketerrors.ErrAdminActionNotAllowed},
+		{"moderator bans staff", entity.AdminActor{ID: moderatorID, Role: role.ModeratorRole}, supportID, "test", marketerrors.ErrAdminActionNotAllowed},
+		{"unknown user", entity.AdminActor{ID: adminID, Role: role.AdminRole}, 99, "test", marketerrors.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, audit, sess := newTestService()
+			if _, err := svc.BanUser(context.Background(), tt.actor, tt.userID, tt.reason); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if len(audit.entries) != 0 || len(sess.loggedOut) != 0 {
+				t.Fatalf("refused ban left %d audit entries and logged out %v", len(audit.entries), sess.loggedOut)
+			}
+		})
+	}
+
+	svc, _, _, _ := newTestService()
+	if _, err := svc.BanUser(context.Background(), entity.AdminActor{ID: adminID, Role: role.AdminRole}, supportID, "test"); err != nil {
+		t.Fatalf("admin bans staff: %v", err)
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	svc, _, audit, _ := newTestService()
+	admin := entity.AdminActor{ID: adminID, Role: role.AdminRole}
+
+	if _, err := svc.SetUserRole(context.Background(), admin, adminID, role.UserRole, ""); !errors.Is(err, marketerrors.ErrAdminActionNotAllowed) {
+		t.Fatalf("own role: err = %v", err)
+	}
+	if _, err := svc.SetUserRole(context.Background(), admin, customerID, "owner", ""); !errors.Is(err, marketerrors.ErrInvalidRole) {
+		t.Fatalf("unknown role: err = %v", err)
+	}
+	u, err := svc.SetUserRole(context.Background(), admin, customerID, role.FinanceRole, "")
+	if err != nil || u.Role != role.FinanceRole {
+		t.Fatalf("SetUserRole = %+v, %v", u, err)
+	}
+	if len(audit.entries) != 1 || string(audit.entries[0].Details) != `{"from":"user","to":"finance"}` {
+		t.Fatalf("audit entries = %+v", audit.entries)
+	}
+}
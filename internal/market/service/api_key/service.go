@@ -0,0 +1,159 @@
+package api_key
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+	"ads-mrkt/pkg/auth"
+	"ads-mrkt/pkg/auth/scope"
+)
+
+const (
+	keyPrefix                 = "amk_"
+	keyBytes                  = 32
+	displayPrefixLength       = len(keyPrefix) + 8
+	maxAPIKeysPerUser         = 10
+	maxNameLength             = 64
+	defaultRateLimitPerMinute = 60
+	maxRateLimitPerMinute     = 600
+	rateLimitWindow           = time.Minute
+	lastUsedResolution        = time.Minute // last_used_at is written at most once per this period
+)
+
+type repository interface {
+	CreateAPIKey(ctx context.Context, k *entity.APIKey) error
+	GetAPIKeyByID(ctx context.Context, id int64) (*entity.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*entity.APIKey, error)
+	ListAPIKeysByUserID(ctx context.Context, userID int64) ([]*entity.APIKey, error)
+	DeleteAPIKey(ctx context.Context, id int64) error
+	TouchAPIKey(ctx context.Context, id int64) error
+}
+
+// rateCounter counts the requests of a key in the current window.
+type rateCounter interface {
+	IncrWithExpire(ctx context.Context, key string, expiration time.Duration) (int64, error)
+}
+
+// service manages the API keys of users and authenticates requests made with them.
+type service struct {
+	repository  repository
+	rateCounter rateCounter
+}
+
+func NewService(repository repository, rateCounter rateCounter) *service {
+	return &service{
+		repository:  repository,
+		rateCounter: rateCounter,
+	}
+}
+
+// CreateAPIKey creates a key of the user with the scopes; a zero rate limit is the default. The returned key is the
+// only one that carries the key itself.
+func (s *service) CreateAPIKey(ctx context.Context, userID int64, name string, scopes []scope.Scope, rateLimitPerMinute int) (*entity.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+		return nil, marketerrors.ErrInvalidAPIKeyName
+	}
+	if len(scopes) == 0 {
+		return nil, marketerrors.ErrInvalidAPIKeyScope
+	}
+	for _, sc := range scopes {
+		if !slices.Contains(scope.All, sc) {
+			return nil, marketerrors.ErrInvalidAPIKeyScope
+		}
+	}
+	if rateLimitPerMinute == 0 {
+		rateLimitPerMinute = defaultRateLimitPerMinute
+	}
+	if rateLimitPerMinute < 0 || rateLimitPerMinute > maxRateLimitPerMinute {
+		return nil, marketerrors.ErrInvalidAPIKeyRateLimit
+	}
+	existing, err := s.repository.ListAPIKeysByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxAPIKeysPerUser {
+		return nil, marketerrors.ErrAPIKeyLimitReached
+	}
+
+	b := make([]byte, keyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	key := keyPrefix + hex.EncodeToString(b)
+	k := &entity.APIKey{
+		UserID:             userID,
+		Name:               name,
+		Prefix:             key[:displayPrefixLength],
+		Key:                key,
+		KeyHash:            hashKey(key),
+		Scopes:             slices.Compact(slices.Sorted(slices.Values(scopes))),
+		RateLimitPerMinute: rateLimitPerMinute,
+	}
+	if err := s.repository.CreateAPIKey(ctx, k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func (s *service) ListAPIKeys(ctx context.Context, userID int64) ([]*entity.APIKey, error) {
+	return s.repository.ListAPIKeysByUserID(ctx, userID)
+}
+
+// DeleteAPIKey revokes the user's key; requests made with it fail from then on.
+func (s *service) DeleteAPIKey(ctx context.Context, userID int64, id int64) error {
+	k, err := s.repository.GetAPIKeyByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if k == nil || k.UserID != userID {
+		return marketerrors.ErrNotFound
+	}
+	return s.repository.DeleteAPIKey(ctx, id)
+}
+
+// AuthenticateAPIKey returns the principal of the key and counts the request against the key's rate limit. Unknown
+// keys yield a nil principal.
+func (s *service) AuthenticateAPIKey(ctx context.Context, key string) (*auth.APIKeyPrincipal, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, nil
+	}
+	k, err := s.repository.GetAPIKeyByHash(ctx, hashKey(key))
+	if err != nil || k == nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	window := strconv.FormatInt(now.Unix()/int64(rateLimitWindow/time.Second), 10)
+	n, err := s.rateCounter.IncrWithExpire(ctx, "api_key_rate:"+strconv.FormatInt(k.ID, 10)+":"+window, rateLimitWindow)
+	if err != nil {
+		return nil, err
+	}
+	if n > int64(k.RateLimitPerMinute) {
+		return nil, auth.ErrAPIKeyRateLimited
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= lastUsedResolution {
+		if err := s.repository.TouchAPIKey(ctx, k.ID); err != nil {
+			slog.Error("touch api key", "api_key_id", k.ID, "error", err)
+		}
+	}
+	return &auth.APIKeyPrincipal{KeyID: k.ID, TelegramID: k.UserID, Scopes: k.Scopes}, nil
+}
+
+// hashKey returns the hex SHA-256 of the key. Keys are random, so an unsalted fast hash is enough to keep them
+// unusable if the table leaks.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,149 @@
+package api_key
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+	"ads-mrkt/pkg/auth"
+	"ads-mrkt/pkg/auth/scope"
+)
+
+type store struct {
+	keys    []*entity.APIKey
+	touched int
+}
+
+func (s *store) CreateAPIKey(ctx context.Context, k *entity.APIKey) error {
+	k.ID = int64(len(s.keys) + 1)
+	cp := *k
+	cp.Key = ""
+	s.keys = append(s.keys, &cp)
+	return nil
+}
+
+func (s *store) GetAPIKeyByID(ctx context.Context, id int64) (*entity.APIKey, error) {
+	for _, k := range s.keys {
+		if k.ID == id {
+			return k, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *store) GetAPIKeyByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	for _, k := range s.keys {
+		if k.KeyHash == keyHash {
+			return k, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *store) ListAPIKeysByUserID(ctx context.Context, userID int64) ([]*entity.APIKey, error) {
+	var out []*entity.APIKey
+	for _, k := range s.keys {
+		if k.UserID == userID {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+func (s *store) DeleteAPIKey(ctx context.Context, id int64) error {
+	for i, k := range s.keys {
+		if k.ID == id {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+		}
+	}
+	return nil
+}
+
+func (s *store) TouchAPIKey(ctx context.Context, id int64) error {
+	now := time.Now()
+	k, _ := s.GetAPIKeyByID(context.Background(), id)
+	k.LastUsedAt = &now
+	s.touched++
+	return nil
+}
+
+type counter map[string]int64
+
+func (c counter) IncrWithExpire(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	c[key]++
+	return c[key], nil
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	repo := &store{}
+	svc := NewService(repo, counter{})
+
+	k, err := svc.CreateAPIKey(ctx, 42, " crm ", []scope.Scope{scope.DealsWrite, scope.ListingsRead, scope.DealsWrite}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(k.Key, k.Prefix) || k.Name != "crm" || len(k.Scopes) != 2 {
+		t.Fatalf("created key = %+v", k)
+	}
+	if stored := repo.keys[0]; stored.KeyHash == k.Key || strings.Contains(stored.KeyHash, k.Key[len(keyPrefix):]) {
+		t.Fatal("key stored in clear")
+	}
+
+	for i := 0; i < 3; i++ {
+		p, err := svc.AuthenticateAPIKey(ctx, k.Key)
+		if err != nil || p == nil || p.TelegramID != 42 || p.KeyID != k.ID {
+			t.Fatalf("request %d: %+v, %v", i, p, err)
+		}
+	}
+	if repo.touched != 1 {
+		t.Errorf("last used written %d times, want 1", repo.touched)
+	}
+	if _, err := svc.AuthenticateAPIKey(ctx, k.Key); !errors.Is(err, auth.ErrAPIKeyRateLimited) {
+		t.Fatalf("over limit: err = %v", err)
+	}
+
+	for _, key := range []string{"", "amk_", k.Key + "0", "Bearer " + k.Key} {
+		if p, err := svc.AuthenticateAPIKey(ctx, key); p != nil || err != nil {
+			t.Errorf("%q: %+v, %v", key, p, err)
+		}
+	}
+
+	if err := svc.DeleteAPIKey(ctx, 7, k.ID); !errors.Is(err, marketerrors.ErrNotFound) {
+		t.Fatalf("delete by other user: err = %v", err)
+	}
+	if err := svc.DeleteAPIKey(ctx, 42, k.ID); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := svc.AuthenticateAPIKey(ctx, k.Key); p != nil {
+		t.Fatal("deleted key still authenticates")
+	}
+}
+
+func TestCreateAPIKeyValidation(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(&store{}, counter{})
+	for name, tc := range map[string]struct {
+		name   string
+		scopes []scope.Scope
+		limit  int
+		want   error
+	}{
+		"no name":       {"", []scope.Scope{scope.ListingsRead}, 0, marketerrors.ErrInvalidAPIKeyName},
+		"no scopes":     {"k", nil, 0, marketerrors.ErrInvalidAPIKeyScope},
+		"unknown scope": {"k", []scope.Scope{"admin"}, 0, marketerrors.ErrInvalidAPIKeyScope},
+		"limit too big": {"k", []scope.Scope{scope.ListingsRead}, maxRateLimitPerMinute + 1, marketerrors.ErrInvalidAPIKeyRateLimit},
+	} {
+		if _, err := svc.CreateAPIKey(ctx, 1, tc.name, tc.scopes, tc.limit); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", name, err, tc.want)
+		}
+	}
+	k, err := svc.CreateAPIKey(ctx, 1, "k", []scope.Scope{scope.ListingsRead}, 0)
+	if err != nil || k.RateLimitPerMinute != defaultRateLimitPerMinute {
+		t.Fatalf("default limit: %+v, %v", k, err)
+	}
+}
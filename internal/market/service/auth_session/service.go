@@ -0,0 +1,295 @@
+package auth_session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+	"ads-mrkt/pkg/auth/role"
+)
+
+const (
+	refreshTokenPrefix = "rt_"
+	refreshTokenBytes  = 32
+	revokedSessionKey  = "auth:revoked_session:"
+	cleanupInterval    = time.Hour
+	endedRetention     = 24 * time.Hour
+)
+
+type sessionRepository interface {
+	CreateAuthSession(ctx context.Context, s *entity.AuthSession) error
+	GetAuthSessionByRefreshTokenHash(ctx context.Context, tokenHash string) (*entity.AuthSession, error)
+	RotateAuthSessionRefreshToken(ctx context.Context, id int64, oldHash, newHash string, expiresAt time.Time) (bool, error)
+	RevokeAuthSession(ctx context.Context, id int64, userID int64) (bool, error)
+	RevokeUserAuthSessions(ctx context.Context, userID int64) ([]int64, error)
+	DeleteEndedAuthSessions(ctx context.Context, before time.Time) (int64, error)
+}
+
+type userRepository interface {
+	GetUserByID(ctx context.Context, id int64) (*entity.User, error)
+}
+
+type tokenIssuer interface {
+	GenerateToken(telegramID int64, role role.Role, sessionID int64) (string, error)
+	TokenDuration() time.Duration
+}
+
+// revocationStore marks sessions revoked for as long as access tokens issued for them can live.
+type revocationStore interface {
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// cachedUser is what the auth middleware checks on every request.
+type cachedUser struct {
+	role    role.Role
+	banned  bool
+	expires time.Time
+}
+
+// service issues access tokens for server-side sessions, rotates their refresh tokens and revokes them. It also
+// tells the auth middleware which sessions are revoked, what the current role of a user is and whether they are
+// banned.
+type service struct {
+	repo         sessionRepository
+	userRepo     userRepository
+	tokens       tokenIssuer
+	revocations  revocationStore
+	refreshTTL   time.Duration
+	roleCacheTTL time.Duration
+
+	mu    sync.Mutex
+	users map[int64]cachedUser
+}
+
+func NewService(repo sessionRepository, userRepo userRepository, tokens tokenIssuer, revocations revocationStore, refreshTTL, roleCacheTTL time.Duration) *service {
+	return &service{
+		repo:         repo,
+		userRepo:     userRepo,
+		tokens:       tokens,
+		revocations:  revocations,
+		refreshTTL:   refreshTTL,
+		roleCacheTTL: roleCacheTTL,
+		users:        make(map[int64]cachedUser),
+	}
+}
+
+// StartSession opens a session for the user, who just authenticated with Telegram init data.
+func (s *service) StartSession(ctx context.Context, userID int64, userRole role.Role) (*entity.AuthTokens, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	session := &entity.AuthSession{UserID: userID, RefreshTokenHash: hashToken(refreshToken), ExpiresAt: time.Now().Add(s.refreshTTL)}
+	if err := s.repo.CreateAuthSession(ctx, session); err != nil {
+		return nil, err
+	}
+	return s.issue(session, userRole, refreshToken)
+}
+
+// StartAccessOnlySession opens a session that ends with its access token, for clients that predate refresh tokens
+// and authenticate again once the token expires. Its refresh token is never handed out, and the session is removed
+// by the cleanup like any expired one instead of living for the refresh TTL.
+func (s *service) StartAccessOnlySession(ctx context.Context, userID int64, userRole role.Role) (string, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	session := &entity.AuthSession{UserID: userID, RefreshTokenHash: hashToken(refreshToken), ExpiresAt: time.Now().Add(s.tokens.TokenDuration())}
+	if err := s.repo.CreateAuthSession(ctx, session); err != nil {
+		return "", err
+	}
+	tokens, err := s.issue(session, userRole, "")
+	if err != nil {
+		return "", err
+	}
+	return tokens.AccessToken, nil
+}
+
+// RefreshSession exchanges a refresh token for a new access token and a new refresh token, with the user's current
+// role. A refresh token can be used once: presenting one that was already exchanged revokes its session, since
+// either the client or someone who stole the token is replaying it.
+func (s *service) RefreshSession(ctx context.Context, refreshToken string) (*entity.AuthTokens, error) {
+	tokenHash := hashToken(refreshToken)
+	session, err := s.repo.GetAuthSessionByRefreshTokenHash(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.RevokedAt != nil || !session.ExpiresAt.After(time.Now()) {
+		return nil, marketerrors.ErrInvalidRefreshToken
+	}
+	if session.RefreshTokenHash != tokenHash {
+		slog.Warn("refresh token reused, revoking session", "session_id", session.ID, "user_id", session.UserID)
+		if err := s.revoke(ctx, session.UserID, session.ID); err != nil {
+			return nil, err
+		}
+		return nil, marketerrors.ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, marketerrors.ErrInvalidRefreshToken
+	}
+	s.cacheUser(user)
+	if user.BannedAt != nil {
+		return nil, marketerrors.ErrUserBanned
+	}
+
+	next, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	session.ExpiresAt = time.Now().Add(s.refreshTTL)
+	rotated, err := s.repo.RotateAuthSessionRefreshToken(ctx, session.ID, tokenHash, hashToken(next), session.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, marketerrors.ErrInvalidRefreshToken
+	}
+	return s.issue(session, user.Role, next)
+}
+
+// Logout revokes the session of the user; its access tokens are refused from now on.
+func (s *service) Logout(ctx context.Context, userID int64, sessionID int64) error {
+	return s.revoke(ctx, userID, sessionID)
+}
+
+// LogoutAll revokes every session of the user and returns how many were live. The cached role and ban of the user
+// are dropped, so a ban that led to the logout applies to API keys at once too.
+func (s *service) LogoutAll(ctx context.Context, userID int64) (int, error) {
+	s.mu.Lock()
+	delete(s.users, userID)
+	s.mu.Unlock()
+
+	ids, err := s.repo.RevokeUserAuthSessions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if err := s.markRevoked(ctx, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// IsSessionRevoked reports whether the session was revoked while access tokens issued for it may still be valid.
+func (s *service) IsSessionRevoked(ctx context.Context, sessionID int64) (bool, error) {
+	return s.revocations.Exists(ctx, revokedSessionKey+strconv.FormatInt(sessionID, 10))
+}
+
+// CurrentRole returns the role of the user in the database, cached for the role cache TTL.
+func (s *service) CurrentRole(ctx context.Context, userID int64) (role.Role, error) {
+	cached, err := s.currentUser(ctx, userID)
+	if err != nil {
+		return role.EmptyRole, err
+	}
+	return cached.role, nil
+}
+
+// IsUserBanned reports whether the user is banned, cached like the role.
+func (s *service) IsUserBanned(ctx context.Context, userID int64) (bool, error) {
+	cached, err := s.currentUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return cached.banned, nil
+}
+
+func (s *service) currentUser(ctx context.Context, userID int64) (cachedUser, error) {
+	s.mu.Lock()
+	cached, ok := s.users[userID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached, nil
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return cachedUser{}, err
+	}
+	if user == nil {
+		user = &entity.User{ID: userID, Role: role.EmptyRole}
+	}
+	return s.cacheUser(user), nil
+}
+
+// RunCleanup removes ended sessions until ctx is done.
+func (s *service) RunCleanup(ctx context.Context) {
+	logger := slog.With("component", "auth_session_cleanup")
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("auth session cleanup stopped")
+			return
+		case <-ticker.C:
+			n, err := s.repo.DeleteEndedAuthSessions(ctx, time.Now().Add(-endedRetention))
+			if err != nil {
+				logger.Error("delete ended auth sessions", "error", err)
+				continue
+			}
+			if n > 0 {
+				logger.Info("ended auth sessions deleted", "count", n)
+			}
+		}
+	}
+}
+
+func (s *service) revoke(ctx context.Context, userID int64, sessionID int64) error {
+	if _, err := s.repo.RevokeAuthSession(ctx, sessionID, userID); err != nil {
+		return err
+	}
+	return s.markRevoked(ctx, sessionID)
+}
+
+func (s *service) markRevoked(ctx context.Context, sessionID int64) error {
+	return s.revocations.Set(ctx, revokedSessionKey+strconv.FormatInt(sessionID, 10), 1, s.tokens.TokenDuration())
+}
+
+func (s *service) cacheUser(user *entity.User) cachedUser {
+	cached := cachedUser{role: user.Role, banned: user.BannedAt != nil, expires: time.Now().Add(s.roleCacheTTL)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.ID] = cached
+	return cached
+}
+
+func (s *service) issue(session *entity.AuthSession, userRole role.Role, refreshToken string) (*entity.AuthTokens, error) {
+	accessToken, err := s.tokens.GenerateToken(session.UserID, userRole, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.AuthTokens{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  time.Now().Add(s.tokens.TokenDuration()),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return refreshTokenPrefix + hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,220 @@
+package auth_session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+	"ads-mrkt/pkg/auth"
+	"ads-mrkt/pkg/auth/role"
+)
+
+// sessions is an in-memory repository.
+type sessions struct {
+	rows []*entity.AuthSession
+}
+
+func (s *sessions) CreateAuthSession(ctx context.Context, session *entity.AuthSession) error {
+	session.ID = int64(len(s.rows) + 1)
+	cp := *session
+	s.rows = append(s.rows, &cp)
+	return nil
+}
+
+func (s *sessions) GetAuthSessionByRefreshTokenHash(ctx context.Context, tokenHash string) (*entity.AuthSession, error) {
+	for _, row := range s.rows {
+		if row.RefreshTokenHash == tokenHash || (row.PreviousRefreshTokenHash != nil && *row.PreviousRefreshTokenHash == tokenHash) {
+			cp := *row
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *sessions) RotateAuthSessionRefreshToken(ctx context.Context, id int64, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	for _, row := range s.rows {
+		if row.ID == id && row.RefreshTokenHash == oldHash && row.RevokedAt == nil {
+			row.PreviousRefreshTokenHash = &oldHash
+			row.RefreshTokenHash = newHash
+			row.ExpiresAt = expiresAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *sessions) RevokeAuthSession(ctx context.Context, id int64, userID int64) (bool, error) {
+	for _, row := range s.rows {
+		if row.ID == id && row.UserID == userID && row.RevokedAt == nil {
+			now := time.Now()
+			row.RevokedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *sessions) RevokeUserAuthSessions(ctx context.Context, userID int64) ([]int64, error) {
+	var ids []int64
+	for _, row := range s.rows {
+		if row.UserID == userID && row.RevokedAt == nil {
+			now := time.Now()
+			row.RevokedAt = &now
+			ids = append(ids, row.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (s *sessions) DeleteEndedAuthSessions(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+type users map[int64]role.Role
+
+func (u users) GetUserByID(ctx context.Context, id int64) (*entity.User, error) {
+	r, ok := u[id]
+	if !ok {
+		return nil, nil
+	}
+	return &entity.User{ID: id, Role: r}, nil
+}
+
+// bannedUser is a user repository with a single, banned user.
+type bannedUser int64
+
+func (b bannedUser) GetUserByID(ctx context.Context, id int64) (*entity.User, error) {
+	if id != int64(b) {
+		return nil, nil
+	}
+	bannedAt := time.Now()
+	return &entity.User{ID: id, Role: role.UserRole, BannedAt: &bannedAt}, nil
+}
+
+type revocations map[string]bool
+
+func (r revocations) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	r[key] = true
+	return nil
+}
+
+func (r revocations) Exists(ctx context.Context, key string) (bool, error) {
+	return r[key], nil
+}
+
+func newTestService(u users) (*service, *auth.JWTManager) {
+	jwtManager := auth.NewJWTManager(auth.SigningKey{ID: "1", Secret: "secret"}, nil, time.Minute)
+	return NewService(&sessions{}, u, jwtManager, revocations{}, time.Hour, time.Hour), jwtManager
+}
+
+func TestRefreshSessionRotatesAndDetectsReuse(t *testing.T) {
+	ctx := context.Background()
+	u := users{42: role.UserRole}
+	svc, jwtManager := newTestService(u)
+
+	first, err := svc.StartSession(ctx, 42, role.UserRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u[42] = role.AdminRole
+	second, err := svc.RefreshSession(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token not rotated")
+	}
+	claims, err := jwtManager.ValidateToken(second.AccessToken)
+	if err != nil || claims.Role != role.AdminRole || claims.SessionID != 1 {
+		t.Fatalf("claims = %+v, %v; want admin role of session 1", claims, err)
+	}
+
+	if _, err := svc.RefreshSession(ctx, first.RefreshToken); !errors.Is(err, marketerrors.ErrInvalidRefreshToken) {
+		t.Fatalf("reused token: err = %v, want ErrInvalidRefreshToken", err)
+	}
+	if revoked, _ := svc.IsSessionRevoked(ctx, 1); !revoked {
+		t.Fatal("session not revoked after refresh token reuse")
+	}
+	if _, err := svc.RefreshSession(ctx, second.RefreshToken); !errors.Is(err, marketerrors.ErrInvalidRefreshToken) {
+		t.Fatalf("token of revoked session: err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestLogoutAllRevokesSessions(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(users{42: role.UserRole, 7: role.UserRole})
+	for _, userID := range []int64{42, 42, 7} {
+		if _, err := svc.StartSession(ctx, userID, role.UserRole); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := svc.LogoutAll(ctx, 42)
+	if err != nil || n != 2 {
+		t.Fatalf("LogoutAll = %d, %v; want 2", n, err)
+	}
+	for id, want := range map[int64]bool{1: true, 2: true, 3: false} {
+		if revoked, _ := svc.IsSessionRevoked(ctx, id); revoked != want {
+			t.Errorf("session %d revoked = %v, want %v", id, revoked, want)
+		}
+	}
+}
+
+func TestCurrentRoleIsCached(t *testing.T) {
+	ctx := context.Background()
+	u := users{42: role.AdminRole}
+	svc, _ := newTestService(u)
+
+	if r, err := svc.CurrentRole(ctx, 42); err != nil || r != role.AdminRole {
+		t.Fatalf("CurrentRole = %v, %v", r, err)
+	}
+	u[42] = role.UserRole
+	if r, _ := svc.CurrentRole(ctx, 42); r != role.AdminRole {
+		t.Fatalf("CurrentRole = %v, want cached admin role", r)
+	}
+	svc.roleCacheTTL = 0
+	svc.cacheUser(&entity.User{ID: 42, Role: role.AdminRole})
+	if r, _ := svc.CurrentRole(ctx, 42); r != role.UserRole {
+		t.Fatalf("CurrentRole = %v, want user role after the cache expired", r)
+	}
+}
+
+func TestBannedUserCannotRefresh(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(&sessions{}, bannedUser(42), auth.NewJWTManager(auth.SigningKey{ID: "1", Secret: "secret"}, nil, time.Minute), revocations{}, time.Hour, time.Hour)
+	tokens, err := svc.StartSession(ctx, 42, role.UserRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.RefreshSession(ctx, tokens.RefreshToken); !errors.Is(err, marketerrors.ErrUserBanned) {
+		t.Fatalf("err = %v, want ErrUserBanned", err)
+	}
+	if banned, err := svc.IsUserBanned(ctx, 42); err != nil || !banned {
+		t.Fatalf("IsUserBanned = %v, %v", banned, err)
+	}
+	if banned, _ := svc.IsUserBanned(ctx, 7); banned {
+		t.Fatal("unknown user is banned")
+	}
+}
+
+func TestAccessOnlySessionEndsWithItsAccessToken(t *testing.T) {
+	ctx := context.Background()
+	repo := &sessions{}
+	jwtManager := auth.NewJWTManager(auth.SigningKey{ID: "1", Secret: "secret"}, nil, time.Minute)
+	svc := NewService(repo, users{42: role.UserRole}, jwtManager, revocations{}, time.Hour, time.Hour)
+
+	token, err := svc.StartAccessOnlySession(ctx, 42, role.UserRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := jwtManager.ValidateToken(token)
+	if err != nil || claims.TelegramID != 42 || claims.SessionID != repo.rows[0].ID {
+		t.Fatalf("claims = %+v, %v", claims, err)
+	}
+	if expires := time.Until(repo.rows[0].ExpiresAt); expires > time.Minute {
+		t.Fatalf("session expires in %v, want at most the access token TTL", expires)
+	}
+}
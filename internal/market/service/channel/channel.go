@@ -74,32 +74,39 @@ func handleRawStatsRequestedAt(raw json.RawMessage, now int64) error {
 	}
 }
 
-func (s *channelService) GetChannelStats(ctx context.Context, channelID int64, userID int64) (interface{}, error) {
+// checkStatsAccess allows channel admins and, for channels with an active listing, everyone.
+func (s *channelService) checkStatsAccess(ctx context.Context, channelID int64, userID int64) error {
 	admin, err := s.channelAdminRepo.IsChannelAdmin(ctx, userID, channelID)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if !admin {
-		listed, err := s.listingRepo.IsChannelHasActiveListing(ctx, channelID)
-		if err != nil {
-			return nil, err
-		}
-		if !listed {
-			return nil, marketerrors.ErrChannelStatsDenied
-		}
+	if admin {
+		return nil
+	}
+	listed, err := s.listingRepo.IsChannelHasActiveListing(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	if !listed {
+		return marketerrors.ErrChannelStatsDenied
 	}
+	return nil
+}
 
-	raw, err := s.channelRepo.GetChannelStats(ctx, channelID)
-	if err != nil || raw == nil {
+// GetChannelStats returns the typed stats of the channel, or nil when they were not fetched yet.
+func (s *channelService) GetChannelStats(ctx context.Context, channelID int64, userID int64) (*entity.ChannelStats, error) {
+	if err := s.checkStatsAccess(ctx, channelID, userID); err != nil {
 		return nil, err
 	}
+	return s.channelRepo.GetChannelStatsModel(ctx, channelID)
+}
 
-	// Decode and re-encode so the response is a JSON object, not a raw string.
-	var out interface{}
-	if err := json.Unmarshal(raw, &out); err != nil {
+// GetChannelStatsHistory returns the channel's stats snapshots fetched since the given time, oldest first.
+func (s *channelService) GetChannelStatsHistory(ctx context.Context, channelID int64, userID int64, since time.Time) ([]*entity.ChannelStatsSnapshot, error) {
+	if err := s.checkStatsAccess(ctx, channelID, userID); err != nil {
 		return nil, err
 	}
-	return out, nil
+	return s.channelRepo.ListChannelStatsSnapshots(ctx, channelID, since)
 }
 
 func (s *channelService) MergeStatsRequestedAt(ctx context.Context, channelID int64, requestedAtUnix int64) error {
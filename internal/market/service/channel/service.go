@@ -14,6 +14,8 @@ type channelRepository interface {
 	GetChannelByID(ctx context.Context, id int64) (*entity.Channel, error)
 	ListChannelsByAdminUserID(ctx context.Context, userID int64) ([]*entity.Channel, error)
 	GetChannelStats(ctx context.Context, channelID int64) (json.RawMessage, error)
+	GetChannelStatsModel(ctx context.Context, channelID int64) (*entity.ChannelStats, error)
+	ListChannelStatsSnapshots(ctx context.Context, channelID int64, since time.Time) ([]*entity.ChannelStatsSnapshot, error)
 	MergeStatsRequestedAt(ctx context.Context, channelID int64, requestedAtUnix int64) error
 }
 
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"strconv"
 	"strings"
@@ -15,17 +16,16 @@ import (
 const completedWorkerInterval = 30 * time.Second
 
 func (s *dealService) CreateDeal(ctx context.Context, d *entity.Deal, otherSideID int64) error {
+	if err := s.contentPolicy.Check(domain.GetMessageFromDetails(d.Details)); err != nil {
+		return err
+	}
 	d.Status = entity.DealStatusDraft
 	d.EscrowAmount = s.escrowSvc.ComputeEscrowAmount(d.Price)
-	if err := s.dealRepo.CreateDeal(ctx, d); err != nil {
-		return err
+	creatorID := d.LessorID
+	if otherSideID == d.LessorID {
+		creatorID = d.LesseeID
 	}
-	_ = s.notificationAdder.AddTelegramNotificationEvent(
-		ctx,
-		otherSideID,
-		"New deal #"+strconv.FormatInt(d.ID, 10)+" on your listing.",
-	)
-	return nil
+	return s.dealStateSvc.CreateDeal(ctx, d, entity.UserDealTransition(creatorID, "deal created"))
 }
 
 func (s *dealService) GetDeal(ctx context.Context, id int64) (*entity.Deal, error) {
@@ -58,7 +58,7 @@ func (s *dealService) GetDealsByUserID(ctx context.Context, userID int64) ([]*en
 }
 
 // UpdateDealDraft updates type, duration, price, details when status is draft. Clears both signatures.
-// Caller must be lessor or lessee.
+// Caller must be lessor or lessee; the ad message must pass the content policy.
 func (s *dealService) UpdateDealDraft(ctx context.Context, userID int64, d *entity.Deal) error {
 	existing, err := s.dealRepo.GetDealByID(ctx, d.ID)
 	if err != nil || existing == nil {
@@ -70,6 +70,9 @@ func (s *dealService) UpdateDealDraft(ctx context.Context, userID int64, d *enti
 	if userID != existing.LessorID && userID != existing.LesseeID {
 		return marketerrors.ErrUnauthorizedSide
 	}
+	if err := s.contentPolicy.Check(domain.GetMessageFromDetails(d.Details)); err != nil {
+		return err
+	}
 	d.LessorID = existing.LessorID
 	d.LesseeID = existing.LesseeID
 	d.ListingID = existing.ListingID
@@ -78,9 +81,10 @@ func (s *dealService) UpdateDealDraft(ctx context.Context, userID int64, d *enti
 	return s.dealRepo.UpdateDealDraftFieldsAndClearSignatures(ctx, d)
 }
 
-// SignDeal sets the current user's signature (hash of type, duration, price, details, user_id, payout addresses) in a transaction.
-// Both payout addresses must already be set on the deal; user's wallet must match their deal payout. Then both signatures use the same payload.
-func (s *dealService) SignDeal(ctx context.Context, userID int64, dealID int64) error {
+// SignDeal records the current user's TON Connect signData signature over the canonical deal terms.
+// Both payout addresses must already be set on the deal; the signing wallet must be the user's linked wallet and
+// match their deal payout. When both parties signed the same terms, the deal is approved.
+func (s *dealService) SignDeal(ctx context.Context, userID int64, dealID int64, signed *entity.SignedText) error {
 	existing, err := s.dealRepo.GetDealByID(ctx, dealID)
 	if err != nil || existing == nil {
 		return marketerrors.ErrNotFound
@@ -92,6 +96,9 @@ func (s *dealService) SignDeal(ctx context.Context, userID int64, dealID int64)
 	if user.WalletAddress == nil || *user.WalletAddress == "" {
 		return marketerrors.ErrWalletNotSet
 	}
+	if user.WalletVerifiedAt == nil || user.WalletPublicKey == nil {
+		return marketerrors.ErrWalletNotVerified // linked before ton_proof verification
+	}
 	if existing.LessorPayoutAddress == nil || *existing.LessorPayoutAddress == "" ||
 		existing.LesseePayoutAddress == nil || *existing.LesseePayoutAddress == "" {
 		return marketerrors.ErrPayoutNotSet
@@ -106,32 +113,39 @@ func (s *dealService) SignDeal(ctx context.Context, userID int64, dealID int64)
 	if *user.WalletAddress != myPayout {
 		return marketerrors.ErrWalletNotSet // wallet does not match deal payout
 	}
-	lessorPayout := *existing.LessorPayoutAddress
-	lesseePayout := *existing.LesseePayoutAddress
-	sig := domain.ComputeDealSignature(existing.Type, existing.Duration, existing.Price, existing.Details, userID, lessorPayout, lesseePayout)
-	if err := s.dealRepo.SignDealInTx(ctx, dealID, userID, sig); err != nil {
+
+	terms := domain.ComputeDealTerms(existing)
+	sig, err := s.verifyDealSignature(terms, *user.WalletAddress, *user.WalletPublicKey, signed)
+	if err != nil {
 		return err
 	}
+	sig.UserID = userID
 	otherID := existing.LesseeID
 	if userID == existing.LesseeID {
 		otherID = existing.LessorID
 	}
-	_ = s.notificationAdder.AddTelegramNotificationEvent(
-		ctx,
-		otherID,
-		"Deal #"+strconv.FormatInt(dealID, 10)+" was signed by the other party.",
-	)
 
-	return nil
+	return s.transactor.InSerializableTx(ctx, "SignDeal", func(ctx context.Context) error {
+		if err := s.dealRepo.SignDealInTx(ctx, dealID, userID, sig); err != nil {
+			return err
+		}
+		return s.notificationAdder.AddTelegramNotificationEvent(
+			ctx,
+			otherID,
+			"Deal #"+strconv.FormatInt(dealID, 10)+" was signed by the other party.",
+		)
+	})
 }
 
-// SetDealPayoutAddress sets the current user's payout address on the deal (lessor or lessee). Only in draft.
+// SetDealPayoutAddress sets the current user's payout address on the deal (lessor or lessee). In draft any address
+// may be set. After a failed escrow transfer only the user's ton_proof-verified wallet is accepted, so the new
+// destination of the retried transfer always comes from the user it pays.
 func (s *dealService) SetDealPayoutAddress(ctx context.Context, userID int64, dealID int64, payoutAddressRaw string) error {
 	existing, err := s.dealRepo.GetDealByID(ctx, dealID)
 	if err != nil || existing == nil {
 		return marketerrors.ErrNotFound
 	}
-	if existing.Status != entity.DealStatusDraft {
+	if existing.Status != entity.DealStatusDraft && existing.Status != entity.DealStatusEscrowTransferFailed {
 		return marketerrors.ErrDealNotDraft
 	}
 	if userID != existing.LessorID && userID != existing.LesseeID {
@@ -140,7 +154,52 @@ func (s *dealService) SetDealPayoutAddress(ctx context.Context, userID int64, de
 	if payoutAddressRaw == "" {
 		return marketerrors.ErrWalletNotSet
 	}
-	return s.dealRepo.SetDealPayoutAddress(ctx, dealID, userID, payoutAddressRaw)
+	if existing.Status == entity.DealStatusEscrowTransferFailed {
+		if payoutAddressRaw, err = s.verifiedWalletAddress(ctx, userID, payoutAddressRaw); err != nil {
+			return err
+		}
+	}
+	if err = s.dealRepo.SetDealPayoutAddress(ctx, dealID, userID, payoutAddressRaw, existing.Status); err != nil {
+		return err
+	}
+	if existing.Status == entity.DealStatusEscrowTransferFailed {
+		slog.Info("payout address changed after failed escrow transfer", "deal_id", dealID, "user_id", userID, "payout_address", payoutAddressRaw)
+	}
+	return nil
+}
+
+// verifiedWalletAddress returns the raw form of payoutAddress when it is the user's linked wallet verified with ton_proof.
+func (s *dealService) verifiedWalletAddress(ctx context.Context, userID int64, payoutAddress string) (string, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil || user == nil {
+		return "", marketerrors.ErrNotFound
+	}
+	if user.WalletAddress == nil || *user.WalletAddress == "" {
+		return "", marketerrors.ErrWalletNotSet
+	}
+	if user.WalletVerifiedAt == nil {
+		return "", marketerrors.ErrWalletNotVerified
+	}
+	addr, err := domain.ParseWalletAddress(payoutAddress)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", marketerrors.ErrInvalidWalletAddress, err)
+	}
+	if addr.StringRaw() != *user.WalletAddress {
+		return "", fmt.Errorf("%w: payout must go to your linked wallet", marketerrors.ErrInvalidWalletAddress)
+	}
+	return addr.StringRaw(), nil
+}
+
+// GetDealTimeline returns the status history of the deal, oldest first. Caller must be lessor or lessee.
+func (s *dealService) GetDealTimeline(ctx context.Context, userID int64, dealID int64) ([]*entity.DealEvent, error) {
+	d, err := s.dealRepo.GetDealByID(ctx, dealID)
+	if err != nil || d == nil {
+		return nil, marketerrors.ErrNotFound
+	}
+	if userID != d.LessorID && userID != d.LesseeID {
+		return nil, marketerrors.ErrUnauthorizedSide
+	}
+	return s.dealRepo.ListDealEvents(ctx, dealID)
 }
 
 // RejectDeal sets deal status to rejected. Only allowed when status is draft; caller must be lessor or lessee.
@@ -152,24 +211,10 @@ func (s *dealService) RejectDeal(ctx context.Context, userID int64, dealID int64
 	if userID != existing.LessorID && userID != existing.LesseeID {
 		return marketerrors.ErrUnauthorizedSide
 	}
-	updated, err := s.dealRepo.SetDealStatusRejected(ctx, dealID)
-	if err != nil {
-		return err
-	}
-	if !updated {
+	if existing.Status != entity.DealStatusDraft {
 		return marketerrors.ErrDealNotDraft
 	}
-	otherID := existing.LesseeID
-	if userID == existing.LesseeID {
-		otherID = existing.LessorID
-	}
-	_ = s.notificationAdder.AddTelegramNotificationEvent(
-		ctx,
-		otherID,
-		"Deal #"+strconv.FormatInt(dealID, 10)+" was rejected.",
-	)
-
-	return nil
+	return s.dealStateSvc.TransitionDeal(ctx, existing, entity.DealStatusRejected, entity.UserDealTransition(userID, "rejected by a side"))
 }
 
 // ExpireTimedOutDeposits marks deals in waiting_escrow_deposit with updated_at before the given time as expired (e.g. on startup: olderThan = now - 1h).
@@ -180,7 +225,8 @@ func (s *dealService) ExpireTimedOutDeposits(ctx context.Context, olderThan time
 	}
 	for _, d := range deals {
 		slog.Info("expiring timed-out deposit deal", "deal_id", d.ID, "updated_at", d.UpdatedAt)
-		if err = s.dealRepo.SetDealStatusExpiredByDealID(ctx, d.ID); err != nil {
+		t := entity.WorkerDealTransition(domain.DealWorkerDepositExpiry, "escrow deposit not received in time")
+		if err = s.dealStateSvc.TransitionDeal(ctx, d, entity.DealStatusExpired, t); err != nil {
 			slog.Error("set deal status expired", "deal_id", d.ID, "error", err)
 			continue
 		}
@@ -190,7 +236,6 @@ func (s *dealService) ExpireTimedOutDeposits(ctx context.Context, olderThan time
 
 // RunCompletedWorker moves deals from escrow_release_confirmed / escrow_refund_confirmed to completed (final status for frontend). Run in a goroutine.
 func (s *dealService) RunCompletedWorker(ctx context.Context) {
-	logger := slog.With("component", "deal_completed_worker")
 	ticker := time.NewTicker(completedWorkerInterval)
 	defer ticker.Stop()
 	for {
@@ -198,18 +243,29 @@ func (s *dealService) RunCompletedWorker(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			deals, err := s.dealRepo.ListDealsEscrowConfirmedToComplete(ctx)
-			if err != nil {
-				logger.Error("list deals to complete", "error", err)
-				continue
-			}
-			for _, d := range deals {
-				if err := s.dealRepo.SetDealStatusCompleted(ctx, d.ID); err != nil {
-					logger.Error("set deal completed", "deal_id", d.ID, "error", err)
-					continue
-				}
-				logger.Info("deal set completed", "deal_id", d.ID)
-			}
+			s.CompleteConfirmedDeals(ctx)
+		}
+	}
+}
+
+// CompleteConfirmedDeals runs a single pass of the completed worker.
+func (s *dealService) CompleteConfirmedDeals(ctx context.Context) {
+	logger := slog.With("component", "deal_completed_worker")
+	deals, err := s.dealRepo.ListDealsEscrowConfirmedToComplete(ctx)
+	if err != nil {
+		logger.Error("list deals to complete", "error", err)
+		return
+	}
+	for _, d := range deals {
+		reason := "escrow released to the lessor"
+		if d.Status == entity.DealStatusEscrowRefundConfirmed {
+			reason = "escrow refunded to the lessee"
+		}
+		t := entity.WorkerDealTransition(domain.DealWorkerCompleted, reason)
+		if err := s.dealStateSvc.TransitionDeal(ctx, d, entity.DealStatusCompleted, t); err != nil {
+			logger.Error("set deal completed", "deal_id", d.ID, "error", err)
+			continue
 		}
+		logger.Info("deal set completed", "deal_id", d.ID)
 	}
 }
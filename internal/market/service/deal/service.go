@@ -8,20 +8,17 @@ import (
 )
 
 type dealRepository interface {
-	CreateDeal(ctx context.Context, d *entity.Deal) error
 	GetDealByID(ctx context.Context, id int64) (*entity.Deal, error)
 	GetDealsByListingID(ctx context.Context, listingID int64) ([]*entity.Deal, error)
 	GetDealsByListingIDForUser(ctx context.Context, listingID int64, userID int64) ([]*entity.Deal, error)
 	ListDealsByUserID(ctx context.Context, userID int64) ([]*entity.Deal, error)
 	UpdateDealDraftFieldsAndClearSignatures(ctx context.Context, d *entity.Deal) error
-	SetDealStatusApproved(ctx context.Context, dealID int64) error
-	SignDealInTx(ctx context.Context, dealID int64, userID int64, sig string) error
-	SetDealPayoutAddress(ctx context.Context, dealID int64, userID int64, payoutAddressRaw string) error
-	SetDealStatusRejected(ctx context.Context, dealID int64) (bool, error)
+	SignDealInTx(ctx context.Context, dealID int64, userID int64, sig *entity.DealSignature) error
+	ListDealSignatures(ctx context.Context, dealID int64) ([]*entity.DealSignature, error)
+	SetDealPayoutAddress(ctx context.Context, dealID int64, userID int64, payoutAddressRaw string, status entity.DealStatus) error
 	ListDealsWaitingEscrowDepositOlderThan(ctx context.Context, before time.Time) ([]*entity.Deal, error)
-	SetDealStatusExpiredByDealID(ctx context.Context, dealID int64) error
 	ListDealsEscrowConfirmedToComplete(ctx context.Context) ([]*entity.Deal, error)
-	SetDealStatusCompleted(ctx context.Context, dealID int64) error
+	ListDealEvents(ctx context.Context, dealID int64) ([]*entity.DealEvent, error)
 }
 
 type userRepository interface {
@@ -32,22 +29,50 @@ type escrowService interface {
 	ComputeEscrowAmount(priceNanoton int64) int64
 }
 
+// dealStateService moves deals through the deal state machine and carries out the effects of the transitions.
+type dealStateService interface {
+	CreateDeal(ctx context.Context, d *entity.Deal, t entity.DealTransition) error
+	TransitionDeal(ctx context.Context, d *entity.Deal, to entity.DealStatus, t entity.DealTransition) error
+}
+
 type telegramNotificationAdder interface {
 	AddTelegramNotificationEvent(ctx context.Context, chatID int64, message string) error
 }
 
+// transactor runs fn in a database transaction; the outbox notification adder writes in it, so notifications
+// are published only for committed changes.
+type transactor interface {
+	InTx(ctx context.Context, source string, fn func(ctx context.Context) error) error
+	InSerializableTx(ctx context.Context, source string, fn func(ctx context.Context) error) error
+}
+
+// contentPolicy refuses ad messages with blocked words, patterns or links.
+type contentPolicy interface {
+	Check(text string) error
+}
+
 type dealService struct {
 	dealRepo          dealRepository
 	userRepo          userRepository
 	escrowSvc         escrowService
+	dealStateSvc      dealStateService
 	notificationAdder telegramNotificationAdder
+	transactor        transactor
+	contentPolicy     contentPolicy
+	signDataDomain    string
+	signDataMaxAge    time.Duration
 }
 
-func NewDealService(dealRepo dealRepository, userRepo userRepository, escrowSvc escrowService, notificationAdder telegramNotificationAdder) *dealService {
+func NewDealService(dealRepo dealRepository, userRepo userRepository, escrowSvc escrowService, dealStateSvc dealStateService, notificationAdder telegramNotificationAdder, transactor transactor, contentPolicy contentPolicy, signDataDomain string, signDataMaxAge time.Duration) *dealService {
 	return &dealService{
 		dealRepo:          dealRepo,
 		userRepo:          userRepo,
 		escrowSvc:         escrowSvc,
+		dealStateSvc:      dealStateSvc,
 		notificationAdder: notificationAdder,
+		transactor:        transactor,
+		contentPolicy:     contentPolicy,
+		signDataDomain:    signDataDomain,
+		signDataMaxAge:    signDataMaxAge,
 	}
 }
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ads-mrkt/internal/market/domain"
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+
+	"github.com/xssnick/tonutils-go/address"
+)
+
+const signDataPrefix = "ton-connect/sign-data/"
+
+// GetDealTerms returns the canonical terms document the user must sign with signData. Caller must be lessor or lessee.
+func (s *dealService) GetDealTerms(ctx context.Context, userID int64, dealID int64) (*entity.DealTerms, error) {
+	d, err := s.dealRepo.GetDealByID(ctx, dealID)
+	if err != nil || d == nil {
+		return nil, marketerrors.ErrNotFound
+	}
+	if userID != d.LessorID && userID != d.LesseeID {
+		return nil, marketerrors.ErrUnauthorizedSide
+	}
+	return domain.ComputeDealTerms(d), nil
+}
+
+// ExportSignedDealTerms returns the deal terms with both parties' wallet signatures, so anyone holding the export
+// can verify them without trusting the server. Caller must be lessor or lessee.
+func (s *dealService) ExportSignedDealTerms(ctx context.Context, userID int64, dealID int64) (*entity.SignedDealTerms, error) {
+	d, err := s.dealRepo.GetDealByID(ctx, dealID)
+	if err != nil || d == nil {
+		return nil, marketerrors.ErrNotFound
+	}
+	if userID != d.LessorID && userID != d.LesseeID {
+		return nil, marketerrors.ErrUnauthorizedSide
+	}
+	signatures, err := s.dealRepo.ListDealSignatures(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.SignedDealTerms{Deal: d, Terms: domain.ComputeDealTerms(d), Signatures: signatures}, nil
+}
+
+// verifyDealSignature checks that signed is a fresh signData over exactly the deal terms, made for our domain
+// by the user's linked wallet.
+func (s *dealService) verifyDealSignature(terms *entity.DealTerms, walletAddressRaw, publicKeyHex string, signed *entity.SignedText) (*entity.DealSignature, error) {
+	if signed == nil {
+		return nil, fmt.Errorf("%w: signature is required", marketerrors.ErrInvalidDealSignature)
+	}
+	if signed.Text != terms.Terms {
+		return nil, fmt.Errorf("%w: signed text is not the current deal terms", marketerrors.ErrInvalidDealSignature)
+	}
+	addr, err := domain.ParseWalletAddress(signed.Address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", marketerrors.ErrInvalidWalletAddress, err)
+	}
+	if addr.StringRaw() != walletAddressRaw {
+		return nil, fmt.Errorf("%w: signed by a wallet other than the linked one", marketerrors.ErrInvalidDealSignature)
+	}
+	if !strings.EqualFold(signed.Domain, s.signDataDomain) {
+		return nil, fmt.Errorf("%w: unexpected domain %q", marketerrors.ErrInvalidDealSignature, signed.Domain)
+	}
+	signedAt := time.Unix(signed.Timestamp, 0)
+	if skew := time.Since(signedAt); skew > s.signDataMaxAge || skew < -s.signDataMaxAge {
+		return nil, fmt.Errorf("%w: timestamp out of range", marketerrors.ErrInvalidDealSignature)
+	}
+	pubKey, err := hex.DecodeString(publicKeyHex)
+	if err != nil || len(pubKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: linked wallet has no valid public key", marketerrors.ErrWalletNotSet)
+	}
+	if err = verifySignDataText(addr, signed, pubKey); err != nil {
+		return nil, fmt.Errorf("%w: %v", marketerrors.ErrInvalidDealSignature, err)
+	}
+
+	return &entity.DealSignature{
+		DealID:        terms.DealID,
+		Terms:         terms.Terms,
+		TermsHash:     terms.TermsHash,
+		WalletAddress: walletAddressRaw,
+		PublicKey:     publicKeyHex,
+		Domain:        signed.Domain,
+		SignedAt:      signedAt,
+		Signature:     base64.StdEncoding.EncodeToString(signed.Signature),
+	}, nil
+}
+
+// verifySignDataText checks the signature over
+// sha256(0xffff ++ "ton-connect/sign-data/" ++ workchain ++ hash ++ domain len ++ domain ++ timestamp ++ "txt" ++ text len ++ text).
+func verifySignDataText(addr *address.Address, signed *entity.SignedText, pubKey ed25519.PublicKey) error {
+	if len(signed.Signature) != ed25519.SignatureSize {
+		return errors.New("invalid signature length")
+	}
+	msgHash := sha256.Sum256(SignDataTextMessage(addr, signed.Domain, signed.Timestamp, signed.Text))
+	if !ed25519.Verify(pubKey, msgHash[:], signed.Signature) {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
+
+// SignDataTextMessage builds the message a TON wallet hashes and signs for a signData "text" payload.
+func SignDataTextMessage(addr *address.Address, domainName string, timestamp int64, text string) []byte {
+	var msg bytes.Buffer
+	msg.Write([]byte{0xff, 0xff})
+	msg.WriteString(signDataPrefix)
+	_ = binary.Write(&msg, binary.BigEndian, addr.Workchain())
+	msg.Write(addr.Data())
+	_ = binary.Write(&msg, binary.BigEndian, uint32(len(domainName)))
+	msg.WriteString(domainName)
+	_ = binary.Write(&msg, binary.BigEndian, uint64(timestamp))
+	msg.WriteString("txt")
+	_ = binary.Write(&msg, binary.BigEndian, uint32(len(text)))
+	msg.WriteString(text)
+	return msg.Bytes()
+}
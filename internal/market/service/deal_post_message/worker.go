@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"time"
 
+	"ads-mrkt/internal/market/domain"
 	"ads-mrkt/internal/market/domain/entity"
 )
 
@@ -12,17 +13,34 @@ const workerInterval = 5 * time.Minute
 
 type repository interface {
 	ListDealPostMessageByStatus(ctx context.Context, status entity.DealPostMessageStatus) ([]*entity.DealPostMessage, error)
-	CompleteDealPostMessagesAndSetDealsWaitingEscrowRelease(ctx context.Context, ids []int64) error
-	FailDealPostMessagesAndSetDealsWaitingEscrowRefund(ctx context.Context, ids []int64) error
+	UpdateDealPostMessageStatus(ctx context.Context, id int64, status entity.DealPostMessageStatus) error
+}
+
+type dealRepository interface {
+	GetDealByID(ctx context.Context, id int64) (*entity.Deal, error)
+}
+
+type dealStateService interface {
+	TransitionDeal(ctx context.Context, d *entity.Deal, to entity.DealStatus, t entity.DealTransition) error
+}
+
+type transactor interface {
+	InTx(ctx context.Context, source string, fn func(ctx context.Context) error) error
 }
 
 type service struct {
-	repository repository
+	repository   repository
+	dealRepo     dealRepository
+	dealStateSvc dealStateService
+	transactor   transactor
 }
 
-func NewService(repository repository) *service {
+func NewService(repository repository, dealRepo dealRepository, dealStateSvc dealStateService, transactor transactor) *service {
 	return &service{
-		repository: repository,
+		repository:   repository,
+		dealRepo:     dealRepo,
+		dealStateSvc: dealStateSvc,
+		transactor:   transactor,
 	}
 }
 
@@ -34,34 +52,48 @@ func (s *service) RunPassedWorker(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			passedList, err := s.repository.ListDealPostMessageByStatus(ctx, entity.DealPostMessageStatusPassed)
-			if err != nil {
-				slog.Error("deal_post_message worker: list passed", "error", err)
-			} else if len(passedList) > 0 {
-				ids := make([]int64, 0, len(passedList))
-				for _, m := range passedList {
-					ids = append(ids, m.ID)
-				}
-				if err := s.repository.CompleteDealPostMessagesAndSetDealsWaitingEscrowRelease(ctx, ids); err != nil {
-					slog.Error("deal_post_message worker: complete (passed)", "error", err)
-				} else {
-					slog.Info("deal_post_message worker: completed (passed)", "count", len(ids), "ids", ids)
-				}
+			s.ProcessFinishedPosts(ctx)
+		}
+	}
+}
+
+// ProcessFinishedPosts moves deals with passed posts to waiting_escrow_release and deals with deleted posts to waiting_escrow_refund.
+func (s *service) ProcessFinishedPosts(ctx context.Context) {
+	s.finishPosts(ctx, entity.DealPostMessageStatusPassed, entity.DealPostMessageStatusCompleted, entity.DealStatusWaitingEscrowRelease,
+		"ad post stayed for the whole deal duration")
+	s.finishPosts(ctx, entity.DealPostMessageStatusDeleted, entity.DealPostMessageStatusFailed, entity.DealStatusWaitingEscrowRefund,
+		"ad post deleted before the deal ended")
+}
+
+// finishPosts sets the posts in status from to status done and moves each in-progress deal to the deal status to,
+// one post per transaction. Posts of deals that already left in_progress are only closed.
+func (s *service) finishPosts(ctx context.Context, from, done entity.DealPostMessageStatus, to entity.DealStatus, reason string) {
+	list, err := s.repository.ListDealPostMessageByStatus(ctx, from)
+	if err != nil {
+		slog.Error("deal_post_message worker: list", "status", from, "error", err)
+		return
+	}
+	for _, m := range list {
+		err := s.transactor.InTx(ctx, "finishPosts", func(ctx context.Context) error {
+			if err := s.repository.UpdateDealPostMessageStatus(ctx, m.ID, done); err != nil {
+				return err
 			}
-			deletedList, err := s.repository.ListDealPostMessageByStatus(ctx, entity.DealPostMessageStatusDeleted)
+			d, err := s.dealRepo.GetDealByID(ctx, m.DealID)
 			if err != nil {
-				slog.Error("deal_post_message worker: list deleted", "error", err)
-			} else if len(deletedList) > 0 {
-				ids := make([]int64, 0, len(deletedList))
-				for _, m := range deletedList {
-					ids = append(ids, m.ID)
-				}
-				if err := s.repository.FailDealPostMessagesAndSetDealsWaitingEscrowRefund(ctx, ids); err != nil {
-					slog.Error("deal_post_message worker: fail (deleted)", "error", err)
-				} else {
-					slog.Info("deal_post_message worker: failed (deleted)", "count", len(ids), "ids", ids)
-				}
+				return err
+			}
+			if d == nil || d.Status != entity.DealStatusInProgress {
+				slog.Warn("deal_post_message worker: deal no longer in progress", "id", m.ID, "deal_id", m.DealID)
+				return nil
 			}
+			t := entity.WorkerDealTransition(domain.DealWorkerPostMessage, reason)
+			t.MessageID = &m.MessageID
+			return s.dealStateSvc.TransitionDeal(ctx, d, to, t)
+		})
+		if err != nil {
+			slog.Error("deal_post_message worker: finish post", "id", m.ID, "deal_id", m.DealID, "status", done, "error", err)
+			continue
 		}
+		slog.Info("deal_post_message worker: finished post", "id", m.ID, "deal_id", m.DealID, "status", done)
 	}
 }
@@ -0,0 +1,142 @@
+package deal_state
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"ads-mrkt/internal/market/domain"
+	"ads-mrkt/internal/market/domain/entity"
+	"ads-mrkt/pkg/auth/role"
+)
+
+type dealRepository interface {
+	CreateDeal(ctx context.Context, d *entity.Deal, t entity.DealTransition) error
+	TransitionDeal(ctx context.Context, d *entity.Deal, to entity.DealStatus, t entity.DealTransition) error
+}
+
+type userRepository interface {
+	ListUserIDsByRole(ctx context.Context, userRole role.Role) ([]int64, error)
+}
+
+type notificationAdder interface {
+	AddTelegramNotificationEvent(ctx context.Context, chatID int64, message string) error
+}
+
+type dealChatService interface {
+	DeleteDealForumTopic(ctx context.Context, dealID int64) error
+}
+
+// transactor runs fn in a database transaction; the outbox notification adder writes in it.
+type transactor interface {
+	InTx(ctx context.Context, source string, fn func(ctx context.Context) error) error
+}
+
+// service moves deals through the domain state machine and carries out the effects of each transition.
+type service struct {
+	dealRepo          dealRepository
+	userRepo          userRepository
+	notificationAdder notificationAdder
+	dealChatService   dealChatService
+	transactor        transactor
+}
+
+func NewService(dealRepo dealRepository, userRepo userRepository, notificationAdder notificationAdder, dealChatService dealChatService, transactor transactor) *service {
+	return &service{
+		dealRepo:          dealRepo,
+		userRepo:          userRepo,
+		notificationAdder: notificationAdder,
+		dealChatService:   dealChatService,
+		transactor:        transactor,
+	}
+}
+
+// CreateDeal inserts the deal in its initial status and carries out the effects of the creation.
+func (s *service) CreateDeal(ctx context.Context, d *entity.Deal, t entity.DealTransition) error {
+	rule, err := domain.CheckDealTransition("", d.Status, t)
+	if err != nil {
+		return err
+	}
+	return s.apply(ctx, d, rule, t, func(ctx context.Context) error {
+		return s.dealRepo.CreateDeal(ctx, d, t)
+	})
+}
+
+// TransitionDeal moves d to status to and carries out the effects of the transition. Notifications are written in
+// the transaction of the status change; the forum topic is deleted once the change is committed, so callers should
+// not wrap transitions that delete it in a transaction of their own.
+func (s *service) TransitionDeal(ctx context.Context, d *entity.Deal, to entity.DealStatus, t entity.DealTransition) error {
+	rule, err := domain.CheckDealTransition(d.Status, to, t)
+	if err != nil {
+		return err
+	}
+	return s.apply(ctx, d, rule, t, func(ctx context.Context) error {
+		return s.dealRepo.TransitionDeal(ctx, d, to, t)
+	})
+}
+
+func (s *service) apply(ctx context.Context, d *entity.Deal, rule *domain.DealTransitionRule, t entity.DealTransition, write func(ctx context.Context) error) error {
+	err := s.transactor.InTx(ctx, "TransitionDeal", func(ctx context.Context) error {
+		if err := write(ctx); err != nil {
+			return err
+		}
+		for _, effect := range rule.Effects {
+			switch effect {
+			case domain.DealEffectNotifyOtherSide:
+				if err := s.notificationAdder.AddTelegramNotificationEvent(ctx, otherSideID(d, t), otherSideMessage(d)); err != nil {
+					return err
+				}
+			case domain.DealEffectNotifyAdmins:
+				if err := s.notifyAdmins(ctx, d, t); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if slices.Contains(rule.Effects, domain.DealEffectDeleteForumTopic) {
+		if err := s.dealChatService.DeleteDealForumTopic(ctx, d.ID); err != nil {
+			slog.Error("delete deal forum topic", "deal_id", d.ID, "status", d.Status, "error", err)
+		}
+	}
+	return nil
+}
+
+func (s *service) notifyAdmins(ctx context.Context, d *entity.Deal, t entity.DealTransition) error {
+	adminIDs, err := s.userRepo.ListUserIDsByRole(ctx, role.AdminRole)
+	if err != nil {
+		return err
+	}
+	message := "Deal #" + strconv.FormatInt(d.ID, 10) + " is " + string(d.Status) + " and needs admin action: " + t.Reason
+	for _, adminID := range adminIDs {
+		if err := s.notificationAdder.AddTelegramNotificationEvent(ctx, adminID, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// otherSideID returns the side of the deal that did not make the transition.
+func otherSideID(d *entity.Deal, t entity.DealTransition) int64 {
+	if t.ActorUserID != nil && *t.ActorUserID == d.LessorID {
+		return d.LesseeID
+	}
+	return d.LessorID
+}
+
+func otherSideMessage(d *entity.Deal) string {
+	id := strconv.FormatInt(d.ID, 10)
+	switch d.Status {
+	case entity.DealStatusDraft:
+		return "New deal #" + id + " on your listing."
+	case entity.DealStatusRejected:
+		return "Deal #" + id + " was rejected."
+	default:
+		return "Deal #" + id + " is now " + string(d.Status) + "."
+	}
+}
@@ -2,71 +2,61 @@ package escrow
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"time"
 
+	"ads-mrkt/internal/event/application/consumer"
 	evententity "ads-mrkt/internal/event/domain/entity"
+	"ads-mrkt/internal/market/domain"
+	"ads-mrkt/internal/market/domain/entity"
 )
 
-const (
-	escrowDepositGroup        = "market"
-	escrowDepositConsumer     = "escrow-deposit"
-	escrowDepositReadCount    = 50
-	escrowDepositPollInterval = 2 * time.Second
-)
+var escrowDepositConsumerConfig = consumer.Config{
+	Group:     "market",
+	Consumer:  "escrow-deposit",
+	BatchSize: 50,
+	Block:     2 * time.Second,
+}
 
 type escrowDepositEventService interface {
-	ReadEscrowDepositEvents(ctx context.Context, group, consumer string, limit int64) ([]*evententity.EventEscrowDeposit, error)
-	AckEscrowDepositMessages(ctx context.Context, group string, messageIDs []string) error
+	ConsumeEscrowDepositEvents(ctx context.Context, cfg consumer.Config, handler consumer.Handler[*evententity.EventEscrowDeposit]) error
 }
 
 func (s *service) DepositStreamWorker(ctx context.Context, eventService escrowDepositEventService) {
 	logger := slog.With("component", "escrow_deposit_worker")
-	ticker := time.NewTicker(escrowDepositPollInterval)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			s.processDepositEvents(ctx, eventService, logger)
-		}
+	err := eventService.ConsumeEscrowDepositEvents(ctx, escrowDepositConsumerConfig, func(ctx context.Context, ev *evententity.EventEscrowDeposit) error {
+		return s.handleDepositEvent(ctx, logger, ev)
+	})
+	if err != nil {
+		logger.Error("escrow deposit worker", "error", err)
 	}
 }
 
-func (s *service) processDepositEvents(ctx context.Context, eventService escrowDepositEventService, logger *slog.Logger) {
-	events, err := eventService.ReadEscrowDepositEvents(ctx, escrowDepositGroup, escrowDepositConsumer, escrowDepositReadCount)
-	if err != nil || len(events) == 0 {
-		return
+// handleDepositEvent confirms the deal escrowed at the deposit address; unknown addresses and short deposits
+// are dropped.
+func (s *service) handleDepositEvent(ctx context.Context, logger *slog.Logger, ev *evententity.EventEscrowDeposit) error {
+	deal, err := s.dealRepo.GetDealByEscrowAddress(ctx, ev.Address)
+	if err != nil {
+		return fmt.Errorf("get deal by escrow address %s: %w", ev.Address, err)
+	}
+	if deal == nil {
+		return nil
+	}
+	if ev.Amount < deal.EscrowAmount {
+		logger.Info("amount too low", "deal_id", deal.ID, "address", ev.Address, "amount", ev.Amount, "escrow_amount", deal.EscrowAmount)
+		return nil
+	}
+	t := entity.WorkerDealTransition(domain.DealWorkerEscrowDeposit, "escrow deposit received")
+	if ev.TxHash != "" {
+		t.TxHash = &ev.TxHash
 	}
-	var ids []string
-	for _, ev := range events {
-		deal, err := s.dealRepo.GetDealByEscrowAddress(ctx, ev.Address)
-		if err != nil {
-			logger.Error("get deal", "address", ev.Address, "error", err)
-			ids = append(ids, ev.ID)
-			continue
-		}
-		if deal == nil {
-			ids = append(ids, ev.ID)
-			continue
-		}
-		if ev.Amount < deal.EscrowAmount {
-			logger.Info("amount too low", "deal_id", deal.ID, "address", ev.Address, "amount", ev.Amount, "escrow_amount", deal.EscrowAmount)
-			ids = append(ids, ev.ID)
-			continue
-		}
-		if err := s.dealRepo.SetDealStatusEscrowDepositConfirmed(ctx, deal.ID); err != nil {
-			logger.Error("set status", "deal_id", deal.ID, "error", err)
-			continue
-		}
-		if deal.EscrowAddress != nil && *deal.EscrowAddress != "" {
-			_ = s.redis.Del(ctx, *deal.EscrowAddress)
-		}
-		logger.Info("escrow deposit confirmed", "deal_id", deal.ID, "address", ev.Address)
-		ids = append(ids, ev.ID)
+	if err := s.dealStateSvc.TransitionDeal(ctx, deal, entity.DealStatusEscrowDepositConfirmed, t); err != nil {
+		return fmt.Errorf("set deal %d status: %w", deal.ID, err)
 	}
-	if len(ids) > 0 {
-		_ = eventService.AckEscrowDepositMessages(ctx, escrowDepositGroup, ids)
+	if deal.EscrowAddress != nil && *deal.EscrowAddress != "" {
+		_ = s.redis.Del(ctx, *deal.EscrowAddress)
 	}
+	logger.Info("escrow deposit confirmed", "deal_id", deal.ID, "address", ev.Address)
+	return nil
 }
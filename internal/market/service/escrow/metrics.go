@@ -0,0 +1,53 @@
+package escrow
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus data collector definitions
+
+var promReconciliationMismatches = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "ads_mrkt_escrow_reconciliation_mismatches",
+		Help: "Number of escrow wallets that do not match their deal, by kind, as of the last reconciliation",
+	},
+	[]string{"kind"},
+)
+
+var promReconciliationDealsChecked = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "ads_mrkt_escrow_reconciliation_deals_checked",
+		Help: "Number of deals checked by the last reconciliation",
+	},
+)
+
+var promEscrowHeldBalance = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "ads_mrkt_escrow_held_balance_nanoton",
+		Help: "Sum of on-chain balances of escrow wallets for funded, not yet paid out deals",
+	},
+)
+
+var promReconciliationLastRun = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "ads_mrkt_escrow_reconciliation_last_run_timestamp_seconds",
+		Help: "Unix time of the last finished reconciliation",
+	},
+)
+
+var promEscrowTransferFailures = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "ads_mrkt_escrow_transfer_failures_total",
+		Help: "Number of failed escrow release/refund transfer attempts, by action",
+	},
+	[]string{"action"},
+)
+
+var promEscrowTransferDeadLetters = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "ads_mrkt_escrow_transfer_dead_letters_total",
+		Help: "Number of deals moved to escrow_transfer_failed after exhausting transfer attempts, by action",
+	},
+	[]string{"action"},
+)
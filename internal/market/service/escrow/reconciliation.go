@@ -0,0 +1,233 @@
+package escrow
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+
+	"github.com/xssnick/tonutils-go/address"
+)
+
+const (
+	reconciliationInterval = 10 * time.Minute
+	// reconciliationLookback limits how long closed deals keep being checked for leftover funds.
+	reconciliationLookback = 30 * 24 * time.Hour
+	// reconciliationMinFailures is the number of failed release/refund attempts reported as repeated failures.
+	reconciliationMinFailures = 3
+)
+
+// ReconciliationWorker periodically compares escrow wallet balances with deal statuses.
+func (s *service) ReconciliationWorker(ctx context.Context) {
+	logger := slog.With("component", "escrow_reconciliation_worker")
+	ticker := time.NewTicker(reconciliationInterval)
+	defer ticker.Stop()
+
+	run := func(ctx context.Context) {
+		report, err := s.ReconcileEscrows(ctx)
+		if err != nil {
+			logger.Error("reconcile escrows", "error", err)
+			return
+		}
+		if len(report.Mismatches) > 0 {
+			logger.Warn("escrow reconciliation found mismatches", "deals_checked", report.DealsChecked, "mismatches", len(report.Mismatches))
+		}
+	}
+
+	run(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run(ctx)
+		}
+	}
+}
+
+// GetEscrowReconciliationReport returns the last reconciliation report, running one if none exists yet.
+func (s *service) GetEscrowReconciliationReport(ctx context.Context) (*entity.EscrowReconciliationReport, error) {
+	s.reconciliationMu.Lock()
+	report := s.lastReconciliation
+	s.reconciliationMu.Unlock()
+	if report != nil {
+		return report, nil
+	}
+	return s.ReconcileEscrows(ctx)
+}
+
+// ReconcileEscrows reads the balance of every escrow wallet and reports wallets that do not match their deal.
+func (s *service) ReconcileEscrows(ctx context.Context) (*entity.EscrowReconciliationReport, error) {
+	report := &entity.EscrowReconciliationReport{
+		StartedAt:  time.Now().UTC(),
+		Mismatches: []*entity.EscrowMismatch{},
+	}
+
+	deals, err := s.dealRepo.ListDealsWithEscrowForReconciliation(ctx, time.Now().Add(-reconciliationLookback))
+	if err != nil {
+		return nil, fmt.Errorf("list deals with escrow: %w", err)
+	}
+	dealsByID := make(map[int64]*entity.Deal, len(deals))
+	for _, deal := range deals {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		dealsByID[deal.ID] = deal
+		report.DealsChecked++
+
+		balance, err := s.escrowBalance(ctx, deal)
+		if err != nil {
+			report.Mismatches = append(report.Mismatches, &entity.EscrowMismatch{
+				Kind:          entity.EscrowMismatchBalanceUnavailable,
+				DealID:        deal.ID,
+				DealStatus:    deal.Status,
+				EscrowAddress: *deal.EscrowAddress,
+				Detail:        err.Error(),
+			})
+			continue
+		}
+		if isEscrowHeld(deal.Status) {
+			report.HeldBalanceNanoton += balance
+		}
+		if m := s.checkEscrowBalance(deal, balance); m != nil {
+			report.Mismatches = append(report.Mismatches, m)
+		}
+	}
+
+	failures, err := s.dealActionLockRepo.ListDealActionFailures(ctx, reconciliationMinFailures)
+	if err != nil {
+		return nil, fmt.Errorf("list deal action failures: %w", err)
+	}
+	for _, f := range failures {
+		m := &entity.EscrowMismatch{
+			Kind:            entity.EscrowMismatchRepeatedTransferFailures,
+			DealID:          f.DealID,
+			FailedTransfers: f.Failures,
+			Detail:          fmt.Sprintf("%s failed %d times, last at %s", f.ActionType, f.Failures, f.LastFailureAt.UTC().Format(time.RFC3339)),
+		}
+		if deal, ok := dealsByID[f.DealID]; ok {
+			m.DealStatus = deal.Status
+			m.EscrowAddress = *deal.EscrowAddress
+		}
+		report.Mismatches = append(report.Mismatches, m)
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	s.reconciliationMu.Lock()
+	s.lastReconciliation = report
+	s.reconciliationMu.Unlock()
+	setReconciliationMetrics(report)
+	return report, nil
+}
+
+// GetEscrowState reads the escrow wallet of the deal and its last release and refund attempts. A balance that
+// cannot be read is reported in the state rather than as an error.
+func (s *service) GetEscrowState(ctx context.Context, deal *entity.Deal) (*entity.EscrowState, error) {
+	state := &entity.EscrowState{
+		Address:       deal.EscrowAddress,
+		Held:          isEscrowHeld(deal.Status),
+		PayoutNanoton: s.GetAmountWithoutGasAndCommission(deal.EscrowAmount),
+	}
+	var err error
+	if state.LastRelease, err = s.dealActionLockRepo.GetLastDealActionLock(ctx, deal.ID, entity.DealActionTypeEscrowRelease); err != nil {
+		return nil, err
+	}
+	if state.LastRefund, err = s.dealActionLockRepo.GetLastDealActionLock(ctx, deal.ID, entity.DealActionTypeEscrowRefund); err != nil {
+		return nil, err
+	}
+	if deal.EscrowAddress == nil {
+		return state, nil
+	}
+
+	balance, err := s.escrowBalance(ctx, deal)
+	if err != nil {
+		state.BalanceError = err.Error()
+		return state, nil
+	}
+	state.BalanceNanoton = &balance
+	state.Mismatch = s.checkEscrowBalance(deal, balance)
+	return state, nil
+}
+
+func (s *service) escrowBalance(ctx context.Context, deal *entity.Deal) (int64, error) {
+	addr, err := address.ParseRawAddr(*deal.EscrowAddress)
+	if err != nil {
+		return 0, fmt.Errorf("parse escrow address: %w", err)
+	}
+	return s.liteclient.GetAccountBalance(ctx, addr)
+}
+
+// isEscrowHeld reports whether the escrow wallet is expected to hold the deposit.
+func isEscrowHeld(status entity.DealStatus) bool {
+	switch status {
+	case entity.DealStatusEscrowDepositConfirmed,
+		entity.DealStatusInProgress,
+		entity.DealStatusWaitingEscrowRelease,
+		entity.DealStatusWaitingEscrowRefund,
+		entity.DealStatusEscrowTransferFailed:
+		return true
+	}
+	return false
+}
+
+// checkEscrowBalance returns a mismatch when the balance does not fit the deal status, nil otherwise.
+func (s *service) checkEscrowBalance(deal *entity.Deal, balance int64) *entity.EscrowMismatch {
+	payout := s.GetAmountWithoutGasAndCommission(deal.EscrowAmount)
+	m := &entity.EscrowMismatch{
+		DealID:         deal.ID,
+		DealStatus:     deal.Status,
+		EscrowAddress:  *deal.EscrowAddress,
+		BalanceNanoton: balance,
+	}
+	switch {
+	case deal.Status == entity.DealStatusWaitingEscrowDeposit:
+		if balance < deal.EscrowAmount {
+			return nil
+		}
+		m.Kind = entity.EscrowMismatchDepositNotConfirmed
+		m.ExpectedNanoton = deal.EscrowAmount
+		m.Detail = "escrow is funded but the deposit was not confirmed"
+	case isEscrowHeld(deal.Status):
+		if balance >= payout {
+			return nil
+		}
+		m.Kind = entity.EscrowMismatchUnfunded
+		m.ExpectedNanoton = deal.EscrowAmount
+		m.Detail = "balance is below the payout amount"
+	case deal.Status == entity.DealStatusEscrowReleaseConfirmed,
+		deal.Status == entity.DealStatusEscrowRefundConfirmed,
+		deal.Status == entity.DealStatusCompleted:
+		// After the payout only commission and unused gas may remain.
+		if balance < payout {
+			return nil
+		}
+		m.Kind = entity.EscrowMismatchFundsLeftAfterPayout
+		m.ExpectedNanoton = deal.EscrowAmount - payout
+		m.Detail = "payout is confirmed but the payout amount is still on the wallet"
+	case deal.Status == entity.DealStatusExpired,
+		deal.Status == entity.DealStatusRejected:
+		if balance <= s.transactionGasNanoton {
+			return nil
+		}
+		m.Kind = entity.EscrowMismatchFundsOnClosedDeal
+		m.Detail = "deal is closed but the escrow wallet holds funds"
+	default:
+		return nil
+	}
+	return m
+}
+
+func setReconciliationMetrics(report *entity.EscrowReconciliationReport) {
+	counts := make(map[entity.EscrowMismatchKind]int, len(entity.EscrowMismatchKinds))
+	for _, m := range report.Mismatches {
+		counts[m.Kind]++
+	}
+	for _, kind := range entity.EscrowMismatchKinds {
+		promReconciliationMismatches.WithLabelValues(string(kind)).Set(float64(counts[kind]))
+	}
+	promReconciliationDealsChecked.Set(float64(report.DealsChecked))
+	promEscrowHeldBalance.Set(float64(report.HeldBalanceNanoton))
+	promReconciliationLastRun.Set(float64(report.FinishedAt.Unix()))
+}
@@ -1,12 +1,14 @@
 package escrow
 
 import (
+	"ads-mrkt/internal/market/domain"
 	"ads-mrkt/internal/market/domain/entity"
 	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/xssnick/tonutils-go/address"
@@ -25,10 +27,14 @@ type dealRepository interface {
 	ListDealsApprovedWithoutEscrow(ctx context.Context) ([]*entity.Deal, error)
 	ListDealsWaitingEscrowRelease(ctx context.Context) ([]*entity.Deal, error)
 	ListDealsWaitingEscrowRefund(ctx context.Context) ([]*entity.Deal, error)
-	SetDealEscrowAddress(ctx context.Context, dealID int64, address string) error
-	SetDealStatusEscrowDepositConfirmed(ctx context.Context, dealID int64) error
-	SetDealStatusEscrowReleaseConfirmed(ctx context.Context, dealID int64) error
-	SetDealStatusEscrowRefundConfirmed(ctx context.Context, dealID int64) error
+	ListDealsWithEscrowForReconciliation(ctx context.Context, closedAfter time.Time) ([]*entity.Deal, error)
+	ListDealsEscrowTransferFailed(ctx context.Context) ([]*entity.Deal, error)
+}
+
+// dealStateService moves deals through the deal state machine and carries out the effects of the transitions
+// (admin notifications, forum topic cleanup).
+type dealStateService interface {
+	TransitionDeal(ctx context.Context, d *entity.Deal, to entity.DealStatus, t entity.DealTransition) error
 }
 
 type vaultRepository interface {
@@ -40,11 +46,15 @@ type dealActionLockRepository interface {
 	TakeDealActionLock(ctx context.Context, dealID int64, actionType entity.DealActionType) (string, error)
 	ReleaseDealActionLock(ctx context.Context, lockID string, status entity.DealActionLockStatus) error
 	GetLastDealActionLock(ctx context.Context, dealID int64, actionType entity.DealActionType) (*entity.DealActionLock, error)
+	FailDealActionLock(ctx context.Context, lockID string, lastError string, baseBackoff, maxBackoff time.Duration) (int, error)
+	ResetDealActionAttempts(ctx context.Context, dealID int64, actionType entity.DealActionType) error
+	ListDealActionFailures(ctx context.Context, minFailures int) ([]*entity.DealActionFailures, error)
 }
 
 type liteclient interface {
 	Client() ton.APIClientWrapped
 	HasOutgoingTxTo(ctx context.Context, fromAddrRaw *address.Address, amountNanoton int64, toAddr *address.Address) (bool, error)
+	GetAccountBalance(ctx context.Context, addr *address.Address) (int64, error)
 }
 
 type redisCache interface {
@@ -52,29 +62,28 @@ type redisCache interface {
 	Del(ctx context.Context, keys ...string) error
 }
 
-type dealChatService interface {
-	DeleteDealForumTopic(ctx context.Context, dealID int64) error
-}
-
 type service struct {
 	dealRepo              dealRepository
 	vaultRepository       vaultRepository
 	dealActionLockRepo    dealActionLockRepository
 	liteclient            liteclient
 	redis                 redisCache
-	dealChatService       dealChatService
+	dealStateSvc          dealStateService
 	transactionGasNanoton int64
 	comissionMultiplier   float64
+
+	reconciliationMu   sync.Mutex
+	lastReconciliation *entity.EscrowReconciliationReport
 }
 
-func NewService(dealRepo dealRepository, vaultRepository vaultRepository, dealActionLockRepo dealActionLockRepository, liteclient liteclient, redis redisCache, dealChatService dealChatService, transactionGasTON float64, commissionPercent float64) *service {
+func NewService(dealRepo dealRepository, vaultRepository vaultRepository, dealActionLockRepo dealActionLockRepository, liteclient liteclient, redis redisCache, dealStateSvc dealStateService, transactionGasTON float64, commissionPercent float64) *service {
 	return &service{
 		dealRepo:              dealRepo,
 		vaultRepository:       vaultRepository,
 		dealActionLockRepo:    dealActionLockRepo,
 		liteclient:            liteclient,
 		redis:                 redis,
-		dealChatService:       dealChatService,
+		dealStateSvc:          dealStateSvc,
 		transactionGasNanoton: int64(transactionGasTON * nanotonPerTON),
 		comissionMultiplier:   1 + (commissionPercent / 100.0),
 	}
@@ -106,7 +115,9 @@ func (s *service) CreateEscrow(ctx context.Context, dealID int64) error {
 		return err
 	}
 	rawAddr := wallet.Address().StringRaw()
-	if err = s.dealRepo.SetDealEscrowAddress(ctx, dealID, rawAddr); err != nil {
+	deal.EscrowAddress = &rawAddr
+	t := entity.WorkerDealTransition(domain.DealWorkerEscrow, "escrow wallet created")
+	if err = s.dealStateSvc.TransitionDeal(ctx, deal, entity.DealStatusWaitingEscrowDeposit, t); err != nil {
 		return err
 	}
 	if err = s.redis.Set(ctx, rawAddr, "1", escrowRedisTTL); err != nil {
@@ -124,14 +135,21 @@ func (s *service) ReleaseOrRefundEscrow(ctx context.Context, logger *slog.Logger
 		return errors.New("deal not found")
 	}
 
-	actionType, destAddr, err := prepareAction(deal, release)
+	actionType, confirmed, destAddr, err := prepareAction(deal, release)
 	if err != nil {
 		return err
 	}
 
 	toAddr, err := address.ParseRawAddr(destAddr)
 	if err != nil {
-		return fmt.Errorf("failed to parse payout address: %w", err)
+		// Retrying cannot fix a malformed address: record the failed attempt and hand the deal over to an admin right away.
+		err = fmt.Errorf("failed to parse payout address: %w", err)
+		lockID, lockErr := s.dealActionLockRepo.TakeDealActionLock(ctx, dealID, actionType)
+		if lockErr != nil {
+			return fmt.Errorf("take deal action lock: %w", lockErr)
+		}
+		s.handleTransferFailure(ctx, logger, deal, actionType, lockID, err, false)
+		return err
 	}
 
 	seedPhrase, err := s.vaultRepository.GetEscrowSeed(ctx, dealID)
@@ -166,48 +184,46 @@ func (s *service) ReleaseOrRefundEscrow(ctx context.Context, logger *slog.Logger
 	if lerr == nil && lastLock != nil && lastLock.Status == entity.DealActionLockStatusLocked && !lastLock.ExpireAt.After(time.Now()) {
 		found, _ := s.liteclient.HasOutgoingTxTo(ctx, escrowAddr, amountNanoton, toAddr)
 		if found {
-			if release {
-				if err = s.dealRepo.SetDealStatusEscrowReleaseConfirmed(ctx, dealID); err != nil {
-					return err
-				}
-			} else {
-				if err = s.dealRepo.SetDealStatusEscrowRefundConfirmed(ctx, dealID); err != nil {
-					return err
-				}
+			t := entity.WorkerDealTransition(domain.DealWorkerEscrowTransfer, "transfer found on chain after an expired lock")
+			if err = s.dealStateSvc.TransitionDeal(ctx, deal, confirmed, t); err != nil {
+				return err
 			}
 			_ = s.dealActionLockRepo.ReleaseDealActionLock(ctx, lastLock.ID, entity.DealActionLockStatusCompleted)
-			_ = s.dealChatService.DeleteDealForumTopic(ctx, dealID)
 			logger.Info("escrow release/refund recovered from expired lock", "deal_id", dealID, "release", release)
 			return nil
 		}
 		_ = s.dealActionLockRepo.ReleaseDealActionLock(ctx, lastLock.ID, entity.DealActionLockStatusFailed)
+	} else if lerr == nil && lastLock != nil && lastLock.Status == entity.DealActionLockStatusFailed &&
+		lastLock.RetryAt != nil && lastLock.RetryAt.After(time.Now()) {
+		return ErrTransferBackoff
 	}
 
 	err = func() error {
 		lockID, err := s.dealActionLockRepo.TakeDealActionLock(ctx, dealID, actionType)
 		if err != nil {
-			return err
+			return fmt.Errorf("take deal action lock: %w", err)
 		}
 		dealACtionLockStatus := entity.DealActionLockStatusFailed
 		defer func() {
-			_ = s.dealActionLockRepo.ReleaseDealActionLock(ctx, lockID, dealACtionLockStatus)
+			if dealACtionLockStatus == "" {
+				return
+			}
+			if err := s.dealActionLockRepo.ReleaseDealActionLock(ctx, lockID, dealACtionLockStatus); err != nil {
+				logger.Error("release deal action lock", "deal_id", dealID, "lock_id", lockID, "error", err)
+			}
 		}()
 
 		if err = w.Transfer(ctx, toAddr, amount, string(actionType)); err != nil {
-			logger.Error("escrow transfer failed", "deal_id", dealID, "release", release, "error", err)
+			// The lock is failed with a backoff here instead of the deferred release.
+			dealACtionLockStatus = ""
+			s.handleTransferFailure(ctx, logger, deal, actionType, lockID, err, true)
 			return err
 		}
 
-		if release {
-			if err = s.dealRepo.SetDealStatusEscrowReleaseConfirmed(ctx, dealID); err != nil {
-				return err
-			}
-		} else {
-			if err = s.dealRepo.SetDealStatusEscrowRefundConfirmed(ctx, dealID); err != nil {
-				return err
-			}
+		t := entity.WorkerDealTransition(domain.DealWorkerEscrowTransfer, "escrow transfer sent")
+		if err = s.dealStateSvc.TransitionDeal(ctx, deal, confirmed, t); err != nil {
+			return err
 		}
-		_ = s.dealChatService.DeleteDealForumTopic(ctx, dealID)
 		dealACtionLockStatus = entity.DealActionLockStatusCompleted
 		return nil
 	}()
@@ -219,28 +235,23 @@ func (s *service) ReleaseOrRefundEscrow(ctx context.Context, logger *slog.Logger
 	return nil
 }
 
-func prepareAction(deal *entity.Deal, release bool) (actionType entity.DealActionType, destAddr string, err error) {
-	var wantStatus entity.DealStatus
+// prepareAction returns the transfer to make for the deal and the status that confirms it. The deal state machine
+// decides whether the release/refund worker may confirm the transfer from the deal's current status.
+func prepareAction(deal *entity.Deal, release bool) (actionType entity.DealActionType, confirmed entity.DealStatus, destAddr string, err error) {
+	var payout *string
 	if release {
-		actionType = entity.DealActionTypeEscrowRelease
-		wantStatus = entity.DealStatusWaitingEscrowRelease
-		if deal.LessorPayoutAddress == nil || *deal.LessorPayoutAddress == "" {
-			return "", "", ErrPayoutAddressNotSet
-		}
-		destAddr = *deal.LessorPayoutAddress
+		actionType, confirmed, payout = entity.DealActionTypeEscrowRelease, entity.DealStatusEscrowReleaseConfirmed, deal.LessorPayoutAddress
 	} else {
-		actionType = entity.DealActionTypeEscrowRefund
-		wantStatus = entity.DealStatusWaitingEscrowRefund
-		if deal.LesseePayoutAddress == nil || *deal.LesseePayoutAddress == "" {
-			return "", "", ErrPayoutAddressNotSet
-		}
-		destAddr = *deal.LesseePayoutAddress
+		actionType, confirmed, payout = entity.DealActionTypeEscrowRefund, entity.DealStatusEscrowRefundConfirmed, deal.LesseePayoutAddress
+	}
+	if _, err = domain.CheckDealTransition(deal.Status, confirmed, entity.WorkerDealTransition(domain.DealWorkerEscrowTransfer, "")); err != nil {
+		return "", "", "", err
 	}
-	if deal.Status != wantStatus {
-		return "", "", errors.New("deal status is not " + string(wantStatus))
+	if payout == nil || *payout == "" {
+		return "", "", "", ErrPayoutAddressNotSet
 	}
 	if deal.EscrowAddress == nil || *deal.EscrowAddress == "" {
-		return "", "", errors.New("deal has no escrow address")
+		return "", "", "", errors.New("deal has no escrow address")
 	}
-	return
+	return actionType, confirmed, *payout, nil
 }
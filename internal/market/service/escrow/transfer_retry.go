@@ -0,0 +1,143 @@
+package escrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"ads-mrkt/internal/market/domain"
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+)
+
+const (
+	// transferMaxAttempts is the number of failed release/refund transfers after which the deal
+	// moves to escrow_transfer_failed and waits for an admin.
+	transferMaxAttempts = 5
+	transferBaseBackoff = 1 * time.Minute
+	transferMaxBackoff  = 1 * time.Hour
+)
+
+// ErrTransferBackoff is returned when the previous release/refund attempt failed and its backoff has not passed yet.
+var ErrTransferBackoff = errors.New("escrow transfer is waiting for retry backoff")
+
+// handleTransferFailure fails the lock with a backoff and gives up on the deal after transferMaxAttempts,
+// or immediately when the failure is not retryable.
+func (s *service) handleTransferFailure(ctx context.Context, logger *slog.Logger, deal *entity.Deal, actionType entity.DealActionType, lockID string, transferErr error, retryable bool) {
+	promEscrowTransferFailures.WithLabelValues(string(actionType)).Inc()
+	attempt, err := s.dealActionLockRepo.FailDealActionLock(ctx, lockID, transferErr.Error(), transferBaseBackoff, transferMaxBackoff)
+	if err != nil {
+		logger.Error("fail deal action lock", "deal_id", deal.ID, "lock_id", lockID, "error", err)
+		return
+	}
+	logger.Error("escrow transfer failed", "deal_id", deal.ID, "action", actionType, "attempt", attempt, "max_attempts", transferMaxAttempts, "error", transferErr)
+	if attempt >= transferMaxAttempts || !retryable {
+		s.markTransferFailed(ctx, logger, deal, actionType, attempt, transferErr)
+	}
+}
+
+// markTransferFailed moves the deal to escrow_transfer_failed; the transition notifies admins.
+func (s *service) markTransferFailed(ctx context.Context, logger *slog.Logger, deal *entity.Deal, actionType entity.DealActionType, attempts int, transferErr error) {
+	reason := string(actionType) + " failed after " + strconv.Itoa(attempts) + " attempts: " + transferErr.Error()
+	t := entity.WorkerDealTransition(domain.DealWorkerEscrowTransfer, reason)
+	if err := s.dealStateSvc.TransitionDeal(ctx, deal, entity.DealStatusEscrowTransferFailed, t); err != nil {
+		logger.Error("set deal status escrow_transfer_failed", "deal_id", deal.ID, "error", err)
+		return
+	}
+	promEscrowTransferDeadLetters.WithLabelValues(string(actionType)).Inc()
+	logger.Warn("escrow transfer moved to escrow_transfer_failed", "deal_id", deal.ID, "action", actionType, "attempts", attempts)
+}
+
+// ListFailedEscrowTransfers returns deals in escrow_transfer_failed.
+func (s *service) ListFailedEscrowTransfers(ctx context.Context) ([]*entity.Deal, error) {
+	return s.dealRepo.ListDealsEscrowTransferFailed(ctx)
+}
+
+// RetryEscrowTransfer puts an escrow_transfer_failed deal back to waiting for release/refund with a fresh
+// attempt counter. The transfer goes to the payout address stored on the deal; only the user it pays can change it
+// (see the deal service SetDealPayoutAddress).
+func (s *service) RetryEscrowTransfer(ctx context.Context, adminID int64, dealID int64) (*entity.Deal, error) {
+	deal, err := s.dealRepo.GetDealByID(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+	if deal == nil {
+		return nil, marketerrors.ErrNotFound
+	}
+	if deal.Status != entity.DealStatusEscrowTransferFailed {
+		return nil, marketerrors.ErrDealNotEscrowTransferFailed
+	}
+
+	actionType, err := s.failedTransferAction(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+
+	to := entity.DealStatusWaitingEscrowRefund
+	if actionType == entity.DealActionTypeEscrowRelease {
+		to = entity.DealStatusWaitingEscrowRelease
+	}
+	reason := "escrow transfer retried"
+
+	if err = s.dealActionLockRepo.ResetDealActionAttempts(ctx, dealID, actionType); err != nil {
+		return nil, fmt.Errorf("reset deal action attempts: %w", err)
+	}
+	if err = s.dealStateSvc.TransitionDeal(ctx, deal, to, entity.AdminDealTransition(adminID, reason)); err != nil {
+		return nil, err
+	}
+	slog.Info("escrow transfer retry requested by admin", "deal_id", dealID, "action", actionType)
+	return s.dealRepo.GetDealByID(ctx, dealID)
+}
+
+// SettleEscrow moves a deal holding escrow to waiting for release (to the lessor) or refund (to the lessee) on an
+// admin's decision; the release/refund worker then makes the transfer. A failed transfer gets a fresh attempt
+// counter. The deal state machine decides from which statuses staff may do this.
+func (s *service) SettleEscrow(ctx context.Context, adminID int64, dealID int64, release bool, reason string) (*entity.Deal, error) {
+	deal, err := s.dealRepo.GetDealByID(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+	if deal == nil {
+		return nil, marketerrors.ErrNotFound
+	}
+
+	to, actionType := entity.DealStatusWaitingEscrowRefund, entity.DealActionTypeEscrowRefund
+	if release {
+		to, actionType = entity.DealStatusWaitingEscrowRelease, entity.DealActionTypeEscrowRelease
+	}
+	t := entity.AdminDealTransition(adminID, reason)
+	if _, err = domain.CheckDealTransition(deal.Status, to, t); err != nil {
+		return nil, err
+	}
+	if deal.Status == entity.DealStatusEscrowTransferFailed {
+		if err = s.dealActionLockRepo.ResetDealActionAttempts(ctx, dealID, actionType); err != nil {
+			return nil, fmt.Errorf("reset deal action attempts: %w", err)
+		}
+	}
+	if err = s.dealStateSvc.TransitionDeal(ctx, deal, to, t); err != nil {
+		return nil, err
+	}
+	slog.Info("escrow settlement requested by admin", "deal_id", dealID, "action", actionType, "admin_id", adminID)
+	return s.dealRepo.GetDealByID(ctx, dealID)
+}
+
+// failedTransferAction returns the action (release or refund) whose lock failed most recently.
+func (s *service) failedTransferAction(ctx context.Context, dealID int64) (entity.DealActionType, error) {
+	var last *entity.DealActionLock
+	for _, actionType := range []entity.DealActionType{entity.DealActionTypeEscrowRelease, entity.DealActionTypeEscrowRefund} {
+		lock, err := s.dealActionLockRepo.GetLastDealActionLock(ctx, dealID, actionType)
+		if err != nil {
+			return "", err
+		}
+		if lock != nil && lock.Status == entity.DealActionLockStatusFailed && (last == nil || lock.CreatedAt.After(last.CreatedAt)) {
+			last = lock
+		}
+	}
+	if last == nil {
+		return "", errors.New("no failed escrow transfer found for deal")
+	}
+	return last.ActionType, nil
+}
@@ -71,9 +71,12 @@ func (s *service) ReleaseRefundWorker(ctx context.Context) {
 					return
 				}
 				if err := s.ReleaseOrRefundEscrow(ctx, logger, d.ID, release); err != nil {
-					if errors.Is(err, ErrPayoutAddressNotSet) {
+					switch {
+					case errors.Is(err, ErrPayoutAddressNotSet):
 						logger.Debug("skip deal, payout address not set", "deal_id", d.ID, "release", release)
-					} else {
+					case errors.Is(err, ErrTransferBackoff):
+						logger.Debug("skip deal, waiting for retry backoff", "deal_id", d.ID, "release", release)
+					default:
 						logger.Error("release/refund failed", "deal_id", d.ID, "release", release, "error", err)
 					}
 					continue
@@ -2,15 +2,36 @@ package service
 
 import (
 	"context"
+	"slices"
+	"strings"
+	"unicode/utf8"
 
 	"ads-mrkt/internal/market/domain"
 	"ads-mrkt/internal/market/domain/entity"
 	marketerrors "ads-mrkt/internal/market/domain/errors"
 )
 
+const maxReportCommentLength = 500
+
+var listingReportReasons = []entity.ListingReportReason{
+	entity.ListingReportReasonSpam,
+	entity.ListingReportReasonScam,
+	entity.ListingReportReasonGambling,
+	entity.ListingReportReasonAdult,
+	entity.ListingReportReasonProhibited,
+	entity.ListingReportReasonOther,
+}
+
 // CreateListing creates a listing. For type lessor, userID must be an admin of the channel (channelID required).
+// The description must pass the content policy.
 func (s *listingService) CreateListing(ctx context.Context, userID int64, l *entity.Listing) error {
 	l.UserID = userID
+	if l.Status == entity.ListingStatusBlocked {
+		return marketerrors.ErrListingBlocked
+	}
+	if err := s.contentPolicy.Check(l.Description); err != nil {
+		return err
+	}
 	if l.Type == entity.ListingTypeLessor {
 		if l.ChannelID == nil {
 			return marketerrors.ErrNotChannelAdmin
@@ -22,10 +43,28 @@ func (s *listingService) CreateListing(ctx context.Context, userID int64, l *ent
 		if !ok {
 			return marketerrors.ErrNotChannelAdmin
 		}
+		if err := s.checkChannelConnected(ctx, l); err != nil {
+			return err
+		}
 	}
 	return s.listingRepo.CreateListing(ctx, l)
 }
 
+// checkChannelConnected refuses to activate a lessor listing whose channel the userbot cannot post to.
+func (s *listingService) checkChannelConnected(ctx context.Context, l *entity.Listing) error {
+	if l.Status != entity.ListingStatusActive || l.ChannelID == nil {
+		return nil
+	}
+	ch, err := s.channelRepo.GetChannelByID(ctx, *l.ChannelID)
+	if err != nil {
+		return err
+	}
+	if ch == nil || !domain.ChannelConnected(ch.AdminRights) {
+		return marketerrors.ErrChannelNotConnected
+	}
+	return nil
+}
+
 func (s *listingService) GetListing(ctx context.Context, id int64) (*entity.Listing, error) {
 	return s.listingRepo.GetListingByID(ctx, id)
 }
@@ -38,6 +77,13 @@ func (s *listingService) UpdateListing(ctx context.Context, userID int64, l *ent
 	if existing.UserID != userID {
 		return marketerrors.ErrUnauthorizedSide
 	}
+	// Only staff can lift a block, through the admin console.
+	if existing.Status == entity.ListingStatusBlocked || l.Status == entity.ListingStatusBlocked {
+		return marketerrors.ErrListingBlocked
+	}
+	if err := s.contentPolicy.Check(l.Description); err != nil {
+		return err
+	}
 	if l.Type == entity.ListingTypeLessor && l.ChannelID != nil {
 		ok, err := s.adminRepo.IsChannelAdmin(ctx, userID, *l.ChannelID)
 		if err != nil {
@@ -46,10 +92,44 @@ func (s *listingService) UpdateListing(ctx context.Context, userID int64, l *ent
 		if !ok {
 			return marketerrors.ErrNotChannelAdmin
 		}
+		if err := s.checkChannelConnected(ctx, l); err != nil {
+			return err
+		}
 	}
 	return s.listingRepo.UpdateListing(ctx, l)
 }
 
+// ReportListing files a complaint of the user about someone else's listing for the moderation queue.
+func (s *listingService) ReportListing(ctx context.Context, userID int64, listingID int64, reason entity.ListingReportReason, comment string) (*entity.ListingReport, error) {
+	comment = strings.TrimSpace(comment)
+	if !slices.Contains(listingReportReasons, reason) || utf8.RuneCountInString(comment) > maxReportCommentLength {
+		return nil, marketerrors.ErrInvalidListingReport
+	}
+	l, err := s.listingRepo.GetListingByID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		return nil, marketerrors.ErrNotFound
+	}
+	if l.UserID == userID {
+		return nil, marketerrors.ErrOwnListingReport
+	}
+	if l.Status == entity.ListingStatusBlocked {
+		return nil, marketerrors.ErrListingBlocked
+	}
+
+	report := &entity.ListingReport{ListingID: listingID, ReporterID: userID, Reason: reason, Comment: comment}
+	created, err := s.reportRepo.CreateListingReport(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, marketerrors.ErrListingAlreadyReported
+	}
+	return report, nil
+}
+
 // DeleteListing deletes a listing. Only the listing owner may delete.
 func (s *listingService) DeleteListing(ctx context.Context, userID int64, id int64) error {
 	existing, err := s.listingRepo.GetListingByID(ctx, id)
@@ -66,11 +146,11 @@ func (s *listingService) ListListingsByUserID(ctx context.Context, userID int64,
 	return s.listingRepo.ListListingsByUserID(ctx, userID, typ)
 }
 
-// ListListingsAll returns all listings, optionally filtered by type, categories, and min channel followers (for public discovery).
+// ListListingsAll returns all listings matching the filter (for public discovery).
 // Categories must be from the predefined set; invalid categories are ignored.
-func (s *listingService) ListListingsAll(ctx context.Context, typ *entity.ListingType, categories []string, minFollowers *int64) ([]*entity.Listing, error) {
-	validCategories := make([]string, 0, len(categories))
-	for _, c := range categories {
+func (s *listingService) ListListingsAll(ctx context.Context, filter entity.ListingFilter) ([]*entity.Listing, error) {
+	validCategories := make([]string, 0, len(filter.Categories))
+	for _, c := range filter.Categories {
 		if c == "" {
 			continue
 		}
@@ -78,5 +158,6 @@ func (s *listingService) ListListingsAll(ctx context.Context, typ *entity.Listin
 			validCategories = append(validCategories, c)
 		}
 	}
-	return s.listingRepo.ListListingsAll(ctx, typ, validCategories, minFollowers)
+	filter.Categories = validCategories
+	return s.listingRepo.ListListingsAll(ctx, &filter)
 }
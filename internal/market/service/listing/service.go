@@ -12,18 +12,40 @@ type listingRepository interface {
 	UpdateListing(ctx context.Context, l *entity.Listing) error
 	DeleteListing(ctx context.Context, id int64) error
 	ListListingsByUserID(ctx context.Context, userID int64, typ *entity.ListingType) ([]*entity.Listing, error)
-	ListListingsAll(ctx context.Context, typ *entity.ListingType, categories []string, minFollowers *int64) ([]*entity.Listing, error)
+	ListListingsAll(ctx context.Context, filter *entity.ListingFilter) ([]*entity.Listing, error)
 }
 
 type channelAdminRepository interface {
 	IsChannelAdmin(ctx context.Context, userID, channelID int64) (bool, error)
 }
 
+type channelRepository interface {
+	GetChannelByID(ctx context.Context, id int64) (*entity.Channel, error)
+}
+
+type reportRepository interface {
+	CreateListingReport(ctx context.Context, report *entity.ListingReport) (bool, error)
+}
+
+// contentPolicy refuses descriptions with blocked words, patterns or links.
+type contentPolicy interface {
+	Check(text string) error
+}
+
 type listingService struct {
-	listingRepo listingRepository
-	adminRepo   channelAdminRepository
+	listingRepo   listingRepository
+	adminRepo     channelAdminRepository
+	channelRepo   channelRepository
+	reportRepo    reportRepository
+	contentPolicy contentPolicy
 }
 
-func NewListingService(listingRepo listingRepository, adminRepo channelAdminRepository) *listingService {
-	return &listingService{listingRepo: listingRepo, adminRepo: adminRepo}
+func NewListingService(listingRepo listingRepository, adminRepo channelAdminRepository, channelRepo channelRepository, reportRepo reportRepository, contentPolicy contentPolicy) *listingService {
+	return &listingService{
+		listingRepo:   listingRepo,
+		adminRepo:     adminRepo,
+		channelRepo:   channelRepo,
+		reportRepo:    reportRepo,
+		contentPolicy: contentPolicy,
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
 	"ads-mrkt/pkg/auth/role"
 )
 
@@ -31,14 +32,10 @@ func (s *userService) AuthUser(ctx context.Context, initDataStr string, referrer
 	if err := s.userRepo.UpsertUser(ctx, u); err != nil {
 		return nil, fmt.Errorf("upsert user: %w", err)
 	}
-	return u, nil
-}
-
-func (s *userService) SetWallet(ctx context.Context, userID int64, walletAddressRaw string) error {
-	if walletAddressRaw == "" {
-		return fmt.Errorf("wallet address is required")
+	if u.BannedAt != nil {
+		return nil, marketerrors.ErrUserBanned
 	}
-	return s.userRepo.SetUserWallet(ctx, userID, walletAddressRaw)
+	return u, nil
 }
 
 func (s *userService) ClearWallet(ctx context.Context, userID int64) error {
@@ -3,20 +3,45 @@ package service
 import (
 	"ads-mrkt/internal/market/domain/entity"
 	"context"
+	"crypto/ed25519"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
 )
 
 type userRepository interface {
 	UpsertUser(ctx context.Context, u *entity.User) error
 	GetUserByID(ctx context.Context, id int64) (*entity.User, error)
-	SetUserWallet(ctx context.Context, userID int64, walletAddressRaw string) error
+	SetUserWallet(ctx context.Context, userID int64, proof *entity.WalletProof) error
 	ClearUserWallet(ctx context.Context, userID int64) error
 }
 
+// challengeStore keeps issued ton_proof payloads until they are used or expire.
+type challengeStore interface {
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	GetDel(ctx context.Context, key string) (string, error)
+}
+
+type walletKeyResolver interface {
+	GetWalletPublicKey(ctx context.Context, addr *address.Address) (ed25519.PublicKey, error)
+}
+
 type userService struct {
-	botToken string
-	userRepo userRepository
+	botToken           string
+	userRepo           userRepository
+	challenges         challengeStore
+	walletKeys         walletKeyResolver
+	tonProofDomain     string
+	tonProofPayloadTTL time.Duration
 }
 
-func NewUserService(botToken string, userRepo userRepository) *userService {
-	return &userService{botToken: botToken, userRepo: userRepo}
+func NewUserService(botToken string, userRepo userRepository, challenges challengeStore, walletKeys walletKeyResolver, tonProofDomain string, tonProofPayloadTTL time.Duration) *userService {
+	return &userService{
+		botToken:           botToken,
+		userRepo:           userRepo,
+		challenges:         challenges,
+		walletKeys:         walletKeys,
+		tonProofDomain:     tonProofDomain,
+		tonProofPayloadTTL: tonProofPayloadTTL,
+	}
 }
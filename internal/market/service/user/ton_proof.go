@@ -0,0 +1,164 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ads-mrkt/internal/market/domain"
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	tonProofPrefix         = "ton-proof-item-v2/"
+	tonConnectPrefix       = "ton-connect"
+	tonProofChallengeKey   = "ton_proof:"
+	tonProofMaxDomainBytes = 256
+)
+
+// tonProofWalletVersions are wallet contracts whose public key can be read from a StateInit.
+var tonProofWalletVersions = []wallet.VersionConfig{
+	wallet.V3R1,
+	wallet.V3R2,
+	wallet.V4R2,
+	wallet.ConfigV5R1Final{NetworkGlobalID: wallet.MainnetGlobalID},
+}
+
+// CreateWalletChallenge issues a one-time payload for the user's next ton_proof.
+func (s *userService) CreateWalletChallenge(ctx context.Context, userID int64) (*entity.TonProofChallenge, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate ton_proof payload: %w", err)
+	}
+	payload := hex.EncodeToString(nonce)
+	if err := s.challenges.Set(ctx, tonProofChallengeKey+payload, strconv.FormatInt(userID, 10), s.tonProofPayloadTTL); err != nil {
+		return nil, fmt.Errorf("store ton_proof payload: %w", err)
+	}
+	return &entity.TonProofChallenge{Payload: payload, ExpiresAt: time.Now().Add(s.tonProofPayloadTTL).UTC()}, nil
+}
+
+// SetWallet links the wallet to the user after verifying its ton_proof: the payload must be a challenge issued
+// to this user, the domain must be ours, the proof must be fresh and signed by the wallet's key.
+func (s *userService) SetWallet(ctx context.Context, userID int64, proof *entity.TonProof) error {
+	addr, err := domain.ParseWalletAddress(proof.Address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", marketerrors.ErrInvalidWalletAddress, err)
+	}
+
+	owner, err := s.challenges.GetDel(ctx, tonProofChallengeKey+proof.Payload)
+	if err != nil {
+		return fmt.Errorf("load ton_proof payload: %w", err)
+	}
+	if owner != strconv.FormatInt(userID, 10) {
+		return fmt.Errorf("%w: unknown or expired payload", marketerrors.ErrInvalidWalletProof)
+	}
+
+	if !strings.EqualFold(proof.Domain, s.tonProofDomain) || int(proof.DomainLengthBytes) != len(proof.Domain) {
+		return fmt.Errorf("%w: unexpected domain %q", marketerrors.ErrInvalidWalletProof, proof.Domain)
+	}
+	proofTime := time.Unix(proof.Timestamp, 0)
+	if skew := time.Since(proofTime); skew > s.tonProofPayloadTTL || skew < -s.tonProofPayloadTTL {
+		return fmt.Errorf("%w: timestamp out of range", marketerrors.ErrInvalidWalletProof)
+	}
+
+	pubKey, err := s.walletPublicKey(ctx, addr, proof.StateInit)
+	if err != nil {
+		return fmt.Errorf("%w: %v", marketerrors.ErrInvalidWalletProof, err)
+	}
+	if proof.PublicKey != "" && !strings.EqualFold(proof.PublicKey, hex.EncodeToString(pubKey)) {
+		return fmt.Errorf("%w: public key does not match the wallet", marketerrors.ErrInvalidWalletProof)
+	}
+	if err = verifyTonProofSignature(addr, proof, pubKey); err != nil {
+		return fmt.Errorf("%w: %v", marketerrors.ErrInvalidWalletProof, err)
+	}
+
+	return s.userRepo.SetUserWallet(ctx, userID, &entity.WalletProof{
+		AddressRaw:     addr.StringRaw(),
+		PublicKey:      hex.EncodeToString(pubKey),
+		Domain:         proof.Domain,
+		ProofTimestamp: proofTime,
+		VerifiedAt:     time.Now(),
+	})
+}
+
+// walletPublicKey reads the key from the StateInit of a not yet deployed wallet (checking that it hashes
+// to the address) or calls get_public_key on the deployed contract.
+func (s *userService) walletPublicKey(ctx context.Context, addr *address.Address, stateInit []byte) (ed25519.PublicKey, error) {
+	if len(stateInit) == 0 {
+		key, err := s.walletKeys.GetWalletPublicKey(ctx, addr)
+		if err != nil {
+			return nil, fmt.Errorf("get_public_key: %w", err)
+		}
+		return key, nil
+	}
+
+	siCell, err := cell.FromBOC(stateInit)
+	if err != nil {
+		return nil, fmt.Errorf("parse state init: %w", err)
+	}
+	if !bytes.Equal(siCell.Hash(), addr.Data()) {
+		return nil, errors.New("state init does not match the address")
+	}
+	var si tlb.StateInit
+	if err = tlb.LoadFromCell(&si, siCell.BeginParse()); err != nil {
+		return nil, fmt.Errorf("parse state init: %w", err)
+	}
+	if si.Code == nil || si.Data == nil {
+		return nil, errors.New("state init has no code or data")
+	}
+	for _, version := range tonProofWalletVersions {
+		known, err := wallet.GetStateInit(make(ed25519.PublicKey, ed25519.PublicKeySize), version, wallet.DefaultSubwallet)
+		if err != nil || !bytes.Equal(known.Code.Hash(), si.Code.Hash()) {
+			continue
+		}
+		return wallet.ParsePubKeyFromData(version, si.Data)
+	}
+	return nil, errors.New("unsupported wallet contract")
+}
+
+// verifyTonProofSignature checks the signature over
+// sha256(0xffff ++ "ton-connect" ++ sha256("ton-proof-item-v2/" ++ workchain ++ hash ++ domain len ++ domain ++ timestamp ++ payload)).
+func verifyTonProofSignature(addr *address.Address, proof *entity.TonProof, pubKey ed25519.PublicKey) error {
+	if len(proof.Domain) > tonProofMaxDomainBytes {
+		return errors.New("domain is too long")
+	}
+	if len(proof.Signature) != ed25519.SignatureSize {
+		return errors.New("invalid signature length")
+	}
+
+	var msg bytes.Buffer
+	msg.WriteString(tonProofPrefix)
+	_ = binary.Write(&msg, binary.BigEndian, addr.Workchain())
+	msg.Write(addr.Data())
+	_ = binary.Write(&msg, binary.LittleEndian, proof.DomainLengthBytes)
+	msg.WriteString(proof.Domain)
+	_ = binary.Write(&msg, binary.LittleEndian, proof.Timestamp)
+	msg.WriteString(proof.Payload)
+	msgHash := sha256.Sum256(msg.Bytes())
+
+	var full bytes.Buffer
+	full.Write([]byte{0xff, 0xff})
+	full.WriteString(tonConnectPrefix)
+	full.Write(msgHash[:])
+	fullHash := sha256.Sum256(full.Bytes())
+
+	if !ed25519.Verify(pubKey, fullHash[:], proof.Signature) {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"ads-mrkt/internal/market/domain"
+)
+
+const (
+	requestTimeout = 10 * time.Second
+	dialTimeout    = 5 * time.Second
+)
+
+var errAddressNotAllowed = errors.New("webhook address is not public")
+
+// NewHTTPClient returns the client deliveries are sent with. It connects only to public addresses, checked after
+// DNS resolution so that hostnames cannot point it at internal services, and does not follow redirects.
+func NewHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: dialTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !domain.PublicIP(ip) {
+				return errAddressNotAllowed
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: dialTimeout,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     time.Minute,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,24 @@
+package webhook
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus data collector definitions
+
+var promWebhookDeliveriesTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "ads_mrkt_webhook_deliveries_total",
+		Help: "Total number of webhook delivery attempts by event and resulting delivery status",
+	},
+	[]string{"event", "status"},
+)
+
+var promWebhookDeliveryDuration = promauto.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "ads_mrkt_webhook_delivery_duration_seconds",
+		Help:    "Duration of webhook delivery requests",
+		Buckets: prometheus.DefBuckets,
+	},
+)
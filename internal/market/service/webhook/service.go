@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"ads-mrkt/internal/market/domain"
+	"ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+)
+
+const (
+	maxWebhooksPerUser   = 5
+	deliveriesListLimit  = 100
+	generatedSecretBytes = 32
+)
+
+type repository interface {
+	CreateWebhook(ctx context.Context, w *entity.Webhook) error
+	GetWebhookByID(ctx context.Context, id int64) (*entity.Webhook, error)
+	ListWebhooksByUserID(ctx context.Context, userID int64) ([]*entity.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	CreateWebhookDelivery(ctx context.Context, d *entity.WebhookDelivery) error
+	ClaimDueWebhookDeliveries(ctx context.Context, limit int, leaseUntil time.Time) ([]*entity.WebhookDeliveryAttempt, error)
+	RecordWebhookDeliveryAttempt(ctx context.Context, id int64, status entity.WebhookDeliveryStatus, statusCode *int, errText *string, nextAttemptAt time.Time) error
+	ListWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]*entity.WebhookDelivery, error)
+	DeleteFinishedWebhookDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// service manages the webhooks of users and sends them the deal events queued by deal transitions.
+type service struct {
+	repository repository
+	client     *http.Client
+}
+
+// NewService returns a webhook service that sends deliveries with client; use NewHTTPClient outside of tests.
+func NewService(repository repository, client *http.Client) *service {
+	return &service{
+		repository: repository,
+		client:     client,
+	}
+}
+
+// CreateWebhook registers an https endpoint of the user. An empty secret is generated; the returned webhook is the
+// only one that carries the secret.
+func (s *service) CreateWebhook(ctx context.Context, userID int64, url, secret string) (*entity.Webhook, error) {
+	if err := domain.ValidateWebhookURL(url); err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		b := make([]byte, generatedSecretBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		secret = "whsec_" + hex.EncodeToString(b)
+	}
+	if err := domain.ValidateWebhookSecret(secret); err != nil {
+		return nil, err
+	}
+	existing, err := s.repository.ListWebhooksByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxWebhooksPerUser {
+		return nil, marketerrors.ErrWebhookLimitReached
+	}
+
+	w := &entity.Webhook{UserID: userID, URL: url, Secret: secret}
+	if err := s.repository.CreateWebhook(ctx, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (s *service) ListWebhooks(ctx context.Context, userID int64) ([]*entity.Webhook, error) {
+	return s.repository.ListWebhooksByUserID(ctx, userID)
+}
+
+// DeleteWebhook removes the user's webhook and its delivery log; pending deliveries are dropped.
+func (s *service) DeleteWebhook(ctx context.Context, userID int64, id int64) error {
+	if _, err := s.getOwnWebhook(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.repository.DeleteWebhook(ctx, id)
+}
+
+// ListWebhookDeliveries returns the latest deliveries of the user's webhook, newest first.
+func (s *service) ListWebhookDeliveries(ctx context.Context, userID int64, id int64) ([]*entity.WebhookDelivery, error) {
+	if _, err := s.getOwnWebhook(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.repository.ListWebhookDeliveries(ctx, id, deliveriesListLimit)
+}
+
+// PingWebhook sends a ping event to the user's webhook right away and returns its logged delivery. Pings are not
+// retried: the delivery is delivered or failed.
+func (s *service) PingWebhook(ctx context.Context, userID int64, id int64) (*entity.WebhookDelivery, error) {
+	w, err := s.getOwnWebhook(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	payload, err := json.Marshal(&entity.WebhookPingPayload{Event: entity.WebhookEventPing, WebhookID: w.ID, SentAt: now})
+	if err != nil {
+		return nil, err
+	}
+	// Leased like a claimed delivery, so the worker leaves it alone while it is sent.
+	d := &entity.WebhookDelivery{WebhookID: w.ID, Event: entity.WebhookEventPing, Payload: payload, NextAttemptAt: now.Add(deliveryLease)}
+	if err := s.repository.CreateWebhookDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+
+	statusCode, sendErr := s.send(ctx, &entity.WebhookDeliveryAttempt{WebhookDelivery: *d, URL: w.URL, Secret: w.Secret})
+	d.Attempts++
+	d.LastStatusCode = statusCode
+	d.NextAttemptAt = time.Now()
+	d.Status = entity.WebhookDeliveryStatusDelivered
+	if sendErr != nil {
+		errText := sendErr.Error()
+		d.Status, d.LastError = entity.WebhookDeliveryStatusFailed, &errText
+	} else {
+		d.DeliveredAt = &d.NextAttemptAt
+	}
+	promWebhookDeliveriesTotal.WithLabelValues(string(d.Event), string(d.Status)).Inc()
+	if err := s.repository.RecordWebhookDeliveryAttempt(ctx, d.ID, d.Status, d.LastStatusCode, d.LastError, d.NextAttemptAt); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// getOwnWebhook returns the webhook if it belongs to the user; webhooks of other users are not found.
+func (s *service) getOwnWebhook(ctx context.Context, userID int64, id int64) (*entity.Webhook, error) {
+	w, err := s.repository.GetWebhookByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil || w.UserID != userID {
+		return nil, marketerrors.ErrNotFound
+	}
+	return w, nil
+}
@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ads-mrkt/internal/market/domain/entity"
+)
+
+const (
+	deliveryInterval    = 5 * time.Second
+	deliveryBatchSize   = 20
+	deliveryLease       = 5 * time.Minute // longer than a batch of deliveries takes to send
+	maxDeliveryAttempts = 10
+	retryBaseDelay      = 30 * time.Second
+	retryMaxDelay       = 6 * time.Hour
+	cleanupInterval     = time.Hour
+	deliveryRetention   = 30 * 24 * time.Hour
+)
+
+// Request headers of a delivery. The signature is "sha256=" and the hex HMAC-SHA256, keyed with the webhook secret,
+// of the timestamp, a dot and the body.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sign returns the signature header value of a delivery body sent at timestamp (Unix seconds).
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay returns how long to wait after the given number of failed attempts: retryBaseDelay doubled after each
+// attempt, up to retryMaxDelay.
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMaxDelay)
+}
+
+// RunDeliveryWorker sends due webhook deliveries until ctx is done and removes finished deliveries after the
+// retention period.
+func (s *service) RunDeliveryWorker(ctx context.Context) {
+	logger := slog.With("component", "webhook_delivery_worker")
+	ticker := time.NewTicker(deliveryInterval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(cleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("webhook delivery worker stopped")
+			return
+		case <-ticker.C:
+			for {
+				n, err := s.deliverDue(ctx, logger)
+				if err != nil {
+					logger.Error("deliver webhooks", "error", err)
+					break
+				}
+				if n < deliveryBatchSize {
+					break
+				}
+			}
+		case <-cleanup.C:
+			n, err := s.repository.DeleteFinishedWebhookDeliveries(ctx, time.Now().Add(-deliveryRetention))
+			if err != nil {
+				logger.Error("delete finished webhook deliveries", "error", err)
+				continue
+			}
+			if n > 0 {
+				logger.Info("finished webhook deliveries deleted", "count", n)
+			}
+		}
+	}
+}
+
+// deliverDue sends one batch of due deliveries and records each attempt. Failed attempts are retried with
+// exponential backoff until maxDeliveryAttempts. It returns the number of deliveries claimed.
+func (s *service) deliverDue(ctx context.Context, logger *slog.Logger) (int, error) {
+	attempts, err := s.repository.ClaimDueWebhookDeliveries(ctx, deliveryBatchSize, time.Now().Add(deliveryLease))
+	if err != nil {
+		return 0, err
+	}
+	for _, a := range attempts {
+		statusCode, sendErr := s.send(ctx, a)
+		made := a.Attempts + 1
+		status, next := entity.WebhookDeliveryStatusDelivered, time.Now()
+		var errText *string
+		if sendErr != nil {
+			text := sendErr.Error()
+			errText = &text
+			if made >= maxDeliveryAttempts {
+				status = entity.WebhookDeliveryStatusFailed
+			} else {
+				status, next = entity.WebhookDeliveryStatusPending, next.Add(retryDelay(made))
+			}
+			logger.Warn("webhook delivery failed", "delivery_id", a.ID, "webhook_id", a.WebhookID, "event", a.Event, "attempts", made, "error", sendErr)
+		}
+		promWebhookDeliveriesTotal.WithLabelValues(string(a.Event), string(status)).Inc()
+		if err := s.repository.RecordWebhookDeliveryAttempt(ctx, a.ID, status, statusCode, errText, next); err != nil {
+			return len(attempts), err
+		}
+	}
+	return len(attempts), nil
+}
+
+// send posts the payload of the delivery to its webhook. Any response other than 2xx is an error; statusCode is nil
+// when no response was received.
+func (s *service) send(ctx context.Context, a *entity.WebhookDeliveryAttempt) (statusCode *int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(a.Payload))
+	if err != nil {
+		return nil, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ads-mrkt-webhooks/1")
+	req.Header.Set(HeaderEvent, string(a.Event))
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(a.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(a.Secret, timestamp, a.Payload))
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	promWebhookDeliveryDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return &resp.StatusCode, nil
+}
@@ -9,7 +9,11 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
-type txKey struct{}
+type (
+	txKey        struct{}
+	txOptionsKey struct{}
+	nestedTxKey  struct{}
+)
 
 type quierier interface {
 	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
@@ -20,8 +24,13 @@ type quierier interface {
 func (p *postgres) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (context.Context, error) {
 	slog.Debug("StartTx", "caller", getCallerFuncName())
 
+	// A transaction begun inside another one joins it and is committed or rolled back with it.
 	if _, exists := ctx.Value(txKey{}).(pgx.Tx); exists {
-		return nil, fmt.Errorf("postgres: duplicate caller function name %q", "BeginTx")
+		outer, _ := ctx.Value(txOptionsKey{}).(pgx.TxOptions)
+		if txOptions.IsoLevel != "" && txOptions.IsoLevel != outer.IsoLevel {
+			return ctx, fmt.Errorf("begin tx: %s transaction nested in a %q one", txOptions.IsoLevel, outer.IsoLevel)
+		}
+		return context.WithValue(ctx, nestedTxKey{}, true), nil
 	}
 
 	tx, err := p.pg.BeginTx(ctx, txOptions)
@@ -29,6 +38,7 @@ func (p *postgres) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (contex
 		return ctx, fmt.Errorf("begin tx: %w", err)
 	}
 
+	ctx = context.WithValue(ctx, txOptionsKey{}, txOptions)
 	return context.WithValue(ctx, txKey{}, tx), nil
 }
 
@@ -40,6 +50,10 @@ func (p *postgres) EndTx(ctx context.Context, err error, source string) error {
 		return fmt.Errorf("postgres: tx not found in context")
 	}
 
+	if nested, _ := ctx.Value(nestedTxKey{}).(bool); nested {
+		return err
+	}
+
 	if err != nil {
 		slog.Error(source, "error", err)
 
@@ -57,6 +71,29 @@ func (p *postgres) EndTx(ctx context.Context, err error, source string) error {
 	return nil
 }
 
+// InTx runs fn in a transaction that is committed when fn succeeds and rolled back otherwise. Repositories
+// called with fn's context run their queries, and their own transactions, in it.
+func (p *postgres) InTx(ctx context.Context, source string, fn func(ctx context.Context) error) error {
+	return p.inTx(ctx, pgx.TxOptions{}, source, fn)
+}
+
+// InSerializableTx is InTx with serializable isolation.
+func (p *postgres) InSerializableTx(ctx context.Context, source string, fn func(ctx context.Context) error) error {
+	return p.inTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, source, fn)
+}
+
+func (p *postgres) inTx(ctx context.Context, txOptions pgx.TxOptions, source string, fn func(ctx context.Context) error) (err error) {
+	txCtx, beginErr := p.BeginTx(ctx, txOptions)
+	if beginErr != nil {
+		return beginErr
+	}
+	defer func() {
+		err = p.EndTx(txCtx, err, source)
+	}()
+
+	return fn(txCtx)
+}
+
 func (p *postgres) acquireQuerier(ctx context.Context) quierier {
 	q := quierier(p.pg)
 
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -26,3 +27,46 @@ func (c *Client) Keys(ctx context.Context, pattern string) ([]string, error) {
 func (c *Client) PSubscribe(ctx context.Context, channels ...string) *redis.PubSub {
 	return c.client.PSubscribe(ctx, channels...)
 }
+
+// GetDel returns the value of key and deletes it atomically; a missing key yields an empty string.
+func (c *Client) GetDel(ctx context.Context, key string) (string, error) {
+	value, err := c.client.GetDel(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return value, err
+}
+
+func (c *Client) HSet(ctx context.Context, key, field string, value interface{}) error {
+	return c.client.HSet(ctx, key, field, value).Err()
+}
+
+func (c *Client) HDel(ctx context.Context, key string, fields ...string) error {
+	return c.client.HDel(ctx, key, fields...).Err()
+}
+
+// HGet returns the value of the hash field; a missing field yields an empty string.
+func (c *Client) HGet(ctx context.Context, key, field string) (string, error) {
+	value, err := c.client.HGet(ctx, key, field).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return value, err
+}
+
+// IncrWithExpire increments the counter at key and sets its expiration; for fixed-window rate limits, put the
+// window in the key.
+func (c *Client) IncrWithExpire(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	pipe := c.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, expiration)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := c.client.Exists(ctx, key).Result()
+	return n > 0, err
+}
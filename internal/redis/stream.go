@@ -41,3 +41,15 @@ func (c *Client) XGroupDelConsumer(ctx context.Context, stream, group, consumer
 func (c *Client) XTrim(ctx context.Context, stream, minId string, limit int64) *redis.IntCmd {
 	return c.client.XTrimMinIDApprox(ctx, stream, minId, limit)
 }
+
+func (c *Client) XRangeN(ctx context.Context, stream, start, stop string, count int64) *redis.XMessageSliceCmd {
+	return c.client.XRangeN(ctx, stream, start, stop, count)
+}
+
+func (c *Client) XDel(ctx context.Context, stream string, ids ...string) *redis.IntCmd {
+	return c.client.XDel(ctx, stream, ids...)
+}
+
+func (c *Client) XLen(ctx context.Context, stream string) *redis.IntCmd {
+	return c.client.XLen(ctx, stream)
+}
@@ -5,11 +5,17 @@ import (
 
 	"ads-mrkt/internal/server"
 	serverconfig "ads-mrkt/internal/server/config"
+	"ads-mrkt/pkg/auth"
 	"ads-mrkt/pkg/auth/role"
+	"ads-mrkt/pkg/auth/scope"
 )
 
 type handler interface {
 	AuthUser(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	RefreshSession(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	Logout(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	LogoutAll(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	CreateWalletChallenge(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	SetWallet(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	DisconnectWallet(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	CreateListing(w http.ResponseWriter, r *http.Request) (interface{}, error)
@@ -18,18 +24,31 @@ type handler interface {
 	ListMyListings(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	UpdateListing(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	DeleteListing(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	ReportListing(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	ListMyChannels(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	RefreshChannel(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	GetChannelStats(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	GetChannelStatsHistory(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	CreateDeal(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	GetDeal(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	ListDealsByListingID(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	ListMyDeals(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	UpdateDealDraft(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	GetDealTerms(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	SignDeal(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	ExportSignedDealTerms(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	GetDealTimeline(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	SetDealPayoutAddress(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	RejectDeal(w http.ResponseWriter, r *http.Request) (interface{}, error)
 	GetOrCreateDealChatLink(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	CreateWebhook(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	ListWebhooks(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	DeleteWebhook(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	PingWebhook(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	CreateAPIKey(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	ListAPIKeys(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	DeleteAPIKey(w http.ResponseWriter, r *http.Request) (interface{}, error)
 }
 
 type authMiddleware interface {
@@ -41,20 +60,51 @@ type AnalyticsHandler interface {
 	GetSnapshotHistory(w http.ResponseWriter, r *http.Request) (interface{}, error)
 }
 
+type AdminHandler interface {
+	GetEscrowReconciliation(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	RunEscrowReconciliation(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	ListFailedEscrowTransfers(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	RetryEscrowTransfer(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	ReleaseEscrow(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	RefundEscrow(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	SearchUsers(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	GetUser(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	SetUserRole(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	BanUser(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	UnbanUser(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	SearchListings(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	BlockListing(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	UnblockListing(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	ListModerationQueue(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	ListListingReports(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	DismissListingReports(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	SearchDeals(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	GetDeal(w http.ResponseWriter, r *http.Request) (interface{}, error)
+	ListAuditLog(w http.ResponseWriter, r *http.Request) (interface{}, error)
+}
+
+// Staff allowed on the admin console routes; the admin service further checks the actor of each change.
+var (
+	moderatorRoles = []role.Role{role.AdminRole, role.ModeratorRole}
+	financeRoles   = []role.Role{role.AdminRole, role.FinanceRole}
+)
+
 type Router struct {
 	Config serverconfig.Config
 
 	handler          handler
 	authMiddleware   authMiddleware
 	analyticsHandler AnalyticsHandler // optional; when set, registers /api/v1/analytics/* routes
+	adminHandler     AdminHandler
 }
 
-func NewRouter(config serverconfig.Config, handler handler, authMiddleware authMiddleware, analyticsHandler AnalyticsHandler) *Router {
+func NewRouter(config serverconfig.Config, handler handler, authMiddleware authMiddleware, analyticsHandler AnalyticsHandler, adminHandler AdminHandler) *Router {
 	return &Router{
 		Config:           config,
 		handler:          handler,
 		authMiddleware:   authMiddleware,
 		analyticsHandler: analyticsHandler,
+		adminHandler:     adminHandler,
 	}
 }
 
@@ -74,6 +124,40 @@ func (r *Router) GetRoutes() http.Handler {
 		),
 		"/api/v1",
 	))
+	mux.HandleFunc("POST /api/v1/market/auth/refresh", server.WithMetrics(
+		server.WithMethod(
+			server.WithJSONResponse(r.handler.RefreshSession),
+			http.MethodPost,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("POST /api/v1/market/auth/logout", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.handler.Logout),
+				http.MethodPost,
+			),
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("POST /api/v1/market/auth/logout-all", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.handler.LogoutAll),
+				http.MethodPost,
+			),
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("POST /api/v1/market/me/wallet/challenge", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.handler.CreateWalletChallenge),
+				http.MethodPost,
+			),
+		),
+		"/api/v1",
+	))
 	mux.HandleFunc("PUT /api/v1/market/me/wallet", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
@@ -94,184 +178,564 @@ func (r *Router) GetRoutes() http.Handler {
 	))
 
 	mux.HandleFunc("GET /api/v1/market/listings", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.ListListings),
+					http.MethodGet,
+				),
+			),
+			scope.ListingsRead,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("GET /api/v1/market/my-listings", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.ListMyListings),
+					http.MethodGet,
+				),
+			),
+			scope.ListingsRead,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("POST /api/v1/market/listings", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.CreateListing),
+					http.MethodPost,
+				),
+			),
+			scope.ListingsWrite,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("GET /api/v1/market/listings/{id}", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.GetListing),
+					http.MethodGet,
+				),
+			),
+			scope.ListingsRead,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("PATCH /api/v1/market/listings/{id}", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.UpdateListing),
+					http.MethodPatch,
+				),
+			),
+			scope.ListingsWrite,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("DELETE /api/v1/market/listings/{id}", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.DeleteListing),
+					http.MethodDelete,
+				),
+			),
+			scope.ListingsWrite,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("POST /api/v1/market/listings/{id}/report", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.ListListings),
+				server.WithJSONResponse(r.handler.ReportListing),
+				http.MethodPost,
+			),
+		),
+		"/api/v1",
+	))
+
+	mux.HandleFunc("GET /api/v1/market/my-channels", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.handler.ListMyChannels),
 				http.MethodGet,
 			),
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("GET /api/v1/market/my-listings", server.WithMetrics(
+	mux.HandleFunc("GET /api/v1/market/channels/{id}/refresh", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.ListMyListings),
+				server.WithJSONResponse(r.handler.RefreshChannel),
 				http.MethodGet,
 			),
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("POST /api/v1/market/listings", server.WithMetrics(
+	mux.HandleFunc("GET /api/v1/market/channels/{id}/stats", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.handler.GetChannelStats),
+				http.MethodGet,
+			),
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("GET /api/v1/market/channels/{id}/stats/history", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.CreateListing),
+				server.WithJSONResponse(r.handler.GetChannelStatsHistory),
+				http.MethodGet,
+			),
+		),
+		"/api/v1",
+	))
+
+	mux.HandleFunc("POST /api/v1/market/deals", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.CreateDeal),
+					http.MethodPost,
+				),
+			),
+			scope.DealsWrite,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("GET /api/v1/market/deals/{id}", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.GetDeal),
+					http.MethodGet,
+				),
+			),
+			scope.DealsWrite,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("GET /api/v1/market/listings/{listing_id}/deals", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.ListDealsByListingID),
+					http.MethodGet,
+				),
+			),
+			scope.DealsWrite,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("GET /api/v1/market/my-deals", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.ListMyDeals),
+					http.MethodGet,
+				),
+			),
+			scope.DealsWrite,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("PATCH /api/v1/market/deals/{id}", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.UpdateDealDraft),
+					http.MethodPatch,
+				),
+			),
+			scope.DealsWrite,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("GET /api/v1/market/deals/{id}/terms", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.GetDealTerms),
+					http.MethodGet,
+				),
+			),
+			scope.DealsWrite,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("GET /api/v1/market/deals/{id}/signatures", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.ExportSignedDealTerms),
+					http.MethodGet,
+				),
+			),
+			scope.DealsWrite,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("GET /api/v1/market/deals/{id}/timeline", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.GetDealTimeline),
+					http.MethodGet,
+				),
+			),
+			scope.DealsWrite,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("POST /api/v1/market/deals/{id}/sign", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.SignDeal),
+					http.MethodPost,
+				),
+			),
+			scope.DealsWrite,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("PUT /api/v1/market/deals/{id}/payout-address", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.SetDealPayoutAddress),
+					http.MethodPut,
+				),
+			),
+			scope.DealsWrite,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("POST /api/v1/market/deals/{id}/reject", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.RejectDeal),
+					http.MethodPost,
+				),
+			),
+			scope.DealsWrite,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("POST /api/v1/market/deals/{id}/chat-link", server.WithMetrics(
+		auth.WithScope(
+			r.authMiddleware.WithAuth(
+				server.WithMethod(
+					server.WithJSONResponse(r.handler.GetOrCreateDealChatLink),
+					http.MethodPost,
+				),
+			),
+			scope.DealsWrite,
+		),
+		"/api/v1",
+	))
+
+	mux.HandleFunc("POST /api/v1/market/webhooks", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.handler.CreateWebhook),
 				http.MethodPost,
 			),
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("GET /api/v1/market/listings/{id}", server.WithMetrics(
+	mux.HandleFunc("GET /api/v1/market/webhooks", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.GetListing),
+				server.WithJSONResponse(r.handler.ListWebhooks),
 				http.MethodGet,
 			),
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("PATCH /api/v1/market/listings/{id}", server.WithMetrics(
+	mux.HandleFunc("DELETE /api/v1/market/webhooks/{id}", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.UpdateListing),
-				http.MethodPatch,
+				server.WithJSONResponse(r.handler.DeleteWebhook),
+				http.MethodDelete,
 			),
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("DELETE /api/v1/market/listings/{id}", server.WithMetrics(
+	mux.HandleFunc("POST /api/v1/market/webhooks/{id}/ping", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.DeleteListing),
+				server.WithJSONResponse(r.handler.PingWebhook),
+				http.MethodPost,
+			),
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("GET /api/v1/market/webhooks/{id}/deliveries", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.handler.ListWebhookDeliveries),
+				http.MethodGet,
+			),
+		),
+		"/api/v1",
+	))
+
+	mux.HandleFunc("POST /api/v1/market/api-keys", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.handler.CreateAPIKey),
+				http.MethodPost,
+			),
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("GET /api/v1/market/api-keys", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.handler.ListAPIKeys),
+				http.MethodGet,
+			),
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("DELETE /api/v1/market/api-keys/{id}", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.handler.DeleteAPIKey),
 				http.MethodDelete,
 			),
 		),
 		"/api/v1",
 	))
 
-	mux.HandleFunc("GET /api/v1/market/my-channels", server.WithMetrics(
+	mux.HandleFunc("GET /api/v1/analytics/snapshot/latest", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.ListMyChannels),
+				server.WithJSONResponse(r.analyticsHandler.GetLatestSnapshot),
 				http.MethodGet,
 			),
+			role.AdminRole,
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("GET /api/v1/market/channels/{id}/refresh", server.WithMetrics(
+	mux.HandleFunc("GET /api/v1/analytics/snapshot/history", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.RefreshChannel),
+				server.WithJSONResponse(r.analyticsHandler.GetSnapshotHistory),
 				http.MethodGet,
 			),
+			role.AdminRole,
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("GET /api/v1/market/channels/{id}/stats", server.WithMetrics(
+
+	mux.HandleFunc("GET /api/v1/admin/users", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.GetChannelStats),
+				server.WithJSONResponse(r.adminHandler.SearchUsers),
+				http.MethodGet,
+			),
+			role.Staff...,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("GET /api/v1/admin/users/{id}", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.adminHandler.GetUser),
+				http.MethodGet,
+			),
+			role.Staff...,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("PUT /api/v1/admin/users/{id}/role", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.adminHandler.SetUserRole),
+				http.MethodPut,
+			),
+			role.AdminRole,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("POST /api/v1/admin/users/{id}/ban", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.adminHandler.BanUser),
+				http.MethodPost,
+			),
+			moderatorRoles...,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("DELETE /api/v1/admin/users/{id}/ban", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.adminHandler.UnbanUser),
+				http.MethodDelete,
+			),
+			moderatorRoles...,
+		),
+		"/api/v1",
+	))
+
+	mux.HandleFunc("GET /api/v1/admin/listings", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.adminHandler.SearchListings),
 				http.MethodGet,
 			),
+			role.Staff...,
 		),
 		"/api/v1",
 	))
 
-	mux.HandleFunc("POST /api/v1/market/deals", server.WithMetrics(
+	mux.HandleFunc("POST /api/v1/admin/listings/{id}/block", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.CreateDeal),
+				server.WithJSONResponse(r.adminHandler.BlockListing),
 				http.MethodPost,
 			),
+			moderatorRoles...,
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("GET /api/v1/market/deals/{id}", server.WithMetrics(
+	mux.HandleFunc("DELETE /api/v1/admin/listings/{id}/block", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.GetDeal),
+				server.WithJSONResponse(r.adminHandler.UnblockListing),
+				http.MethodDelete,
+			),
+			moderatorRoles...,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("GET /api/v1/admin/moderation/queue", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.adminHandler.ListModerationQueue),
 				http.MethodGet,
 			),
+			moderatorRoles...,
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("GET /api/v1/market/listings/{listing_id}/deals", server.WithMetrics(
+	mux.HandleFunc("GET /api/v1/admin/listings/{id}/reports", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.ListDealsByListingID),
+				server.WithJSONResponse(r.adminHandler.ListListingReports),
 				http.MethodGet,
 			),
+			moderatorRoles...,
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("GET /api/v1/market/my-deals", server.WithMetrics(
+	mux.HandleFunc("POST /api/v1/admin/listings/{id}/reports/dismiss", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.ListMyDeals),
+				server.WithJSONResponse(r.adminHandler.DismissListingReports),
+				http.MethodPost,
+			),
+			moderatorRoles...,
+		),
+		"/api/v1",
+	))
+
+	mux.HandleFunc("GET /api/v1/admin/deals", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.adminHandler.SearchDeals),
 				http.MethodGet,
 			),
+			role.Staff...,
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("PATCH /api/v1/market/deals/{id}", server.WithMetrics(
+	mux.HandleFunc("GET /api/v1/admin/deals/{id}", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.UpdateDealDraft),
-				http.MethodPatch,
+				server.WithJSONResponse(r.adminHandler.GetDeal),
+				http.MethodGet,
 			),
+			role.Staff...,
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("POST /api/v1/market/deals/{id}/sign", server.WithMetrics(
+
+	mux.HandleFunc("GET /api/v1/admin/escrow/reconciliation", server.WithMetrics(
+		r.authMiddleware.WithAuth(
+			server.WithMethod(
+				server.WithJSONResponse(r.adminHandler.GetEscrowReconciliation),
+				http.MethodGet,
+			),
+			financeRoles...,
+		),
+		"/api/v1",
+	))
+	mux.HandleFunc("POST /api/v1/admin/escrow/reconciliation", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.SignDeal),
+				server.WithJSONResponse(r.adminHandler.RunEscrowReconciliation),
 				http.MethodPost,
 			),
+			financeRoles...,
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("PUT /api/v1/market/deals/{id}/payout-address", server.WithMetrics(
+
+	mux.HandleFunc("GET /api/v1/admin/escrow/failed-transfers", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.SetDealPayoutAddress),
-				http.MethodPut,
+				server.WithJSONResponse(r.adminHandler.ListFailedEscrowTransfers),
+				http.MethodGet,
 			),
+			financeRoles...,
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("POST /api/v1/market/deals/{id}/reject", server.WithMetrics(
+	mux.HandleFunc("POST /api/v1/admin/deals/{id}/escrow/retry", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.RejectDeal),
+				server.WithJSONResponse(r.adminHandler.RetryEscrowTransfer),
 				http.MethodPost,
 			),
+			financeRoles...,
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("POST /api/v1/market/deals/{id}/chat-link", server.WithMetrics(
+	mux.HandleFunc("POST /api/v1/admin/deals/{id}/escrow/release", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.handler.GetOrCreateDealChatLink),
+				server.WithJSONResponse(r.adminHandler.ReleaseEscrow),
 				http.MethodPost,
 			),
+			financeRoles...,
 		),
 		"/api/v1",
 	))
-
-	mux.HandleFunc("GET /api/v1/analytics/snapshot/latest", server.WithMetrics(
+	mux.HandleFunc("POST /api/v1/admin/deals/{id}/escrow/refund", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.analyticsHandler.GetLatestSnapshot),
-				http.MethodGet,
+				server.WithJSONResponse(r.adminHandler.RefundEscrow),
+				http.MethodPost,
 			),
-			role.AdminRole,
+			financeRoles...,
 		),
 		"/api/v1",
 	))
-	mux.HandleFunc("GET /api/v1/analytics/snapshot/history", server.WithMetrics(
+
+	mux.HandleFunc("GET /api/v1/admin/audit-log", server.WithMetrics(
 		r.authMiddleware.WithAuth(
 			server.WithMethod(
-				server.WithJSONResponse(r.analyticsHandler.GetSnapshotHistory),
+				server.WithJSONResponse(r.adminHandler.ListAuditLog),
 				http.MethodGet,
 			),
 			role.AdminRole,
@@ -1,8 +1,78 @@
 package config
 
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	SessionStorageFile     = "file"
+	SessionStorageVault    = "vault"
+	SessionStoragePostgres = "postgres"
+)
+
 type Config struct {
 	ApiID       int    `env:"API_ID"`
 	ApiHash     string `env:"API_HASH"`
 	SessionFile string `env:"SESSION_FILE_PATH"`
 	Phone       string `env:"PHONE"`
+	// Accounts adds accounts to the pool as comma separated entries: "phone=session_file_path" with file session
+	// storage, "phone" otherwise. The account from PHONE and SESSION_FILE_PATH, when set, is the first one.
+	Accounts []string `env:"ACCOUNTS" env-separator:","`
+	// SessionStorage is where MTProto sessions are kept: file, vault or postgres. Sessions in postgres are
+	// encrypted with SessionEncryptionKey, a base64 encoded 32 byte key.
+	SessionStorage       string `env:"SESSION_STORAGE" env-default:"file"`
+	SessionEncryptionKey string `env:"SESSION_ENCRYPTION_KEY"`
+	// StatsRefreshInterval is how often stats of channels with an active listing are refreshed; 0 disables the scheduler.
+	StatsRefreshInterval time.Duration `env:"STATS_REFRESH_INTERVAL" env-default:"24h"`
+	// ChannelCheckInterval is how often channels in use are checked for lost rights, removal and admin changes; 0 disables the check.
+	ChannelCheckInterval time.Duration `env:"CHANNEL_CHECK_INTERVAL" env-default:"1h"`
+}
+
+// Account is one Telegram account of the userbot pool.
+type Account struct {
+	Phone       string
+	SessionFile string
+}
+
+// AccountList returns the configured accounts, PHONE/SESSION_FILE_PATH first.
+func (c Config) AccountList() ([]Account, error) {
+	var accounts []Account
+	if c.Phone != "" || c.SessionFile != "" {
+		accounts = append(accounts, Account{Phone: c.Phone, SessionFile: c.SessionFile})
+	}
+	for _, entry := range c.Accounts {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		phone, sessionFile, _ := strings.Cut(entry, "=")
+		accounts = append(accounts, Account{Phone: strings.TrimSpace(phone), SessionFile: strings.TrimSpace(sessionFile)})
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no userbot accounts configured")
+	}
+	for _, acc := range accounts {
+		if c.SessionStorage == SessionStorageFile && acc.SessionFile == "" {
+			return nil, fmt.Errorf("userbot account %q has no session file path", acc.Phone)
+		}
+		if c.SessionStorage != SessionStorageFile && acc.Phone == "" {
+			return nil, fmt.Errorf("userbot account with session %q has no phone", acc.SessionFile)
+		}
+	}
+	return accounts, nil
+}
+
+// EncryptionKey decodes SessionEncryptionKey.
+func (c Config) EncryptionKey() ([]byte, error) {
+	if c.SessionEncryptionKey == "" {
+		return nil, fmt.Errorf("session encryption key is not set")
+	}
+	key, err := base64.StdEncoding.DecodeString(c.SessionEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode session encryption key: %w", err)
+	}
+	return key, nil
 }
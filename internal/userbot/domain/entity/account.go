@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+type AccountStatus string
+
+const (
+	AccountStatusActive AccountStatus = "active"
+	// AccountStatusLimited is set on FLOOD_WAIT; the account is skipped until LimitedUntil.
+	AccountStatusLimited AccountStatus = "limited"
+	// AccountStatusBanned is set when Telegram rejects the account's session; the account is not used again until restart.
+	AccountStatusBanned AccountStatus = "banned"
+)
+
+// Account is a Telegram account of the userbot pool.
+type Account struct {
+	UserID       int64         `json:"user_id"`
+	Phone        string        `json:"phone"`
+	Status       AccountStatus `json:"status"`
+	LimitedUntil *time.Time    `json:"limited_until,omitempty"`
+}
+
+// ChannelAccount is an account's membership in a channel. Access hashes are per account, so each member keeps
+// its own; Assigned marks the one account that does the channel's work.
+type ChannelAccount struct {
+	ChannelID  int64 `json:"channel_id"`
+	AccountID  int64 `json:"account_id"`
+	AccessHash int64 `json:"-"`
+	CanPost    bool  `json:"can_post"`
+	Assigned   bool  `json:"assigned"`
+}
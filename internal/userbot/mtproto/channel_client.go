@@ -0,0 +1,215 @@
+// Package mtproto wraps the gotd client calls the userbot makes against channels.
+// The userbot service depends on these operations through a narrow interface so tests can use mtprototest.Channels instead.
+package mtproto
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"reflect"
+	"time"
+
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/telegram/downloader"
+	"github.com/gotd/td/tg"
+	"golang.org/x/sync/errgroup"
+)
+
+// Message is a channel history entry.
+type Message struct {
+	ID        int64
+	Text      string
+	Date      time.Time
+	Views     int
+	Forwards  int
+	Reactions int
+}
+
+type ChannelClient struct {
+	client *telegram.Client
+}
+
+func NewChannelClient(client *telegram.Client) *ChannelClient {
+	return &ChannelClient{client: client}
+}
+
+func inputChannel(channelID, accessHash int64) *tg.InputChannel {
+	return &tg.InputChannel{ChannelID: channelID, AccessHash: accessHash}
+}
+
+func inputPeer(channelID, accessHash int64) *tg.InputPeerChannel {
+	return &tg.InputPeerChannel{ChannelID: channelID, AccessHash: accessHash}
+}
+
+// SendMessage posts text to the channel and returns the new message id (0 if Telegram did not report it).
+func (c *ChannelClient) SendMessage(ctx context.Context, channelID, accessHash int64, text string) (int64, error) {
+	result, err := c.client.API().MessagesSendMessage(ctx, &tg.MessagesSendMessageRequest{
+		Peer:     inputPeer(channelID, accessHash),
+		Message:  text,
+		RandomID: rand.Int63(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	upd, ok := result.(*tg.Updates)
+	if !ok {
+		return 0, nil
+	}
+	for _, u := range upd.Updates {
+		if msg, ok := u.(*tg.UpdateMessageID); ok {
+			return int64(msg.ID), nil
+		}
+	}
+	return 0, nil
+}
+
+// GetHistory returns channel messages, newest first.
+// See https://core.telegram.org/api/offsets: offset = offsetFromID(offsetID) + addOffset.
+func (c *ChannelClient) GetHistory(ctx context.Context, channelID, accessHash int64, offsetID, addOffset, limit int) ([]Message, error) {
+	result, err := c.client.API().MessagesGetHistory(ctx, &tg.MessagesGetHistoryRequest{
+		Peer:      inputPeer(channelID, accessHash),
+		OffsetID:  offsetID,
+		AddOffset: addOffset,
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var messages []tg.MessageClass
+	switch r := result.(type) {
+	case *tg.MessagesMessages:
+		messages = r.Messages
+	case *tg.MessagesChannelMessages:
+		messages = r.Messages
+	default:
+		return nil, nil
+	}
+	out := make([]Message, 0, len(messages))
+	for _, msg := range messages {
+		m, ok := msg.(*tg.Message)
+		if !ok {
+			continue
+		}
+		var reactions int
+		for _, r := range m.Reactions.Results {
+			reactions += r.Count
+		}
+		out = append(out, Message{
+			ID:        int64(m.ID),
+			Text:      m.Message,
+			Date:      time.Unix(int64(m.Date), 0),
+			Views:     m.Views,
+			Forwards:  m.Forwards,
+			Reactions: reactions,
+		})
+	}
+	return out, nil
+}
+
+func (c *ChannelClient) GetFullChannel(ctx context.Context, channelID, accessHash int64) (*tg.MessagesChatFull, error) {
+	return c.client.API().ChannelsGetFullChannel(ctx, inputChannel(channelID, accessHash))
+}
+
+// GetAdmins returns one page of channel administrators (creator included).
+func (c *ChannelClient) GetAdmins(ctx context.Context, channelID, accessHash int64, offset, limit int) (tg.ChannelsChannelParticipantsClass, error) {
+	return c.client.API().ChannelsGetParticipants(ctx, &tg.ChannelsGetParticipantsRequest{
+		Channel: inputChannel(channelID, accessHash),
+		Filter:  &tg.ChannelParticipantsAdmins{},
+		Offset:  offset,
+		Limit:   limit,
+	})
+}
+
+// GetBroadcastStats requests channel stats from the stats DC and resolves all async graphs.
+func (c *ChannelClient) GetBroadcastStats(ctx context.Context, channelID, accessHash int64, statsDC int) (*tg.StatsBroadcastStats, error) {
+	invoker, err := c.client.DC(ctx, statsDC, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DC: %w", err)
+	}
+	defer invoker.Close()
+
+	var stats tg.StatsBroadcastStats
+	if err = invoker.Invoke(ctx, &tg.StatsGetBroadcastStatsRequest{Channel: inputChannel(channelID, accessHash)}, &stats); err != nil {
+		return nil, fmt.Errorf("failed to get broadcast stats: %w", err)
+	}
+
+	jobs := collectAsyncGraphJobs(&stats)
+	slog.Info("collected async graph jobs", "channel_id", channelID, "jobs", len(jobs))
+	if len(jobs) == 0 {
+		return &stats, nil
+	}
+	results := make([]tg.StatsGraphClass, len(jobs))
+	g, gctx := errgroup.WithContext(ctx)
+	for i := range jobs {
+		idx := i
+		g.Go(func() error {
+			var box tg.StatsGraphBox
+			if err := invoker.Invoke(gctx, &tg.StatsLoadAsyncGraphRequest{Token: jobs[idx].token}, &box); err != nil {
+				return fmt.Errorf("load async graph: %w: token: %s", err, jobs[idx].token)
+			}
+			results[idx] = box.StatsGraph
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, fmt.Errorf("loading async graphs: %w", err)
+	}
+	applyLoadedGraphs(&stats, jobs, results)
+	return &stats, nil
+}
+
+// DownloadPhoto downloads the full-size channel profile photo.
+func (c *ChannelClient) DownloadPhoto(ctx context.Context, channelID, accessHash, photoID int64) ([]byte, error) {
+	location := &tg.InputPeerPhotoFileLocation{
+		Big:     true,
+		Peer:    inputPeer(channelID, accessHash),
+		PhotoID: photoID,
+	}
+	var buf bytes.Buffer
+	if _, err := downloader.NewDownloader().Download(c.client.API(), location).Stream(ctx, &buf); err != nil {
+		return nil, fmt.Errorf("download photo: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type asyncGraphJob struct {
+	fieldIndex int
+	token      string
+}
+
+func collectAsyncGraphJobs(stats *tg.StatsBroadcastStats) []asyncGraphJob {
+	statsVal := reflect.ValueOf(stats).Elem()
+	graphIface := reflect.TypeOf((*tg.StatsGraphClass)(nil)).Elem()
+
+	var jobs []asyncGraphJob
+	for i := 0; i < statsVal.NumField(); i++ {
+		f := statsVal.Field(i)
+		if f.Type() != graphIface {
+			continue
+		}
+		if f.IsNil() {
+			continue
+		}
+		graph, ok := f.Interface().(tg.StatsGraphClass)
+		if !ok {
+			continue
+		}
+		async, ok := graph.(*tg.StatsGraphAsync)
+		if !ok {
+			continue
+		}
+		jobs = append(jobs, asyncGraphJob{fieldIndex: i, token: async.Token})
+	}
+	return jobs
+}
+
+func applyLoadedGraphs(stats *tg.StatsBroadcastStats, jobs []asyncGraphJob, results []tg.StatsGraphClass) {
+	statsVal := reflect.ValueOf(stats).Elem()
+	for i, job := range jobs {
+		if results[i] != nil {
+			statsVal.Field(job.fieldIndex).Set(reflect.ValueOf(results[i]))
+		}
+	}
+}
@@ -0,0 +1,277 @@
+// Package mtprototest provides a scriptable in-memory fake of mtproto.ChannelClient.
+package mtprototest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"ads-mrkt/internal/userbot/mtproto"
+
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+// Admin is a channel administrator returned by GetAdmins.
+type Admin struct {
+	UserID int64
+	Owner  bool
+}
+
+// Channel describes a channel known to the fake.
+type Channel struct {
+	ID           int64
+	AccessHash   int64
+	Title        string
+	Username     string
+	AdminRights  tg.ChatAdminRights
+	CanViewStats bool
+	StatsDC      int
+	// Participants is the ParticipantsCount of the full channel.
+	Participants int
+	Admins       []Admin
+	// Photo is returned by DownloadPhoto; a nil photo means the channel has no profile picture.
+	Photo []byte
+	// Stats is returned by GetBroadcastStats; nil yields empty stats.
+	Stats *tg.StatsBroadcastStats
+	// Removed makes every call fail with CHANNEL_PRIVATE, as after the userbot is kicked from the channel.
+	Removed bool
+}
+
+type channelState struct {
+	Channel
+	messages map[int64]mtproto.Message
+	nextID   int64
+}
+
+// Channels is an in-memory set of channels with message history.
+// Operations fail with an error scripted by FailNext or when the channel or access hash is unknown.
+type Channels struct {
+	mu       sync.Mutex
+	channels map[int64]*channelState
+	failures map[string][]error
+	calls    map[string]int
+}
+
+func New() *Channels {
+	return &Channels{
+		channels: make(map[int64]*channelState),
+		failures: make(map[string][]error),
+		calls:    make(map[string]int),
+	}
+}
+
+// AddChannel registers (or replaces) a channel.
+func (c *Channels) AddChannel(ch Channel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.channels[ch.ID] = &channelState{Channel: ch, messages: make(map[int64]mtproto.Message)}
+}
+
+// UpdateChannel applies fn to a registered channel, e.g. to revoke admin rights.
+func (c *Channels) UpdateChannel(channelID int64, fn func(ch *Channel)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if st, ok := c.channels[channelID]; ok {
+		fn(&st.Channel)
+	}
+}
+
+// Post adds a message to the channel as if someone else had posted it and returns its id.
+func (c *Channels) Post(channelID int64, text string) int64 {
+	return c.PostMessage(channelID, mtproto.Message{Text: text})
+}
+
+// PostMessage adds a message with its counters to the channel and returns its id. A zero Date means now.
+func (c *Channels) PostMessage(channelID int64, m mtproto.Message) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.channels[channelID]
+	st.nextID++
+	m.ID = st.nextID
+	if m.Date.IsZero() {
+		m.Date = time.Now()
+	}
+	st.messages[m.ID] = m
+	return m.ID
+}
+
+// Delete removes a message from the channel history.
+func (c *Channels) Delete(channelID, messageID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.channels[channelID].messages, messageID)
+}
+
+// Messages returns the channel history, oldest first.
+func (c *Channels) Messages(channelID int64) []mtproto.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := c.historyLocked(c.channels[channelID])
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// FailNext makes the next call of op (the ChannelClient method name, e.g. "SendMessage") return err.
+func (c *Channels) FailNext(op string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures[op] = append(c.failures[op], err)
+}
+
+// Calls returns how many times op was called.
+func (c *Channels) Calls(op string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[op]
+}
+
+// begin records the call, pops a scripted failure and resolves the channel. Must be called with mu held.
+func (c *Channels) begin(op string, channelID, accessHash int64) (*channelState, error) {
+	c.calls[op]++
+	if queue := c.failures[op]; len(queue) > 0 {
+		c.failures[op] = queue[1:]
+		return nil, queue[0]
+	}
+	st, ok := c.channels[channelID]
+	if !ok || st.AccessHash != accessHash {
+		return nil, tgerr.New(400, "CHANNEL_INVALID")
+	}
+	if st.Removed {
+		return nil, tgerr.New(400, "CHANNEL_PRIVATE")
+	}
+	return st, nil
+}
+
+// historyLocked returns messages newest first.
+func (c *Channels) historyLocked(st *channelState) []mtproto.Message {
+	out := make([]mtproto.Message, 0, len(st.messages))
+	for _, m := range st.messages {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	return out
+}
+
+func (c *Channels) SendMessage(ctx context.Context, channelID, accessHash int64, text string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.begin("SendMessage", channelID, accessHash)
+	if err != nil {
+		return 0, err
+	}
+	if !st.AdminRights.PostMessages {
+		return 0, fmt.Errorf("rpc error code 403: CHAT_WRITE_FORBIDDEN")
+	}
+	st.nextID++
+	st.messages[st.nextID] = mtproto.Message{ID: st.nextID, Text: text, Date: time.Now()}
+	return st.nextID, nil
+}
+
+// GetHistory follows the MTProto offset rules: the page starts at the first message older than offsetID
+// (or the newest one when offsetID is 0), shifted by addOffset.
+func (c *Channels) GetHistory(ctx context.Context, channelID, accessHash int64, offsetID, addOffset, limit int) ([]mtproto.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.begin("GetHistory", channelID, accessHash)
+	if err != nil {
+		return nil, err
+	}
+	history := c.historyLocked(st)
+	start := 0
+	if offsetID != 0 {
+		start = sort.Search(len(history), func(i int) bool { return history[i].ID < int64(offsetID) })
+	}
+	start += addOffset
+	start = max(0, min(start, len(history)))
+	end := min(start+limit, len(history))
+	return history[start:end], nil
+}
+
+func (c *Channels) GetFullChannel(ctx context.Context, channelID, accessHash int64) (*tg.MessagesChatFull, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.begin("GetFullChannel", channelID, accessHash)
+	if err != nil {
+		return nil, err
+	}
+	channel := &tg.Channel{
+		ID:          st.ID,
+		AccessHash:  st.AccessHash,
+		Title:       st.Title,
+		Broadcast:   true,
+		Photo:       &tg.ChatPhotoEmpty{},
+		AdminRights: st.AdminRights,
+	}
+	channel.SetFlags()
+	if st.Username != "" {
+		channel.SetUsername(st.Username)
+	}
+	if st.Photo != nil {
+		channel.Photo = &tg.ChatPhoto{PhotoID: st.ID}
+	}
+	full := &tg.ChannelFull{
+		ID:           st.ID,
+		CanViewStats: st.CanViewStats,
+		StatsDC:      st.StatsDC,
+	}
+	if st.Participants > 0 {
+		full.SetParticipantsCount(st.Participants)
+	}
+	return &tg.MessagesChatFull{
+		FullChat: full,
+		Chats:    []tg.ChatClass{channel},
+	}, nil
+}
+
+func (c *Channels) GetAdmins(ctx context.Context, channelID, accessHash int64, offset, limit int) (tg.ChannelsChannelParticipantsClass, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.begin("GetAdmins", channelID, accessHash)
+	if err != nil {
+		return nil, err
+	}
+	admins := st.Admins[min(offset, len(st.Admins)):]
+	admins = admins[:min(limit, len(admins))]
+	participants := make([]tg.ChannelParticipantClass, 0, len(admins))
+	for _, a := range admins {
+		if a.Owner {
+			participants = append(participants, &tg.ChannelParticipantCreator{UserID: a.UserID})
+		} else {
+			participants = append(participants, &tg.ChannelParticipantAdmin{UserID: a.UserID})
+		}
+	}
+	return &tg.ChannelsChannelParticipants{Count: len(st.Admins), Participants: participants}, nil
+}
+
+func (c *Channels) GetBroadcastStats(ctx context.Context, channelID, accessHash int64, statsDC int) (*tg.StatsBroadcastStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.begin("GetBroadcastStats", channelID, accessHash)
+	if err != nil {
+		return nil, err
+	}
+	if !st.CanViewStats {
+		return nil, fmt.Errorf("rpc error code 400: CHAT_ADMIN_REQUIRED")
+	}
+	if st.Stats == nil {
+		return &tg.StatsBroadcastStats{}, nil
+	}
+	stats := *st.Stats
+	return &stats, nil
+}
+
+func (c *Channels) DownloadPhoto(ctx context.Context, channelID, accessHash, photoID int64) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, err := c.begin("DownloadPhoto", channelID, accessHash)
+	if err != nil {
+		return nil, err
+	}
+	if st.Photo == nil {
+		return nil, fmt.Errorf("rpc error code 400: LOCATION_INVALID")
+	}
+	return append([]byte(nil), st.Photo...), nil
+}
@@ -0,0 +1,26 @@
+package model
+
+import "ads-mrkt/internal/userbot/domain/entity"
+
+type ChannelAccountRow struct {
+	ChannelID  int64 `db:"channel_id"`
+	AccountID  int64 `db:"account_id"`
+	AccessHash int64 `db:"access_hash"`
+	CanPost    bool  `db:"can_post"`
+	Assigned   bool  `db:"assigned"`
+}
+
+func ChannelAccountRowToEntity(row ChannelAccountRow) *entity.ChannelAccount {
+	return &entity.ChannelAccount{
+		ChannelID:  row.ChannelID,
+		AccountID:  row.AccountID,
+		AccessHash: row.AccessHash,
+		CanPost:    row.CanPost,
+		Assigned:   row.Assigned,
+	}
+}
+
+type AssignedCountRow struct {
+	AccountID int64 `db:"account_id"`
+	Count     int   `db:"count"`
+}
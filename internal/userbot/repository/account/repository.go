@@ -0,0 +1,176 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ads-mrkt/internal/userbot/domain/entity"
+	"ads-mrkt/internal/userbot/repository/account/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type database interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (context.Context, error)
+	EndTx(ctx context.Context, err error, source string) error
+}
+
+type repository struct {
+	db database
+}
+
+func New(db database) *repository {
+	return &repository{db: db}
+}
+
+func (r *repository) UpsertAccount(ctx context.Context, account *entity.Account) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO userbot.account (user_id, phone, status, limited_until)
+		VALUES (@user_id, @phone, @status, @limited_until)
+		ON CONFLICT (user_id) DO UPDATE SET
+			phone = EXCLUDED.phone,
+			status = EXCLUDED.status,
+			limited_until = EXCLUDED.limited_until,
+			updated_at = NOW()`,
+		pgx.NamedArgs{
+			"user_id":       account.UserID,
+			"phone":         account.Phone,
+			"status":        string(account.Status),
+			"limited_until": account.LimitedUntil,
+		})
+	if err != nil {
+		return fmt.Errorf("failed to upsert account: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) SetAccountStatus(ctx context.Context, userID int64, status entity.AccountStatus, limitedUntil *time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE userbot.account
+		SET status = @status, limited_until = @limited_until, updated_at = NOW()
+		WHERE user_id = @user_id`,
+		pgx.NamedArgs{
+			"user_id":       userID,
+			"status":        string(status),
+			"limited_until": limitedUntil,
+		})
+	if err != nil {
+		return fmt.Errorf("failed to set account status: %w", err)
+	}
+	return nil
+}
+
+// UpsertChannelAccount records the account's membership in the channel; the assignment is left as is.
+func (r *repository) UpsertChannelAccount(ctx context.Context, m *entity.ChannelAccount) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO userbot.channel_account (channel_id, account_id, access_hash, can_post)
+		VALUES (@channel_id, @account_id, @access_hash, @can_post)
+		ON CONFLICT (channel_id, account_id) DO UPDATE SET
+			access_hash = EXCLUDED.access_hash,
+			can_post = EXCLUDED.can_post,
+			updated_at = NOW()`,
+		pgx.NamedArgs{
+			"channel_id":  m.ChannelID,
+			"account_id":  m.AccountID,
+			"access_hash": m.AccessHash,
+			"can_post":    m.CanPost,
+		})
+	if err != nil {
+		return fmt.Errorf("failed to upsert channel account: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteChannelAccount(ctx context.Context, channelID, accountID int64) error {
+	_, err := r.db.Exec(ctx, `
+		DELETE FROM userbot.channel_account
+		WHERE channel_id = @channel_id AND account_id = @account_id`,
+		pgx.NamedArgs{
+			"channel_id": channelID,
+			"account_id": accountID,
+		})
+	if err != nil {
+		return fmt.Errorf("failed to delete channel account: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListChannelAccounts(ctx context.Context, channelID int64) ([]*entity.ChannelAccount, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT channel_id, account_id, access_hash, can_post, assigned
+		FROM userbot.channel_account
+		WHERE channel_id = @channel_id
+		ORDER BY account_id`,
+		pgx.NamedArgs{"channel_id": channelID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query channel accounts: %w", err)
+	}
+	defer rows.Close()
+
+	list, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.ChannelAccountRow])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect channel accounts: %w", err)
+	}
+	out := make([]*entity.ChannelAccount, 0, len(list))
+	for _, row := range list {
+		out = append(out, model.ChannelAccountRowToEntity(row))
+	}
+	return out, nil
+}
+
+// AssignChannel makes accountID the channel's only assigned member.
+func (r *repository) AssignChannel(ctx context.Context, channelID, accountID int64) (err error) {
+	txCtx, beginErr := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if beginErr != nil {
+		return beginErr
+	}
+	defer func() { _ = r.db.EndTx(txCtx, err, "AssignChannel") }()
+
+	if _, err = r.db.Exec(txCtx, `
+		UPDATE userbot.channel_account
+		SET assigned = FALSE, updated_at = NOW()
+		WHERE channel_id = @channel_id AND assigned AND account_id <> @account_id`,
+		pgx.NamedArgs{"channel_id": channelID, "account_id": accountID}); err != nil {
+		return fmt.Errorf("failed to unassign channel: %w", err)
+	}
+	tag, err := r.db.Exec(txCtx, `
+		UPDATE userbot.channel_account
+		SET assigned = TRUE, updated_at = NOW()
+		WHERE channel_id = @channel_id AND account_id = @account_id`,
+		pgx.NamedArgs{"channel_id": channelID, "account_id": accountID})
+	if err != nil {
+		return fmt.Errorf("failed to assign channel: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		err = fmt.Errorf("account %d is not a member of channel %d", accountID, channelID)
+		return err
+	}
+	return nil
+}
+
+// CountAssignedChannels returns the number of channels assigned to each account that has any.
+func (r *repository) CountAssignedChannels(ctx context.Context) (map[int64]int, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT account_id, COUNT(*)::INT AS count
+		FROM userbot.channel_account
+		WHERE assigned
+		GROUP BY account_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query assigned channels: %w", err)
+	}
+	defer rows.Close()
+
+	list, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.AssignedCountRow])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect assigned channels: %w", err)
+	}
+	out := make(map[int64]int, len(list))
+	for _, row := range list {
+		out[row.AccountID] = row.Count
+	}
+	return out, nil
+}
@@ -0,0 +1,5 @@
+package model
+
+type SessionRow struct {
+	Data []byte `db:"data"`
+}
@@ -0,0 +1,58 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ads-mrkt/internal/userbot/repository/session/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type database interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+type repository struct {
+	db database
+}
+
+func New(db database) *repository {
+	return &repository{db: db}
+}
+
+// LoadSession returns the stored session data, or nil when there is none.
+func (r *repository) LoadSession(ctx context.Context, key string) ([]byte, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT data FROM userbot.session
+		WHERE key = @key`,
+		pgx.NamedArgs{"key": key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session: %w", err)
+	}
+	defer rows.Close()
+
+	row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.SessionRow])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return row.Data, nil
+}
+
+func (r *repository) StoreSession(ctx context.Context, key string, data []byte) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO userbot.session (key, data, updated_at)
+		VALUES (@key, @data, NOW())
+		ON CONFLICT (key) DO UPDATE SET data = EXCLUDED.data, updated_at = NOW()`,
+		pgx.NamedArgs{"key": key, "data": data})
+	if err != nil {
+		return fmt.Errorf("failed to store session: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	"ads-mrkt/internal/userbot/config"
+	"ads-mrkt/internal/userbot/mtproto"
+	"ads-mrkt/internal/userbot/session"
+
+	"github.com/gotd/td/examples"
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/telegram/auth"
+	"github.com/gotd/td/telegram/updates"
+	updhook "github.com/gotd/td/telegram/updates/hook"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+// account is one logged in Telegram client of the pool, with its own session and update state.
+type account struct {
+	phone          string
+	telegramClient *telegram.Client
+	authFlow       *auth.Flow
+	updatesManager *updates.Manager
+	userID         atomic.Int64
+}
+
+func (s *service) newAccount(cfg config.Config, acc config.Account) *account {
+	a := &account{phone: acc.Phone}
+
+	dispatcher := tg.NewUpdateDispatcher()
+	dispatcher.OnChannel(s.pool.channelUpdateHandler(a.userID.Load, s.handleChannelUpdate))
+
+	a.updatesManager = updates.New(updates.Config{
+		Handler: dispatcher,
+		Storage: s.stateStorage,
+	})
+
+	a.telegramClient = telegram.NewClient(
+		cfg.ApiID,
+		cfg.ApiHash,
+		telegram.Options{
+			SessionStorage: session.AccountStorage(s.sessionStore, acc),
+			UpdateHandler:  a.updatesManager,
+			Middlewares: []telegram.Middleware{
+				updhook.UpdateHook(a.updatesManager.Handle),
+			},
+			Device: telegram.DeviceConfig{
+				DeviceModel:    "ADS Market",
+				SystemVersion:  "n/a",
+				AppVersion:     "n/a",
+				SystemLangCode: "en",
+				LangPack:       "en",
+				LangCode:       "en",
+			},
+		},
+	)
+	// Sessions kept outside the file system are provisioned with the login command, not at start.
+	if s.sessionStore == nil {
+		flow := auth.NewFlow(examples.Terminal{PhoneNumber: acc.Phone}, auth.SendCodeOptions{})
+		a.authFlow = &flow
+	}
+	return a
+}
+
+func (s *service) getCurrentState(ctx context.Context, a *account) error {
+	api := a.telegramClient.API()
+
+	state, err := api.UpdatesGetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get current state from Telegram: %w", err)
+	}
+
+	if err := s.stateStorage.SetState(
+		ctx,
+		a.userID.Load(),
+		updates.State{
+			Pts:  state.Pts,
+			Qts:  state.Qts,
+			Date: state.Date,
+			Seq:  state.Seq,
+		},
+	); err != nil {
+		return fmt.Errorf("failed to store state in database: %w", err)
+	}
+
+	slog.Info("current state retrieved and stored",
+		"account_id", a.userID.Load(),
+		"pts", state.Pts,
+		"qts", state.Qts,
+		"date", state.Date,
+		"seq", state.Seq,
+	)
+
+	return nil
+}
+
+// runAccount logs the account in, adds it to the pool and polls its updates until ctx is done.
+func (s *service) runAccount(ctx context.Context, a *account) error {
+	if a.authFlow != nil {
+		slog.Debug("performing auth if necessary", "phone", a.phone)
+		if err := a.telegramClient.Auth().IfNecessary(ctx, *a.authFlow); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	} else {
+		status, err := a.telegramClient.Auth().Status(ctx)
+		if err != nil {
+			return fmt.Errorf("auth status: %w", err)
+		}
+		if !status.Authorized {
+			return fmt.Errorf("account %s is not logged in, run userbot login", a.phone)
+		}
+	}
+
+	slog.Debug("getting user info", "phone", a.phone)
+	user, err := a.telegramClient.Self(ctx)
+	if err != nil {
+		return fmt.Errorf("call self: %w", err)
+	}
+	a.userID.Store(user.ID)
+
+	if err := s.pool.add(ctx, user.ID, a.phone, mtproto.NewChannelClient(a.telegramClient)); err != nil {
+		return fmt.Errorf("add account to pool: %w", err)
+	}
+
+	slog.Debug("getting current state", "account_id", user.ID)
+	if err := s.getCurrentState(ctx, a); err != nil {
+		slog.Warn("Failed to get current state, continuing anyway", "account_id", user.ID, "error", err)
+	}
+
+	authOpts := updates.AuthOptions{
+		IsBot:  false,
+		Forget: false,
+		OnStart: func(ctx context.Context) {
+			slog.Info("updates manager started successfully", "account_id", user.ID)
+		},
+	}
+
+	slog.Info("starting updates polling", "user_id", user.ID)
+	err = a.updatesManager.Run(ctx, a.telegramClient.API(), user.ID, authOpts)
+	if tgerr.Is(err, accountBannedErrors...) {
+		s.pool.markBanned(ctx, user.ID, err)
+	}
+	return err
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"ads-mrkt/internal/userbot/domain/entity"
+	"ads-mrkt/internal/userbot/mtproto"
+
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+// Errors Telegram returns once an account's session is no longer usable.
+var accountBannedErrors = []string{
+	"AUTH_KEY_UNREGISTERED",
+	"AUTH_KEY_DUPLICATED",
+	"SESSION_REVOKED",
+	"USER_DEACTIVATED",
+	"USER_DEACTIVATED_BAN",
+}
+
+var errNoChannelAccount = errors.New("no usable userbot account in channel")
+
+type accountRepository interface {
+	UpsertAccount(ctx context.Context, account *entity.Account) error
+	SetAccountStatus(ctx context.Context, userID int64, status entity.AccountStatus, limitedUntil *time.Time) error
+	UpsertChannelAccount(ctx context.Context, m *entity.ChannelAccount) error
+	DeleteChannelAccount(ctx context.Context, channelID, accountID int64) error
+	ListChannelAccounts(ctx context.Context, channelID int64) ([]*entity.ChannelAccount, error)
+	AssignChannel(ctx context.Context, channelID, accountID int64) error
+	CountAssignedChannels(ctx context.Context) (map[int64]int, error)
+}
+
+// poolAccount is an account as seen by the pool: its channel operations and health.
+type poolAccount struct {
+	userID       int64
+	api          channelAPI
+	status       entity.AccountStatus
+	limitedUntil time.Time
+}
+
+func (a *poolAccount) usable(now time.Time) bool {
+	switch a.status {
+	case entity.AccountStatusBanned:
+		return false
+	case entity.AccountStatusLimited:
+		return !now.Before(a.limitedUntil)
+	}
+	return true
+}
+
+// accountPool routes channel operations to the account assigned to the channel. When that account is limited
+// or banned the channel is reassigned to another member account and the operation is retried there.
+type accountPool struct {
+	repo accountRepository
+
+	mu       sync.Mutex
+	accounts map[int64]*poolAccount
+
+	readyOnce sync.Once
+	ready     chan struct{}
+}
+
+var _ channelAPI = (*accountPool)(nil)
+
+func newAccountPool(repo accountRepository) *accountPool {
+	return &accountPool{
+		repo:     repo,
+		accounts: make(map[int64]*poolAccount),
+		ready:    make(chan struct{}),
+	}
+}
+
+// add makes a logged in account available for routing.
+func (p *accountPool) add(ctx context.Context, userID int64, phone string, api channelAPI) error {
+	if err := p.repo.UpsertAccount(ctx, &entity.Account{UserID: userID, Phone: phone, Status: entity.AccountStatusActive}); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.accounts[userID] = &poolAccount{userID: userID, api: api, status: entity.AccountStatusActive}
+	p.mu.Unlock()
+	p.readyOnce.Do(func() { close(p.ready) })
+	return nil
+}
+
+// Ready is closed once the first account is logged in.
+func (p *accountPool) Ready() <-chan struct{} {
+	return p.ready
+}
+
+type pinnedAccountKey struct{}
+
+// withAccount pins channel operations made with ctx to the account, bypassing routing. Updates are handled
+// this way since the access hashes they carry belong to the receiving account.
+func withAccount(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, pinnedAccountKey{}, userID)
+}
+
+func (p *accountPool) account(userID int64) *poolAccount {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.accounts[userID]
+}
+
+// do runs fn with the api and access hash of the account serving the channel.
+func (p *accountPool) do(ctx context.Context, channelID, accessHash int64, fn func(api channelAPI, accessHash int64) error) error {
+	if userID, ok := ctx.Value(pinnedAccountKey{}).(int64); ok {
+		acc := p.account(userID)
+		if acc == nil {
+			return fmt.Errorf("userbot account %d is not running", userID)
+		}
+		err := fn(acc.api, accessHash)
+		p.markFailure(ctx, acc, err)
+		return err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= p.size(); attempt++ {
+		acc, member, err := p.route(ctx, channelID)
+		if err != nil {
+			if lastErr != nil {
+				return lastErr
+			}
+			return err
+		}
+		err = fn(acc.api, member.AccessHash)
+		if !p.markFailure(ctx, acc, err) {
+			return err
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+func (p *accountPool) size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.accounts)
+}
+
+// markFailure records FLOOD_WAIT and session errors on the account and reports whether it became unusable.
+func (p *accountPool) markFailure(ctx context.Context, acc *poolAccount, err error) bool {
+	if err == nil {
+		return false
+	}
+	var (
+		status entity.AccountStatus
+		until  *time.Time
+	)
+	if d, ok := tgerr.AsFloodWait(err); ok {
+		status = entity.AccountStatusLimited
+		t := time.Now().Add(d)
+		until = &t
+	} else if tgerr.Is(err, accountBannedErrors...) {
+		status = entity.AccountStatusBanned
+	} else {
+		return false
+	}
+
+	p.mu.Lock()
+	acc.status = status
+	if until != nil {
+		acc.limitedUntil = *until
+	}
+	p.mu.Unlock()
+
+	slog.Warn("userbot account unusable", "account_id", acc.userID, "status", status, "limited_until", until, "error", err)
+	if err := p.repo.SetAccountStatus(ctx, acc.userID, status, until); err != nil {
+		slog.Error("set userbot account status", "account_id", acc.userID, "error", err)
+	}
+	return true
+}
+
+// markBanned takes an account whose client stopped on a session error out of the pool.
+func (p *accountPool) markBanned(ctx context.Context, userID int64, err error) {
+	if acc := p.account(userID); acc != nil {
+		p.markFailure(ctx, acc, err)
+	}
+}
+
+// route returns the account assigned to the channel, reassigning the channel first when that account is
+// unusable or there is none: usable members that can post come first, then the least loaded.
+func (p *accountPool) route(ctx context.Context, channelID int64) (*poolAccount, *entity.ChannelAccount, error) {
+	members, err := p.repo.ListChannelAccounts(ctx, channelID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	p.mu.Lock()
+	var (
+		candidates []*entity.ChannelAccount
+		assigned   *entity.ChannelAccount
+	)
+	for _, m := range members {
+		acc := p.accounts[m.AccountID]
+		if acc == nil || !acc.usable(now) {
+			continue
+		}
+		if m.Assigned {
+			assigned = m
+		}
+		candidates = append(candidates, m)
+	}
+	p.mu.Unlock()
+
+	if assigned != nil {
+		return p.account(assigned.AccountID), assigned, nil
+	}
+	if len(candidates) == 0 {
+		return nil, nil, fmt.Errorf("channel id=%d: %w", channelID, errNoChannelAccount)
+	}
+
+	load, err := p.repo.CountAssignedChannels(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	best := candidates[0]
+	for _, m := range candidates[1:] {
+		if m.CanPost != best.CanPost {
+			if m.CanPost {
+				best = m
+			}
+			continue
+		}
+		if load[m.AccountID] < load[best.AccountID] {
+			best = m
+		}
+	}
+	if err := p.repo.AssignChannel(ctx, channelID, best.AccountID); err != nil {
+		return nil, nil, err
+	}
+	slog.Info("channel assigned to userbot account", "channel_id", channelID, "account_id", best.AccountID)
+	best.Assigned = true
+	return p.account(best.AccountID), best, nil
+}
+
+// channelUpdateHandler records the account's membership in the updated channel and passes the update on
+// only when the account serves the channel, so a channel administered by several accounts is handled once.
+func (p *accountPool) channelUpdateHandler(userID func() int64, next func(ctx context.Context, e tg.Entities, update *tg.UpdateChannel) error) func(ctx context.Context, e tg.Entities, update *tg.UpdateChannel) error {
+	return func(ctx context.Context, e tg.Entities, update *tg.UpdateChannel) error {
+		accountID := userID()
+		if ch, ok := e.Channels[update.ChannelID]; ok && !ch.Left {
+			rights := ch.AdminRights
+			if err := p.repo.UpsertChannelAccount(ctx, &entity.ChannelAccount{
+				ChannelID:  update.ChannelID,
+				AccountID:  accountID,
+				AccessHash: ch.AccessHash,
+				CanPost:    rights.PostMessages && rights.DeleteMessages,
+			}); err != nil {
+				return err
+			}
+		} else if err := p.repo.DeleteChannelAccount(ctx, update.ChannelID, accountID); err != nil {
+			return err
+		}
+
+		acc, _, err := p.route(ctx, update.ChannelID)
+		if err != nil && !errors.Is(err, errNoChannelAccount) {
+			return err
+		}
+		if acc != nil && acc.userID != accountID {
+			slog.Debug("channel update left to assigned account", "channel_id", update.ChannelID, "account_id", accountID, "assigned_account_id", acc.userID)
+			return nil
+		}
+		return next(withAccount(ctx, accountID), e, update)
+	}
+}
+
+func (p *accountPool) SendMessage(ctx context.Context, channelID, accessHash int64, text string) (id int64, err error) {
+	err = p.do(ctx, channelID, accessHash, func(api channelAPI, accessHash int64) error {
+		id, err = api.SendMessage(ctx, channelID, accessHash, text)
+		return err
+	})
+	return id, err
+}
+
+func (p *accountPool) GetHistory(ctx context.Context, channelID, accessHash int64, offsetID, addOffset, limit int) (messages []mtproto.Message, err error) {
+	err = p.do(ctx, channelID, accessHash, func(api channelAPI, accessHash int64) error {
+		messages, err = api.GetHistory(ctx, channelID, accessHash, offsetID, addOffset, limit)
+		return err
+	})
+	return messages, err
+}
+
+func (p *accountPool) GetFullChannel(ctx context.Context, channelID, accessHash int64) (full *tg.MessagesChatFull, err error) {
+	err = p.do(ctx, channelID, accessHash, func(api channelAPI, accessHash int64) error {
+		full, err = api.GetFullChannel(ctx, channelID, accessHash)
+		return err
+	})
+	return full, err
+}
+
+func (p *accountPool) GetAdmins(ctx context.Context, channelID, accessHash int64, offset, limit int) (admins tg.ChannelsChannelParticipantsClass, err error) {
+	err = p.do(ctx, channelID, accessHash, func(api channelAPI, accessHash int64) error {
+		admins, err = api.GetAdmins(ctx, channelID, accessHash, offset, limit)
+		return err
+	})
+	return admins, err
+}
+
+func (p *accountPool) GetBroadcastStats(ctx context.Context, channelID, accessHash int64, statsDC int) (stats *tg.StatsBroadcastStats, err error) {
+	err = p.do(ctx, channelID, accessHash, func(api channelAPI, accessHash int64) error {
+		stats, err = api.GetBroadcastStats(ctx, channelID, accessHash, statsDC)
+		return err
+	})
+	return stats, err
+}
+
+func (p *accountPool) DownloadPhoto(ctx context.Context, channelID, accessHash, photoID int64) (photo []byte, err error) {
+	err = p.do(ctx, channelID, accessHash, func(api channelAPI, accessHash int64) error {
+		photo, err = api.DownloadPhoto(ctx, channelID, accessHash, photoID)
+		return err
+	})
+	return photo, err
+}
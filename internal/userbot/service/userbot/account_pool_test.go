@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"ads-mrkt/internal/userbot/domain/entity"
+	"ads-mrkt/internal/userbot/mtproto/mtprototest"
+
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+const (
+	testAccountA = int64(101)
+	testAccountB = int64(102)
+)
+
+// accountRepo is an in-memory accountRepository.
+type accountRepo struct {
+	mu       sync.Mutex
+	accounts map[int64]*entity.Account
+	members  map[int64]map[int64]*entity.ChannelAccount
+}
+
+func newAccountRepo() *accountRepo {
+	return &accountRepo{
+		accounts: make(map[int64]*entity.Account),
+		members:  make(map[int64]map[int64]*entity.ChannelAccount),
+	}
+}
+
+func (r *accountRepo) UpsertAccount(ctx context.Context, account *entity.Account) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a := *account
+	r.accounts[a.UserID] = &a
+	return nil
+}
+
+func (r *accountRepo) SetAccountStatus(ctx context.Context, userID int64, status entity.AccountStatus, limitedUntil *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.accounts[userID].Status = status
+	r.accounts[userID].LimitedUntil = limitedUntil
+	return nil
+}
+
+func (r *accountRepo) UpsertChannelAccount(ctx context.Context, m *entity.ChannelAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.members[m.ChannelID] == nil {
+		r.members[m.ChannelID] = make(map[int64]*entity.ChannelAccount)
+	}
+	c := *m
+	if prev := r.members[m.ChannelID][m.AccountID]; prev != nil {
+		c.Assigned = prev.Assigned
+	}
+	r.members[m.ChannelID][m.AccountID] = &c
+	return nil
+}
+
+func (r *accountRepo) DeleteChannelAccount(ctx context.Context, channelID, accountID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.members[channelID], accountID)
+	return nil
+}
+
+func (r *accountRepo) ListChannelAccounts(ctx context.Context, channelID int64) ([]*entity.ChannelAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entity.ChannelAccount
+	for _, id := range []int64{testAccountA, testAccountB} {
+		if m := r.members[channelID][id]; m != nil {
+			c := *m
+			out = append(out, &c)
+		}
+	}
+	return out, nil
+}
+
+func (r *accountRepo) AssignChannel(ctx context.Context, channelID, accountID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.members[channelID][accountID] == nil {
+		return errors.New("not a member")
+	}
+	for id, m := range r.members[channelID] {
+		m.Assigned = id == accountID
+	}
+	return nil
+}
+
+func (r *accountRepo) CountAssignedChannels(ctx context.Context) (map[int64]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[int64]int)
+	for _, members := range r.members {
+		for _, m := range members {
+			if m.Assigned {
+				out[m.AccountID]++
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *accountRepo) assigned(channelID int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, m := range r.members[channelID] {
+		if m.Assigned {
+			return id
+		}
+	}
+	return 0
+}
+
+// newTestPool returns a pool of two accounts that both administer the test channel, each with its own access hash.
+func newTestPool(t *testing.T) (*accountPool, *accountRepo, *mtprototest.Channels, *mtprototest.Channels) {
+	t.Helper()
+	ctx := context.Background()
+	repo := newAccountRepo()
+	pool := newAccountPool(repo)
+	apis := make(map[int64]*mtprototest.Channels)
+	for _, id := range []int64{testAccountA, testAccountB} {
+		api := mtprototest.New()
+		api.AddChannel(mtprototest.Channel{
+			ID:          testChannelID,
+			AccessHash:  id * 10,
+			AdminRights: tg.ChatAdminRights{PostMessages: true, DeleteMessages: true},
+		})
+		apis[id] = api
+		if err := pool.add(ctx, id, "", api); err != nil {
+			t.Fatalf("add account: %v", err)
+		}
+		_ = repo.UpsertChannelAccount(ctx, &entity.ChannelAccount{ChannelID: testChannelID, AccountID: id, AccessHash: id * 10, CanPost: true})
+	}
+	return pool, repo, apis[testAccountA], apis[testAccountB]
+}
+
+func TestAccountPoolFailsOverOnFloodWait(t *testing.T) {
+	pool, repo, a, b := newTestPool(t)
+	ctx := context.Background()
+	if err := repo.AssignChannel(ctx, testChannelID, testAccountA); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pool.SendMessage(ctx, testChannelID, 0, "first"); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if len(a.Messages(testChannelID)) != 1 {
+		t.Fatal("message not sent by the assigned account")
+	}
+
+	a.FailNext("SendMessage", tgerr.New(420, "FLOOD_WAIT_60"))
+	if _, err := pool.SendMessage(ctx, testChannelID, 0, "second"); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if msgs := b.Messages(testChannelID); len(msgs) != 1 || msgs[0].Text != "second" {
+		t.Fatalf("account B messages = %+v", msgs)
+	}
+	if got := repo.assigned(testChannelID); got != testAccountB {
+		t.Fatalf("assigned account = %d, want %d", got, testAccountB)
+	}
+	if st := repo.accounts[testAccountA]; st.Status != entity.AccountStatusLimited || st.LimitedUntil == nil {
+		t.Fatalf("account A = %+v, want limited", st)
+	}
+}
+
+func TestAccountPoolBannedAccountLosesChannels(t *testing.T) {
+	pool, repo, a, b := newTestPool(t)
+	ctx := context.Background()
+	if err := repo.AssignChannel(ctx, testChannelID, testAccountA); err != nil {
+		t.Fatal(err)
+	}
+
+	a.FailNext("GetHistory", tgerr.New(401, "AUTH_KEY_UNREGISTERED"))
+	if _, err := pool.GetHistory(ctx, testChannelID, 0, 0, 0, 10); err != nil {
+		t.Fatalf("GetHistory: %v", err)
+	}
+	if b.Calls("GetHistory") != 1 || repo.assigned(testChannelID) != testAccountB {
+		t.Fatalf("history not served by account B, assigned %d", repo.assigned(testChannelID))
+	}
+
+	// With the only other member limited too, the error is returned as is.
+	b.FailNext("GetHistory", tgerr.New(420, "FLOOD_WAIT_30"))
+	_, err := pool.GetHistory(ctx, testChannelID, 0, 0, 0, 10)
+	if d, ok := tgerr.AsFloodWait(err); !ok || d != 30*time.Second {
+		t.Fatalf("err = %v, want FLOOD_WAIT_30", err)
+	}
+	if a.Calls("GetHistory") != 1 {
+		t.Fatal("banned account was used again")
+	}
+}
+
+func TestAccountPoolAssignsCapableLeastLoadedAccount(t *testing.T) {
+	pool, repo, _, _ := newTestPool(t)
+	ctx := context.Background()
+	const otherChannelID = int64(778)
+	_ = repo.UpsertChannelAccount(ctx, &entity.ChannelAccount{ChannelID: otherChannelID, AccountID: testAccountA, CanPost: true})
+	_ = repo.AssignChannel(ctx, otherChannelID, testAccountA)
+
+	acc, _, err := pool.route(ctx, testChannelID)
+	if err != nil {
+		t.Fatalf("route: %v", err)
+	}
+	if acc.userID != testAccountB {
+		t.Fatalf("routed to %d, want the less loaded %d", acc.userID, testAccountB)
+	}
+
+	const thirdChannelID = int64(779)
+	_ = repo.UpsertChannelAccount(ctx, &entity.ChannelAccount{ChannelID: thirdChannelID, AccountID: testAccountA, CanPost: true})
+	_ = repo.UpsertChannelAccount(ctx, &entity.ChannelAccount{ChannelID: thirdChannelID, AccountID: testAccountB})
+	if acc, _, err = pool.route(ctx, thirdChannelID); err != nil || acc.userID != testAccountA {
+		t.Fatalf("routed to %+v (%v), want %d which can post", acc, err, testAccountA)
+	}
+}
+
+func TestAccountPoolChannelUpdateHandledByAssignedAccount(t *testing.T) {
+	pool, repo, _, _ := newTestPool(t)
+	ctx := context.Background()
+	if err := repo.AssignChannel(ctx, testChannelID, testAccountA); err != nil {
+		t.Fatal(err)
+	}
+
+	var handledBy []int64
+	handler := func(accountID int64) func(ctx context.Context, e tg.Entities, update *tg.UpdateChannel) error {
+		return pool.channelUpdateHandler(func() int64 { return accountID }, func(ctx context.Context, e tg.Entities, update *tg.UpdateChannel) error {
+			handledBy = append(handledBy, ctx.Value(pinnedAccountKey{}).(int64))
+			return nil
+		})
+	}
+	update := &tg.UpdateChannel{ChannelID: testChannelID}
+	present := tg.Entities{Channels: map[int64]*tg.Channel{testChannelID: {ID: testChannelID, AccessHash: 1020}}}
+
+	if err := handler(testAccountB)(ctx, present, update); err != nil {
+		t.Fatal(err)
+	}
+	if len(handledBy) != 0 {
+		t.Fatalf("update handled by %v, want skipped for unassigned account", handledBy)
+	}
+	if m := repo.members[testChannelID][testAccountB]; m.AccessHash != 1020 || m.CanPost {
+		t.Fatalf("account B membership = %+v", m)
+	}
+
+	// Account A is removed from the channel: B takes it over.
+	if err := handler(testAccountA)(ctx, tg.Entities{}, update); err != nil {
+		t.Fatal(err)
+	}
+	if repo.assigned(testChannelID) != testAccountB || len(handledBy) != 0 {
+		t.Fatalf("assigned %d, handled by %v", repo.assigned(testChannelID), handledBy)
+	}
+
+	// The last member is removed: the update is handled so the channel gets disconnected.
+	if err := handler(testAccountB)(ctx, tg.Entities{}, update); err != nil {
+		t.Fatal(err)
+	}
+	if len(handledBy) != 1 || handledBy[0] != testAccountB {
+		t.Fatalf("handled by %v, want [%d]", handledBy, testAccountB)
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ads-mrkt/internal/market/domain"
+	marketentity "ads-mrkt/internal/market/domain/entity"
+
+	"github.com/gotd/td/tgerr"
+)
+
+// Errors Telegram returns for a channel the userbot is no longer a member of.
+var channelRemovedErrors = []string{"CHANNEL_PRIVATE", "CHANNEL_INVALID", "CHAT_FORBIDDEN"}
+
+// RunChannelConnectionChecker periodically verifies channels with active listings or in-flight deals, since
+// Telegram does not always deliver an update when the userbot is removed or its rights change.
+func (s *service) RunChannelConnectionChecker(ctx context.Context) {
+	logger := slog.With("component", "channel_connection_checker")
+	if s.channelCheckInterval <= 0 {
+		logger.Info("channel connection checker disabled")
+		return
+	}
+	ticker := time.NewTicker(s.channelCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkChannelConnections(ctx, logger)
+		}
+	}
+}
+
+func (s *service) checkChannelConnections(ctx context.Context, logger *slog.Logger) {
+	channels, err := s.channelRepo.ListChannelsInUse(ctx)
+	if err != nil {
+		logger.Error("list channels in use", "error", err)
+		return
+	}
+	for _, ch := range channels {
+		if err := s.verifyChannelConnection(ctx, ch); err != nil {
+			logger.Error("verify channel connection", "channel_id", ch.ID, "error", err)
+		}
+	}
+}
+
+// verifyChannelConnection re-reads a known channel from Telegram and applies what changed: removal of the
+// userbot, lost rights and admin changes.
+func (s *service) verifyChannelConnection(ctx context.Context, known *marketentity.Channel) error {
+	fullChannel, err := s.channelAPI.GetFullChannel(ctx, known.ID, known.AccessHash)
+	if tgerr.Is(err, channelRemovedErrors...) {
+		slog.Warn("userbot removed from channel", "channel_id", known.ID, "error", err)
+		removed := *known
+		removed.AdminRights = marketentity.AdminRights{}
+		if err := s.channelRepo.UpsertChannel(ctx, &removed); err != nil {
+			return fmt.Errorf("failed to upsert channel id=%d: %w", known.ID, err)
+		}
+		return s.disconnectChannel(ctx, known.ID, "userbot removed from the channel")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get full channel: %w", err)
+	}
+
+	channel, _ := mapChannel(fullChannel)
+	if channel == nil {
+		return fmt.Errorf("failed to map channel id=%d", known.ID)
+	}
+	channel.AccessHash = known.AccessHash
+	channel.Photo = known.Photo
+	if err := s.channelRepo.UpsertChannel(ctx, channel); err != nil {
+		return fmt.Errorf("failed to upsert channel id=%d: %w", known.ID, err)
+	}
+	return s.applyChannelConnection(ctx, channel)
+}
+
+// applyChannelConnection reacts to the channel's current state: without posting rights its listings and deals
+// are stopped; its admins are synced and listings of users who are no longer admins are handed over.
+func (s *service) applyChannelConnection(ctx context.Context, channel *marketentity.Channel) error {
+	if !domain.ChannelConnected(channel.AdminRights) {
+		slog.Warn("userbot lacks posting rights in channel", "channel_id", channel.ID, "rights", channel.AdminRights)
+		if err := s.disconnectChannel(ctx, channel.ID, "userbot lost posting rights in the channel"); err != nil {
+			return err
+		}
+	}
+
+	admins, err := s.syncChannelAdmins(ctx, channel.ID, channel.AccessHash)
+	if err != nil {
+		return err
+	}
+	if admins == nil {
+		return nil
+	}
+	return s.reconcileListingOwners(ctx, channel.ID, admins)
+}
+
+// disconnectChannel deactivates the channel's listings, sends its funded deals whose ad has not run to refund and
+// expires its deals not funded yet; reason is recorded in the history of the stopped deals.
+func (s *service) disconnectChannel(ctx context.Context, channelID int64, reason string) error {
+	deactivated, err := s.listingRepo.DeactivateChannelListings(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("deactivate channel listings: %w", err)
+	}
+	refunded, expired, err := s.dealRepo.StopChannelDeals(ctx, channelID, marketentity.WorkerDealTransition(domain.DealWorkerChannelConnection, reason))
+	if err != nil {
+		return fmt.Errorf("stop channel deals: %w", err)
+	}
+	if deactivated > 0 || len(refunded) > 0 || len(expired) > 0 {
+		slog.Info("channel disconnected", "channel_id", channelID, "deactivated_listings", deactivated, "refunded_deals", refunded, "expired_deals", expired)
+	}
+	return nil
+}
+
+// reconcileListingOwners hands active listings of users who are no longer channel admins to the channel owner,
+// or deactivates them when the owner is unknown.
+func (s *service) reconcileListingOwners(ctx context.Context, channelID int64, admins []*marketentity.ChannelAdmin) error {
+	listings, err := s.listingRepo.ListActiveListingsByChannelID(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("list channel listings: %w", err)
+	}
+	isAdmin := make(map[int64]bool, len(admins))
+	var owner *marketentity.ChannelAdmin
+	for _, a := range admins {
+		isAdmin[a.UserID] = true
+		if a.Role == marketentity.ChannelAdminRoleOwner {
+			owner = a
+		}
+	}
+
+	for _, l := range listings {
+		if isAdmin[l.UserID] {
+			continue
+		}
+		if owner != nil {
+			ok, err := s.listingRepo.ReassignListing(ctx, l.ID, l.UserID, owner.UserID)
+			if err != nil {
+				return fmt.Errorf("reassign listing id=%d: %w", l.ID, err)
+			}
+			if ok {
+				slog.Info("listing reassigned to channel owner", "listing_id", l.ID, "channel_id", channelID, "from_user_id", l.UserID, "to_user_id", owner.UserID)
+			}
+			continue
+		}
+		if err := s.listingRepo.DeactivateListing(ctx, l.ID); err != nil {
+			return fmt.Errorf("deactivate listing id=%d: %w", l.ID, err)
+		}
+		slog.Info("listing deactivated, owner is no longer channel admin", "listing_id", l.ID, "channel_id", channelID, "user_id", l.UserID)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	marketentity "ads-mrkt/internal/market/domain/entity"
+	"ads-mrkt/internal/userbot/mtproto/mtprototest"
+
+	"github.com/gotd/td/tg"
+)
+
+func TestChannelConnectionLostRightsStopsListingsAndDeals(t *testing.T) {
+	s, r, channels := newTestService(t)
+	ctx := context.Background()
+	r.channels[testChannelID] = &marketentity.Channel{ID: testChannelID, AccessHash: testAccessHash, AdminRights: marketentity.AdminRights{PostMessages: true, DeleteMessages: true}}
+	r.addDeal(1, "Buy our coin")
+	r.addDeal(2, "Approved")
+	r.addDeal(3, "Waiting for deposit")
+	r.deals[2].Status = marketentity.DealStatusApproved
+	r.deals[3].Status = marketentity.DealStatusWaitingEscrowDeposit
+	channels.UpdateChannel(testChannelID, func(ch *mtprototest.Channel) {
+		ch.AdminRights = tg.ChatAdminRights{DeleteMessages: true}
+	})
+
+	s.checkChannelConnections(ctx, slog.Default())
+
+	if r.channels[testChannelID].AdminRights.PostMessages {
+		t.Fatal("stored rights not updated")
+	}
+	if got := r.listings[testListingID].Status; got != marketentity.ListingStatusInactive {
+		t.Fatalf("listing status = %s, want inactive", got)
+	}
+	if got := r.deals[1].Status; got != marketentity.DealStatusWaitingEscrowRefund {
+		t.Fatalf("deal status = %s, want waiting_escrow_refund", got)
+	}
+	// Deals not funded yet can no longer be funded.
+	for _, id := range []int64{2, 3} {
+		if got := r.deals[id].Status; got != marketentity.DealStatusExpired {
+			t.Fatalf("deal %d status = %s, want expired", id, got)
+		}
+	}
+}
+
+func TestChannelConnectionUserbotRemoved(t *testing.T) {
+	s, r, channels := newTestService(t)
+	ctx := context.Background()
+	r.channels[testChannelID] = &marketentity.Channel{ID: testChannelID, AccessHash: testAccessHash, Title: "Test channel", AdminRights: marketentity.AdminRights{PostMessages: true, DeleteMessages: true}}
+	r.addDeal(1, "Buy our coin")
+	channels.UpdateChannel(testChannelID, func(ch *mtprototest.Channel) { ch.Removed = true })
+
+	if err := s.verifyChannelConnection(ctx, r.channels[testChannelID]); err != nil {
+		t.Fatalf("verifyChannelConnection: %v", err)
+	}
+
+	if ch := r.channels[testChannelID]; ch.AdminRights.PostMessages || ch.Title != "Test channel" {
+		t.Fatalf("channel = %+v", ch)
+	}
+	if got := r.listings[testListingID].Status; got != marketentity.ListingStatusInactive {
+		t.Fatalf("listing status = %s, want inactive", got)
+	}
+	if got := r.deals[1].Status; got != marketentity.DealStatusWaitingEscrowRefund {
+		t.Fatalf("deal status = %s, want waiting_escrow_refund", got)
+	}
+}
+
+func TestChannelConnectionOwnerChangeReassignsListing(t *testing.T) {
+	s, r, channels := newTestService(t)
+	ctx := context.Background()
+	r.channels[testChannelID] = &marketentity.Channel{ID: testChannelID, AccessHash: testAccessHash}
+	channels.UpdateChannel(testChannelID, func(ch *mtprototest.Channel) {
+		ch.Admins = []mtprototest.Admin{{UserID: 3, Owner: true}, {UserID: 2}}
+	})
+
+	if err := s.verifyChannelConnection(ctx, r.channels[testChannelID]); err != nil {
+		t.Fatalf("verifyChannelConnection: %v", err)
+	}
+
+	l := r.listings[testListingID]
+	if l.UserID != 3 || l.Status != marketentity.ListingStatusActive {
+		t.Fatalf("listing = %+v, want active and owned by 3", l)
+	}
+	if got := r.admins[testChannelID]; got[1] != "" || got[3] != "owner" {
+		t.Fatalf("admins = %v", got)
+	}
+}
+
+func TestSyncChannelAdminsPaginates(t *testing.T) {
+	s, r, channels := newTestService(t)
+	ctx := context.Background()
+	admins := []mtprototest.Admin{{UserID: 1, Owner: true}}
+	for id := int64(2); id <= adminsPageLimit+50; id++ {
+		admins = append(admins, mtprototest.Admin{UserID: id})
+	}
+	channels.UpdateChannel(testChannelID, func(ch *mtprototest.Channel) { ch.Admins = admins })
+
+	got, err := s.syncChannelAdmins(ctx, testChannelID, testAccessHash)
+	if err != nil {
+		t.Fatalf("syncChannelAdmins: %v", err)
+	}
+	if len(got) != len(admins) || len(r.admins[testChannelID]) != len(admins) {
+		t.Fatalf("synced %d admins, stored %d, want %d", len(got), len(r.admins[testChannelID]), len(admins))
+	}
+	if calls := channels.Calls("GetAdmins"); calls != 2 {
+		t.Fatalf("GetAdmins calls = %d, want 2", calls)
+	}
+}
@@ -1,13 +1,11 @@
 package service
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"fmt"
 	"log/slog"
 
-	"github.com/gotd/td/telegram/downloader"
 	"github.com/gotd/td/tg"
 )
 
@@ -31,10 +29,7 @@ func (s *service) updateChannelPhotoFromTelegram(ctx context.Context, channelID,
 
 // getChannelPhotoBytes returns the channel profile picture bytes (full size) or nil if not set / on error.
 func (s *service) getChannelPhotoBytes(ctx context.Context, channelID, accessHash int64) ([]byte, error) {
-	fullChannel, err := s.telegramClient.API().ChannelsGetFullChannel(ctx, &tg.InputChannel{
-		ChannelID:  channelID,
-		AccessHash: accessHash,
-	})
+	fullChannel, err := s.channelAPI.GetFullChannel(ctx, channelID, accessHash)
 	if err != nil {
 		return nil, fmt.Errorf("get full channel: %w", err)
 	}
@@ -53,18 +48,5 @@ func (s *service) getChannelPhotoBytes(ctx context.Context, channelID, accessHas
 	if !ok {
 		return nil, nil
 	}
-
-	location := &tg.InputPeerPhotoFileLocation{
-		Big:     true,
-		Peer:    &tg.InputPeerChannel{ChannelID: channelID, AccessHash: accessHash},
-		PhotoID: chatPhoto.PhotoID,
-	}
-
-	var buf bytes.Buffer
-	dl := downloader.NewDownloader()
-	_, err = dl.Download(s.telegramClient.API(), location).Stream(ctx, &buf)
-	if err != nil {
-		return nil, fmt.Errorf("download photo: %w", err)
-	}
-	return buf.Bytes(), nil
+	return s.channelAPI.DownloadPhoto(ctx, channelID, accessHash, chatPhoto.PhotoID)
 }
@@ -3,13 +3,11 @@ package service
 import (
 	"context"
 	"log/slog"
-	"math/rand"
 	"time"
 
 	"ads-mrkt/internal/market/domain"
 	marketentity "ads-mrkt/internal/market/domain/entity"
-
-	"github.com/gotd/td/tg"
+	"ads-mrkt/internal/userbot/mtproto"
 )
 
 const (
@@ -79,7 +77,8 @@ func (s *service) runDealPostSenderOnce(ctx context.Context, logger *slog.Logger
 					NextCheck:   nextCheck,
 					UntilTs:     untilTs,
 				}
-				if err := s.dealPostMessageRepo.CreateDealPostMessageAndSetDealInProgress(ctx, m); err != nil {
+				t := marketentity.WorkerDealTransition(domain.DealWorkerUserbotPost, "ad post found in the channel after an expired lock")
+				if err := s.startDealPost(ctx, deal, m, t); err != nil {
 					logger.Error("recover create deal_post_message", "deal_id", deal.ID, "error", err)
 					_ = s.dealActionLockRepo.ReleaseDealActionLock(ctx, lastLock.ID, marketentity.DealActionLockStatusFailed)
 					continue
@@ -117,7 +116,8 @@ func (s *service) runDealPostSenderOnce(ctx context.Context, logger *slog.Logger
 			NextCheck:   nextCheck,
 			UntilTs:     untilTs,
 		}
-		if err := s.dealPostMessageRepo.CreateDealPostMessageAndSetDealInProgress(ctx, m); err != nil {
+		t := marketentity.WorkerDealTransition(domain.DealWorkerUserbotPost, "ad posted to the channel")
+		if err := s.startDealPost(ctx, deal, m, t); err != nil {
 			logger.Error("create deal_post_message", "deal_id", deal.ID, "error", err)
 			releaseLock(marketentity.DealActionLockStatusFailed)
 			continue
@@ -128,29 +128,25 @@ func (s *service) runDealPostSenderOnce(ctx context.Context, logger *slog.Logger
 	}
 }
 
+// startDealPost saves the post of the deal and moves the deal to in_progress, recording the post's message ID
+// with the transition. A post already saved for the deal is kept as is.
+func (s *service) startDealPost(ctx context.Context, deal *marketentity.Deal, m *marketentity.DealPostMessage, t marketentity.DealTransition) error {
+	return s.transactor.InTx(ctx, "startDealPost", func(ctx context.Context) error {
+		if err := s.dealPostMessageRepo.CreateDealPostMessage(ctx, m); err != nil {
+			return err
+		}
+		if m.ID == 0 {
+			return nil
+		}
+		t.MessageID = &m.MessageID
+		return s.dealRepo.TransitionDeal(ctx, deal, marketentity.DealStatusInProgress, t)
+	})
+}
+
 const lastMessagesRecoveryLimit = 20
 
 func (s *service) sendChannelMessage(ctx context.Context, channelID int64, accessHash int64, text string) (int64, error) {
-	peer := &tg.InputPeerChannel{ChannelID: channelID, AccessHash: accessHash}
-	req := &tg.MessagesSendMessageRequest{
-		Peer:     peer,
-		Message:  text,
-		RandomID: rand.Int63(),
-	}
-	result, err := s.telegramClient.API().MessagesSendMessage(ctx, req)
-	if err != nil {
-		return 0, err
-	}
-	upd, ok := result.(*tg.Updates)
-	if !ok {
-		return 0, nil
-	}
-	for _, u := range upd.Updates {
-		if msg, ok := u.(*tg.UpdateMessageID); ok {
-			return int64(msg.ID), nil
-		}
-	}
-	return 0, nil
+	return s.channelAPI.SendMessage(ctx, channelID, accessHash, text)
 }
 
 func (s *service) RunDealPostCheckerWorker(ctx context.Context) {
@@ -200,49 +196,11 @@ func (s *service) runDealPostCheckerOnce(ctx context.Context, logger *slog.Logge
 	}
 }
 
-type channelMessage struct {
-	ID   int64
-	Text string
-}
-
 // See https://core.telegram.org/api/offsets: offset = offsetFromID(offsetID) + addOffset; results are reverse chronological (newest first).
 // For "most recent N": offsetID=0, addOffset=0, limit=N.
 // For "around message ID": offsetID=messageID, addOffset=-halfWindow, limit=windowSize.
-func (s *service) getChannelHistory(ctx context.Context, channelID int64, accessHash int64, offsetID int, addOffset int, limit int) ([]channelMessage, error) {
-	result, err := s.telegramClient.API().MessagesGetHistory(ctx, &tg.MessagesGetHistoryRequest{
-		Peer: &tg.InputPeerChannel{
-			ChannelID:  channelID,
-			AccessHash: accessHash,
-		},
-		OffsetID:   offsetID,
-		OffsetDate: 0,
-		AddOffset:  addOffset,
-		Limit:      limit,
-		MaxID:      0,
-		MinID:      0,
-		Hash:       0,
-	})
-	if err != nil {
-		return nil, err
-	}
-	var messages []tg.MessageClass
-	switch r := result.(type) {
-	case *tg.MessagesMessages:
-		messages = r.Messages
-	case *tg.MessagesChannelMessages:
-		messages = r.Messages
-	default:
-		return nil, nil
-	}
-	out := make([]channelMessage, 0, len(messages))
-	for _, msg := range messages {
-		m, ok := msg.(*tg.Message)
-		if !ok {
-			continue
-		}
-		out = append(out, channelMessage{ID: int64(m.ID), Text: m.Message})
-	}
-	return out, nil
+func (s *service) getChannelHistory(ctx context.Context, channelID int64, accessHash int64, offsetID int, addOffset int, limit int) ([]mtproto.Message, error) {
+	return s.channelAPI.GetHistory(ctx, channelID, accessHash, offsetID, addOffset, limit)
 }
 
 func (s *service) getChannelMessageExists(ctx context.Context, channelID int64, accessHash int64, messageID int64) (bool, error) {
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ads-mrkt/internal/userbot/mtproto"
+
+	"github.com/gotd/td/tg"
+)
+
+const (
+	estimatedStatsPeriod       = 30 * 24 * time.Hour
+	estimatedStatsHistoryLimit = 100
+	// Views of younger posts are still growing, so they are left out of averages while older posts exist.
+	estimatedStatsMinPostAge = 24 * time.Hour
+)
+
+// statsEstimate carries what estimated stats add to the broadcast stats shape.
+type statsEstimate struct {
+	postsPerDay float64
+}
+
+// UpdateChannelEstimatedStats approximates broadcast stats for channels Telegram gives no stats for (small
+// channels, no stats right) from the participant count and recent posts, and stores them marked as estimated.
+func (s *service) UpdateChannelEstimatedStats(ctx context.Context, channelID int64, accessHash int64) error {
+	full, err := s.channelAPI.GetFullChannel(ctx, channelID, accessHash)
+	if err != nil {
+		return fmt.Errorf("failed to get full channel: %w", err)
+	}
+	channelFull, ok := full.GetFullChat().(*tg.ChannelFull)
+	if !ok {
+		return fmt.Errorf("unexpected full chat %T", full.GetFullChat())
+	}
+	followers, _ := channelFull.GetParticipantsCount()
+
+	messages, err := s.channelAPI.GetHistory(ctx, channelID, accessHash, 0, 0, estimatedStatsHistoryLimit)
+	if err != nil {
+		return fmt.Errorf("failed to get channel history: %w", err)
+	}
+
+	now := time.Now()
+	stats, estimate := estimateBroadcastStats(followers, messages, len(messages) < estimatedStatsHistoryLimit, now)
+	slog.Info("estimated channel stats", "channel_id", channelID, "followers", followers, "posts", len(stats.RecentPostsInteractions))
+	return s.saveChannelStats(ctx, channelID, stats, now, estimate)
+}
+
+// estimateBroadcastStats builds broadcast stats for the last estimatedStatsPeriod from posts (newest first):
+// per-post averages over the period and the period before it, and posting frequency. complete tells that
+// messages is the whole channel history.
+func estimateBroadcastStats(followers int, messages []mtproto.Message, complete bool, now time.Time) (*tg.StatsBroadcastStats, *statsEstimate) {
+	periodStart := now.Add(-estimatedStatsPeriod)
+	var current, mature, previous []mtproto.Message
+	for _, m := range messages {
+		switch {
+		case !m.Date.Before(periodStart):
+			current = append(current, m)
+			if now.Sub(m.Date) >= estimatedStatsMinPostAge {
+				mature = append(mature, m)
+			}
+		case !m.Date.Before(periodStart.Add(-estimatedStatsPeriod)):
+			previous = append(previous, m)
+		}
+	}
+	if len(mature) > 0 {
+		current = mature
+	}
+
+	stats := &tg.StatsBroadcastStats{
+		Period:    tg.StatsDateRangeDays{MinDate: int(periodStart.Unix()), MaxDate: int(now.Unix())},
+		Followers: tg.StatsAbsValueAndPrev{Current: float64(followers), Previous: float64(followers)},
+	}
+	stats.ViewsPerPost.Current, stats.SharesPerPost.Current, stats.ReactionsPerPost.Current = postAverages(current)
+	stats.ViewsPerPost.Previous, stats.SharesPerPost.Previous, stats.ReactionsPerPost.Previous = postAverages(previous)
+	for _, m := range current {
+		stats.RecentPostsInteractions = append(stats.RecentPostsInteractions, &tg.PostInteractionCountersMessage{
+			MsgID:     int(m.ID),
+			Views:     m.Views,
+			Forwards:  m.Forwards,
+			Reactions: m.Reactions,
+		})
+	}
+
+	// When the history page ends inside the period, count posts over the span it covers.
+	var posted int
+	span := estimatedStatsPeriod
+	for _, m := range messages {
+		if !m.Date.Before(periodStart) {
+			posted++
+		}
+	}
+	if !complete && len(messages) > 0 {
+		if oldest := messages[len(messages)-1].Date; oldest.After(periodStart) {
+			span = now.Sub(oldest)
+		}
+	}
+	estimate := &statsEstimate{}
+	if days := span.Hours() / 24; days > 0 {
+		estimate.postsPerDay = float64(posted) / days
+	}
+	return stats, estimate
+}
+
+func postAverages(posts []mtproto.Message) (views, shares, reactions float64) {
+	if len(posts) == 0 {
+		return 0, 0, 0
+	}
+	for _, m := range posts {
+		views += float64(m.Views)
+		shares += float64(m.Forwards)
+		reactions += float64(m.Reactions)
+	}
+	n := float64(len(posts))
+	return views / n, shares / n, reactions / n
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ads-mrkt/internal/userbot/mtproto"
+	"ads-mrkt/internal/userbot/mtproto/mtprototest"
+
+	"github.com/gotd/td/tg"
+)
+
+func TestHandleChannelUpdateEstimatesStatsWithoutStatsAccess(t *testing.T) {
+	s, r, channels := newTestService(t)
+	ctx := context.Background()
+	channels.UpdateChannel(testChannelID, func(ch *mtprototest.Channel) {
+		ch.CanViewStats = false
+		ch.Participants = 200
+	})
+	now := time.Now()
+	channels.PostMessage(testChannelID, mtproto.Message{Text: "old", Date: now.Add(-45 * 24 * time.Hour), Views: 50})
+	channels.PostMessage(testChannelID, mtproto.Message{Text: "a", Date: now.Add(-10 * 24 * time.Hour), Views: 100, Forwards: 4, Reactions: 10})
+	channels.PostMessage(testChannelID, mtproto.Message{Text: "b", Date: now.Add(-5 * 24 * time.Hour), Views: 60, Forwards: 2})
+	channels.PostMessage(testChannelID, mtproto.Message{Text: "fresh", Date: now.Add(-time.Hour), Views: 5})
+
+	entities := tg.Entities{Channels: map[int64]*tg.Channel{testChannelID: {ID: testChannelID, AccessHash: testAccessHash, Title: "Test channel"}}}
+	if err := s.handleChannelUpdate(ctx, entities, &tg.UpdateChannel{ChannelID: testChannelID}); err != nil {
+		t.Fatalf("handleChannelUpdate: %v", err)
+	}
+	if n := channels.Calls("GetBroadcastStats"); n != 0 {
+		t.Fatalf("GetBroadcastStats calls = %d, want 0", n)
+	}
+
+	if len(r.snapshots) != 1 || !r.snapshots[0].Estimated || r.snapshots[0].Followers != 200 || r.snapshots[0].ViewsPerPost != 80 {
+		t.Fatalf("stats snapshots = %+v", r.snapshots)
+	}
+	m := r.statsModels[testChannelID]
+	if m == nil || !m.Estimated {
+		t.Fatalf("stats model = %+v", m)
+	}
+	// The fresh post is left out of averages; the old one only counts for the previous period.
+	if m.ViewsPerPost.Current != 80 || m.ViewsPerPost.Previous != 50 || m.SharesPerPost.Current != 3 || m.ReactionsPerPost.Current != 5 {
+		t.Fatalf("per post = views %+v shares %+v reactions %+v", m.ViewsPerPost, m.SharesPerPost, m.ReactionsPerPost)
+	}
+	if m.PostsPerDay != 0.1 {
+		t.Fatalf("posts per day = %v, want 0.1", m.PostsPerDay)
+	}
+	if len(m.TopPosts) != 2 || m.TopPosts[0].Views != 100 {
+		t.Fatalf("top posts = %+v", m.TopPosts)
+	}
+}
@@ -13,15 +13,20 @@ import (
 func (s *service) handleChannelUpdate(ctx context.Context, e tg.Entities, update *tg.UpdateChannel) error {
 	channelEnt, ok := e.Channels[update.ChannelID]
 	if !ok || channelEnt == nil {
-		slog.Info("channel update skipped: channel not in entities", "channel_id", update.ChannelID)
-		return nil
+		// The userbot was removed from the channel or the channel became private; re-check a known channel.
+		known, err := s.channelRepo.GetChannelByID(ctx, update.ChannelID)
+		if err != nil {
+			return fmt.Errorf("failed to get channel: %w", err)
+		}
+		if known == nil {
+			slog.Info("channel update skipped: channel not in entities", "channel_id", update.ChannelID)
+			return nil
+		}
+		return s.verifyChannelConnection(ctx, known)
 	}
 	slog.Info("channel update received", "channel_id", update.ChannelID, "title", channelEnt.Title)
 
-	fullChannel, err := s.telegramClient.API().ChannelsGetFullChannel(ctx, &tg.InputChannel{
-		ChannelID:  update.ChannelID,
-		AccessHash: channelEnt.AccessHash,
-	})
+	fullChannel, err := s.channelAPI.GetFullChannel(ctx, update.ChannelID, channelEnt.AccessHash)
 	if err != nil {
 		return fmt.Errorf("failed to get full channel: %w", err)
 	}
@@ -43,9 +48,15 @@ func (s *service) handleChannelUpdate(ctx context.Context, e tg.Entities, update
 			slog.Error("failed to update channel stats", "channel_id", update.ChannelID, "error", err)
 			return fmt.Errorf("failed to update channel stats: %w", err)
 		}
+	} else {
+		slog.Info("estimating channel stats", "channel_id", update.ChannelID)
+		if err = s.UpdateChannelEstimatedStats(ctx, update.ChannelID, channelEnt.AccessHash); err != nil {
+			slog.Error("failed to estimate channel stats", "channel_id", update.ChannelID, "error", err)
+			return fmt.Errorf("failed to estimate channel stats: %w", err)
+		}
 	}
 
-	if err := s.syncChannelAdmins(ctx, update.ChannelID, channelEnt.AccessHash); err != nil {
+	if err := s.applyChannelConnection(ctx, channel); err != nil {
 		return err
 	}
 
@@ -53,50 +64,43 @@ func (s *service) handleChannelUpdate(ctx context.Context, e tg.Entities, update
 	return nil
 }
 
-// syncChannelAdmins fetches current admins from Telegram and replaces channel_admin rows for the channel.
-func (s *service) syncChannelAdmins(ctx context.Context, channelID, accessHash int64) error {
-	participantsResp, err := s.telegramClient.API().ChannelsGetParticipants(ctx, &tg.ChannelsGetParticipantsRequest{
-		Channel: &tg.InputChannel{
-			ChannelID:  channelID,
-			AccessHash: accessHash,
-		},
-		Filter: &tg.ChannelParticipantsAdmins{},
-		Offset: 0,
-		Limit:  100,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to get participants: %w", err)
-	}
+const adminsPageLimit = 100
 
-	modified, ok := participantsResp.(*tg.ChannelsChannelParticipants)
-	if !ok {
-		slog.Info("channel participants not modified or empty", "channel_id", channelID)
-		return nil
-	}
-
-	if err = s.channelAdminRepo.DeleteChannelAdmins(ctx, channelID); err != nil {
-		return fmt.Errorf("delete channel admins: %w", err)
-	}
-
-	for _, p := range modified.Participants {
-		var userID int64
-		var role string
-		switch v := p.(type) {
-		case *tg.ChannelParticipantCreator:
-			userID = v.UserID
-			role = "owner"
-		case *tg.ChannelParticipantAdmin:
-			userID = v.UserID
-			role = "admin"
-		default:
-			continue
+// syncChannelAdmins fetches all current admins from Telegram page by page and replaces channel_admin rows for the channel.
+func (s *service) syncChannelAdmins(ctx context.Context, channelID, accessHash int64) ([]*marketentity.ChannelAdmin, error) {
+	var admins []*marketentity.ChannelAdmin
+	for offset := 0; ; {
+		participantsResp, err := s.channelAPI.GetAdmins(ctx, channelID, accessHash, offset, adminsPageLimit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get participants: %w", err)
+		}
+		page, ok := participantsResp.(*tg.ChannelsChannelParticipants)
+		if !ok {
+			if offset == 0 {
+				slog.Info("channel participants not modified or empty", "channel_id", channelID)
+				return nil, nil
+			}
+			break
 		}
-		if err = s.channelAdminRepo.UpsertChannelAdmin(ctx, userID, channelID, role); err != nil {
-			return fmt.Errorf("upsert channel admin user_id=%d: %w", userID, err)
+		for _, p := range page.Participants {
+			switch v := p.(type) {
+			case *tg.ChannelParticipantCreator:
+				admins = append(admins, &marketentity.ChannelAdmin{UserID: v.UserID, Role: marketentity.ChannelAdminRoleOwner})
+			case *tg.ChannelParticipantAdmin:
+				admins = append(admins, &marketentity.ChannelAdmin{UserID: v.UserID, Role: marketentity.ChannelAdminRoleAdmin})
+			}
+		}
+		offset += len(page.Participants)
+		if len(page.Participants) == 0 || offset >= page.Count {
+			break
 		}
-		slog.Info("synced channel admin", "channel_id", channelID, "user_id", userID, "role", role)
 	}
-	return nil
+
+	if err := s.channelAdminRepo.ReplaceChannelAdmins(ctx, channelID, admins); err != nil {
+		return nil, fmt.Errorf("replace channel admins: %w", err)
+	}
+	slog.Info("synced channel admins", "channel_id", channelID, "count", len(admins))
+	return admins, nil
 }
 
 func mapChannel(rawChannel *tg.MessagesChatFull) (*marketentity.Channel, int) {
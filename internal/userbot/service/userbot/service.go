@@ -3,44 +3,51 @@ package service
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log/slog"
+	"sync"
 	"time"
 
+	"ads-mrkt/internal/event/application/consumer"
 	evententity "ads-mrkt/internal/event/domain/entity"
 	marketentity "ads-mrkt/internal/market/domain/entity"
 	"ads-mrkt/internal/userbot/config"
+	"ads-mrkt/internal/userbot/mtproto"
+	"ads-mrkt/internal/userbot/session"
 
-	"github.com/gotd/td/examples"
-	"github.com/gotd/td/telegram"
-	"github.com/gotd/td/telegram/auth"
 	"github.com/gotd/td/telegram/updates"
-	updhook "github.com/gotd/td/telegram/updates/hook"
 	"github.com/gotd/td/tg"
 )
 
 type channelRepository interface {
 	UpsertChannel(ctx context.Context, channel *marketentity.Channel) error
-	UpsertChannelStats(ctx context.Context, channelID int64, stats json.RawMessage) error
+	SaveChannelStatsSnapshot(ctx context.Context, snapshot *marketentity.ChannelStatsSnapshot, latest json.RawMessage, statsModel *marketentity.ChannelStats, quality *marketentity.ChannelQuality) error
 	UpdateChannelPhoto(ctx context.Context, channelID int64, photo string) error
 	GetChannelByID(ctx context.Context, id int64) (*marketentity.Channel, error)
+	ListChannelsInUse(ctx context.Context) ([]*marketentity.Channel, error)
 }
 
 type channelAdminRepository interface {
-	DeleteChannelAdmins(ctx context.Context, channelID int64) error
-	UpsertChannelAdmin(ctx context.Context, userID, channelID int64, role string) error
+	ReplaceChannelAdmins(ctx context.Context, channelID int64, admins []*marketentity.ChannelAdmin) error
 }
 
 type listingRepository interface {
 	GetListingByID(ctx context.Context, id int64) (*marketentity.Listing, error)
+	ListChannelIDsForStatsRefresh(ctx context.Context, staleAfter time.Duration) ([]int64, error)
+	ListActiveListingsByChannelID(ctx context.Context, channelID int64) ([]*marketentity.Listing, error)
+	DeactivateChannelListings(ctx context.Context, channelID int64) (int64, error)
+	DeactivateListing(ctx context.Context, id int64) error
+	ReassignListing(ctx context.Context, id int64, fromUserID, toUserID int64) (bool, error)
 }
 
 type dealRepository interface {
 	ListDealsEscrowDepositConfirmedWithoutPostMessage(ctx context.Context) ([]*marketentity.Deal, error)
+	StopChannelDeals(ctx context.Context, channelID int64, t marketentity.DealTransition) (refunded, expired []int64, err error)
+	TransitionDeal(ctx context.Context, d *marketentity.Deal, to marketentity.DealStatus, t marketentity.DealTransition) error
 }
 
 type dealPostMessageRepository interface {
-	CreateDealPostMessageAndSetDealInProgress(ctx context.Context, m *marketentity.DealPostMessage) error
+	CreateDealPostMessage(ctx context.Context, m *marketentity.DealPostMessage) error
 	UpdateDealPostMessageStatus(ctx context.Context, id int64, status marketentity.DealPostMessageStatus) error
 	UpdateDealPostMessageStatusAndNextCheck(ctx context.Context, id int64, status marketentity.DealPostMessageStatus, nextCheck time.Time) error
 	ListDealPostMessageExistsWithNextCheckBefore(ctx context.Context, before time.Time) ([]*marketentity.DealPostMessage, error)
@@ -52,15 +59,31 @@ type dealActionLockRepository interface {
 	GetLastDealActionLock(ctx context.Context, dealID int64, actionType marketentity.DealActionType) (*marketentity.DealActionLock, error)
 }
 
+// transactor runs fn in a database transaction, so that a saved post and the deal moving to in_progress commit together.
+type transactor interface {
+	InTx(ctx context.Context, source string, fn func(ctx context.Context) error) error
+}
+
 type channelUpdateStatsEventService interface {
-	ReadChannelUpdateStatsEvents(ctx context.Context, group, consumer string, limit int64) ([]*evententity.EventChannelUpdateStats, error)
-	PendingChannelUpdateStatsEvents(ctx context.Context, group, consumer string, limit int64, minIdle time.Duration) ([]*evententity.EventChannelUpdateStats, error)
-	AckChannelUpdateStatsMessages(ctx context.Context, group string, messageIDs []string) error
-	TrimStreamByAge(ctx context.Context, age time.Duration) error
+	AddChannelUpdateStatsEvent(ctx context.Context, channelID int64) error
+	ConsumeChannelUpdateStatsEvents(ctx context.Context, cfg consumer.Config, handler consumer.Handler[*evententity.EventChannelUpdateStats]) error
+}
+
+// channelAPI is the set of MTProto channel operations the userbot performs; see mtproto.ChannelClient.
+type channelAPI interface {
+	SendMessage(ctx context.Context, channelID, accessHash int64, text string) (int64, error)
+	GetHistory(ctx context.Context, channelID, accessHash int64, offsetID, addOffset, limit int) ([]mtproto.Message, error)
+	GetFullChannel(ctx context.Context, channelID, accessHash int64) (*tg.MessagesChatFull, error)
+	GetAdmins(ctx context.Context, channelID, accessHash int64, offset, limit int) (tg.ChannelsChannelParticipantsClass, error)
+	GetBroadcastStats(ctx context.Context, channelID, accessHash int64, statsDC int) (*tg.StatsBroadcastStats, error)
+	DownloadPhoto(ctx context.Context, channelID, accessHash, photoID int64) ([]byte, error)
 }
 
+var _ channelAPI = (*mtproto.ChannelClient)(nil)
+
 type service struct {
 	stateStorage               updates.StateStorage
+	sessionStore               session.Store
 	channelRepo                channelRepository
 	channelAdminRepo           channelAdminRepository
 	listingRepo                listingRepository
@@ -68,15 +91,25 @@ type service struct {
 	dealPostMessageRepo        dealPostMessageRepository
 	dealActionLockRepo         dealActionLockRepository
 	channelUpdateStatsEventSvc channelUpdateStatsEventService
-	telegramClient             *telegram.Client
-	authFlow                   auth.Flow
-	updatesManager             *updates.Manager
-	userID                     int64
+	transactor                 transactor
+	accounts                   []*account
+	pool                       *accountPool
+	channelAPI                 channelAPI
+	statsRefreshInterval       time.Duration
+	statsFloodGate             floodGate
+	channelCheckInterval       time.Duration
 }
 
-func New(cfg config.Config, stateStorage updates.StateStorage, channelRepo channelRepository, channelAdminRepo channelAdminRepository, listingRepo listingRepository, dealRepo dealRepository, dealPostMessageRepo dealPostMessageRepository, dealActionLockRepo dealActionLockRepository, channelUpdateStatsEventSvc channelUpdateStatsEventService) *service {
+// New builds the userbot; sessionStore keeps account sessions, nil keeps them in the configured session files.
+func New(cfg config.Config, stateStorage updates.StateStorage, sessionStore session.Store, accountRepo accountRepository, channelRepo channelRepository, channelAdminRepo channelAdminRepository, listingRepo listingRepository, dealRepo dealRepository, dealPostMessageRepo dealPostMessageRepository, dealActionLockRepo dealActionLockRepository, channelUpdateStatsEventSvc channelUpdateStatsEventService, transactor transactor) (*service, error) {
+	accounts, err := cfg.AccountList()
+	if err != nil {
+		return nil, err
+	}
+
 	s := &service{
 		stateStorage:               stateStorage,
+		sessionStore:               sessionStore,
 		channelRepo:                channelRepo,
 		channelAdminRepo:           channelAdminRepo,
 		listingRepo:                listingRepo,
@@ -84,109 +117,53 @@ func New(cfg config.Config, stateStorage updates.StateStorage, channelRepo chann
 		dealPostMessageRepo:        dealPostMessageRepo,
 		dealActionLockRepo:         dealActionLockRepo,
 		channelUpdateStatsEventSvc: channelUpdateStatsEventSvc,
+		transactor:                 transactor,
+		pool:                       newAccountPool(accountRepo),
+		statsRefreshInterval:       cfg.StatsRefreshInterval,
+		channelCheckInterval:       cfg.ChannelCheckInterval,
 	}
-
-	dispatcher := tg.NewUpdateDispatcher()
-	dispatcher.OnChannel(s.handleChannelUpdate)
-
-	s.updatesManager = updates.New(updates.Config{
-		Handler: dispatcher,
-		Storage: stateStorage,
-	})
-
-	s.telegramClient = telegram.NewClient(
-		cfg.ApiID,
-		cfg.ApiHash,
-		telegram.Options{
-			SessionStorage: &telegram.FileSessionStorage{
-				Path: cfg.SessionFile,
-			},
-			UpdateHandler: s.updatesManager,
-			Middlewares: []telegram.Middleware{
-				updhook.UpdateHook(s.updatesManager.Handle),
-			},
-			Device: telegram.DeviceConfig{
-				DeviceModel:    "ADS Market",
-				SystemVersion:  "n/a",
-				AppVersion:     "n/a",
-				SystemLangCode: "en",
-				LangPack:       "en",
-				LangCode:       "en",
-			},
-		},
-	)
-	s.authFlow = auth.NewFlow(examples.Terminal{PhoneNumber: cfg.Phone}, auth.SendCodeOptions{})
-
-	return s
-}
-
-func (s *service) getCurrentState(ctx context.Context) error {
-	api := s.telegramClient.API()
-
-	state, err := api.UpdatesGetState(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get current state from Telegram: %w", err)
-	}
-
-	if err := s.stateStorage.SetState(
-		ctx,
-		s.userID,
-		updates.State{
-			Pts:  state.Pts,
-			Qts:  state.Qts,
-			Date: state.Date,
-			Seq:  state.Seq,
-		},
-	); err != nil {
-		return fmt.Errorf("failed to store state in database: %w", err)
+	s.channelAPI = s.pool
+	for _, acc := range accounts {
+		s.accounts = append(s.accounts, s.newAccount(cfg, acc))
 	}
 
-	slog.Info("current state retrieved and stored",
-		"pts", state.Pts,
-		"qts", state.Qts,
-		"date", state.Date,
-		"seq", state.Seq,
-	)
-
-	return nil
+	return s, nil
 }
 
+// Start runs every account of the pool and, once the first one is logged in, the workers. An account that
+// stops is logged and the others keep serving; Start returns when all accounts have stopped.
 func (s *service) Start(ctx context.Context) error {
-	return s.telegramClient.Run(ctx, s.run)
-}
-
-func (s *service) run(ctx context.Context) error {
-	slog.Debug("performing auth if necessary")
-	if err := s.telegramClient.Auth().IfNecessary(ctx, s.authFlow); err != nil {
-		return fmt.Errorf("auth: %w", err)
-	}
-
-	slog.Debug("getting user info")
-	user, err := s.telegramClient.Self(ctx)
-	if err != nil {
-		return fmt.Errorf("call self: %w", err)
-	}
-
-	s.userID = user.ID
-
-	go s.RunDealPostSenderWorker(ctx)
-	go s.RunDealPostCheckerWorker(ctx)
-	go s.RunChannelUpdateStatsWorker(ctx)
-
-	slog.Debug("getting current state")
-	if err := s.getCurrentState(ctx); err != nil {
-		slog.Warn("Failed to get current state, continuing anyway", "error", err)
-	}
-
-	api := s.telegramClient.API()
-	authOpts := updates.AuthOptions{
-		IsBot:  false,
-		Forget: false,
-		OnStart: func(ctx context.Context) {
-			slog.Info("updates manager started successfully")
-		},
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.pool.Ready():
+		}
+		go s.RunDealPostSenderWorker(ctx)
+		go s.RunDealPostCheckerWorker(ctx)
+		go s.RunChannelUpdateStatsWorker(ctx)
+		go s.RunStatsRefreshScheduler(ctx)
+		go s.RunChannelConnectionChecker(ctx)
+	}()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, a := range s.accounts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := a.telegramClient.Run(ctx, func(ctx context.Context) error { return s.runAccount(ctx, a) })
+			if err != nil && ctx.Err() == nil {
+				slog.Error("userbot account stopped", "phone", a.phone, "account_id", a.userID.Load(), "error", err)
+			}
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}()
 	}
-
-	slog.Info("starting updates polling", "user_id", user.ID)
-	return s.updatesManager.Run(ctx, api, user.ID, authOpts)
+	wg.Wait()
+	return errors.Join(errs...)
 }
@@ -0,0 +1,484 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"ads-mrkt/internal/market/domain"
+	marketentity "ads-mrkt/internal/market/domain/entity"
+	marketerrors "ads-mrkt/internal/market/domain/errors"
+	"ads-mrkt/internal/userbot/mtproto/mtprototest"
+
+	"github.com/gotd/td/tg"
+)
+
+const (
+	testChannelID  = int64(777)
+	testAccessHash = int64(999)
+	testListingID  = int64(5)
+)
+
+var _ channelAPI = (*mtprototest.Channels)(nil)
+
+// repo is an in-memory implementation of the repositories the userbot uses.
+type repo struct {
+	mu           sync.Mutex
+	channels     map[int64]*marketentity.Channel
+	stats        map[int64]json.RawMessage
+	snapshots    []*marketentity.ChannelStatsSnapshot
+	statsModels  map[int64]*marketentity.ChannelStats
+	admins       map[int64]map[int64]string
+	listings     map[int64]*marketentity.Listing
+	deals        map[int64]*marketentity.Deal
+	postMessages []*marketentity.DealPostMessage
+	locks        []*marketentity.DealActionLock
+}
+
+func newRepo() *repo {
+	channelID := testChannelID
+	return &repo{
+		channels:    make(map[int64]*marketentity.Channel),
+		stats:       make(map[int64]json.RawMessage),
+		statsModels: make(map[int64]*marketentity.ChannelStats),
+		admins:      make(map[int64]map[int64]string),
+		listings:    map[int64]*marketentity.Listing{testListingID: {ID: testListingID, Status: marketentity.ListingStatusActive, UserID: 1, ChannelID: &channelID}},
+		deals:       make(map[int64]*marketentity.Deal),
+	}
+}
+
+func (r *repo) UpsertChannel(ctx context.Context, channel *marketentity.Channel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := *channel
+	r.channels[c.ID] = &c
+	return nil
+}
+
+func (r *repo) SaveChannelStatsSnapshot(ctx context.Context, snapshot *marketentity.ChannelStatsSnapshot, latest json.RawMessage, statsModel *marketentity.ChannelStats, quality *marketentity.ChannelQuality) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats[snapshot.ChannelID] = latest
+	r.statsModels[snapshot.ChannelID] = statsModel
+	r.snapshots = append(r.snapshots, snapshot)
+	return nil
+}
+
+func (r *repo) UpdateChannelPhoto(ctx context.Context, channelID int64, photo string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.channels[channelID].Photo = photo
+	return nil
+}
+
+func (r *repo) GetChannelByID(ctx context.Context, id int64) (*marketentity.Channel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.channels[id], nil
+}
+
+func (r *repo) ListChannelsInUse(ctx context.Context) ([]*marketentity.Channel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inUse := make(map[int64]bool)
+	for _, l := range r.listings {
+		if l.ChannelID != nil && l.Status == marketentity.ListingStatusActive {
+			inUse[*l.ChannelID] = true
+		}
+	}
+	var out []*marketentity.Channel
+	for id := range inUse {
+		if ch := r.channels[id]; ch != nil {
+			c := *ch
+			out = append(out, &c)
+		}
+	}
+	return out, nil
+}
+
+func (r *repo) ReplaceChannelAdmins(ctx context.Context, channelID int64, admins []*marketentity.ChannelAdmin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.admins[channelID] = make(map[int64]string, len(admins))
+	for _, a := range admins {
+		r.admins[channelID][a.UserID] = string(a.Role)
+	}
+	return nil
+}
+
+func (r *repo) GetListingByID(ctx context.Context, id int64) (*marketentity.Listing, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.listings[id], nil
+}
+
+func (r *repo) ListActiveListingsByChannelID(ctx context.Context, channelID int64) ([]*marketentity.Listing, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*marketentity.Listing
+	for _, l := range r.listings {
+		if l.ChannelID != nil && *l.ChannelID == channelID && l.Status == marketentity.ListingStatusActive {
+			c := *l
+			out = append(out, &c)
+		}
+	}
+	return out, nil
+}
+
+func (r *repo) DeactivateChannelListings(ctx context.Context, channelID int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for _, l := range r.listings {
+		if l.ChannelID != nil && *l.ChannelID == channelID && l.Status == marketentity.ListingStatusActive {
+			l.Status = marketentity.ListingStatusInactive
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *repo) DeactivateListing(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if l := r.listings[id]; l != nil {
+		l.Status = marketentity.ListingStatusInactive
+	}
+	return nil
+}
+
+func (r *repo) ReassignListing(ctx context.Context, id, fromUserID, toUserID int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l := r.listings[id]
+	if l == nil || l.UserID != fromUserID {
+		return false, nil
+	}
+	l.UserID = toUserID
+	return true, nil
+}
+
+// ListChannelIDsForStatsRefresh treats every listed channel without stored stats as stale.
+func (r *repo) ListChannelIDsForStatsRefresh(ctx context.Context, staleAfter time.Duration) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []int64
+	for _, l := range r.listings {
+		if l.ChannelID != nil && r.stats[*l.ChannelID] == nil {
+			ids = append(ids, *l.ChannelID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *repo) ListDealsEscrowDepositConfirmedWithoutPostMessage(ctx context.Context) ([]*marketentity.Deal, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*marketentity.Deal
+	for _, d := range r.deals {
+		if d.Status != marketentity.DealStatusEscrowDepositConfirmed {
+			continue
+		}
+		posted := false
+		for _, m := range r.postMessages {
+			posted = posted || m.DealID == d.ID
+		}
+		if !posted {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// StopChannelDeals refunds funded deals of the channel whose post has not been checked as passed and expires the
+// ones not funded yet.
+func (r *repo) StopChannelDeals(ctx context.Context, channelID int64, t marketentity.DealTransition) (refunded, expired []int64, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.deals {
+		l := r.listings[d.ListingID]
+		if l == nil || l.ChannelID == nil || *l.ChannelID != channelID {
+			continue
+		}
+		switch d.Status {
+		case marketentity.DealStatusApproved, marketentity.DealStatusWaitingEscrowDeposit:
+			d.Status = marketentity.DealStatusExpired
+			d.Version++
+			expired = append(expired, d.ID)
+			continue
+		case marketentity.DealStatusEscrowDepositConfirmed, marketentity.DealStatusInProgress:
+		default:
+			continue
+		}
+		d.Status = marketentity.DealStatusWaitingEscrowRefund
+		d.Version++
+		refunded = append(refunded, d.ID)
+		for _, m := range r.postMessages {
+			if m.DealID == d.ID && m.Status == marketentity.DealPostMessageStatusExists {
+				m.Status = marketentity.DealPostMessageStatusFailed
+			}
+		}
+	}
+	return refunded, expired, nil
+}
+
+func (r *repo) CreateDealPostMessage(ctx context.Context, m *marketentity.DealPostMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m.ID = int64(len(r.postMessages) + 1)
+	r.postMessages = append(r.postMessages, m)
+	return nil
+}
+
+// TransitionDeal applies the transition if the stored deal still has the status and version d was read with.
+func (r *repo) TransitionDeal(ctx context.Context, d *marketentity.Deal, to marketentity.DealStatus, t marketentity.DealTransition) error {
+	if _, err := domain.CheckDealTransition(d.Status, to, t); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.deals[d.ID]
+	if stored == nil || stored.Status != d.Status || stored.Version != d.Version {
+		return marketerrors.ErrDealChanged
+	}
+	stored.Status, stored.Version = to, stored.Version+1
+	d.Status, d.Version = stored.Status, stored.Version
+	return nil
+}
+
+func (r *repo) InTx(ctx context.Context, source string, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (r *repo) UpdateDealPostMessageStatus(ctx context.Context, id int64, status marketentity.DealPostMessageStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.postMessages[id-1].Status = status
+	return nil
+}
+
+func (r *repo) UpdateDealPostMessageStatusAndNextCheck(ctx context.Context, id int64, status marketentity.DealPostMessageStatus, nextCheck time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.postMessages[id-1].Status = status
+	r.postMessages[id-1].NextCheck = nextCheck
+	return nil
+}
+
+func (r *repo) ListDealPostMessageExistsWithNextCheckBefore(ctx context.Context, before time.Time) ([]*marketentity.DealPostMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*marketentity.DealPostMessage
+	for _, m := range r.postMessages {
+		if m.Status == marketentity.DealPostMessageStatusExists && m.NextCheck.Before(before) {
+			c := *m
+			out = append(out, &c)
+		}
+	}
+	return out, nil
+}
+
+func (r *repo) TakeDealActionLock(ctx context.Context, dealID int64, actionType marketentity.DealActionType) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l := &marketentity.DealActionLock{
+		ID:         time.Now().Format(time.RFC3339Nano),
+		DealID:     dealID,
+		ActionType: actionType,
+		Status:     marketentity.DealActionLockStatusLocked,
+		ExpireAt:   time.Now().Add(time.Minute),
+	}
+	r.locks = append(r.locks, l)
+	return l.ID, nil
+}
+
+func (r *repo) ReleaseDealActionLock(ctx context.Context, lockID string, status marketentity.DealActionLockStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range r.locks {
+		if l.ID == lockID {
+			l.Status = status
+		}
+	}
+	return nil
+}
+
+func (r *repo) GetLastDealActionLock(ctx context.Context, dealID int64, actionType marketentity.DealActionType) (*marketentity.DealActionLock, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.locks) - 1; i >= 0; i-- {
+		if l := r.locks[i]; l.DealID == dealID && l.ActionType == actionType {
+			c := *l
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func newTestService(t *testing.T) (*service, *repo, *mtprototest.Channels) {
+	t.Helper()
+	r := newRepo()
+	channels := mtprototest.New()
+	channels.AddChannel(mtprototest.Channel{
+		ID:           testChannelID,
+		AccessHash:   testAccessHash,
+		Title:        "Test channel",
+		Username:     "test_channel",
+		AdminRights:  tg.ChatAdminRights{PostMessages: true, DeleteMessages: true},
+		CanViewStats: true,
+		StatsDC:      2,
+		Admins:       []mtprototest.Admin{{UserID: 1, Owner: true}, {UserID: 2}},
+		Photo:        []byte{0xff, 0xd8},
+		Stats:        &tg.StatsBroadcastStats{Followers: tg.StatsAbsValueAndPrev{Current: 1500, Previous: 1400}},
+	})
+	s := &service{
+		channelRepo:         r,
+		channelAdminRepo:    r,
+		listingRepo:         r,
+		dealRepo:            r,
+		dealPostMessageRepo: r,
+		dealActionLockRepo:  r,
+		transactor:          r,
+		channelAPI:          channels,
+	}
+	return s, r, channels
+}
+
+func (r *repo) addDeal(id int64, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deals[id] = &marketentity.Deal{
+		ID:        id,
+		ListingID: testListingID,
+		Status:    marketentity.DealStatusEscrowDepositConfirmed,
+		Duration:  24,
+		Details:   json.RawMessage(`{"message":"` + message + `"}`),
+	}
+}
+
+func TestHandleChannelUpdateSyncsChannel(t *testing.T) {
+	s, r, _ := newTestService(t)
+	ctx := context.Background()
+
+	entities := tg.Entities{Channels: map[int64]*tg.Channel{testChannelID: {ID: testChannelID, AccessHash: testAccessHash, Title: "Test channel"}}}
+	if err := s.handleChannelUpdate(ctx, entities, &tg.UpdateChannel{ChannelID: testChannelID}); err != nil {
+		t.Fatalf("handleChannelUpdate: %v", err)
+	}
+
+	ch := r.channels[testChannelID]
+	if ch == nil {
+		t.Fatal("channel not stored")
+	}
+	if ch.Username != "test_channel" || ch.AccessHash != testAccessHash || !ch.AdminRights.PostMessages || !ch.AdminRights.CanViewStats {
+		t.Fatalf("unexpected channel: %+v", ch)
+	}
+	if ch.Photo == "" {
+		t.Fatal("photo not stored")
+	}
+	if got := r.admins[testChannelID]; got[1] != "owner" || got[2] != "admin" {
+		t.Fatalf("admins = %v", got)
+	}
+	var stats map[string]any
+	if err := json.Unmarshal(r.stats[testChannelID], &stats); err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if _, ok := stats["requested_at"]; !ok {
+		t.Fatal("requested_at missing from stats")
+	}
+	if len(r.snapshots) != 1 || r.snapshots[0].Followers != 1500 {
+		t.Fatalf("stats snapshots = %+v", r.snapshots)
+	}
+	if m := r.statsModels[testChannelID]; m == nil || m.Version != marketentity.ChannelStatsVersion || m.Followers.Current != 1500 {
+		t.Fatalf("stats model = %+v", m)
+	}
+}
+
+func TestDealPostSenderAndChecker(t *testing.T) {
+	s, r, channels := newTestService(t)
+	ctx := context.Background()
+	logger := slog.Default()
+	r.channels[testChannelID] = &marketentity.Channel{ID: testChannelID, AccessHash: testAccessHash}
+	r.addDeal(1, "Buy our coin")
+
+	s.runDealPostSenderOnce(ctx, logger)
+
+	msgs := channels.Messages(testChannelID)
+	if len(msgs) != 1 || msgs[0].Text != "Buy our coin" {
+		t.Fatalf("channel messages = %+v", msgs)
+	}
+	if len(r.postMessages) != 1 || r.postMessages[0].MessageID != msgs[0].ID {
+		t.Fatalf("post messages = %+v", r.postMessages)
+	}
+	if r.deals[1].Status != marketentity.DealStatusInProgress {
+		t.Fatalf("deal status = %s", r.deals[1].Status)
+	}
+
+	// Other posts arrive after ours; the checker still finds it.
+	for range 30 {
+		channels.Post(testChannelID, "noise")
+	}
+	r.postMessages[0].NextCheck = time.Now().Add(-time.Minute)
+	s.runDealPostCheckerOnce(ctx, logger)
+	if r.postMessages[0].Status != marketentity.DealPostMessageStatusExists {
+		t.Fatalf("status = %s, want exists", r.postMessages[0].Status)
+	}
+
+	channels.Delete(testChannelID, msgs[0].ID)
+	r.postMessages[0].NextCheck = time.Now().Add(-time.Minute)
+	s.runDealPostCheckerOnce(ctx, logger)
+	if r.postMessages[0].Status != marketentity.DealPostMessageStatusDeleted {
+		t.Fatalf("status = %s, want deleted", r.postMessages[0].Status)
+	}
+}
+
+func TestDealPostSenderRecoversPostAfterCrash(t *testing.T) {
+	s, r, channels := newTestService(t)
+	ctx := context.Background()
+	r.channels[testChannelID] = &marketentity.Channel{ID: testChannelID, AccessHash: testAccessHash}
+	r.addDeal(1, "Buy our coin")
+
+	// A previous run posted the message and crashed before saving it.
+	msgID := channels.Post(testChannelID, "Buy our coin")
+	r.locks = append(r.locks, &marketentity.DealActionLock{
+		ID:         "stale",
+		DealID:     1,
+		ActionType: marketentity.DealActionTypePostMessage,
+		Status:     marketentity.DealActionLockStatusLocked,
+		ExpireAt:   time.Now().Add(-time.Minute),
+	})
+
+	s.runDealPostSenderOnce(ctx, slog.Default())
+
+	if n := channels.Calls("SendMessage"); n != 0 {
+		t.Fatalf("SendMessage called %d times, want 0", n)
+	}
+	if len(r.postMessages) != 1 || r.postMessages[0].MessageID != msgID {
+		t.Fatalf("post messages = %+v", r.postMessages)
+	}
+	if r.locks[0].Status != marketentity.DealActionLockStatusCompleted {
+		t.Fatalf("lock status = %s", r.locks[0].Status)
+	}
+}
+
+func TestDealPostSenderReleasesLockOnSendFailure(t *testing.T) {
+	s, r, channels := newTestService(t)
+	ctx := context.Background()
+	r.channels[testChannelID] = &marketentity.Channel{ID: testChannelID, AccessHash: testAccessHash}
+	r.addDeal(1, "Buy our coin")
+	channels.UpdateChannel(testChannelID, func(ch *mtprototest.Channel) { ch.AdminRights.PostMessages = false })
+
+	s.runDealPostSenderOnce(ctx, slog.Default())
+
+	if len(r.postMessages) != 0 {
+		t.Fatalf("post messages = %+v", r.postMessages)
+	}
+	if len(r.locks) != 1 || r.locks[0].Status != marketentity.DealActionLockStatusFailed {
+		t.Fatalf("locks = %+v", r.locks)
+	}
+	if r.deals[1].Status != marketentity.DealStatusEscrowDepositConfirmed {
+		t.Fatalf("deal status = %s", r.deals[1].Status)
+	}
+}
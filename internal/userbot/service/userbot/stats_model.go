@@ -0,0 +1,156 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	marketdomain "ads-mrkt/internal/market/domain"
+	marketentity "ads-mrkt/internal/market/domain/entity"
+
+	"github.com/gotd/td/tg"
+)
+
+const topPostsLimit = 10
+
+// channelStatsModel converts broadcast stats to the typed model served by the market API.
+func channelStatsModel(stats *tg.StatsBroadcastStats, fetchedAt time.Time) *marketentity.ChannelStats {
+	model := &marketentity.ChannelStats{
+		Version:   marketentity.ChannelStatsVersion,
+		FetchedAt: fetchedAt.UTC(),
+		Period: marketentity.ChannelStatsPeriod{
+			From: time.Unix(int64(stats.Period.MinDate), 0).UTC(),
+			To:   time.Unix(int64(stats.Period.MaxDate), 0).UTC(),
+		},
+		Followers:         statsValue(stats.Followers),
+		ViewsPerPost:      statsValue(stats.ViewsPerPost),
+		SharesPerPost:     statsValue(stats.SharesPerPost),
+		ReactionsPerPost:  statsValue(stats.ReactionsPerPost),
+		ViewsPerStory:     statsValue(stats.ViewsPerStory),
+		SharesPerStory:    statsValue(stats.SharesPerStory),
+		ReactionsPerStory: statsValue(stats.ReactionsPerStory),
+		TopPosts:          topPosts(stats.RecentPostsInteractions),
+		Graphs: marketentity.ChannelStatsGraphs{
+			Growth:                  timeGraph(stats.GrowthGraph),
+			Followers:               timeGraph(stats.FollowersGraph),
+			Mute:                    timeGraph(stats.MuteGraph),
+			Interactions:            timeGraph(stats.InteractionsGraph),
+			ViewsBySource:           timeGraph(stats.ViewsBySourceGraph),
+			FollowersBySource:       timeGraph(stats.NewFollowersBySourceGraph),
+			Languages:               timeGraph(stats.LanguagesGraph),
+			ReactionsByEmotion:      timeGraph(stats.ReactionsByEmotionGraph),
+			StoryInteractions:       timeGraph(stats.StoryInteractionsGraph),
+			StoryReactionsByEmotion: timeGraph(stats.StoryReactionsByEmotionGraph),
+		},
+		TopHours: hourlyGraph(stats.TopHoursGraph),
+	}
+	if stats.EnabledNotifications.Total > 0 {
+		model.EnabledNotificationsPercent = stats.EnabledNotifications.Part / stats.EnabledNotifications.Total * 100
+	}
+	model.Languages = breakdown(model.Graphs.Languages)
+	model.ViewsBySource = breakdown(model.Graphs.ViewsBySource)
+	model.FollowersBySource = breakdown(model.Graphs.FollowersBySource)
+	return model
+}
+
+func statsValue(v tg.StatsAbsValueAndPrev) marketentity.ChannelStatsValue {
+	return marketentity.ChannelStatsValue{Current: v.Current, Previous: v.Previous}
+}
+
+func topPosts(interactions []tg.PostInteractionCountersClass) []marketentity.ChannelStatsPost {
+	posts := make([]marketentity.ChannelStatsPost, 0, len(interactions))
+	for _, c := range interactions {
+		switch c := c.(type) {
+		case *tg.PostInteractionCountersMessage:
+			posts = append(posts, marketentity.ChannelStatsPost{MessageID: c.MsgID, Views: c.Views, Forwards: c.Forwards, Reactions: c.Reactions})
+		case *tg.PostInteractionCountersStory:
+			posts = append(posts, marketentity.ChannelStatsPost{StoryID: c.StoryID, Views: c.Views, Forwards: c.Forwards, Reactions: c.Reactions})
+		}
+	}
+	sort.SliceStable(posts, func(i, j int) bool { return posts[i].Views > posts[j].Views })
+	if len(posts) > topPostsLimit {
+		posts = posts[:topPostsLimit]
+	}
+	return posts
+}
+
+// parseStatsGraph decodes a loaded graph into its title, x column and named y columns. Async and error
+// graphs yield ok=false.
+func parseStatsGraph(g tg.StatsGraphClass) (data marketdomain.StatsGraphData, x []float64, ys []marketdomain.StatsColumn, ok bool) {
+	graph, isGraph := g.(*tg.StatsGraph)
+	if !isGraph {
+		return data, nil, nil, false
+	}
+	return marketdomain.ParseStatsGraph(graph.JSON.Data)
+}
+
+// timeGraph converts a daily graph; x values are unix milliseconds.
+func timeGraph(g tg.StatsGraphClass) *marketentity.ChannelStatsGraph {
+	data, x, ys, ok := parseStatsGraph(g)
+	if !ok {
+		return nil
+	}
+	out := &marketentity.ChannelStatsGraph{Title: data.Title, Series: make([]marketentity.ChannelStatsSeries, 0, len(ys))}
+	for _, y := range ys {
+		series := marketentity.ChannelStatsSeries{
+			Key:    y.Key,
+			Name:   seriesName(data, y.Key),
+			Type:   data.Types[y.Key],
+			Points: make([]marketentity.ChannelStatsPoint, 0, len(x)),
+		}
+		for i, ms := range x {
+			series.Points = append(series.Points, marketentity.ChannelStatsPoint{T: time.UnixMilli(int64(ms)).UTC(), V: y.Values[i]})
+		}
+		out.Series = append(out.Series, series)
+	}
+	return out
+}
+
+// hourlyGraph converts the top hours graph, whose x values are UTC hours of day.
+func hourlyGraph(g tg.StatsGraphClass) []marketentity.ChannelStatsHourly {
+	data, x, ys, ok := parseStatsGraph(g)
+	if !ok {
+		return nil
+	}
+	out := make([]marketentity.ChannelStatsHourly, 0, len(ys))
+	for _, y := range ys {
+		values := make([]float64, 24)
+		for i, hour := range x {
+			if h := int(hour); h >= 0 && h < 24 {
+				values[h] = y.Values[i]
+			}
+		}
+		out = append(out, marketentity.ChannelStatsHourly{Name: seriesName(data, y.Key), Values: values})
+	}
+	return out
+}
+
+func seriesName(data marketdomain.StatsGraphData, key string) string {
+	if name := data.Names[key]; name != "" {
+		return name
+	}
+	return key
+}
+
+// breakdown sums each series of the graph over the period, largest first.
+func breakdown(g *marketentity.ChannelStatsGraph) []marketentity.ChannelStatsShare {
+	if g == nil {
+		return nil
+	}
+	var total float64
+	shares := make([]marketentity.ChannelStatsShare, 0, len(g.Series))
+	for _, s := range g.Series {
+		var sum float64
+		for _, p := range s.Points {
+			sum += p.V
+		}
+		total += sum
+		shares = append(shares, marketentity.ChannelStatsShare{Name: s.Name, Value: sum})
+	}
+	if total > 0 {
+		for i := range shares {
+			shares[i].Percent = shares[i].Value / total * 100
+		}
+	}
+	sort.SliceStable(shares, func(i, j int) bool { return shares[i].Value > shares[j].Value })
+	return shares
+}
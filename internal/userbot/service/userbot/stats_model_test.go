@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gotd/td/tg"
+)
+
+func TestChannelStatsModel(t *testing.T) {
+	day := int64(1700006400000)
+	stats := &tg.StatsBroadcastStats{
+		Period:               tg.StatsDateRangeDays{MinDate: 1700000000, MaxDate: 1700600000},
+		Followers:            tg.StatsAbsValueAndPrev{Current: 1000, Previous: 900},
+		EnabledNotifications: tg.StatsPercentValue{Part: 25, Total: 100},
+		RecentPostsInteractions: []tg.PostInteractionCountersClass{
+			&tg.PostInteractionCountersMessage{MsgID: 1, Views: 10},
+			&tg.PostInteractionCountersStory{StoryID: 2, Views: 30},
+			&tg.PostInteractionCountersMessage{MsgID: 3, Views: 20, Forwards: 1},
+		},
+		GrowthGraph: &tg.StatsGraph{JSON: tg.DataJSON{Data: `{"columns":[["x",1700006400000,1700092800000],["y0",900,1000]],
+			"types":{"x":"x","y0":"line"},"names":{"y0":"Total followers"}}`}},
+		LanguagesGraph: &tg.StatsGraph{JSON: tg.DataJSON{Data: `{"columns":[["x",1700006400000,1700092800000],["y0",1,2],["y1",3,4]],
+			"types":{"x":"x","y0":"area","y1":"area"},"names":{"y0":"English","y1":"Russian"}}`}},
+		TopHoursGraph:     &tg.StatsGraph{JSON: tg.DataJSON{Data: `{"columns":[["x",0,13],["y0",5,7]],"types":{"x":"x","y0":"line"},"names":{"y0":"Views"}}`}},
+		InteractionsGraph: &tg.StatsGraphAsync{Token: "pending"},
+		MuteGraph:         &tg.StatsGraphError{Error: "not enough data"},
+	}
+	m := channelStatsModel(stats, time.Unix(1700700000, 0))
+
+	if m.Followers.Current != 1000 || m.Followers.Previous != 900 || m.EnabledNotificationsPercent != 25 {
+		t.Fatalf("values = %+v, notifications %v", m.Followers, m.EnabledNotificationsPercent)
+	}
+	if !m.Period.From.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("period = %+v", m.Period)
+	}
+	if len(m.TopPosts) != 3 || m.TopPosts[0].StoryID != 2 || m.TopPosts[1].MessageID != 3 {
+		t.Fatalf("top posts = %+v", m.TopPosts)
+	}
+	growth := m.Graphs.Growth
+	if growth == nil || len(growth.Series) != 1 || growth.Series[0].Name != "Total followers" || len(growth.Series[0].Points) != 2 {
+		t.Fatalf("growth = %+v", growth)
+	}
+	if p := growth.Series[0].Points[0]; !p.T.Equal(time.UnixMilli(day)) || p.V != 900 {
+		t.Fatalf("growth point = %+v", p)
+	}
+	if m.Graphs.Interactions != nil || m.Graphs.Mute != nil {
+		t.Fatal("async and error graphs must be omitted")
+	}
+	if len(m.Languages) != 2 || m.Languages[0].Name != "Russian" || m.Languages[0].Value != 7 || m.Languages[0].Percent != 70 {
+		t.Fatalf("languages = %+v", m.Languages)
+	}
+	if len(m.TopHours) != 1 || len(m.TopHours[0].Values) != 24 || m.TopHours[0].Values[13] != 7 {
+		t.Fatalf("top hours = %+v", m.TopHours)
+	}
+}
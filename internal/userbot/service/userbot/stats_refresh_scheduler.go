@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/tgerr"
+)
+
+const (
+	statsRefreshCheckInterval = time.Minute
+	statsRefreshMinSpread     = 2 * time.Second
+	statsRefreshMaxSpread     = 10 * time.Minute
+
+	statsFloodWaitMaxRetries = 3
+)
+
+// RunStatsRefreshScheduler pushes EventChannelUpdateStats for channels with an active listing whose stats are older
+// than the refresh interval. Events of one pass are spread evenly over the interval so Telegram is not hit in bursts.
+func (s *service) RunStatsRefreshScheduler(ctx context.Context) {
+	logger := slog.With("component", "stats_refresh_scheduler")
+	if s.statsRefreshInterval <= 0 {
+		logger.Info("stats refresh scheduler disabled")
+		return
+	}
+
+	scheduled := make(map[int64]time.Time)
+	for {
+		s.scheduleStatsRefresh(ctx, logger, scheduled)
+		select {
+		case <-ctx.Done():
+			logger.Info("stats refresh scheduler stopped")
+			return
+		case <-time.After(statsRefreshCheckInterval):
+		}
+	}
+}
+
+// scheduleStatsRefresh runs one pass; scheduled remembers when a channel was last pushed so a channel whose
+// refresh is still queued (e.g. behind a FLOOD_WAIT) is not pushed again within the interval.
+func (s *service) scheduleStatsRefresh(ctx context.Context, logger *slog.Logger, scheduled map[int64]time.Time) {
+	ids, err := s.listingRepo.ListChannelIDsForStatsRefresh(ctx, s.statsRefreshInterval)
+	if err != nil {
+		logger.Error("list channels for stats refresh", "error", err)
+		return
+	}
+
+	now := time.Now()
+	due := ids[:0]
+	for _, id := range ids {
+		if at, ok := scheduled[id]; ok && now.Sub(at) < s.statsRefreshInterval {
+			continue
+		}
+		due = append(due, id)
+	}
+	for id, at := range scheduled {
+		if now.Sub(at) >= s.statsRefreshInterval {
+			delete(scheduled, id)
+		}
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	spread := min(max(s.statsRefreshInterval/time.Duration(len(due)), statsRefreshMinSpread), statsRefreshMaxSpread)
+	logger.Info("scheduling channel stats refresh", "channels", len(due), "spread", spread)
+	for i, id := range due {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(spread):
+			}
+		}
+		if err := s.channelUpdateStatsEventSvc.AddChannelUpdateStatsEvent(ctx, id); err != nil {
+			logger.Error("add channel update stats event", "channel_id", id, "error", err)
+			continue
+		}
+		scheduled[id] = time.Now()
+	}
+}
+
+// floodGate holds back stats requests while Telegram asks us to wait. It is shared by the stream and
+// pending workers, so a FLOOD_WAIT seen by one pauses both.
+type floodGate struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+func (g *floodGate) delay(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if until := time.Now().Add(d); until.After(g.until) {
+		g.until = until
+	}
+}
+
+func (g *floodGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	d := time.Until(g.until)
+	g.mu.Unlock()
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// updateChannelStatsWithFloodWait refreshes channel stats (estimated ones when the channel has no stats access),
+// waiting out FLOOD_WAIT errors instead of failing.
+func (s *service) updateChannelStatsWithFloodWait(ctx context.Context, logger *slog.Logger, channelID, accessHash int64, canViewStats bool) error {
+	for attempt := 0; ; attempt++ {
+		if err := s.statsFloodGate.wait(ctx); err != nil {
+			return err
+		}
+		var err error
+		if canViewStats {
+			err = s.UpdateChannelStats(ctx, channelID, accessHash, 0)
+		} else {
+			err = s.UpdateChannelEstimatedStats(ctx, channelID, accessHash)
+		}
+		wait, ok := tgerr.AsFloodWait(err)
+		if !ok || attempt >= statsFloodWaitMaxRetries {
+			return err
+		}
+		logger.Warn("flood wait on channel stats, delaying", "channel_id", channelID, "wait", wait, "attempt", attempt+1)
+		s.statsFloodGate.delay(wait)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"ads-mrkt/internal/event/application/consumer"
+	evententity "ads-mrkt/internal/event/domain/entity"
+	marketentity "ads-mrkt/internal/market/domain/entity"
+
+	"github.com/gotd/td/tgerr"
+)
+
+// statsEvents records pushed EventChannelUpdateStats.
+type statsEvents struct {
+	mu     sync.Mutex
+	pushed []int64
+}
+
+func (e *statsEvents) AddChannelUpdateStatsEvent(ctx context.Context, channelID int64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pushed = append(e.pushed, channelID)
+	return nil
+}
+
+func (e *statsEvents) ConsumeChannelUpdateStatsEvents(ctx context.Context, cfg consumer.Config, handler consumer.Handler[*evententity.EventChannelUpdateStats]) error {
+	return nil
+}
+
+func TestScheduleStatsRefreshPushesStaleChannelsOnce(t *testing.T) {
+	s, _, _ := newTestService(t)
+	events := &statsEvents{}
+	s.channelUpdateStatsEventSvc = events
+	s.statsRefreshInterval = time.Hour
+	scheduled := make(map[int64]time.Time)
+
+	s.scheduleStatsRefresh(context.Background(), slog.Default(), scheduled)
+	s.scheduleStatsRefresh(context.Background(), slog.Default(), scheduled)
+
+	if len(events.pushed) != 1 || events.pushed[0] != testChannelID {
+		t.Fatalf("pushed = %v, want [%d] once", events.pushed, testChannelID)
+	}
+}
+
+func TestUpdateChannelStatsWaitsOutFloodWait(t *testing.T) {
+	s, r, channels := newTestService(t)
+	r.channels[testChannelID] = &marketentity.Channel{ID: testChannelID, AccessHash: testAccessHash}
+	channels.FailNext("GetBroadcastStats", tgerr.New(420, "FLOOD_WAIT_1"))
+
+	start := time.Now()
+	if err := s.updateChannelStatsWithFloodWait(context.Background(), slog.Default(), testChannelID, testAccessHash, true); err != nil {
+		t.Fatalf("update stats: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("returned after %s, want the 1s flood wait respected", elapsed)
+	}
+	if n := channels.Calls("GetBroadcastStats"); n != 2 {
+		t.Fatalf("GetBroadcastStats calls = %d, want 2", n)
+	}
+	if len(r.snapshots) != 1 {
+		t.Fatalf("stats snapshots = %d, want 1", len(r.snapshots))
+	}
+}
@@ -0,0 +1,127 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/telegram/auth"
+	"github.com/gotd/td/tg"
+)
+
+// LoginOptions drive a non-interactive login. Without Code the login code is sent and read as a line from
+// Input, unless SendCodeOnly is set: then the code hash is printed and the login is finished by a second run
+// with Code and CodeHash. A missing Password is read from Input when the account has 2FA.
+type LoginOptions struct {
+	Phone        string
+	Code         string
+	CodeHash     string
+	Password     string
+	SendCodeOnly bool
+	// Reset discards the stored session and logs in with a new auth key, rotating the session.
+	Reset  bool
+	Input  io.Reader
+	Output io.Writer
+}
+
+// Login logs the account in and leaves its session in storage.
+func Login(ctx context.Context, apiID int, apiHash string, storage telegram.SessionStorage, opts LoginOptions) error {
+	if opts.Reset {
+		storage = &resetStorage{SessionStorage: storage}
+	}
+	client := telegram.NewClient(apiID, apiHash, telegram.Options{SessionStorage: storage})
+	input := bufio.NewReader(opts.Input)
+
+	return client.Run(ctx, func(ctx context.Context) error {
+		status, err := client.Auth().Status(ctx)
+		if err != nil {
+			return fmt.Errorf("auth status: %w", err)
+		}
+		if status.Authorized {
+			fmt.Fprintf(opts.Output, "already logged in as user_id=%d\n", status.User.ID)
+			return nil
+		}
+
+		code, hash := opts.Code, opts.CodeHash
+		if code == "" {
+			sent, err := client.Auth().SendCode(ctx, opts.Phone, auth.SendCodeOptions{})
+			if err != nil {
+				return fmt.Errorf("send code: %w", err)
+			}
+			sentCode, ok := sent.(*tg.AuthSentCode)
+			if !ok {
+				return fmt.Errorf("unexpected sent code %T", sent)
+			}
+			hash = sentCode.PhoneCodeHash
+			if opts.SendCodeOnly {
+				fmt.Fprintf(opts.Output, "code_hash=%s\n", hash)
+				return nil
+			}
+			fmt.Fprintln(opts.Output, "code sent, reading it from input")
+			if code, err = readLine(input); err != nil {
+				return fmt.Errorf("read code: %w", err)
+			}
+		} else if hash == "" {
+			return errors.New("code hash is required with code")
+		}
+
+		authorization, err := client.Auth().SignIn(ctx, opts.Phone, code, hash)
+		if errors.Is(err, auth.ErrPasswordAuthNeeded) {
+			password := opts.Password
+			if password == "" {
+				fmt.Fprintln(opts.Output, "2FA password required, reading it from input")
+				if password, err = readLine(input); err != nil {
+					return fmt.Errorf("read password: %w", err)
+				}
+			}
+			authorization, err = client.Auth().Password(ctx, password)
+		}
+		var signUpRequired *auth.SignUpRequired
+		if errors.As(err, &signUpRequired) {
+			return fmt.Errorf("phone %s has no Telegram account", opts.Phone)
+		}
+		if err != nil {
+			return fmt.Errorf("sign in: %w", err)
+		}
+
+		user, _ := authorization.User.AsNotEmpty()
+		if user != nil {
+			fmt.Fprintf(opts.Output, "logged in as user_id=%d\n", user.ID)
+		}
+		return nil
+	})
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line == "" && err != nil {
+		return "", err
+	}
+	return line, nil
+}
+
+// resetStorage hides the stored session so the client starts over; the new session replaces it.
+type resetStorage struct {
+	telegram.SessionStorage
+}
+
+func (s *resetStorage) LoadSession(ctx context.Context) ([]byte, error) {
+	return nil, nil
+}
+
+// Import copies a session file created by the file storage into storage.
+func Import(ctx context.Context, storage telegram.SessionStorage, file io.Reader) error {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return fmt.Errorf("read session file: %w", err)
+	}
+	if len(data) == 0 {
+		return errors.New("session file is empty")
+	}
+	return storage.StoreSession(ctx, data)
+}
@@ -0,0 +1,111 @@
+// Package session keeps userbot MTProto sessions outside the file system and logs accounts in without a terminal.
+package session
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"regexp"
+
+	"ads-mrkt/internal/userbot/config"
+
+	tdsession "github.com/gotd/td/session"
+	"github.com/gotd/td/telegram"
+)
+
+// Store keeps raw session data by key. LoadSession returns nil data when the key has no session.
+type Store interface {
+	LoadSession(ctx context.Context, key string) ([]byte, error)
+	StoreSession(ctx context.Context, key string, data []byte) error
+}
+
+var nonKeyChars = regexp.MustCompile(`[^0-9A-Za-z_]`)
+
+// Key returns the store key of an account's session.
+func Key(phone string) string {
+	return nonKeyChars.ReplaceAllString(phone, "")
+}
+
+// storage is the telegram session storage of one account.
+type storage struct {
+	store Store
+	key   string
+}
+
+var _ tdsession.Storage = (*storage)(nil)
+
+func NewStorage(store Store, key string) *storage {
+	return &storage{store: store, key: key}
+}
+
+func (s *storage) LoadSession(ctx context.Context) ([]byte, error) {
+	data, err := s.store.LoadSession(ctx, s.key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, tdsession.ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *storage) StoreSession(ctx context.Context, data []byte) error {
+	return s.store.StoreSession(ctx, s.key, data)
+}
+
+// encryptedStore seals sessions with AES-256-GCM before they reach the underlying store.
+type encryptedStore struct {
+	store Store
+	aead  cipher.AEAD
+}
+
+// NewEncryptedStore wraps store so it only sees ciphertext; key must be 32 bytes. The session key is bound as
+// additional data, so a row copied under another key fails to open.
+func NewEncryptedStore(store Store, key []byte) (Store, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("session encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("session cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("session cipher: %w", err)
+	}
+	return &encryptedStore{store: store, aead: aead}, nil
+}
+
+func (s *encryptedStore) LoadSession(ctx context.Context, key string) ([]byte, error) {
+	sealed, err := s.store.LoadSession(ctx, key)
+	if err != nil || len(sealed) == 0 {
+		return nil, err
+	}
+	size := s.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, fmt.Errorf("session %s: ciphertext too short", key)
+	}
+	data, err := s.aead.Open(nil, sealed[:size], sealed[size:], []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("session %s: decrypt: %w", key, err)
+	}
+	return data, nil
+}
+
+func (s *encryptedStore) StoreSession(ctx context.Context, key string, data []byte) error {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("session nonce: %w", err)
+	}
+	return s.store.StoreSession(ctx, key, s.aead.Seal(nonce, nonce, data, []byte(key)))
+}
+
+// AccountStorage returns the session storage of a configured account: its session file when store is nil.
+func AccountStorage(store Store, acc config.Account) telegram.SessionStorage {
+	if store == nil {
+		return &telegram.FileSessionStorage{Path: acc.SessionFile}
+	}
+	return NewStorage(store, Key(acc.Phone))
+}
@@ -0,0 +1,57 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	tdsession "github.com/gotd/td/session"
+)
+
+type memStore map[string][]byte
+
+func (m memStore) LoadSession(ctx context.Context, key string) ([]byte, error) {
+	return m[key], nil
+}
+
+func (m memStore) StoreSession(ctx context.Context, key string, data []byte) error {
+	m[key] = data
+	return nil
+}
+
+func TestEncryptedStore(t *testing.T) {
+	ctx := context.Background()
+	raw := memStore{}
+	store, err := NewEncryptedStore(raw, bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := NewStorage(store, Key("+7 999 000-00-01"))
+
+	if _, err := storage.LoadSession(ctx); !errors.Is(err, tdsession.ErrNotFound) {
+		t.Fatalf("empty load err = %v, want ErrNotFound", err)
+	}
+
+	session := []byte(`{"Version":1,"Data":{"AuthKey":"secret"}}`)
+	if err := storage.StoreSession(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	if sealed := raw["79990000001"]; len(sealed) == 0 || bytes.Contains(sealed, []byte("secret")) {
+		t.Fatalf("stored session is not encrypted: %q", sealed)
+	}
+	got, err := storage.LoadSession(ctx)
+	if err != nil || !bytes.Equal(got, session) {
+		t.Fatalf("load = %q, %v", got, err)
+	}
+
+	// A session moved under another key does not open.
+	raw["79990000002"] = raw["79990000001"]
+	if _, err := NewStorage(store, "79990000002").LoadSession(ctx); err == nil {
+		t.Fatal("session opened under another key")
+	}
+
+	if _, err := NewEncryptedStore(raw, []byte("short")); err == nil {
+		t.Fatal("short key accepted")
+	}
+}
@@ -2,9 +2,11 @@ package vault
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -14,7 +16,10 @@ import (
 	"ads-mrkt/internal/vault/config"
 )
 
-const escrowSecretKey = "seed_phrase"
+const (
+	escrowSecretKey         = "seed_phrase"
+	userbotSessionSecretKey = "session"
+)
 
 type Client struct {
 	client    *vaultclient.Client
@@ -85,4 +90,57 @@ func (c *Client) GetEscrowSeed(ctx context.Context, dealID int64) (string, error
 	return raw, nil
 }
 
+func (c *Client) userbotSessionPath(key string) string {
+	return "userbot/session_" + key
+}
+
+// PutUserbotSession stores the MTProto session of a userbot account.
+func (c *Client) PutUserbotSession(ctx context.Context, key string, data []byte) error {
+	path := c.userbotSessionPath(key)
+	_, err := c.client.Secrets.KvV2Write(
+		ctx,
+		path,
+		schema.KvV2WriteRequest{
+			Data: map[string]any{
+				userbotSessionSecretKey: base64.StdEncoding.EncodeToString(data),
+			},
+		},
+		vaultclient.WithMountPath(c.mountPath),
+		vaultclient.WithToken(c.token),
+	)
+	if err != nil {
+		return fmt.Errorf("vault write userbot session path %s: %w", path, err)
+	}
+	return nil
+}
+
+// GetUserbotSession returns the stored MTProto session of a userbot account, or nil when there is none.
+func (c *Client) GetUserbotSession(ctx context.Context, key string) ([]byte, error) {
+	path := c.userbotSessionPath(key)
+	resp, err := c.client.Secrets.KvV2Read(
+		ctx, path,
+		vaultclient.WithMountPath(c.mountPath),
+		vaultclient.WithToken(c.token),
+	)
+	if vaultclient.IsErrorStatus(err, http.StatusNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vault read userbot session path %s: %w", path, err)
+	}
+	if resp == nil || resp.Data.Data == nil {
+		return nil, nil
+	}
+
+	raw, ok := resp.Data.Data[userbotSessionSecretKey].(string)
+	if !ok {
+		return nil, fmt.Errorf("vault: missing or invalid %q at path %s", userbotSessionSecretKey, path)
+	}
+	data, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("vault: decode userbot session path %s: %w", path, err)
+	}
+	return data, nil
+}
+
 var ErrSecretNotFound = errors.New("secret not found")
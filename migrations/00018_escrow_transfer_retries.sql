@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- Terminal status for deals whose escrow release/refund kept failing; an admin retries it or changes the destination.
+ALTER TYPE market.deal_status ADD VALUE 'escrow_transfer_failed';
+
+-- attempt counts consecutive failed locks for a deal action; retry_at is the earliest time the next attempt may start.
+ALTER TABLE market.deal_action_lock ADD COLUMN IF NOT EXISTS attempt INT NOT NULL DEFAULT 1;
+ALTER TABLE market.deal_action_lock ADD COLUMN IF NOT EXISTS retry_at TIMESTAMPTZ;
+ALTER TABLE market.deal_action_lock ADD COLUMN IF NOT EXISTS last_error TEXT;
+
+-- +goose Down
+ALTER TABLE market.deal_action_lock DROP COLUMN IF EXISTS last_error;
+ALTER TABLE market.deal_action_lock DROP COLUMN IF EXISTS retry_at;
+ALTER TABLE market.deal_action_lock DROP COLUMN IF EXISTS attempt;
+-- PostgreSQL does not support removing enum values; leave as-is.
@@ -0,0 +1,16 @@
+-- +goose Up
+
+-- ton_proof metadata of the linked wallet: the wallet key that signed the proof, the dApp domain and time of the proof.
+ALTER TABLE market.user ADD COLUMN IF NOT EXISTS wallet_public_key TEXT;
+ALTER TABLE market.user ADD COLUMN IF NOT EXISTS wallet_proof_domain TEXT;
+ALTER TABLE market.user ADD COLUMN IF NOT EXISTS wallet_proof_timestamp TIMESTAMPTZ;
+ALTER TABLE market.user ADD COLUMN IF NOT EXISTS wallet_verified_at TIMESTAMPTZ;
+
+-- Wallets linked before ton_proof verification keep their address with wallet_verified_at NULL: the owner proves
+-- the wallet on the next wallet link before signing a deal with it.
+
+-- +goose Down
+ALTER TABLE market.user DROP COLUMN IF EXISTS wallet_verified_at;
+ALTER TABLE market.user DROP COLUMN IF EXISTS wallet_proof_timestamp;
+ALTER TABLE market.user DROP COLUMN IF EXISTS wallet_proof_domain;
+ALTER TABLE market.user DROP COLUMN IF EXISTS wallet_public_key;
@@ -0,0 +1,26 @@
+-- +goose Up
+
+-- TON Connect signData signatures of each party over the canonical deal terms document.
+-- deal.lessor_signature / deal.lessee_signature hold the hash of the terms the party signed.
+CREATE TABLE IF NOT EXISTS market.deal_signature (
+    deal_id        BIGINT      NOT NULL,
+    user_id        BIGINT      NOT NULL,
+    terms          TEXT        NOT NULL,
+    terms_hash     TEXT        NOT NULL,
+    wallet_address TEXT        NOT NULL,
+    public_key     TEXT        NOT NULL,
+    domain         TEXT        NOT NULL,
+    signed_at      TIMESTAMPTZ NOT NULL,
+    signature      TEXT        NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (deal_id, user_id),
+    FOREIGN KEY (deal_id) REFERENCES market.deal(id)
+);
+
+-- Server-side hashes on drafts prove nothing; parties re-sign with their wallets.
+UPDATE market.deal
+SET lessor_signature = NULL, lessee_signature = NULL
+WHERE status = 'draft' AND (lessor_signature IS NOT NULL OR lessee_signature IS NOT NULL);
+
+-- +goose Down
+DROP TABLE IF EXISTS market.deal_signature;
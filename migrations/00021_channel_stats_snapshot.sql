@@ -0,0 +1,23 @@
+-- +goose Up
+
+-- Every fetch of the channel broadcast stats; channel_stats keeps only the latest one.
+CREATE TABLE IF NOT EXISTS market.channel_stats_snapshot (
+    id                            BIGSERIAL        NOT NULL,
+    channel_id                    BIGINT           NOT NULL,
+    fetched_at                    TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+    followers                     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    views_per_post                DOUBLE PRECISION NOT NULL DEFAULT 0,
+    shares_per_post               DOUBLE PRECISION NOT NULL DEFAULT 0,
+    reactions_per_post            DOUBLE PRECISION NOT NULL DEFAULT 0,
+    enabled_notifications_percent DOUBLE PRECISION NOT NULL DEFAULT 0,
+    stats                         JSONB            NOT NULL DEFAULT '{}',
+    PRIMARY KEY (id),
+    FOREIGN KEY (channel_id) REFERENCES market.channel(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_stats_snapshot_channel_fetched_at
+ON market.channel_stats_snapshot (channel_id, fetched_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS market.idx_channel_stats_snapshot_channel_fetched_at;
+DROP TABLE IF EXISTS market.channel_stats_snapshot;
@@ -0,0 +1,23 @@
+-- +goose Up
+
+-- Quality metrics derived from the latest stats (see domain.ComputeChannelQuality). NULL until the next stats refresh.
+ALTER TABLE market.channel_stats ADD COLUMN IF NOT EXISTS quality_score DOUBLE PRECISION;
+ALTER TABLE market.channel_stats ADD COLUMN IF NOT EXISTS err_percent DOUBLE PRECISION;
+ALTER TABLE market.channel_stats ADD COLUMN IF NOT EXISTS avg_reach DOUBLE PRECISION;
+ALTER TABLE market.channel_stats ADD COLUMN IF NOT EXISTS shares_ratio_percent DOUBLE PRECISION;
+ALTER TABLE market.channel_stats ADD COLUMN IF NOT EXISTS reactions_ratio_percent DOUBLE PRECISION;
+ALTER TABLE market.channel_stats ADD COLUMN IF NOT EXISTS notifications_enabled_percent DOUBLE PRECISION;
+ALTER TABLE market.channel_stats ADD COLUMN IF NOT EXISTS max_daily_growth_percent DOUBLE PRECISION;
+ALTER TABLE market.channel_stats ADD COLUMN IF NOT EXISTS suspicious BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE market.channel_stats ADD COLUMN IF NOT EXISTS quality_flags TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE market.channel_stats DROP COLUMN IF EXISTS quality_flags;
+ALTER TABLE market.channel_stats DROP COLUMN IF EXISTS suspicious;
+ALTER TABLE market.channel_stats DROP COLUMN IF EXISTS max_daily_growth_percent;
+ALTER TABLE market.channel_stats DROP COLUMN IF EXISTS notifications_enabled_percent;
+ALTER TABLE market.channel_stats DROP COLUMN IF EXISTS reactions_ratio_percent;
+ALTER TABLE market.channel_stats DROP COLUMN IF EXISTS shares_ratio_percent;
+ALTER TABLE market.channel_stats DROP COLUMN IF EXISTS avg_reach;
+ALTER TABLE market.channel_stats DROP COLUMN IF EXISTS err_percent;
+ALTER TABLE market.channel_stats DROP COLUMN IF EXISTS quality_score;
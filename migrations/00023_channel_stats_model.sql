@@ -0,0 +1,7 @@
+-- +goose Up
+
+-- Typed channel stats (entity.ChannelStats) built by the userbot. NULL until the next stats refresh.
+ALTER TABLE market.channel_stats ADD COLUMN IF NOT EXISTS stats_model JSONB;
+
+-- +goose Down
+ALTER TABLE market.channel_stats DROP COLUMN IF EXISTS stats_model;
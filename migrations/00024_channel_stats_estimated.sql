@@ -0,0 +1,7 @@
+-- +goose Up
+
+-- Snapshots approximated from recent posts for channels Telegram gives no broadcast stats for.
+ALTER TABLE market.channel_stats_snapshot ADD COLUMN IF NOT EXISTS estimated BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE market.channel_stats_snapshot DROP COLUMN IF EXISTS estimated;
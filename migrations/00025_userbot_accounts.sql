@@ -0,0 +1,50 @@
+-- +goose Up
+
+-- Telegram accounts of the userbot pool; user_id matches userbot.telegram_state.
+CREATE TABLE IF NOT EXISTS userbot.account (
+    user_id       BIGINT      NOT NULL,
+    phone         TEXT        NOT NULL DEFAULT '',
+    status        TEXT        NOT NULL DEFAULT 'active',
+    limited_until TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id)
+);
+
+-- Channels each account is a member of, with the account's own access hash; the assigned account does the channel's work.
+CREATE TABLE IF NOT EXISTS userbot.channel_account (
+    channel_id  BIGINT      NOT NULL,
+    account_id  BIGINT      NOT NULL,
+    access_hash BIGINT      NOT NULL,
+    can_post    BOOLEAN     NOT NULL DEFAULT FALSE,
+    assigned    BOOLEAN     NOT NULL DEFAULT FALSE,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (channel_id, account_id),
+    FOREIGN KEY (account_id) REFERENCES userbot.account(user_id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_channel_account_assigned
+ON userbot.channel_account (channel_id) WHERE assigned;
+
+CREATE INDEX IF NOT EXISTS idx_channel_account_account_id
+ON userbot.channel_account (account_id);
+
+-- Single-account deployments: the one account already administers every known channel.
+INSERT INTO userbot.account (user_id)
+SELECT user_id FROM userbot.telegram_state
+WHERE (SELECT COUNT(*) FROM userbot.telegram_state) = 1
+ON CONFLICT (user_id) DO NOTHING;
+
+INSERT INTO userbot.channel_account (channel_id, account_id, access_hash, can_post, assigned)
+SELECT c.id, a.user_id, c.access_hash,
+       COALESCE((c.admin_rights->>'post_messages')::BOOLEAN, FALSE) AND COALESCE((c.admin_rights->>'delete_messages')::BOOLEAN, FALSE),
+       TRUE
+FROM market.channel c
+CROSS JOIN userbot.account a
+ON CONFLICT (channel_id, account_id) DO NOTHING;
+
+-- +goose Down
+DROP INDEX IF EXISTS userbot.idx_channel_account_account_id;
+DROP INDEX IF EXISTS userbot.uq_channel_account_assigned;
+DROP TABLE IF EXISTS userbot.channel_account;
+DROP TABLE IF EXISTS userbot.account;
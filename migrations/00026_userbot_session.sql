@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- MTProto sessions of userbot accounts, sealed with USER_BOT_SESSION_ENCRYPTION_KEY.
+CREATE TABLE IF NOT EXISTS userbot.session (
+    key        TEXT        NOT NULL,
+    data       BYTEA       NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (key)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS userbot.session;
@@ -0,0 +1,18 @@
+-- +goose Up
+
+-- Events written in the transaction of the state change they announce, published to Redis streams by the relay.
+CREATE TABLE IF NOT EXISTS market.outbox (
+    id         BIGSERIAL   NOT NULL,
+    stream     TEXT        NOT NULL,
+    payload    JSONB       NOT NULL,
+    attempts   INT         NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at    TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unsent_idx ON market.outbox (id) WHERE sent_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS market.outbox;
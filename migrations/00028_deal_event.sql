@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- Append-only history of deal status transitions, written in the statement that changes market.deal.status.
+CREATE TABLE IF NOT EXISTS market.deal_event (
+    id            BIGSERIAL          NOT NULL,
+    deal_id       BIGINT             NOT NULL REFERENCES market.deal (id),
+    from_status   market.deal_status,
+    to_status     market.deal_status NOT NULL,
+    actor_type    TEXT               NOT NULL,
+    actor_user_id BIGINT,
+    actor_name    TEXT               NOT NULL DEFAULT '',
+    reason        TEXT               NOT NULL DEFAULT '',
+    tx_hash       TEXT,
+    message_id    BIGINT,
+    created_at    TIMESTAMPTZ        NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS deal_event_deal_id_idx ON market.deal_event (deal_id, id);
+
+-- +goose Down
+DROP TABLE IF EXISTS market.deal_event;
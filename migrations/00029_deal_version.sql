@@ -0,0 +1,7 @@
+-- +goose Up
+
+-- Optimistic concurrency for deal status transitions: every transition checks and bumps the version it read.
+ALTER TABLE market.deal ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE market.deal DROP COLUMN IF EXISTS version;
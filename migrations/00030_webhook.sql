@@ -0,0 +1,38 @@
+-- +goose Up
+
+-- HTTPS endpoints users register to receive signed deal lifecycle events.
+CREATE TABLE IF NOT EXISTS market.webhook (
+    id         BIGSERIAL   NOT NULL,
+    user_id    BIGINT      NOT NULL,
+    url        TEXT        NOT NULL,
+    secret     TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_user_id_idx ON market.webhook (user_id);
+
+-- Delivery log: one row per event and webhook, queued in the statement of the deal transition it announces.
+CREATE TABLE IF NOT EXISTS market.webhook_delivery (
+    id               BIGSERIAL   NOT NULL,
+    webhook_id       BIGINT      NOT NULL REFERENCES market.webhook (id) ON DELETE CASCADE,
+    deal_id          BIGINT      REFERENCES market.deal (id),
+    event            TEXT        NOT NULL,
+    payload          JSONB       NOT NULL,
+    status           TEXT        NOT NULL DEFAULT 'pending',
+    attempts         INT         NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_status_code INT,
+    last_error       TEXT,
+    delivered_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_webhook_id_idx ON market.webhook_delivery (webhook_id, id);
+CREATE INDEX IF NOT EXISTS webhook_delivery_pending_idx ON market.webhook_delivery (next_attempt_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS market.webhook_delivery;
+DROP TABLE IF EXISTS market.webhook;
@@ -0,0 +1,21 @@
+-- +goose Up
+
+-- API keys users create for scripts; only the SHA-256 of a key is stored.
+CREATE TABLE IF NOT EXISTS market.api_key (
+    id                    BIGSERIAL   NOT NULL,
+    user_id               BIGINT      NOT NULL,
+    name                  TEXT        NOT NULL,
+    prefix                TEXT        NOT NULL,
+    key_hash              TEXT        NOT NULL,
+    scopes                TEXT[]      NOT NULL,
+    rate_limit_per_minute INT         NOT NULL,
+    last_used_at          TIMESTAMPTZ,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id),
+    UNIQUE (key_hash)
+);
+
+CREATE INDEX IF NOT EXISTS api_key_user_id_idx ON market.api_key (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS market.api_key;
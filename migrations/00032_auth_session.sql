@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- Login sessions: each holds the hash of its current refresh token, rotated on every refresh, and of the previous
+-- one, to detect a stolen refresh token being reused.
+CREATE TABLE IF NOT EXISTS market.auth_session (
+    id                          BIGSERIAL   NOT NULL,
+    user_id                     BIGINT      NOT NULL,
+    refresh_token_hash          TEXT        NOT NULL,
+    previous_refresh_token_hash TEXT,
+    expires_at                  TIMESTAMPTZ NOT NULL,
+    revoked_at                  TIMESTAMPTZ,
+    created_at                  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    refreshed_at                TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    UNIQUE (refresh_token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS auth_session_previous_refresh_token_hash_idx ON market.auth_session (previous_refresh_token_hash);
+CREATE INDEX IF NOT EXISTS auth_session_user_id_idx ON market.auth_session (user_id) WHERE revoked_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS market.auth_session;
@@ -0,0 +1,39 @@
+-- +goose Up
+
+ALTER TYPE market.user_role ADD VALUE IF NOT EXISTS 'support';
+ALTER TYPE market.user_role ADD VALUE IF NOT EXISTS 'moderator';
+ALTER TYPE market.user_role ADD VALUE IF NOT EXISTS 'finance';
+
+-- Listings taken down by moderation; their owners cannot reactivate them.
+ALTER TYPE market.listing_status ADD VALUE IF NOT EXISTS 'blocked';
+
+ALTER TABLE market.user
+    ADD COLUMN IF NOT EXISTS banned_at  TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS banned_by  BIGINT,
+    ADD COLUMN IF NOT EXISTS ban_reason TEXT;
+
+-- Every change made through the admin console, written in the transaction of the change.
+CREATE TABLE IF NOT EXISTS market.admin_audit_log (
+    id          BIGSERIAL   NOT NULL,
+    admin_id    BIGINT      NOT NULL,
+    admin_role  TEXT        NOT NULL,
+    action      TEXT        NOT NULL,
+    target_type TEXT        NOT NULL,
+    target_id   BIGINT,
+    reason      TEXT        NOT NULL DEFAULT '',
+    details     JSONB,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS admin_audit_log_admin_id_idx ON market.admin_audit_log (admin_id, id DESC);
+CREATE INDEX IF NOT EXISTS admin_audit_log_target_idx ON market.admin_audit_log (target_type, target_id, id DESC);
+
+-- +goose Down
+-- PostgreSQL does not support removing enum values; the roles and the listing status stay.
+DROP TABLE IF EXISTS market.admin_audit_log;
+
+ALTER TABLE market.user
+    DROP COLUMN IF EXISTS ban_reason,
+    DROP COLUMN IF EXISTS banned_by,
+    DROP COLUMN IF EXISTS banned_at;
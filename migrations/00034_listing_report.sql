@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- Complaints of users about listings; open ones make up the moderation queue.
+CREATE TABLE IF NOT EXISTS market.listing_report (
+    id          BIGSERIAL   NOT NULL,
+    listing_id  BIGINT      NOT NULL REFERENCES market.listing (id) ON DELETE CASCADE,
+    reporter_id BIGINT      NOT NULL,
+    reason      TEXT        NOT NULL,
+    comment     TEXT        NOT NULL DEFAULT '',
+    status      TEXT        NOT NULL DEFAULT 'open',
+    resolved_by BIGINT,
+    resolved_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id)
+);
+
+-- One open report per user and listing.
+CREATE UNIQUE INDEX IF NOT EXISTS listing_report_open_uniq ON market.listing_report (listing_id, reporter_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS listing_report_open_idx ON market.listing_report (listing_id) WHERE status = 'open';
+
+-- +goose Down
+DROP TABLE IF EXISTS market.listing_report;
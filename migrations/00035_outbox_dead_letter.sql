@@ -0,0 +1,16 @@
+-- +goose Up
+
+-- Backoff of a message whose publish failed, and the time the relay gave up on it. Failed messages are kept for
+-- inspection and no longer hold back the messages after them.
+ALTER TABLE market.outbox ADD COLUMN IF NOT EXISTS retry_at TIMESTAMPTZ;
+ALTER TABLE market.outbox ADD COLUMN IF NOT EXISTS failed_at TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS market.outbox_unsent_idx;
+CREATE INDEX IF NOT EXISTS outbox_unsent_idx ON market.outbox (id) WHERE sent_at IS NULL AND failed_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS market.outbox_unsent_idx;
+CREATE INDEX IF NOT EXISTS outbox_unsent_idx ON market.outbox (id) WHERE sent_at IS NULL;
+
+ALTER TABLE market.outbox DROP COLUMN IF EXISTS failed_at;
+ALTER TABLE market.outbox DROP COLUMN IF EXISTS retry_at;
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"ads-mrkt/pkg/auth/scope"
+)
+
+// APIKeyHeader carries an API key, as an alternative to a Bearer JWT.
+const APIKeyHeader = "X-API-Key"
+
+// ErrAPIKeyRateLimited is returned by an APIKeyAuthenticator when the key made too many requests.
+var ErrAPIKeyRateLimited = errors.New("api key rate limit exceeded")
+
+// APIKeyPrincipal is the user an API key acts for and what the key may do.
+type APIKeyPrincipal struct {
+	KeyID      int64
+	TelegramID int64
+	Scopes     []scope.Scope
+}
+
+// APIKeyAuthenticator resolves an API key, counting the request against the key's rate limit. It returns a nil
+// principal for an unknown key and ErrAPIKeyRateLimited when the key is over its limit.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error)
+}
+
+type APIKeyIDContextKey struct{}
+type requiredScopeContextKey struct{}
+
+var (
+	// apiKeyIDKey is the context key for storing the ID of the API key a request was authenticated with
+	apiKeyIDKey        = APIKeyIDContextKey{}
+	requiredScopeKey   = requiredScopeContextKey{}
+	errScopeNotGranted = errors.New("api key scope not granted")
+)
+
+// WithScope lets API keys with the scope call next. Wrap WithAuth with it: WithAuth refuses API keys on endpoints
+// that do not name a scope. Requests authenticated with a JWT are not affected.
+func WithScope(next http.HandlerFunc, s scope.Scope) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requiredScopeKey, s)))
+	})
+}
+
+// checkScope returns nil if the endpoint of the request names a scope granted to the key.
+func checkScope(ctx context.Context, principal *APIKeyPrincipal) error {
+	required, ok := ctx.Value(requiredScopeKey).(scope.Scope)
+	if !ok || !slices.Contains(principal.Scopes, required) {
+		return errScopeNotGranted
+	}
+	return nil
+}
+
+// GetAPIKeyID extracts the ID of the API key the request was authenticated with, if any
+func GetAPIKeyID(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(apiKeyIDKey).(int64)
+	return id, ok
+}
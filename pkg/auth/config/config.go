@@ -1,6 +1,12 @@
 package config
 
+import "time"
+
 type Config struct {
-	JWTSecret     string `env:"JWT_SECRET" env-required:"true"`
-	JWTTimeToLive int    `env:"JWT_TIME_TO_LIVE" env-default:"24"` // hours
+	JWTSecret       string        `env:"JWT_SECRET" env-required:"true"`
+	JWTKeyID        string        `env:"JWT_KEY_ID" env-default:"1"` // kid of JWT_SECRET in issued tokens
+	JWTPreviousKeys string        `env:"JWT_PREVIOUS_KEYS"`          // "kid:secret,..." of retired keys whose tokens are still accepted
+	AccessTokenTTL  time.Duration `env:"JWT_ACCESS_TOKEN_TTL" env-default:"15m"`
+	RefreshTokenTTL time.Duration `env:"JWT_REFRESH_TOKEN_TTL" env-default:"720h"` // sliding: every refresh extends the session
+	RoleCacheTTL    time.Duration `env:"AUTH_ROLE_CACHE_TTL" env-default:"30s"`    // how long admin routes trust a role read from the database
 }
@@ -3,6 +3,7 @@ package auth
 import (
 	"ads-mrkt/pkg/auth/role"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,28 +13,67 @@ import (
 type Claims struct {
 	TelegramID int64     `json:"telegram_id"`
 	Role       role.Role `json:"role"`
+	SessionID  int64     `json:"sid"` // server-side session the token was issued for
 	jwt.RegisteredClaims
 }
 
+// SigningKey is an HMAC key with the ID put in the kid header of the tokens it signs
+type SigningKey struct {
+	ID     string
+	Secret string
+}
+
+// ParseSigningKeys parses keys given as "kid:secret,kid:secret"; an empty string yields no keys
+func ParseSigningKeys(s string) ([]SigningKey, error) {
+	var keys []SigningKey
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(part, ":")
+		if !ok || id == "" || secret == "" {
+			return nil, fmt.Errorf("invalid signing key: want kid:secret")
+		}
+		keys = append(keys, SigningKey{ID: id, Secret: secret})
+	}
+	return keys, nil
+}
+
 // JWTManager handles JWT token operations
 type JWTManager struct {
-	secretKey     []byte
+	currentKeyID  string
+	keys          map[string][]byte
 	tokenDuration time.Duration
 }
 
-// NewJWTManager creates a new instance of JWTManager
-func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
+// NewJWTManager creates a new instance of JWTManager. Tokens are signed with current; tokens signed with any of
+// previous are still accepted, so keys can be rotated without logging users out.
+func NewJWTManager(current SigningKey, previous []SigningKey, tokenDuration time.Duration) *JWTManager {
+	keys := map[string][]byte{current.ID: []byte(current.Secret)}
+	for _, k := range previous {
+		if k.ID != current.ID {
+			keys[k.ID] = []byte(k.Secret)
+		}
+	}
 	return &JWTManager{
-		secretKey:     []byte(secretKey),
+		currentKeyID:  current.ID,
+		keys:          keys,
 		tokenDuration: tokenDuration,
 	}
 }
 
-// GenerateToken creates a new JWT token for a given Telegram user ID
-func (m *JWTManager) GenerateToken(telegramID int64, role role.Role) (string, error) {
+// TokenDuration returns the lifetime of issued tokens
+func (m *JWTManager) TokenDuration() time.Duration {
+	return m.tokenDuration
+}
+
+// GenerateToken creates a new JWT token for a given Telegram user ID and session
+func (m *JWTManager) GenerateToken(telegramID int64, role role.Role, sessionID int64) (string, error) {
 	claims := Claims{
 		TelegramID: telegramID,
 		Role:       role,
+		SessionID:  sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.tokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -42,7 +82,8 @@ func (m *JWTManager) GenerateToken(telegramID int64, role role.Role) (string, er
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.secretKey)
+	token.Header["kid"] = m.currentKeyID
+	return token.SignedString(m.keys[m.currentKeyID])
 }
 
 // ValidateToken verifies and parses the JWT token
@@ -56,7 +97,12 @@ func (m *JWTManager) ValidateToken(tokenStr string) (*Claims, error) {
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 				return nil, fmt.Errorf("unexpected token signing method: %v", token.Header["alg"])
 			}
-			return m.secretKey, nil
+			kid, _ := token.Header["kid"].(string)
+			key, ok := m.keys[kid]
+			if !ok {
+				return nil, fmt.Errorf("unknown signing key %q", kid)
+			}
+			return key, nil
 		},
 	)
 
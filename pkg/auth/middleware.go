@@ -3,6 +3,8 @@ package auth
 import (
 	"ads-mrkt/pkg/auth/role"
 	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 	"slices"
 	"strings"
@@ -10,29 +12,51 @@ import (
 
 type TelegramIDContextKey struct{}
 type RoleContextKey struct{}
+type SessionIDContextKey struct{}
 
 var (
 	// TelegramIDKey is the context key for storing the Telegram user ID
 	telegramIDKey = TelegramIDContextKey{}
 	// RoleKey is the context key for storing the user role
 	roleKey = RoleContextKey{}
+	// sessionIDKey is the context key for storing the session of the access token
+	sessionIDKey = SessionIDContextKey{}
 )
 
-// AuthMiddleware handles JWT authentication for HTTP requests
+// SessionChecker tells what a valid access token cannot: whether its session was revoked since it was issued, the
+// current role of the user and whether the user is banned.
+type SessionChecker interface {
+	IsSessionRevoked(ctx context.Context, sessionID int64) (bool, error)
+	CurrentRole(ctx context.Context, telegramID int64) (role.Role, error)
+	IsUserBanned(ctx context.Context, telegramID int64) (bool, error)
+}
+
+// AuthMiddleware handles JWT and API key authentication for HTTP requests
 type AuthMiddleware struct {
 	jwtManager *JWTManager
+	sessions   SessionChecker
+	apiKeys    APIKeyAuthenticator
 }
 
-// NewAuthMiddleware creates a new instance of AuthMiddleware
-func NewAuthMiddleware(jwtManager *JWTManager) *AuthMiddleware {
+// NewAuthMiddleware creates a new instance of AuthMiddleware; API keys are refused when apiKeys is nil
+func NewAuthMiddleware(jwtManager *JWTManager, sessions SessionChecker, apiKeys APIKeyAuthenticator) *AuthMiddleware {
 	return &AuthMiddleware{
 		jwtManager: jwtManager,
+		sessions:   sessions,
+		apiKeys:    apiKeys,
 	}
 }
 
-// WithAuth Middleware function to handle JWT authentication
+// WithAuth Middleware function to handle JWT authentication, or API key authentication on endpoints wrapped
+// in WithScope. Tokens of revoked sessions and requests of banned users are refused; allowedRoles are checked
+// against the current role of the user rather than the role in the token.
 func (m *AuthMiddleware) WithAuth(next http.HandlerFunc, allowedRoles ...role.Role) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(APIKeyHeader); key != "" {
+			m.withAPIKey(w, r, key, next, allowedRoles)
+			return
+		}
+
 		// Extract token from Authorization header
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -48,31 +72,100 @@ func (m *AuthMiddleware) WithAuth(next http.HandlerFunc, allowedRoles ...role.Ro
 		}
 
 		// Extract and validate token
-		tokenStr := parts[1]
-		telegramID, err := m.jwtManager.ExtractTelegramID(tokenStr)
-		if err != nil {
+		claims, err := m.jwtManager.ValidateToken(parts[1])
+		if err != nil || m.jwtManager.IsExpired(claims) || claims.SessionID == 0 {
 			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
 			return
 		}
 
-		role, err := m.jwtManager.ExtractRole(tokenStr)
+		revoked, err := m.sessions.IsSessionRevoked(r.Context(), claims.SessionID)
 		if err != nil {
+			slog.Error("check session revocation", "session_id", claims.SessionID, "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		if revoked {
 			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
 			return
 		}
-
-		if len(allowedRoles) > 0 && !slices.Contains(allowedRoles, role) {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		if m.refuseBanned(w, r, claims.TelegramID) {
 			return
 		}
 
+		userRole := claims.Role
+		if len(allowedRoles) > 0 {
+			if userRole, err = m.sessions.CurrentRole(r.Context(), claims.TelegramID); err != nil {
+				slog.Error("get current user role", "telegram_id", claims.TelegramID, "error", err)
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+				return
+			}
+			if !slices.Contains(allowedRoles, userRole) {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
 		// Add telegram ID to request context
-		ctx := context.WithValue(r.Context(), telegramIDKey, telegramID)
-		ctx = context.WithValue(ctx, roleKey, role)
+		ctx := context.WithValue(r.Context(), telegramIDKey, claims.TelegramID)
+		ctx = context.WithValue(ctx, roleKey, userRole)
+		ctx = context.WithValue(ctx, sessionIDKey, claims.SessionID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// withAPIKey authenticates the request with an API key. Keys act for their user with the user role.
+func (m *AuthMiddleware) withAPIKey(w http.ResponseWriter, r *http.Request, key string, next http.HandlerFunc, allowedRoles []role.Role) {
+	if m.apiKeys == nil {
+		http.Error(w, "API keys are not accepted", http.StatusUnauthorized)
+		return
+	}
+	principal, err := m.apiKeys.AuthenticateAPIKey(r.Context(), key)
+	switch {
+	case errors.Is(err, ErrAPIKeyRateLimited):
+		http.Error(w, "API key rate limit exceeded", http.StatusTooManyRequests)
+		return
+	case err != nil:
+		slog.Error("authenticate api key", "error", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	case principal == nil:
+		http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		return
+	}
+
+	if len(allowedRoles) > 0 && !slices.Contains(allowedRoles, role.UserRole) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if m.refuseBanned(w, r, principal.TelegramID) {
+		return
+	}
+	if err := checkScope(r.Context(), principal); err != nil {
+		http.Error(w, "API key is not allowed to call this endpoint", http.StatusForbidden)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), telegramIDKey, principal.TelegramID)
+	ctx = context.WithValue(ctx, roleKey, role.UserRole)
+	ctx = context.WithValue(ctx, apiKeyIDKey, principal.KeyID)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// refuseBanned answers the request when the user is banned or the ban cannot be checked, and reports whether it did.
+func (m *AuthMiddleware) refuseBanned(w http.ResponseWriter, r *http.Request, telegramID int64) bool {
+	banned, err := m.sessions.IsUserBanned(r.Context(), telegramID)
+	if err != nil {
+		slog.Error("check user ban", "telegram_id", telegramID, "error", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return true
+	}
+	if banned {
+		http.Error(w, "Account is banned", http.StatusForbidden)
+		return true
+	}
+	return false
+}
+
 // GetTelegramID extracts the Telegram ID from the context
 func GetTelegramID(ctx context.Context) (int64, bool) {
 	id, ok := ctx.Value(telegramIDKey).(int64)
@@ -84,3 +177,9 @@ func GetRole(ctx context.Context) (role.Role, bool) {
 	role, ok := ctx.Value(roleKey).(role.Role)
 	return role, ok
 }
+
+// GetSessionID extracts the session of the access token from the context; requests made with API keys have none
+func GetSessionID(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(sessionIDKey).(int64)
+	return id, ok
+}
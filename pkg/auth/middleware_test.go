@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ads-mrkt/pkg/auth/role"
+	"ads-mrkt/pkg/auth/scope"
+)
+
+type apiKeys map[string]*APIKeyPrincipal
+
+func (k apiKeys) AuthenticateAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error) {
+	if key == "limited" {
+		return nil, ErrAPIKeyRateLimited
+	}
+	return k[key], nil
+}
+
+// sessions has revoked session IDs, the current roles of users and banned users.
+type sessions struct {
+	revoked map[int64]bool
+	roles   map[int64]role.Role
+	banned  map[int64]bool
+}
+
+func (s sessions) IsSessionRevoked(ctx context.Context, sessionID int64) (bool, error) {
+	return s.revoked[sessionID], nil
+}
+
+func (s sessions) CurrentRole(ctx context.Context, telegramID int64) (role.Role, error) {
+	return s.roles[telegramID], nil
+}
+
+func (s sessions) IsUserBanned(ctx context.Context, telegramID int64) (bool, error) {
+	return s.banned[telegramID], nil
+}
+
+func serve(handler http.HandlerFunc, header, value string) int {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(header, value)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec.Code
+}
+
+func TestWithAuthAPIKeys(t *testing.T) {
+	jwtManager := NewJWTManager(SigningKey{ID: "1", Secret: "secret"}, nil, time.Hour)
+	m := NewAuthMiddleware(jwtManager, sessions{banned: map[int64]bool{44: true}}, apiKeys{
+		"deals":  {KeyID: 3, TelegramID: 42, Scopes: []scope.Scope{scope.DealsWrite}},
+		"banned": {KeyID: 4, TelegramID: 44, Scopes: []scope.Scope{scope.DealsWrite}},
+	})
+	token, err := jwtManager.GenerateToken(42, role.UserRole, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		if id, _ := GetTelegramID(r.Context()); id != 42 {
+			t.Errorf("telegram id = %d", id)
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		handler http.HandlerFunc
+		header  string
+		value   string
+		want    int
+	}{
+		"key with scope":       {WithScope(m.WithAuth(ok), scope.DealsWrite), APIKeyHeader, "deals", http.StatusOK},
+		"key without scope":    {WithScope(m.WithAuth(ok), scope.ListingsWrite), APIKeyHeader, "deals", http.StatusForbidden},
+		"key on unscoped path": {m.WithAuth(ok), APIKeyHeader, "deals", http.StatusForbidden},
+		"key on admin path":    {WithScope(m.WithAuth(ok, role.AdminRole), scope.DealsWrite), APIKeyHeader, "deals", http.StatusUnauthorized},
+		"unknown key":          {WithScope(m.WithAuth(ok), scope.DealsWrite), APIKeyHeader, "nope", http.StatusUnauthorized},
+		"rate limited key":     {WithScope(m.WithAuth(ok), scope.DealsWrite), APIKeyHeader, "limited", http.StatusTooManyRequests},
+		"key of banned user":   {WithScope(m.WithAuth(ok), scope.DealsWrite), APIKeyHeader, "banned", http.StatusForbidden},
+		"jwt on unscoped path": {m.WithAuth(ok), "Authorization", "Bearer " + token, http.StatusOK},
+		"jwt on scoped path":   {WithScope(m.WithAuth(ok), scope.ListingsWrite), "Authorization", "Bearer " + token, http.StatusOK},
+	} {
+		if code := serve(tc.handler, tc.header, tc.value); code != tc.want {
+			t.Errorf("%s: status = %d, want %d", name, code, tc.want)
+		}
+	}
+}
+
+func TestWithAuthSessions(t *testing.T) {
+	old := SigningKey{ID: "2025", Secret: "old secret"}
+	oldManager := NewJWTManager(old, nil, time.Hour)
+	jwtManager := NewJWTManager(SigningKey{ID: "2026", Secret: "new secret"}, []SigningKey{old}, time.Hour)
+	m := NewAuthMiddleware(jwtManager, sessions{
+		revoked: map[int64]bool{2: true},
+		roles:   map[int64]role.Role{42: role.UserRole, 43: role.AdminRole},
+		banned:  map[int64]bool{44: true},
+	}, nil)
+	token := func(manager *JWTManager, telegramID int64, r role.Role, sessionID int64) string {
+		tok, err := manager.GenerateToken(telegramID, r, sessionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + tok
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	unknownKey := NewJWTManager(SigningKey{ID: "other", Secret: "new secret"}, nil, time.Hour)
+
+	for name, tc := range map[string]struct {
+		handler http.HandlerFunc
+		token   string
+		want    int
+	}{
+		"current key":           {m.WithAuth(ok), token(jwtManager, 42, role.UserRole, 1), http.StatusOK},
+		"previous key":          {m.WithAuth(ok), token(oldManager, 42, role.UserRole, 1), http.StatusOK},
+		"unknown key":           {m.WithAuth(ok), token(unknownKey, 42, role.UserRole, 1), http.StatusUnauthorized},
+		"revoked session":       {m.WithAuth(ok), token(jwtManager, 42, role.UserRole, 2), http.StatusUnauthorized},
+		"no session":            {m.WithAuth(ok), token(jwtManager, 42, role.UserRole, 0), http.StatusUnauthorized},
+		"admin":                 {m.WithAuth(ok, role.AdminRole), token(jwtManager, 43, role.AdminRole, 1), http.StatusOK},
+		"admin claim, now user": {m.WithAuth(ok, role.AdminRole), token(jwtManager, 42, role.AdminRole, 1), http.StatusUnauthorized},
+		"user claim, now admin": {m.WithAuth(ok, role.AdminRole), token(jwtManager, 43, role.UserRole, 1), http.StatusOK},
+		"banned user":           {m.WithAuth(ok), token(jwtManager, 44, role.UserRole, 1), http.StatusForbidden},
+	} {
+		if code := serve(tc.handler, "Authorization", tc.token); code != tc.want {
+			t.Errorf("%s: status = %d, want %d", name, code, tc.want)
+		}
+	}
+}
+
+func TestParseSigningKeys(t *testing.T) {
+	keys, err := ParseSigningKeys(" a:1, b:x:y ,")
+	if err != nil || len(keys) != 2 || keys[0] != (SigningKey{ID: "a", Secret: "1"}) || keys[1] != (SigningKey{ID: "b", Secret: "x:y"}) {
+		t.Fatalf("keys = %+v, %v", keys, err)
+	}
+	if _, err := ParseSigningKeys("nokid"); err == nil {
+		t.Fatal("key without kid accepted")
+	}
+}
@@ -3,17 +3,29 @@ package role
 type Role string
 
 const (
-	UserRole  Role = "user"
-	AdminRole Role = "admin"
-	EmptyRole Role = ""
+	UserRole      Role = "user"
+	AdminRole     Role = "admin"
+	SupportRole   Role = "support"   // looks up users, deals and listings to answer them
+	ModeratorRole Role = "moderator" // blocks listings and bans users
+	FinanceRole   Role = "finance"   // runs escrow reconciliation and manual escrow transfers
+	EmptyRole     Role = ""
 )
 
+// Staff are the roles that can use the admin console.
+var Staff = []Role{AdminRole, SupportRole, ModeratorRole, FinanceRole}
+
 func FromString(s string) Role {
 	switch s {
 	case "user":
 		return UserRole
 	case "admin":
 		return AdminRole
+	case "support":
+		return SupportRole
+	case "moderator":
+		return ModeratorRole
+	case "finance":
+		return FinanceRole
 	default:
 		return EmptyRole
 	}
@@ -0,0 +1,26 @@
+package scope
+
+// Scope is a permission granted to an API key. Requests authenticated with a JWT are not limited by scopes.
+type Scope string
+
+const (
+	ListingsRead  Scope = "listings:read"  // read own listings
+	ListingsWrite Scope = "listings:write" // create, update and delete own listings
+	DealsWrite    Scope = "deals:write"    // read, negotiate, sign and reject own deals
+)
+
+// All lists every scope an API key may be given.
+var All = []Scope{ListingsRead, ListingsWrite, DealsWrite}
+
+func FromString(s string) (Scope, bool) {
+	for _, sc := range All {
+		if string(sc) == s {
+			return sc, true
+		}
+	}
+	return "", false
+}
+
+func (s Scope) String() string {
+	return string(s)
+}
@@ -8,6 +8,7 @@ import { useTonAddress, useTonWallet, useTonConnectUI } from '@tonconnect/ui-rea
 import { api, ensureValidToken } from '@/lib/api';
 import { useTelegramBackButton, openTelegramLink } from '@/lib/telegram';
 import { getTelegramUser } from '@/lib/initData';
+import { prepareTonProof, linkWalletWithProof } from '@/lib/tonProof';
 import { formatPriceKey, formatPriceValue, parseListingPrices, formatPriceEntry } from '@/lib/formatPrice';
 import { toFriendlyAddress, formatAddressForDisplay, truncateAddressDisplay, addressesEqual } from '@/lib/tonAddress';
 import type { Deal, Listing } from '@/types';
@@ -16,7 +17,7 @@ import { Button } from '@/components/ui/button';
 import { Label } from '@/components/ui/label';
 import { PageTopSpacer } from '@/components/PageTopSpacer';
 import { LoadingScreen } from '@/components/LoadingScreen';
-import { BarChart3, MessageCircle, FileEdit, FileCheck, Wallet, CircleCheck, Play, Send, CheckCircle2, Clock, XCircle } from 'lucide-react';
+import { BarChart3, MessageCircle, FileEdit, FileCheck, Wallet, CircleCheck, Play, Send, CheckCircle2, Clock, XCircle, AlertTriangle } from 'lucide-react';
 import type { DealStatus } from '@/types';
 import { HandshakeDealSign } from '@/components/HandshakeDealSign';
 
@@ -49,6 +50,8 @@ function getRoadmapSegment(current: DealStatus): { segment: SegmentItem[]; align
         return ['in_progress', 'waiting_escrow_refund', 'escrow_refund_confirmed'];
       case 'escrow_refund_confirmed':
         return ['waiting_escrow_refund', 'escrow_refund_confirmed', 'completed'];
+      case 'escrow_transfer_failed':
+        return ['in_progress', 'escrow_transfer_failed'];
       case 'expired':
         return ['approved', 'waiting_escrow_deposit', 'expired'];
       case 'rejected':
@@ -77,6 +80,7 @@ const DEAL_STATUS_ICON: Record<DealStatus, typeof FileEdit> = {
   completed: CheckCircle2,
   waiting_escrow_refund: Wallet,
   escrow_refund_confirmed: CircleCheck,
+  escrow_transfer_failed: AlertTriangle,
   expired: Clock,
   rejected: XCircle,
 };
@@ -446,6 +450,8 @@ export default function DealDetailPage() {
   const [rejecting, setRejecting] = useState(false);
   const [depositing, setDepositing] = useState(false);
   const [depositError, setDepositError] = useState<string | null>(null);
+  const [payoutSaving, setPayoutSaving] = useState(false);
+  const [payoutError, setPayoutError] = useState<string | null>(null);
   const [currentUserId, setCurrentUserId] = useState<number | null>(null);
   const walletSyncedRef = useRef(false);
   const dealPayoutSyncedRef = useRef(false);
@@ -468,19 +474,13 @@ export default function DealDetailPage() {
   const depositDeadlinePassed = deal?.status === 'waiting_escrow_deposit' && depositDeadlineMs > 0 && depositTimeLeftMs === 0;
   const [tonConnectUI] = useTonConnectUI();
 
-  // Sync connected wallet to backend (raw format) so user can sign deals.
+  // Link connected wallet to backend with its ton_proof so user can sign deals.
   useEffect(() => {
-    if (!rawAddress || walletSyncedRef.current) return;
+    if (!wallet || walletSyncedRef.current) return;
     (async () => {
-      const token = await ensureValidToken();
-      if (!token) return;
-      const res = await api<{ status: string }>('/api/v1/market/me/wallet', {
-        method: 'PUT',
-        body: JSON.stringify({ wallet_address: rawAddress }),
-      });
-      if (res.ok) walletSyncedRef.current = true;
+      if (await linkWalletWithProof(wallet)) walletSyncedRef.current = true;
     })();
-  }, [rawAddress]);
+  }, [wallet]);
 
   // Set this deal's payout address when wallet connected and user is lessor or lessee.
   useEffect(() => {
@@ -600,10 +600,25 @@ export default function DealDetailPage() {
       return;
     }
     setSigning(true);
-    const res = await api<Deal>(`/api/v1/market/deals/${id}/sign`, { method: 'POST' });
-    setSigning(false);
-    if (res.ok && res.data) setDeal(res.data);
-    else alert(res.error_code || 'Failed to sign');
+    try {
+      const terms = await api<{ deal_id: number; terms: string; terms_hash: string }>(`/api/v1/market/deals/${id}/terms`);
+      if (!terms.ok || !terms.data) {
+        alert(terms.error_code || 'Failed to load deal terms');
+        return;
+      }
+      // The wallet signs the canonical terms document; the backend verifies it against the linked wallet key.
+      const signed = await tonConnectUI.signData({ type: 'text', text: terms.data.terms });
+      const res = await api<Deal>(`/api/v1/market/deals/${id}/sign`, {
+        method: 'POST',
+        body: JSON.stringify(signed),
+      });
+      if (res.ok && res.data) setDeal(res.data);
+      else alert(res.error_code || 'Failed to sign');
+    } catch {
+      alert('Signing was cancelled in the wallet');
+    } finally {
+      setSigning(false);
+    }
   };
 
   const handleRejectDeal = async () => {
@@ -651,6 +666,20 @@ export default function DealDetailPage() {
     }
   };
 
+  // After a failed escrow transfer the side it pays may move its payout to its own ton_proof-linked wallet.
+  const handleSetPayoutToWallet = async () => {
+    if (!rawAddress || !id) return;
+    setPayoutError(null);
+    setPayoutSaving(true);
+    const res = await api<Deal>(`/api/v1/market/deals/${id}/payout-address`, {
+      method: 'PUT',
+      body: JSON.stringify({ wallet_address: rawAddress }),
+    });
+    setPayoutSaving(false);
+    if (res.ok && res.data) setDeal(res.data);
+    else setPayoutError(typeof res.data === 'string' ? res.data : res.error_code || 'Failed to set payout address');
+  };
+
   return (
     <>
       <div className={loading ? 'opacity-0' : 'opacity-100'}>
@@ -677,7 +706,10 @@ export default function DealDetailPage() {
                     <span className="text-sm text-muted-foreground">Wallet</span>
                     <button
                       type="button"
-                      onClick={() => tonConnectUI.openModal()}
+                      onClick={async () => {
+                        await prepareTonProof(tonConnectUI);
+                        tonConnectUI.openModal();
+                      }}
                       className="shrink-0 inline-flex items-center gap-1.5 text-sm text-primary hover:underline"
                     >
                       <span>Connect</span>
@@ -781,6 +813,31 @@ export default function DealDetailPage() {
             </div>
           )}
 
+          {/* Escrow transfer failed - the side it pays can move its payout to the connected wallet before support retries */}
+          {deal.status === 'escrow_transfer_failed' && (isLessor || isLessee) && (() => {
+            const myPayout = (isLessor ? deal.lessor_payout_address : deal.lessee_payout_address) ?? '';
+            return (
+              <div className="space-y-2 rounded-md border border-border bg-muted/30 p-3 text-sm">
+                <p className="font-medium">Escrow transfer failed</p>
+                <p className="text-muted-foreground">
+                  Support will retry the transfer to {myPayout ? truncateAddressDisplay(myPayout) : 'your payout address'}. To receive it on another wallet, connect that wallet and use it for payout.
+                </p>
+                {!wallet ? (
+                  <p className="py-3 text-center text-sm text-muted-foreground">Connect wallet to change the payout.</p>
+                ) : addressesEqual(rawAddress ?? '', myPayout) ? (
+                  <p className="py-3 text-center text-sm text-muted-foreground">Payout goes to the connected wallet.</p>
+                ) : (
+                  <Button size="sm" className="w-full" onClick={handleSetPayoutToWallet} disabled={payoutSaving}>
+                    {payoutSaving ? 'Saving…' : 'Use connected wallet for payout'}
+                  </Button>
+                )}
+                {payoutError && (
+                  <p className="text-xs text-destructive">{payoutError}</p>
+                )}
+              </div>
+            );
+          })()}
+
           {/* Type, Duration, Price, Listing, Post date, Post text */}
           <p className="text-sm">
             <strong>Type:</strong> Post
@@ -7,8 +7,8 @@ import { useTelegramBackButton } from '@/lib/telegram';
 import type { Channel } from '@/types';
 import type { ChannelStatsResponse } from '@/types/channelStats';
 import {
-  parseGraphData,
   getGraphChartConfig,
+  getHourlyChartConfig,
   getGraphTitle,
   STATS_GRAPH_ORDER,
   getStatsDelta,
@@ -164,26 +164,25 @@ export default function ChannelStatsPage() {
       .finally(() => setLoading(false));
   }, [id]);
 
-  const requestedAt = stats?.requested_at;
+  const fetchedAt = stats?.fetched_at;
   const requestedAtLabel =
-    requestedAt != null
-      ? `Last updated: ${new Date(requestedAt * 1000).toLocaleString(undefined, { dateStyle: 'medium', timeStyle: 'short' })}`
+    fetchedAt != null
+      ? `Last updated: ${new Date(fetchedAt).toLocaleString(undefined, { dateStyle: 'medium', timeStyle: 'short' })}`
       : null;
-  const followers = stats?.Followers;
-  const viewsPerPost = stats?.ViewsPerPost;
-  const sharesPerPost = stats?.SharesPerPost;
-  const reactionsPerPost = stats?.ReactionsPerPost;
-  const viewsPerStory = stats?.ViewsPerStory;
-  const sharesPerStory = stats?.SharesPerStory;
-  const reactionsPerStory = stats?.ReactionsPerStory;
-  const enabledNotifications = stats?.EnabledNotifications;
-  const recentPosts = stats?.RecentPostsInteractions ?? [];
-  const period = stats?.Period;
+  const followers = stats?.followers;
+  const viewsPerPost = stats?.views_per_post;
+  const sharesPerPost = stats?.shares_per_post;
+  const reactionsPerPost = stats?.reactions_per_post;
+  const viewsPerStory = stats?.views_per_story;
+  const sharesPerStory = stats?.shares_per_story;
+  const reactionsPerStory = stats?.reactions_per_story;
+  const enabledNotificationsPercent = stats?.enabled_notifications_percent;
+  const recentPosts = (stats?.top_posts ?? []).filter((post) => post.message_id != null);
+  const period = stats?.period;
   const dateFormatEn = { month: 'short' as const, day: 'numeric' as const, year: 'numeric' as const };
-  const periodLabel =
-    period?.MinDate != null && period?.MaxDate != null
-      ? `${new Date(period.MinDate * 1000).toLocaleDateString('en-US', dateFormatEn)} – ${new Date(period.MaxDate * 1000).toLocaleDateString('en-US', dateFormatEn)}`
-      : null;
+  const periodLabel = period
+    ? `${new Date(period.from).toLocaleDateString('en-US', dateFormatEn)} – ${new Date(period.to).toLocaleDateString('en-US', dateFormatEn)}`
+    : null;
 
   /** Overview stat: value (left) and delta on the right, name under them. Delta = absolute and (%). */
   function StatWithDelta({
@@ -256,22 +255,22 @@ export default function ChannelStatsPage() {
     const label = n || k;
 
     switch (graphKey) {
-      case 'FollowersGraph':
+      case 'followers':
         if (label.includes('join') || index === 0) return GREEN;
         if (label.includes('left') || index === 1) return RED;
         break;
-      case 'InteractionsGraph':
+      case 'interactions':
         if (label.includes('view')) return GREEN;
         if (label.includes('share')) return BLUE;
         break;
-      case 'MuteGraph':
+      case 'mute':
         if (label.includes('mute') && !label.includes('un')) return RED;
         if (label.includes('unmute') || label.includes('un mute')) return GREEN;
         break;
-      case 'ReactionsByEmotionGraph':
+      case 'reactions_by_emotion':
         if (label.includes('positive')) return GREEN;
         return YELLOW;
-      case 'TopHoursGraph':
+      case 'top_hours':
         return index === 0 ? BLACK : GRAY;
       default:
         break;
@@ -281,11 +280,7 @@ export default function ChannelStatsPage() {
 
   const graphEntries = (stats ? STATS_GRAPH_ORDER : [])
     .map((key) => {
-      const g = (stats as Record<string, unknown>)[key] as { JSON?: { Data?: string }; Error?: string } | undefined;
-      if (!g || g.Error) return null;
-      const dataStr = g?.JSON?.Data;
-      const data = parseGraphData(dataStr);
-      const config = getGraphChartConfig(data);
+      const config = key === 'top_hours' ? getHourlyChartConfig(stats?.top_hours) : getGraphChartConfig(stats?.graphs[key]);
       return config && config.rows.length > 0 ? { key, title: getGraphTitle(key), config } : null;
     })
     .filter((e): e is NonNullable<typeof e> => e != null);
@@ -309,6 +304,12 @@ export default function ChannelStatsPage() {
         {requestedAtLabel && (
           <p className="text-sm text-muted-foreground">{requestedAtLabel}</p>
         )}
+        {stats?.estimated && (
+          <p className="text-sm text-muted-foreground">
+            Estimated from recent posts: Telegram provides no detailed stats for this channel.
+            {stats.posts_per_day != null && ` ${stats.posts_per_day.toFixed(1)} posts per day.`}
+          </p>
+        )}
         {/* Overview: two columns */}
         <Card>
           <CardHeader>
@@ -321,39 +322,35 @@ export default function ChannelStatsPage() {
             <div className="grid grid-cols-2 gap-x-6 gap-y-0">
               <div className="space-y-0">
                 {followers != null && (
-                  <StatWithDelta label="Followers" current={followers.Current} previous={followers.Previous} />
+                  <StatWithDelta label="Followers" current={followers.current} previous={followers.previous} />
                 )}
                 {viewsPerPost != null && (
-                  <StatWithDelta label="Views per post" current={viewsPerPost.Current} previous={viewsPerPost.Previous} />
+                  <StatWithDelta label="Views per post" current={viewsPerPost.current} previous={viewsPerPost.previous} />
                 )}
                 {sharesPerPost != null && (
-                  <StatWithDelta label="Shares per post" current={sharesPerPost.Current} previous={sharesPerPost.Previous} />
+                  <StatWithDelta label="Shares per post" current={sharesPerPost.current} previous={sharesPerPost.previous} />
                 )}
                 {reactionsPerPost != null && (
-                  <StatWithDelta label="Reactions per post" current={reactionsPerPost.Current} previous={reactionsPerPost.Previous} />
+                  <StatWithDelta label="Reactions per post" current={reactionsPerPost.current} previous={reactionsPerPost.previous} />
                 )}
               </div>
               <div className="space-y-0">
-                {enabledNotifications != null && (
+                {enabledNotificationsPercent != null && (
                   <div className="py-2">
                     <div className="flex items-baseline justify-between gap-2">
-                      <span className="font-semibold">
-                        {enabledNotifications.Total
-                          ? Math.round(((enabledNotifications.Part ?? 0) / enabledNotifications.Total) * 100)
-                          : 0}%
-                      </span>
+                      <span className="font-semibold">{Math.round(enabledNotificationsPercent)}%</span>
                     </div>
                     <p className="text-xs text-muted-foreground">Enabled notifications</p>
                   </div>
                 )}
                 {viewsPerStory != null && (
-                  <StatWithDelta label="Views per story" current={viewsPerStory.Current} previous={viewsPerStory.Previous} />
+                  <StatWithDelta label="Views per story" current={viewsPerStory.current} previous={viewsPerStory.previous} />
                 )}
                 {sharesPerStory != null && (
-                  <StatWithDelta label="Shares per story" current={sharesPerStory.Current} previous={sharesPerStory.Previous} />
+                  <StatWithDelta label="Shares per story" current={sharesPerStory.current} previous={sharesPerStory.previous} />
                 )}
                 {reactionsPerStory != null && (
-                  <StatWithDelta label="Reactions per story" current={reactionsPerStory.Current} previous={reactionsPerStory.Previous} />
+                  <StatWithDelta label="Reactions per story" current={reactionsPerStory.current} previous={reactionsPerStory.previous} />
                 )}
               </div>
             </div>
@@ -393,7 +390,7 @@ export default function ChannelStatsPage() {
           };
 
           // 100% stacked area for Languages: normalize so visible series always sum to 100%
-          const isLanguages = key === 'LanguagesGraph';
+          const isLanguages = key === 'languages';
           const chartRows = isLanguages
             ? rows.map((row) => {
                 const visibleSum = yColumns
@@ -560,7 +557,7 @@ export default function ChannelStatsPage() {
                         <YAxis
                           mirror
                           className="text-xs"
-                          domain={key === 'GrowthGraph' ? ['dataMin', 'dataMax'] : undefined}
+                          domain={key === 'growth' ? ['dataMin', 'dataMax'] : undefined}
                         />
                         <Tooltip
                           labelFormatter={tooltipLabel}
@@ -570,7 +567,7 @@ export default function ChannelStatsPage() {
                             fontSize: '0.75rem',
                           }}
                         />
-                        {key !== 'GrowthGraph' && hasMultipleSeries && <Legend wrapperStyle={{ paddingTop: 0 }} content={() => null} />}
+                        {key !== 'growth' && hasMultipleSeries && <Legend wrapperStyle={{ paddingTop: 0 }} content={() => null} />}
                         {yColumns.filter((col) => isSeriesVisible(col.key)).map((col, i) => (
                           <Line
                             key={col.key}
@@ -664,7 +661,7 @@ export default function ChannelStatsPage() {
             <CardContent>
               <ul className="space-y-2">
                 {recentPosts.map((post, i) => {
-                const msgId = post.MsgID ?? i + 1;
+                const msgId = post.message_id;
                 const messageUrl = `https://t.me/c/${id}/${msgId}`;
                 return (
                   <li
@@ -680,7 +677,7 @@ export default function ChannelStatsPage() {
                       Message #{msgId}
                     </a>
                     <span>
-                      {post.Views ?? 0} views · {post.Reactions ?? 0} reactions · {post.Forwards ?? 0} forwards
+                      {post.views} views · {post.reactions} reactions · {post.forwards} forwards
                     </span>
                   </li>
                 );
@@ -1,10 +1,11 @@
-import type { ApiResponse } from '@/types';
+import type { ApiResponse, AuthTokens } from '@/types';
 
 const BASE_URL =
   typeof window !== 'undefined'
     ? (process.env.NEXT_PUBLIC_API_URL || '').replace(/\/$/, '')
     : '';
 const JWT_STORAGE_KEY = 'ads_mrkt_jwt';
+const REFRESH_TOKEN_STORAGE_KEY = 'ads_mrkt_refresh_token';
 /** Consider token expired this many seconds before actual exp for safer refresh */
 const JWT_EXPIRY_BUFFER_SEC = 60;
 
@@ -13,6 +14,11 @@ function getAuthToken(): string | null {
   return localStorage.getItem(JWT_STORAGE_KEY);
 }
 
+function getRefreshToken(): string | null {
+  if (typeof window === 'undefined') return null;
+  return localStorage.getItem(REFRESH_TOKEN_STORAGE_KEY);
+}
+
 /** JWT payload shape (matches backend pkg/auth Claims). */
 interface JwtPayload {
   exp?: number;
@@ -49,15 +55,40 @@ export function getRoleFromToken(token: string | null): string | null {
   return payload?.role ?? null;
 }
 
-/** Returns a valid token from storage or refreshes via auth() and stores it. */
+/** Refresh in flight, shared so concurrent requests do not spend the same refresh token twice (that revokes the session). */
+let pendingToken: Promise<string | null> | null = null;
+
+/**
+ * Returns a valid access token from storage, or gets the next one with the stored refresh token.
+ * Authenticates with Telegram init data again only when there is no refresh token or the server refused it.
+ */
 export async function ensureValidToken(): Promise<string | null> {
   if (typeof window === 'undefined') return null;
   const token = getAuthToken();
   if (token && !isJwtExpired(token)) return token;
+  if (!pendingToken) {
+    pendingToken = renewToken().finally(() => {
+      pendingToken = null;
+    });
+  }
+  return pendingToken;
+}
+
+async function renewToken(): Promise<string | null> {
+  const refreshToken = getRefreshToken();
+  if (refreshToken) {
+    const refreshed = await refreshSession(refreshToken);
+    if (refreshed.ok && refreshed.data) {
+      setAuthTokens(refreshed.data);
+      return refreshed.data.access_token;
+    }
+    // Keep the session on network or server errors; only a refused refresh token needs a new session.
+    if (!refreshed.refused) return null;
+  }
   const res = await auth();
   if (res.ok && res.data) {
-    setAuthToken(res.data);
-    return res.data;
+    setAuthTokens(res.data);
+    return res.data.access_token;
   }
   clearAuthToken();
   return null;
@@ -87,12 +118,16 @@ export async function api<T>(
   return body as ApiResponse<T>;
 }
 
-export function setAuthToken(token: string): void {
-  if (typeof window !== 'undefined') localStorage.setItem(JWT_STORAGE_KEY, token);
+export function setAuthTokens(tokens: AuthTokens): void {
+  if (typeof window === 'undefined') return;
+  localStorage.setItem(JWT_STORAGE_KEY, tokens.access_token);
+  localStorage.setItem(REFRESH_TOKEN_STORAGE_KEY, tokens.refresh_token);
 }
 
 export function clearAuthToken(): void {
-  if (typeof window !== 'undefined') localStorage.removeItem(JWT_STORAGE_KEY);
+  if (typeof window === 'undefined') return;
+  localStorage.removeItem(JWT_STORAGE_KEY);
+  localStorage.removeItem(REFRESH_TOKEN_STORAGE_KEY);
 }
 
 /** Telegram Mini App init data. In Telegram this comes from WebApp.initData; locally use NEXT_PUBLIC_TG_WEB_APP_DATA. */
@@ -113,7 +148,7 @@ export function getTelegramInitData(): string | null {
   return fromEnv;
 }
 
-export async function auth(referrer?: number): Promise<ApiResponse<string>> {
+export async function auth(referrer?: number): Promise<ApiResponse<AuthTokens>> {
   const initData = getTelegramInitData();
   const url = `${BASE_URL}/api/v1/market/auth`;
   const headers: HeadersInit = {
@@ -123,11 +158,31 @@ export async function auth(referrer?: number): Promise<ApiResponse<string>> {
   const res = await fetch(url, {
     method: 'POST',
     headers,
-    body: JSON.stringify({ referrer: referrer ?? null }),
+    // tokens: access and refresh tokens instead of the bare access token of older clients
+    body: JSON.stringify({ referrer: referrer ?? null, tokens: true }),
   });
   const body = await res.json().catch(() => ({}));
   if (!res.ok) {
     return { ok: false, error_code: body.error_code || 'auth_failed' };
   }
-  return body as ApiResponse<string>;
+  return body as ApiResponse<AuthTokens>;
+}
+
+/** Exchanges the refresh token for new tokens. refused is set when the server rejected the token (401/403). */
+async function refreshSession(refreshToken: string): Promise<ApiResponse<AuthTokens> & { refused?: boolean }> {
+  const url = `${BASE_URL}/api/v1/market/auth/refresh`;
+  try {
+    const res = await fetch(url, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ refresh_token: refreshToken }),
+    });
+    const body = await res.json().catch(() => ({}));
+    if (!res.ok) {
+      return { ok: false, error_code: body.error_code || 'refresh_failed', refused: res.status === 401 || res.status === 403 };
+    }
+    return body as ApiResponse<AuthTokens>;
+  } catch {
+    return { ok: false, error_code: 'refresh_failed' };
+  }
 }
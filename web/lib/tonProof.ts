@@ -0,0 +1,38 @@
+import type { TonConnectUI, Wallet } from '@tonconnect/ui-react';
+import { api, ensureValidToken } from '@/lib/api';
+
+/** Asks the backend for a one-time ton_proof payload and makes TON Connect request a proof on the next connect. */
+export async function prepareTonProof(tonConnectUI: TonConnectUI): Promise<void> {
+  tonConnectUI.setConnectRequestParameters({ state: 'loading' });
+  const token = await ensureValidToken();
+  if (!token) {
+    tonConnectUI.setConnectRequestParameters(null);
+    return;
+  }
+  const res = await api<{ payload: string; expires_at: string }>('/api/v1/market/me/wallet/challenge', {
+    method: 'POST',
+  });
+  if (res.ok && res.data) {
+    tonConnectUI.setConnectRequestParameters({ state: 'ready', value: { tonProof: res.data.payload } });
+  } else {
+    tonConnectUI.setConnectRequestParameters(null);
+  }
+}
+
+/** Sends the wallet's ton_proof to the backend to link it. Returns false when the wallet has no proof (restored session). */
+export async function linkWalletWithProof(wallet: Wallet): Promise<boolean> {
+  const tonProof = wallet.connectItems?.tonProof;
+  if (!tonProof || !('proof' in tonProof)) return false;
+  const token = await ensureValidToken();
+  if (!token) return false;
+  const res = await api<{ status: string }>('/api/v1/market/me/wallet', {
+    method: 'PUT',
+    body: JSON.stringify({
+      wallet_address: wallet.account.address,
+      public_key: wallet.account.publicKey,
+      state_init: wallet.account.walletStateInit,
+      proof: tonProof.proof,
+    }),
+  });
+  return res.ok;
+}
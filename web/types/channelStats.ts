@@ -1,73 +1,78 @@
-/** Channel stats response shape (Telegram broadcast stats, see test.json). */
+/** Channel stats response shape (entity.ChannelStats, version 1). */
+
+export const CHANNEL_STATS_VERSION = 1;
 
 export interface StatsPeriod {
-  MinDate?: number;
-  MaxDate?: number;
+  from: string;
+  to: string;
 }
 
 export interface StatsCurrentPrevious {
-  Current?: number;
-  Previous?: number;
+  current: number;
+  previous: number;
 }
 
-export interface StatsGraphData {
-  columns?: [string, ...(number | string)[]][];
-  types?: Record<string, string>;
-  names?: Record<string, string>;
+export interface StatsPoint {
+  t: string;
+  v: number;
 }
 
-export interface StatsGraphJson {
-  Data?: string; // JSON string of StatsGraphData
-  Error?: string;
+export interface StatsSeries {
+  key: string;
+  name: string;
+  type: string;
+  points: StatsPoint[];
 }
 
-export interface RecentPostInteraction {
-  MsgID?: number;
-  Views?: number;
-  Forwards?: number;
-  Reactions?: number;
+export interface StatsGraph {
+  title?: string;
+  series: StatsSeries[];
 }
 
-export interface ChannelStatsResponse {
-  /** Unix timestamp when stats were last requested/updated */
-  requested_at?: number;
-  Period?: StatsPeriod;
-  Followers?: StatsCurrentPrevious;
-  ViewsPerPost?: StatsCurrentPrevious;
-  SharesPerPost?: StatsCurrentPrevious;
-  ReactionsPerPost?: StatsCurrentPrevious;
-  ViewsPerStory?: StatsCurrentPrevious;
-  SharesPerStory?: StatsCurrentPrevious;
-  ReactionsPerStory?: StatsCurrentPrevious;
-  ReactionsByEmotionGraph?: StatsGraphJson;
-  EnabledNotifications?: { Part?: number; Total?: number };
-  RecentPostsInteractions?: RecentPostInteraction[];
-  [key: string]: unknown;
+export interface StatsHourly {
+  name: string;
+  values: number[];
 }
 
-export function parseGraphData(jsonStr: string | undefined): StatsGraphData | null {
-  if (!jsonStr) return null;
-  try {
-    return JSON.parse(jsonStr) as StatsGraphData;
-  } catch {
-    return null;
-  }
+export interface StatsShare {
+  name: string;
+  value: number;
+  percent: number;
 }
 
-const plottableTypes = ['line', 'step', 'bar'];
+export interface StatsPost {
+  message_id?: number;
+  story_id?: number;
+  views: number;
+  forwards: number;
+  reactions: number;
+}
 
-/** Extract x and first y column for a simple line/step/bar chart. */
-export function getGraphSeries(data: StatsGraphData | null): { x: number; value: number }[] {
-  if (!data?.columns?.length) return [];
-  const cols = data.columns;
-  const xCol = cols.find((c) => c[0] === 'x' || (data.types && data.types[c[0] as string] === 'x'));
-  const yCol = cols.find((c) =>
-    c[0] !== 'x' && plottableTypes.includes(data.types?.[c[0] as string] ?? '')
-  );
-  if (!xCol || !yCol || xCol.length !== yCol.length) return [];
-  return xCol.slice(1).map((x, i) => ({ x: Number(x), value: Number(yCol[i + 1]) ?? 0 }));
+export interface ChannelStatsResponse {
+  version: number;
+  fetched_at: string;
+  /** Approximated from recent posts; Telegram gives no broadcast stats for the channel. */
+  estimated: boolean;
+  posts_per_day?: number;
+  period: StatsPeriod;
+  followers: StatsCurrentPrevious;
+  views_per_post: StatsCurrentPrevious;
+  shares_per_post: StatsCurrentPrevious;
+  reactions_per_post: StatsCurrentPrevious;
+  views_per_story: StatsCurrentPrevious;
+  shares_per_story: StatsCurrentPrevious;
+  reactions_per_story: StatsCurrentPrevious;
+  enabled_notifications_percent: number;
+  top_posts: StatsPost[] | null;
+  languages: StatsShare[] | null;
+  views_by_source: StatsShare[] | null;
+  followers_by_source: StatsShare[] | null;
+  graphs: Partial<Record<string, StatsGraph>>;
+  top_hours: StatsHourly[] | null;
 }
 
+const plottableTypes = ['line', 'step', 'bar'];
+
 export interface GraphSeriesColumn {
   key: string;
   name: string;
@@ -82,80 +87,67 @@ export interface GraphChartConfig {
   chartType: 'line' | 'bar';
 }
 
-/** Build multi-series chart config: rows with x + all y columns, axis labels, and series metadata. */
-export function getGraphChartConfig(data: StatsGraphData | null): GraphChartConfig | null {
-  if (!data?.columns?.length) return null;
-  const cols = data.columns;
-  const types = data.types ?? {};
-  const names = data.names ?? {};
-  const xCol = cols.find((c) => c[0] === 'x' || types[c[0] as string] === 'x');
-  if (!xCol) return null;
-  const yCols = cols.filter(
-    (c) => c[0] !== 'x' && plottableTypes.includes(types[c[0] as string] ?? '')
-  );
-  if (yCols.length === 0) return null;
-  const n = xCol.length - 1;
-  const rows: Record<string, number>[] = [];
-  for (let i = 0; i < n; i++) {
-    const row: Record<string, number> = { x: Number(xCol[i + 1]) };
-    for (const yCol of yCols) {
-      const key = yCol[0] as string;
-      row[key] = Number(yCol[i + 1]) ?? 0;
+/** Build multi-series chart config from a daily graph: rows with x (unix ms) + all y columns. */
+export function getGraphChartConfig(graph: StatsGraph | undefined): GraphChartConfig | null {
+  const series = graph?.series.filter((s) => plottableTypes.includes(s.type) || s.type === 'area') ?? [];
+  if (series.length === 0) return null;
+  const rows: Record<string, number>[] = series[0].points.map((p, i) => {
+    const row: Record<string, number> = { x: Date.parse(p.t) };
+    for (const s of series) {
+      row[s.key] = s.points[i]?.v ?? 0;
     }
-    rows.push(row);
-  }
-  const yColumns: GraphSeriesColumn[] = yCols.map((yCol) => {
-    const key = yCol[0] as string;
-    return {
-      key,
-      name: (names[key] as string) || key,
-      type: (types[key] as 'line' | 'step' | 'bar') || 'line',
-    };
+    return row;
   });
-  const firstX = rows[0]?.x;
-  const xLabel =
-    typeof firstX === 'number' && firstX > 1e10
-      ? 'Date'
-      : typeof firstX === 'number' && firstX >= 0 && firstX <= 23
-        ? 'Hour (UTC)'
-        : 'X';
+  const yColumns: GraphSeriesColumn[] = series.map((s) => ({
+    key: s.key,
+    name: s.name || s.key,
+    type: plottableTypes.includes(s.type) ? (s.type as 'line' | 'step' | 'bar') : 'line',
+  }));
   const hasBar = yColumns.some((s) => s.type === 'bar');
-  return {
-    rows,
-    yColumns,
-    xLabel,
-    yLabel: 'Count',
-    chartType: hasBar ? 'bar' : 'line',
-  };
+  return { rows, yColumns, xLabel: 'Date', yLabel: 'Count', chartType: hasBar ? 'bar' : 'line' };
+}
+
+/** Build chart config for views by UTC hour of day. */
+export function getHourlyChartConfig(hourly: StatsHourly[] | null | undefined): GraphChartConfig | null {
+  if (!hourly?.length) return null;
+  const yColumns: GraphSeriesColumn[] = hourly.map((s, i) => ({ key: `y${i}`, name: s.name, type: 'line' }));
+  const rows: Record<string, number>[] = Array.from({ length: 24 }, (_, hour) => {
+    const row: Record<string, number> = { x: hour };
+    hourly.forEach((s, i) => {
+      row[`y${i}`] = s.values[hour] ?? 0;
+    });
+    return row;
+  });
+  return { rows, yColumns, xLabel: 'Hour (UTC)', yLabel: 'Views', chartType: 'line' };
 }
 
 const graphTitleMap: Record<string, string> = {
-  GrowthGraph: 'Growth',
-  MuteGraph: 'Notifications',
-  TopHoursGraph: 'View by hours (UTC)',
-  FollowersGraph: 'Followers',
-  LanguagesGraph: 'Languages',
-  InteractionsGraph: 'Interactions',
-  ViewsBySourceGraph: 'Views by source',
-  NewFollowersBySourceGraph: 'New followers by source',
-  ReactionsByEmotionGraph: 'Reactions',
+  growth: 'Growth',
+  mute: 'Notifications',
+  top_hours: 'View by hours (UTC)',
+  followers: 'Followers',
+  languages: 'Languages',
+  interactions: 'Interactions',
+  views_by_source: 'Views by source',
+  followers_by_source: 'New followers by source',
+  reactions_by_emotion: 'Reactions',
 };
 
 /** Order in which stats graphs are displayed. */
 export const STATS_GRAPH_ORDER: string[] = [
-  'GrowthGraph',
-  'FollowersGraph',
-  'MuteGraph',
-  'TopHoursGraph',
-  'ViewsBySourceGraph',
-  'NewFollowersBySourceGraph',
-  'LanguagesGraph',
-  'InteractionsGraph',
-  'ReactionsByEmotionGraph',
+  'growth',
+  'followers',
+  'mute',
+  'top_hours',
+  'views_by_source',
+  'followers_by_source',
+  'languages',
+  'interactions',
+  'reactions_by_emotion',
 ];
 
 export function getGraphTitle(key: string): string {
-  return graphTitleMap[key] ?? (key.replace(/Graph$/, '').replace(/([A-Z])/g, ' $1').trim() || key);
+  return graphTitleMap[key] ?? (key.replace(/_/g, ' ').replace(/^./, (c) => c.toUpperCase()) || key);
 }
 
 /** Delta from previous to current. showDelta is false when both are 0 (display only "0"). */
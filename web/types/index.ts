@@ -5,6 +5,14 @@ export interface ApiResponse<T> {
   error_code?: string;
 }
 
+/** Session tokens from /market/auth and /market/auth/refresh. */
+export interface AuthTokens {
+  access_token: string;
+  access_token_expires_at: string;
+  refresh_token: string;
+  refresh_token_expires_at: string;
+}
+
 // Listing
 export type ListingType = 'lessor' | 'lessee';
 export type ListingStatus = 'active' | 'inactive';
@@ -46,6 +54,7 @@ export type DealStatus =
   | 'completed'
   | 'waiting_escrow_refund'
   | 'escrow_refund_confirmed'
+  | 'escrow_transfer_failed'
   | 'expired'
   | 'rejected';
 
@@ -61,6 +70,7 @@ export const DEAL_STATUS_LABEL: Record<DealStatus, string> = {
   completed: 'Completed',
   waiting_escrow_refund: 'Waiting escrow refund',
   escrow_refund_confirmed: 'Escrow refunded',
+  escrow_transfer_failed: 'Escrow payout on hold',
   expired: 'Expired',
   rejected: 'Rejected',
 };